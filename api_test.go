@@ -0,0 +1,272 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyteclient
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var updateAPI = flag.Bool("update", false, "record the current exported api in testdata/api")
+
+// the packages whose exported api is covered by the stability policy
+var apiPackages = []string{"client", "config", "conformance", "flyte", "flytetest", "healthcheck"}
+
+// Test_API_ShouldBeCompatible checks the exported api of each package against the api recorded in testdata/api.
+// Removing or changing anything recorded there breaks packs built against an earlier version, so is only allowed in
+// a new major version. So is adding a method to a recorded interface, which breaks the types implementing it, as
+// apidiff reports. Other additions are compatible, but must be recorded, by running:
+//
+//	go test . -run Test_API -update
+func Test_API_ShouldBeCompatible(t *testing.T) {
+	for _, pkg := range apiPackages {
+		t.Run(pkg, func(t *testing.T) {
+			current, err := exportedAPI(pkg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "api", pkg+".txt")
+			if *updateAPI {
+				if err := ioutil.WriteFile(golden, []byte(strings.Join(current, "\n")+"\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			b, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("cannot read the recorded api, run with -update to record it: %v", err)
+			}
+			recorded := strings.Split(strings.TrimSpace(string(b)), "\n")
+			removed, grown, added := diffAPI(recorded, current)
+			for _, r := range removed {
+				t.Errorf("incompatible api change, %s was removed or changed", r)
+			}
+			for _, g := range grown {
+				t.Errorf("incompatible api change, %s was added to an existing interface", g)
+			}
+			for _, a := range added {
+				t.Errorf("%s was added, run with -update to record it", a)
+			}
+		})
+	}
+}
+
+// diffAPI returns the declarations recorded that are no longer present, the methods present in recorded interfaces
+// that were not recorded, and the other declarations present that were not recorded
+func diffAPI(recorded, current []string) (removed, grown, added []string) {
+	inCurrent := make(map[string]bool, len(current))
+	for _, c := range current {
+		inCurrent[c] = true
+	}
+	inRecorded := make(map[string]bool, len(recorded))
+	for _, r := range recorded {
+		inRecorded[r] = true
+		if !inCurrent[r] {
+			removed = append(removed, r)
+		}
+	}
+	for _, c := range current {
+		if inRecorded[c] {
+			continue
+		}
+		if i := strings.Index(c, " interface, "); i >= 0 && inRecorded[c[:i+len(" interface")]] {
+			grown = append(grown, c)
+			continue
+		}
+		added = append(added, c)
+	}
+	return removed, grown, added
+}
+
+// exportedAPI lists the exported declarations of the package in dir, one per line, sorted
+func exportedAPI(dir string) ([]string, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var api []string
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			for _, decl := range f.Decls {
+				api = append(api, declAPI(fset, decl)...)
+			}
+		}
+	}
+	sort.Strings(api)
+	return api, nil
+}
+
+func declAPI(fset *token.FileSet, decl ast.Decl) []string {
+	var api []string
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if !d.Name.IsExported() {
+			return nil
+		}
+		if d.Recv == nil {
+			return []string{"func " + d.Name.Name + signature(fset, d.Type)}
+		}
+		recv := d.Recv.List[0].Type
+		if !ast.IsExported(receiverName(recv)) {
+			return nil
+		}
+		return []string{fmt.Sprintf("method (%s) %s%s", node(fset, recv), d.Name.Name, signature(fset, d.Type))}
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				if s.Name.IsExported() {
+					api = append(api, typeAPI(fset, s)...)
+				}
+			case *ast.ValueSpec:
+				for _, name := range s.Names {
+					if !name.IsExported() {
+						continue
+					}
+					line := d.Tok.String() + " " + name.Name
+					if s.Type != nil {
+						line += " " + node(fset, s.Type)
+					}
+					api = append(api, line)
+				}
+			}
+		}
+	}
+	return api
+}
+
+func typeAPI(fset *token.FileSet, s *ast.TypeSpec) []string {
+	name := s.Name.Name
+	alias := ""
+	if s.Assign.IsValid() {
+		alias = "= "
+	}
+	switch t := s.Type.(type) {
+	case *ast.StructType:
+		api := []string{"type " + name + " struct"}
+		for _, f := range t.Fields.List {
+			if len(f.Names) == 0 {
+				api = append(api, fmt.Sprintf("type %s struct, embedded %s", name, node(fset, f.Type)))
+				continue
+			}
+			for _, n := range f.Names {
+				if n.IsExported() {
+					api = append(api, fmt.Sprintf("type %s struct, %s %s", name, n.Name, node(fset, f.Type)))
+				}
+			}
+		}
+		return api
+	case *ast.InterfaceType:
+		api := []string{"type " + name + " interface"}
+		for _, m := range t.Methods.List {
+			if len(m.Names) == 0 {
+				api = append(api, fmt.Sprintf("type %s interface, embedded %s", name, node(fset, m.Type)))
+				continue
+			}
+			api = append(api, fmt.Sprintf("type %s interface, %s%s", name, m.Names[0].Name, signature(fset, m.Type.(*ast.FuncType))))
+		}
+		return api
+	}
+	return []string{"type " + name + " " + alias + node(fset, s.Type)}
+}
+
+// signature prints the parameter and result types of the function, leaving out their names, which callers do not
+// depend on
+func signature(fset *token.FileSet, ft *ast.FuncType) string {
+	sig := "(" + strings.Join(fieldTypes(fset, ft.Params), ", ") + ")"
+	results := fieldTypes(fset, ft.Results)
+	switch len(results) {
+	case 0:
+	case 1:
+		sig += " " + results[0]
+	default:
+		sig += " (" + strings.Join(results, ", ") + ")"
+	}
+	return sig
+}
+
+func fieldTypes(fset *token.FileSet, fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+	var types []string
+	for _, f := range fields.List {
+		n := len(f.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			types = append(types, node(fset, f.Type))
+		}
+	}
+	return types
+}
+
+func receiverName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+func node(fset *token.FileSet, n ast.Node) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, fset, n)
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+func Test_diffAPI_ShouldReportMethodsAddedToRecordedInterfacesAsIncompatible(t *testing.T) {
+	recorded := []string{"type Client interface", "type Client interface, CreatePack(Pack) error"}
+	current := []string{
+		"type Client interface",
+		"type Client interface, CreatePack(Pack) error",
+		"type Client interface, UpdatePack(Pack) error",
+		"type PackUpdater interface",
+		"type PackUpdater interface, UpdatePack(Pack) error",
+	}
+
+	removed, grown, added := diffAPI(recorded, current)
+
+	if len(removed) != 0 {
+		t.Errorf("expected nothing removed, got %v", removed)
+	}
+	if want := []string{"type Client interface, UpdatePack(Pack) error"}; fmt.Sprint(grown) != fmt.Sprint(want) {
+		t.Errorf("expected %v added to an existing interface, got %v", want, grown)
+	}
+	if want := []string{"type PackUpdater interface", "type PackUpdater interface, UpdatePack(Pack) error"}; fmt.Sprint(added) != fmt.Sprint(want) {
+		t.Errorf("expected %v added, got %v", want, added)
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import "net/http"
+
+// DefaultAcceptedStatusCodes are the response status codes treated as success for each operation that changes state
+// on the flyte api. Operations that read state, and TakeAction (where 200 and 204 mean different things), are not
+// configurable.
+var DefaultAcceptedStatusCodes = map[Operation][]int{
+	OpRegisterPack:        {http.StatusCreated},
+	OpReplacePack:         {http.StatusOK, http.StatusCreated},
+	OpDeletePack:          {http.StatusOK, http.StatusNoContent},
+	OpUpdatePackStatus:    {http.StatusOK, http.StatusAccepted, http.StatusNoContent},
+	OpPostEvent:           {http.StatusOK, http.StatusAccepted, http.StatusNoContent},
+	OpCompleteAction:      {http.StatusOK, http.StatusAccepted, http.StatusNoContent},
+	OpHeartbeatAction:     {http.StatusOK, http.StatusAccepted, http.StatusNoContent},
+	OpPutDatastoreItem:    {http.StatusCreated, http.StatusNoContent},
+	OpDeleteDatastoreItem: {http.StatusOK, http.StatusNoContent},
+	OpCreateFlow:          {http.StatusCreated},
+	OpReplaceFlow:         {http.StatusOK, http.StatusNoContent},
+	OpDeleteFlow:          {http.StatusOK, http.StatusNoContent},
+}
+
+// WithAcceptedStatusCodes replaces the response status codes treated as success for the operation, for flyte api
+// deployments (or proxies in front of them) that respond with nonstandard status codes, e.g. a 3xx or 204 where the
+// flyte api would respond with a 202. Registering a pack and replacing a pack expect a pack definition in the response
+// body, so should only accept status codes with a body.
+func WithAcceptedStatusCodes(op Operation, codes ...int) Option {
+	return func(c *client) {
+		if c.acceptedStatusCodes == nil {
+			c.acceptedStatusCodes = map[Operation][]int{}
+		}
+		c.acceptedStatusCodes[op] = codes
+	}
+}
+
+// accepts reports whether the response status code is treated as success for the operation
+func (c client) accepts(op Operation, code int) bool {
+	codes, ok := c.acceptedStatusCodes[op]
+	if !ok {
+		codes = DefaultAcceptedStatusCodes[op]
+	}
+	for _, accepted := range codes {
+		if code == accepted {
+			return true
+		}
+	}
+	return false
+}
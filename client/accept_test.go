@@ -0,0 +1,68 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func Test_PostEvent_ShouldAcceptDefaultSuccessStatusCodes(t *testing.T) {
+	for _, status := range []int{http.StatusOK, http.StatusAccepted, http.StatusNoContent} {
+		ts := mockServer(status, "")
+		c := newTestClient(ts.URL, t)
+		c.packLinks.eventsURL, _ = url.Parse(ts.URL + "/events")
+
+		assert.NoError(t, c.PostEvent(Event{Name: "MessageSent"}), "status %d", status)
+		ts.Close()
+	}
+}
+
+func Test_CompleteAction_ShouldAcceptConfiguredStatusCodes(t *testing.T) {
+	// given a proxy that responds to action results with a 304
+	ts := mockServer(http.StatusNotModified, "")
+	defer ts.Close()
+	resultURL, _ := url.Parse(ts.URL + "/result")
+	action := Action{Links: []Link{{Href: resultURL, Rel: "actionResult"}}}
+
+	// when the default status codes are used
+	c := newTestClient(ts.URL, t)
+	err := c.CompleteAction(action, Event{Name: "MessageSent"})
+
+	// then
+	assert.Error(t, err)
+
+	// and when the status code is accepted
+	WithAcceptedStatusCodes(OpCompleteAction, http.StatusAccepted, http.StatusNotModified)(c)
+	err = c.CompleteAction(action, Event{Name: "MessageSent"})
+
+	// then
+	assert.NoError(t, err)
+}
+
+func Test_WithAcceptedStatusCodes_ShouldOnlyChangeTheOperationPassedIn(t *testing.T) {
+	c := newTestClient("http://localhost", t)
+
+	WithAcceptedStatusCodes(OpPostEvent, http.StatusFound)(c)
+
+	assert.True(t, c.accepts(OpPostEvent, http.StatusFound))
+	assert.False(t, c.accepts(OpPostEvent, http.StatusAccepted))
+	assert.True(t, c.accepts(OpCompleteAction, http.StatusAccepted))
+	assert.False(t, c.accepts(OpCompleteAction, http.StatusFound))
+}
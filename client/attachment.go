@@ -0,0 +1,121 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// DefaultMaxInlineAttachmentSize is the largest attachment embedded in an event payload by default. Larger
+// attachments are uploaded to the datastore by Attach.
+const DefaultMaxInlineAttachmentSize = 256 << 10
+
+// Attachment is binary data, such as a screenshot or a log bundle, attached to an event payload. It is either
+// embedded in the payload, base64 encoded as the "data" field, or uploaded to the flyte api datastore, in which case
+// the payload holds its datastore key and, when the client knows it, the url it can be downloaded from.
+//
+//	type ScreenshotTakenPayload struct {
+//		Page       string            `json:"page"`
+//		Screenshot client.Attachment `json:"screenshot"`
+//	}
+type Attachment struct {
+	Name        string `json:"name,omitempty"`         // the file name, e.g. "screenshot.png"
+	ContentType string `json:"contentType"`            // the media type of the data e.g. "image/png"
+	Size        int    `json:"size"`                   // the size of the data in bytes
+	Data        []byte `json:"data,omitempty"`         // the data, when it is embedded in the payload
+	Key         string `json:"datastoreKey,omitempty"` // the datastore key, when the data has been uploaded
+	URL         string `json:"url,omitempty"`          // where uploaded data can be downloaded from
+}
+
+// AttachmentTooLargeError is returned when attaching data larger than can be embedded in an event payload. It
+// matches ErrPayloadTooLarge.
+type AttachmentTooLargeError struct {
+	Name  string // the name of the attachment
+	Size  int    // the size of the data in bytes
+	Limit int    // the largest attachment that can be embedded in bytes
+}
+
+func (e AttachmentTooLargeError) Error() string {
+	return fmt.Sprintf("attachment %q of %d bytes is larger than the limit of %d bytes", e.Name, e.Size, e.Limit)
+}
+
+// Is reports whether the target is ErrPayloadTooLarge.
+func (e AttachmentTooLargeError) Is(target error) bool {
+	return target == ErrPayloadTooLarge
+}
+
+// InlineAttachment embeds the data in an attachment, failing with an AttachmentTooLargeError if it is larger than
+// maxBytes, or than DefaultMaxInlineAttachmentSize if maxBytes is zero or less. Bear in mind the data grows by a third
+// when base64 encoded in the payload.
+func InlineAttachment(name, contentType string, data []byte, maxBytes int) (Attachment, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxInlineAttachmentSize
+	}
+	if len(data) > maxBytes {
+		return Attachment{}, AttachmentTooLargeError{Name: name, Size: len(data), Limit: maxBytes}
+	}
+	return Attachment{Name: name, ContentType: contentType, Size: len(data), Data: data}, nil
+}
+
+// UploadAttachment stores the data in the datastore against the key, returning an attachment referencing it. The
+// attachment has the url of the datastore item when the datastore is the client returned by NewClient.
+func UploadAttachment(store Datastore, key, name, contentType string, data []byte) (Attachment, error) {
+	if store == nil {
+		return Attachment{}, errors.New("cannot upload attachment: no datastore")
+	}
+	item := DatastoreItem{Key: key, Description: name, ContentType: contentType, Value: data}
+	if err := store.PutDatastoreItem(item); err != nil {
+		return Attachment{}, fmt.Errorf("cannot upload attachment %q: %w", name, err)
+	}
+	a := Attachment{Name: name, ContentType: contentType, Size: len(data), Key: key}
+	if s, ok := store.(interface {
+		getDatastoreItemURL(key string) (*url.URL, error)
+	}); ok {
+		if u, err := s.getDatastoreItemURL(key); err == nil {
+			a.URL = u.String()
+		}
+	}
+	return a, nil
+}
+
+// Attach embeds the data in an attachment if it is no larger than maxInlineBytes, or than
+// DefaultMaxInlineAttachmentSize if maxInlineBytes is zero or less, and otherwise uploads it to the datastore against
+// the key. Without a datastore, data too large to embed fails with an AttachmentTooLargeError.
+func Attach(store Datastore, key, name, contentType string, data []byte, maxInlineBytes int) (Attachment, error) {
+	a, err := InlineAttachment(name, contentType, data, maxInlineBytes)
+	if err == nil || store == nil {
+		return a, err
+	}
+	return UploadAttachment(store, key, name, contentType, data)
+}
+
+// Bytes returns the attachment data, getting it from the datastore if it was uploaded.
+func (a Attachment) Bytes(store Datastore) ([]byte, error) {
+	if a.Key == "" {
+		return a.Data, nil
+	}
+	if store == nil {
+		return nil, fmt.Errorf("cannot get attachment %q: no datastore", a.Name)
+	}
+	item, err := store.GetDatastoreItem(a.Key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get attachment %q: %w", a.Name, err)
+	}
+	return item.Value, nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func Test_InlineAttachment_ShouldBase64EncodeTheDataInThePayload(t *testing.T) {
+	a, err := InlineAttachment("screenshot.png", "image/png", []byte{0x89, 'P', 'N', 'G'}, 0)
+	require.NoError(t, err)
+
+	b, err := json.Marshal(a)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name": "screenshot.png", "contentType": "image/png", "size": 4, "data": "iVBORw=="}`, string(b))
+
+	var decoded Attachment
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	data, err := decoded.Bytes(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x89, 'P', 'N', 'G'}, data)
+}
+
+func Test_InlineAttachment_ShouldRejectDataOverTheLimit(t *testing.T) {
+	_, err := InlineAttachment("logs.zip", "application/zip", make([]byte, 11), 10)
+
+	assert.EqualError(t, err, `attachment "logs.zip" of 11 bytes is larger than the limit of 10 bytes`)
+	assert.True(t, errors.Is(err, ErrPayloadTooLarge))
+}
+
+func Test_Attach_ShouldUploadDataTooLargeToEmbed(t *testing.T) {
+	store := &mockDatastore{items: map[string]string{}}
+
+	a, err := Attach(store, "run-1/logs.zip", "logs.zip", "application/zip", []byte("0123456789a"), 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, Attachment{Name: "logs.zip", ContentType: "application/zip", Size: 11, Key: "run-1/logs.zip"}, a)
+	assert.Equal(t, "0123456789a", store.items["run-1/logs.zip"])
+	data, err := a.Bytes(store)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789a", string(data))
+}
+
+func Test_Attach_ShouldEmbedSmallData(t *testing.T) {
+	store := &mockDatastore{items: map[string]string{}}
+
+	a, err := Attach(store, "run-1/logs.zip", "logs.zip", "application/zip", []byte("0123456789"), 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(a.Data))
+	assert.Empty(t, a.Key)
+	assert.Empty(t, store.items)
+}
+
+func Test_Attach_ShouldFailForLargeDataWithoutADatastore(t *testing.T) {
+	_, err := Attach(nil, "run-1/logs.zip", "logs.zip", "application/zip", make([]byte, 11), 10)
+
+	assert.True(t, errors.Is(err, ErrPayloadTooLarge))
+}
+
+func Test_UploadAttachment_ShouldReferenceTheDatastoreItemURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	datastoreURL, _ := url.Parse(ts.URL + "/v1/datastore")
+	c.apiLinks = map[string][]Link{"links": {{Href: datastoreURL, Rel: "http://example.com/swagger#!/datastore/listDataItems"}}}
+
+	a, err := UploadAttachment(c, "screenshots/1.png", "1.png", "image/png", []byte("png"))
+
+	require.NoError(t, err)
+	assert.Equal(t, ts.URL+"/v1/datastore/screenshots%2F1.png", a.URL)
+	assert.Equal(t, "screenshots/1.png", a.Key)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// EventFinder is implemented by clients that can search the events packs have sent to the flyte server. The client
+// returned by NewClient implements it.
+type EventFinder interface {
+	// FindEvents searches the flyte api audit for the events matching the query, oldest first.
+	FindEvents(EventQuery) ([]AuditEvent, error)
+}
+
+// the EventQuery struct selects the events returned by FindEvents.
+type EventQuery struct {
+	PackName   string            // the name of the pack that sent the events
+	PackLabels map[string]string // optional, the labels of the pack that sent the events
+	EventName  string            // optional, the name of the events
+	Since      time.Time         // optional, only events sent at or after this time are returned
+}
+
+// the AuditEvent struct describes an event recorded in the flyte api audit.
+type AuditEvent struct {
+	ID         string            `json:"id"`
+	PackName   string            `json:"packName"`
+	PackLabels map[string]string `json:"packLabels,omitempty"`
+	Name       string            `json:"name"`
+	Payload    json.RawMessage   `json:"payload,omitempty"`
+	Time       time.Time         `json:"time"`
+}
+
+// FindEvents searches the flyte api audit for the events matching the query.
+func (c *client) FindEvents(query EventQuery) ([]AuditEvent, error) {
+	auditURL, err := c.apiURL(RelAudit)
+	if err != nil {
+		return nil, err
+	}
+	u := *auditURL
+	q := u.Query()
+	q.Set("eventPackName", query.PackName)
+	for k, v := range query.PackLabels {
+		q.Add("eventPackLabels", k+":"+v)
+	}
+	if query.EventName != "" {
+		q.Set("eventName", query.EventName)
+	}
+	if !query.Since.IsZero() {
+		q.Set("start", query.Since.UTC().Format(time.RFC3339Nano))
+	}
+	u.RawQuery = q.Encode()
+
+	var audit struct {
+		Events []AuditEvent `json:"events"`
+	}
+	if err := c.getStruct(OpFindEvents, &u, &audit); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(audit.Events, func(i, j int) bool {
+		return audit.Events[i].Time.Before(audit.Events[j].Time)
+	})
+	return audit.Events, nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_FindEvents_ShouldSearchTheAuditForMatchingEventsOldestFirst(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/audit/flows", r.URL.Path)
+		q := r.URL.Query()
+		assert.Equal(t, "Jira", q.Get("eventPackName"))
+		assert.Equal(t, []string{"env:prod"}, q["eventPackLabels"])
+		assert.Equal(t, "IssueCreated", q.Get("eventName"))
+		assert.Equal(t, "2020-01-01T00:00:00.5Z", q.Get("start"))
+		w.Write([]byte(`{"events": [
+			{"id": "2", "packName": "Jira", "name": "IssueCreated", "payload": {"key": "OPS-2"}, "time": "2020-01-03T00:00:00Z"},
+			{"id": "1", "packName": "Jira", "name": "IssueCreated", "payload": {"key": "OPS-1"}, "time": "2020-01-02T00:00:00Z"}
+		]}`))
+	}))
+	defer ts.Close()
+
+	c := newTestAuditClient(ts.URL, t)
+
+	events, err := c.FindEvents(EventQuery{
+		PackName:   "Jira",
+		PackLabels: map[string]string{"env": "prod"},
+		EventName:  "IssueCreated",
+		Since:      time.Date(2020, 1, 1, 0, 0, 0, 500000000, time.UTC),
+	})
+
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "1", events[0].ID)
+	assert.JSONEq(t, `{"key": "OPS-1"}`, string(events[0].Payload))
+	assert.Equal(t, "2", events[1].ID)
+}
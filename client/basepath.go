@@ -0,0 +1,103 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/url"
+	"strings"
+)
+
+// pathPrefix returns the path the flyte api is exposed under, such as "/flyte" for a flyte api at
+// https://gateway.example.com/flyte behind an ingress, or "" for a flyte api at the root path
+func (c client) pathPrefix() string {
+	if c.baseURL == nil {
+		return ""
+	}
+	return strings.TrimSuffix(absolutePath(c.baseURL.Path), "/"+ApiVersion)
+}
+
+// resolveURL resolves a link href published by the flyte api against the base url. Absolute hrefs are left as they
+// are. Hrefs with an absolute path, which a flyte api behind a path prefix publishes without the prefix, are resolved
+// against the base url host with the prefix added, unless they already have it; other relative hrefs are resolved
+// against the base url, e.g. "packs" against https://gateway.example.com/flyte/v1 is
+// https://gateway.example.com/flyte/v1/packs.
+func (c client) resolveURL(href *url.URL) *url.URL {
+	if href == nil || href.IsAbs() || c.baseURL == nil {
+		return href
+	}
+	ref := *href
+	if strings.HasPrefix(ref.Path, "/") {
+		ref.Path = c.withPathPrefix(ref.Path)
+		if ref.RawPath != "" {
+			ref.RawPath = c.withPathPrefix(ref.RawPath)
+		}
+		return c.baseURL.ResolveReference(&ref)
+	}
+	base := *c.baseURL
+	base.Path = absolutePath(base.Path) + "/"
+	base.RawPath = ""
+	return base.ResolveReference(&ref)
+}
+
+// resolveTemplate resolves a link template against the base url in the same way as resolveURL
+func (c client) resolveTemplate(template string) string {
+	if template == "" || strings.Contains(template, "://") || c.baseURL == nil {
+		return template
+	}
+	root := url.URL{Scheme: c.baseURL.Scheme, Host: c.baseURL.Host}
+	if strings.HasPrefix(template, "/") {
+		return root.String() + c.withPathPrefix(template)
+	}
+	return root.String() + absolutePath(c.baseURL.EscapedPath()) + "/" + template
+}
+
+// absolutePath returns the path with a leading slash and without a trailing one, e.g. "/flyte/v1" for "flyte/v1/"
+func absolutePath(p string) string {
+	return "/" + strings.Trim(p, "/")
+}
+
+// withPathPrefix adds the path prefix to the absolute path unless it already has it
+func (c client) withPathPrefix(p string) string {
+	prefix := c.pathPrefix()
+	if prefix == "" || p == prefix || strings.HasPrefix(p, prefix+"/") {
+		return p
+	}
+	return prefix + p
+}
+
+// resolveLinks returns a copy of the links with their hrefs resolved against the base url
+func (c client) resolveLinks(links []Link) Links {
+	if links == nil {
+		return nil
+	}
+	resolved := make(Links, len(links))
+	for i, l := range links {
+		l.Href = c.resolveURL(l.Href)
+		l.Template = c.resolveTemplate(l.Template)
+		resolved[i] = l
+	}
+	return resolved
+}
+
+// linkURL returns the URL of the first link with the rel, resolved against the base url
+func (c client) linkURL(links []Link, rel Rel) (*url.URL, error) {
+	u, err := findURLByRel(links, rel)
+	if err != nil {
+		return nil, err
+	}
+	return c.resolveURL(u), nil
+}
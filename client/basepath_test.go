@@ -0,0 +1,110 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_resolveURL_ShouldResolveLinksAgainstTheBaseURL(t *testing.T) {
+	for _, tc := range []struct {
+		root, href, want string
+	}{
+		{"https://gateway.example.com/flyte", "/v1/packs", "https://gateway.example.com/flyte/v1/packs"},
+		{"https://gateway.example.com/flyte", "/flyte/v1/packs", "https://gateway.example.com/flyte/v1/packs"},
+		{"https://gateway.example.com/flyte/", "packs", "https://gateway.example.com/flyte/v1/packs"},
+		{"https://gateway.example.com/flyte", "packs/Slack?label=x", "https://gateway.example.com/flyte/v1/packs/Slack?label=x"},
+		{"https://gateway.example.com/flyte", "http://flyte-api:8080/v1/packs", "http://flyte-api:8080/v1/packs"},
+		{"https://gateway.example.com/flyte", "/v1/datastore/a%2Fb", "https://gateway.example.com/flyte/v1/datastore/a%2Fb"},
+		{"http://flyte:8080", "/v1/packs", "http://flyte:8080/v1/packs"},
+		{"http://flyte:8080", "packs", "http://flyte:8080/v1/packs"},
+	} {
+		root, _ := url.Parse(tc.root)
+		href, _ := url.Parse(tc.href)
+		c := client{baseURL: getBaseURL(*root)}
+
+		assert.Equal(t, tc.want, c.resolveURL(href).String(), "%s against %s", tc.href, tc.root)
+	}
+}
+
+func Test_resolveTemplate_ShouldResolveTemplatesAgainstTheBaseURL(t *testing.T) {
+	root, _ := url.Parse("https://gateway.example.com/flyte")
+	c := client{baseURL: getBaseURL(*root)}
+
+	assert.Equal(t, "https://gateway.example.com/flyte/v1/datastore/{key}", c.resolveTemplate("/v1/datastore/{key}"))
+	assert.Equal(t, "https://gateway.example.com/flyte/v1/flows{?name}", c.resolveTemplate("flows{?name}"))
+	assert.Equal(t, "http://flyte-api/v1/flows{?name}", c.resolveTemplate("http://flyte-api/v1/flows{?name}"))
+}
+
+func Test_Client_ShouldWorkWithAFlyteApiBehindAPathPrefix(t *testing.T) {
+	// given a flyte api behind an ingress that strips the /flyte prefix, publishing links without it
+	var mu sync.Mutex
+	var paths []string
+	api := http.NewServeMux()
+	api.HandleFunc("/v1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"links": [{"href": "/v1/packs", "rel": "http://example.com/swagger#!/pack/listPacks"}]}`))
+	})
+	api.HandleFunc("/v1/packs", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"name": "Slack", "links": [
+			{"href": "/v1/packs/Slack/actions/take", "rel": "takeAction"},
+			{"href": "packs/Slack/events", "rel": "event"}
+		]}`))
+	})
+	api.HandleFunc("/v1/packs/Slack/actions/take", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": "1", "command": "SendMessage", "links": [{"href": "/v1/packs/Slack/actions/1/result", "rel": "actionResult"}]}`))
+	})
+	api.HandleFunc("/v1/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		http.StripPrefix("/flyte", api).ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	rootURL, _ := url.Parse(ts.URL + "/flyte")
+
+	// when
+	c := NewClient(rootURL, time.Second)
+	require.NoError(t, c.CreatePack(Pack{Name: "Slack"}))
+	a, err := c.TakeAction()
+	require.NoError(t, err)
+	require.NoError(t, c.CompleteAction(*a, Event{Name: "MessageSent"}))
+	require.NoError(t, c.PostEvent(Event{Name: "MessageSent"}))
+
+	// then
+	assert.Equal(t, []string{
+		"/flyte/v1",
+		"/flyte/v1/packs",
+		"/flyte/v1/packs/Slack/actions/take",
+		"/flyte/v1/packs/Slack/actions/1/result",
+		"/flyte/v1/packs/Slack/events",
+	}, paths)
+	packs, ok := c.(LinkProvider).ApiLinks().FindLink(RelListPacks)
+	require.True(t, ok)
+	assert.Equal(t, fmt.Sprintf("%s/flyte/v1/packs", ts.URL), packs.Href.String())
+}
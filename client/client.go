@@ -20,7 +20,6 @@ package client
 
 import (
 	"crypto/tls"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/ExpediaGroup/flyte-client/config"
@@ -32,6 +31,10 @@ import (
 	"time"
 )
 
+// Client is how packs talk to the flyte api. The flyte package only uses clients through this interface, so tests and
+// custom deployments can substitute their own implementation, such as flytetest.Client. Clients created by NewClient
+// also implement the optional interfaces PackUpdater, PackStatusUpdater, StatsReporter, Datastore, Flows,
+// ActionStreamer, PackFetcher and ActionHeartbeater, which packs use when they are implemented.
 type Client interface {
 	// CreatePack is responsible for posting your pack to the flyte server.
 	CreatePack(Pack) error
@@ -45,40 +48,166 @@ type Client interface {
 	GetFlyteHealthCheckURL() (*url.URL, error)
 }
 
+// PackUpdater is implemented by clients that can replace the registered definition of a pack. The client returned by
+// NewClient implements it.
+type PackUpdater interface {
+	// UpdatePack posts your pack to the flyte server, replacing the existing definition if the pack is already registered.
+	UpdatePack(Pack) error
+}
+
+// client is the Client created by NewClient
+var (
+	_ Client            = (*client)(nil)
+	_ PackUpdater       = (*client)(nil)
+	_ PackStatusUpdater = (*client)(nil)
+	_ StatsReporter     = (*client)(nil)
+	_ Datastore         = (*client)(nil)
+	_ Flows             = (*client)(nil)
+	_ ActionStreamer    = (*client)(nil)
+	_ PackFetcher       = (*client)(nil)
+	_ ActionHeartbeater = (*client)(nil)
+)
+
 type client struct {
-	eventsURL     *url.URL
-	baseURL       *url.URL
-	takeActionURL *url.URL
-	apiLinks      map[string][]Link
-	httpClient    *http.Client
+	baseURL    *url.URL
+	apiLinks   map[string][]Link
+	httpClient *http.Client
+	// the registered pack name and links, see setPackLinks
+	packLinks *packLinks
+	stats     *clientStats
+	// the response status codes treated as success, by operation, when they differ from the defaults
+	acceptedStatusCodes map[Operation][]int
+	rateLimiter         *rateLimiter
+	// used to construct the action result url when an action has no actionResult link
+	actionResultTemplate string
+	// how long to wait before retrying to get the api links, when it differs from the default
+	retryWait time.Duration
+	// how long an action stream may be idle, without actions or keepalive pings, before it is considered dead
+	streamIdleTimeout time.Duration
+	// how many times, and after how long, TakeAction is retried after a transient transport error
+	transientRetries      int
+	transientRetryBackoff time.Duration
+	// compresses request bodies, see WithCompression
+	compressor Compressor
+	hooks      hookList
+	// the attempt at a request the client is sending, when it is being retried
+	attempt int
+	// the User-Agent header, when it is set in full, else the version of the pack to include in the default
+	userAgent   string
+	packVersion string
+	// whether events are posted in the CloudEvents format, and the source they are posted with
+	cloudEvents      bool
+	cloudEventSource string
+	// checked before the pack is registered, see WithRegistrationLimits
+	registrationLimits RegistrationLimits
+	// limits on the stages of requests, see WithTimeouts
+	timeouts Timeouts
+	// connection pool settings, see WithConnectionPool
+	pool ConnectionPool
+	// whether HTTP/1.1 is forced, see WithHTTP1
+	http1 bool
+	// the copy of the http transport the client changes, rather than the transport it was given, see transport
+	ownTransport *http.Transport
+	// request bodies smaller than this are not compressed, see WithCompressionThreshold
+	compressionThreshold int
+	// the most bytes of a response body read, see WithMaxResponseSize
+	maxResponseBytes int64
+	// encodes event payloads and is asked for in responses, see WithCodec. JSON when nil
+	codec Codec
+	// whether responses are decoded strictly, see WithStrictJSON
+	strictJSON bool
+	// urls used in place of the api and pack links, see WithStaticLinks
+	staticLinks *StaticLinks
+	// the api links as last refreshed, and how often they are refreshed, see WithLinksRefresh
+	linksCache           *linksCache
+	linksRefreshInterval time.Duration
+	// the flyte api endpoints failed over to, see WithEndpoints
+	fallbackURLs     []*url.URL
+	failbackInterval time.Duration
+	endpoints        *endpoints
+	// discovers the flyte api hosts from DNS SRV records, see WithSRV
+	srv *SRVConfig
+	// the Unix domain socket connected to, for a unix:///path/to/socket flyte api url
+	unixSocket string
+	// the TLS config the flyte api is connected to with, see WithTLSConfig
+	tlsConfig *tls.Config
+	// how many times PostEvent is retried, and the wait before the first retry, see WithEventRetries
+	eventRetries      int
+	eventRetryBackoff time.Duration
+	// how many times CompleteAction is retried, and the wait before the first retry, see WithActionResultRetries
+	resultRetries      int
+	resultRetryBackoff time.Duration
 }
 
 const (
-	ApiVersion        = "v1"
+	ApiVersion = "v1"
+	// DefaultActionResultURLTemplate is where the flyte api accepts action results, see WithActionResultURLTemplate.
+	DefaultActionResultURLTemplate = "{baseURL}/packs/{packName}/actions/{actionId}/result"
 )
+
 var (
 	flyteApiRetryWait = 3 * time.Second
 )
 
-
 // To create a new client, please provide the url of the flyte server and the timeout.
+// The url can be of the form unix:///path/to/socket to connect to the flyte api over a Unix domain socket.
 // timeout specifies a time limit for requests made by this
 // client. A timeout of zero means no timeout.
 // Insecure mode is either true or false
-func NewClient(rootURL *url.URL, timeout time.Duration) Client {
-	return newClient(rootURL, timeout, false)
+// Optional client behaviour can be configured by passing in options.
+func NewClient(rootURL *url.URL, timeout time.Duration, opts ...Option) Client {
+	return newClient(rootURL, timeout, false, opts)
 }
 
-func NewInsecureClient(rootURL *url.URL, timeout time.Duration) Client {
-	return newClient(rootURL, timeout, true)
+func NewInsecureClient(rootURL *url.URL, timeout time.Duration, opts ...Option) Client {
+	return newClient(rootURL, timeout, true, opts)
 }
 
-func newClient(rootURL *url.URL, timeout time.Duration, isInsecure bool) Client {
+func newClient(rootURL *url.URL, timeout time.Duration, isInsecure bool, opts []Option) Client {
+	rootURL, socket := unixSocket(rootURL)
 	client := &client{
-		baseURL:    getBaseURL(*rootURL),
-		httpClient: newHttpClient(timeout, isInsecure),
+		baseURL:               getBaseURL(*rootURL),
+		httpClient:            newHttpClient(timeout, isInsecure, config.GetJWT()),
+		packLinks:             &packLinks{},
+		actionResultTemplate:  DefaultActionResultURLTemplate,
+		stats:                 newClientStats(),
+		transientRetries:      DefaultTransientRetries,
+		transientRetryBackoff: DefaultTransientRetryBackoff,
+		maxResponseBytes:      DefaultMaxResponseBytes,
+		linksRefreshInterval:  DefaultLinksRefreshInterval,
+		unixSocket:            socket,
+	}
+	client.eventRetries = DefaultEventRetries
+	client.eventRetryBackoff = DefaultEventRetryBackoff
+	client.resultRetries = DefaultActionResultRetries
+	client.resultRetryBackoff = DefaultActionResultRetryBackoff
+	for _, opt := range opts {
+		opt(client)
+	}
+	// the rate limiter is created up front, so its limits can be changed while requests are being made
+	client.rateLimits()
+	client.applyUnixSocket()
+	client.applyTLSConfig()
+	client.applyTimeouts()
+	client.applySRV()
+	client.applyConnectionPool()
+	client.applyProtocol()
+	if len(client.fallbackURLs) > 0 {
+		bases := []*url.URL{client.baseURL}
+		for _, u := range client.fallbackURLs {
+			if u.Scheme == "unix" {
+				log.Warn().Msgf("cannot fail over to the Unix domain socket %s, only the flyte api url can be one", u.Path)
+				continue
+			}
+			bases = append(bases, getBaseURL(*u))
+		}
+		client.endpoints = newEndpoints(bases, client.failbackInterval)
+	}
+	if client.staticLinks != nil {
+		client.useStaticLinks()
+	} else {
+		client.getApiLinks()
 	}
-	client.getApiLinks()
 	return client
 }
 
@@ -88,23 +217,42 @@ func getBaseURL(u url.URL) *url.URL {
 	return &u
 }
 
-func newHttpClient(timeout time.Duration, isInsecure bool) *http.Client {
+// resourceURL returns the url of the named resource in the collection at the url passed in. The name is escaped as a
+// single path segment, so it cannot address anything outside the collection, and must not be empty, "." or "..".
+func resourceURL(collection *url.URL, name string) (*url.URL, error) {
+	if name == "" || name == "." || name == ".." {
+		return nil, fmt.Errorf("invalid name %q", name)
+	}
+	u := *collection
+	u.RawPath = strings.TrimSuffix(collection.EscapedPath(), "/") + "/" + url.PathEscape(name)
+	u.Path = strings.TrimSuffix(collection.Path, "/") + "/" + name
+	return &u, nil
+}
+
+func newHttpClient(timeout time.Duration, isInsecure bool, jwt string) *http.Client {
 	httpClient := &http.Client{
 		Timeout: timeout,
 		Transport: &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: isInsecure},
+			// a custom TLS config stops the transport negotiating HTTP/2 unless it is asked to, see WithHTTP1
+			ForceAttemptHTTP2: true,
 		},
 	}
 
-	// this decorates the client transport with the jwt header
-	if config.GetJWT() != "" {
-		t := transportWithHeader{Header: make(http.Header), rt: httpClient.Transport}
-		t.Set("Authorization", fmt.Sprintf("Bearer %s", config.GetJWT()))
-		httpClient.Transport = t
-	}
+	httpClient.Transport = withJWT(httpClient.Transport, jwt)
 	return httpClient
 }
 
+// withJWT decorates the transport with the jwt authorisation header, if there is a jwt
+func withJWT(rt http.RoundTripper, jwt string) http.RoundTripper {
+	if jwt == "" {
+		return rt
+	}
+	t := transportWithHeader{Header: make(http.Header), rt: rt}
+	t.Set("Authorization", fmt.Sprintf("Bearer %s", jwt))
+	return t
+}
+
 type transportWithHeader struct {
 	http.Header
 	rt http.RoundTripper
@@ -120,141 +268,323 @@ func (h transportWithHeader) RoundTrip(req *http.Request) (*http.Response, error
 // getApiLinks retrieves links from the flyte api server that are useful to the client such as packs url and health url and so on
 func (c *client) getApiLinks() {
 	var links map[string][]Link
+	var etag string
 
-	if err := c.getStruct(c.baseURL, &links); err != nil {
+	for attempt := 1; ; attempt++ {
+		var err error
+		var endpoint int
+		if c.endpoints != nil {
+			endpoint, _ = c.endpoints.current()
+		}
+		links, etag, err = c.withAttempt(attempt).fetchApiLinks("")
+		if err == nil {
+			break
+		}
 		log.Err(err).Msg("cannot get api links")
-		time.Sleep(flyteApiRetryWait)
-		c.getApiLinks()
-		return
+		if c.endpoints != nil {
+			// unless the request failed without a response, and the client has already failed over
+			if active, _ := c.endpoints.current(); active == endpoint {
+				c.useHealthyEndpoint()
+			}
+		}
+		wait := flyteApiRetryWait
+		if c.retryWait > 0 {
+			wait = c.retryWait
+		}
+		if d, ok := RetryAfter(err); ok {
+			wait = d
+		}
+		c.stats.retrying(wait)
+		c.hooks.retry(retryInfo(OpGetApiLinks, http.MethodGet, c.baseURL, attempt, err, wait))
+		time.Sleep(wait)
 	}
 	c.apiLinks = links
+	c.linksCache = newLinksCache(links, etag)
+	c.stats.linksRefreshed()
 }
 
 // CreatePack is responsible for posting your pack to the flyte server, making it available to be used by the flows.
 func (c *client) CreatePack(pack Pack) error {
+	if !c.registers() {
+		return c.setPackLinks(pack)
+	}
+
+	if err := c.registerPack(&pack); err != nil {
+		return err
+	}
+	return c.setPackLinks(pack)
+}
 
-	var err error
-	if err = c.registerPack(&pack); err != nil {
+// UpdatePack is responsible for posting your pack to the flyte server. If the flyte server reports that the pack
+// is already registered, the existing definition is fetched and compared with the one passed in and, if they differ,
+// the existing definition is replaced.
+func (c *client) UpdatePack(pack Pack) error {
+	if !c.registers() {
+		return c.setPackLinks(pack)
+	}
+	err := c.registerPack(&pack)
+	if err == nil {
+		return c.setPackLinks(pack)
+	}
+	if _, ok := err.(ConflictError); !ok {
 		return err
 	}
 
-	if c.eventsURL, err = findURLByRel(pack.Links, "event"); err != nil {
+	existing, selfURL, err := c.getRegisteredPack(pack)
+	if err != nil {
 		return err
 	}
 
-	if c.takeActionURL, err = findURLByRel(pack.Links, "takeAction"); err != nil {
+	diff := DiffPacks(*existing, pack)
+	if diff.Empty() {
+		log.Info().Msgf("pack %q is already registered with an identical definition", pack.Name)
+		return c.setPackLinks(*existing)
+	}
+
+	log.Info().Msgf("updating definition of pack %q: %s", pack.Name, diff)
+	if err := c.replacePack(selfURL, &pack); err != nil {
 		return err
 	}
+	return c.setPackLinks(pack)
+}
 
+// setPackLinks stores the links the client needs from a registered pack, or the static links in their place
+func (c *client) setPackLinks(pack Pack) error {
+	eventsURL, err := c.packLink(pack, RelEvent)
+	if err != nil {
+		return err
+	}
+	takeActionURL, err := c.packLink(pack, RelTakeAction)
+	if err != nil {
+		return err
+	}
+
+	// older flyte servers do not support pack status updates, so the status link is optional
+	statusURL, _ := c.linkURL(pack.Links, RelPackStatus)
+	// as are action streams
+	streamURL, _ := c.linkURL(pack.Links, RelActionStream)
+	c.packLinks.set(pack.Name, eventsURL, takeActionURL, statusURL, streamURL)
 	return nil
 }
 
 // registerPack posts the pack, and handles the response
 func (c *client) registerPack(pack *Pack) error {
+	c.packLinks.setName(pack.Name)
+	if err := checkRegistrationLimits(*pack, c.registrationLimits); err != nil {
+		return err
+	}
 	packsURL, err := c.getPacksURL()
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.post(packsURL, pack)
+	resp, err := c.post(OpRegisterPack, packsURL, pack)
 	if err != nil {
-		return fmt.Errorf("error posting pack %+v to %s: %v", pack, packsURL.String(), err)
+		return fmt.Errorf("error posting pack %+v to %s: %w", pack, packsURL.String(), err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("pack not created, response was: %+v", resp)
+	switch {
+	case c.accepts(OpRegisterPack, resp.StatusCode):
+	case resp.StatusCode == http.StatusConflict:
+		return ConflictError{fmt.Sprintf("pack %q is already registered at %s", pack.Name, packsURL.String())}
+	case resp.StatusCode == http.StatusRequestEntityTooLarge:
+		return payloadTooLargeError(*pack, c.registrationLimits, resp)
+	default:
+		return fmt.Errorf("pack not created, response was: %w", newResponseError(resp))
 	}
 
-	err = json.NewDecoder(resp.Body).Decode(pack)
+	return c.decodeResponse(resp, pack)
+}
+
+// PackFetcher is implemented by clients that can fetch the definition of a pack as it is registered on the flyte
+// server, so it can be compared with the definition the pack registered. The client returned by NewClient implements it.
+type PackFetcher interface {
+	// GetPack returns the registered definition of the pack with the same name as the pack passed in, preferring one
+	// with the same labels. If the pack is not registered an error matching ErrNotFound is returned.
+	GetPack(Pack) (*Pack, error)
+}
+
+// GetPack returns the registered definition of the pack with the same name, and preferably labels, as the pack passed in.
+func (c *client) GetPack(pack Pack) (*Pack, error) {
+	existing, _, err := c.getRegisteredPack(pack)
+	return existing, err
+}
+
+// getRegisteredPack finds the registered pack with the same name and labels as the pack passed in, returning its
+// full definition and the url it can be found at
+func (c *client) getRegisteredPack(pack Pack) (*Pack, *url.URL, error) {
+	packsURL, err := c.getPacksURL()
 	if err != nil {
-		return fmt.Errorf("could not deserialise response: %s", err)
+		return nil, nil, err
 	}
 
-	return nil
+	var packs struct {
+		Packs []Pack `json:"packs"`
+	}
+	if err := c.getStruct(OpListPacks, packsURL, &packs); err != nil {
+		return nil, nil, err
+	}
+
+	// a pack with matching labels is preferred, otherwise any pack with the same name is the one being updated
+	var match *Pack
+	for i, p := range packs.Packs {
+		if p.Name != pack.Name {
+			continue
+		}
+		if match == nil || equalLabels(p.Labels, pack.Labels) {
+			match = &packs.Packs[i]
+		}
+	}
+	if match == nil {
+		return nil, nil, NotFoundError{fmt.Sprintf("pack %q not found at %s", pack.Name, packsURL.String())}
+	}
+
+	selfURL, err := c.linkURL(match.Links, RelSelf)
+	if err != nil {
+		return nil, nil, err
+	}
+	existing := &Pack{}
+	if err := c.getStruct(OpGetPack, selfURL, existing); err != nil {
+		return nil, nil, err
+	}
+	return existing, selfURL, nil
+}
+
+// replacePack puts the pack to the url of the existing registration, and handles the response
+func (c *client) replacePack(u *url.URL, pack *Pack) error {
+	resp, err := c.put(OpReplacePack, u, pack)
+	if err != nil {
+		return fmt.Errorf("error putting pack %+v to %s: %w", pack, u.String(), err)
+	}
+	defer resp.Body.Close()
+
+	if !c.accepts(OpReplacePack, resp.StatusCode) {
+		return fmt.Errorf("pack not updated, response was: %w", newResponseError(resp))
+	}
+
+	return c.decodeResponse(resp, pack)
 }
 
 // getPacksURL finds out where packs should be posted to
 func (c *client) getPacksURL() (*url.URL, error) {
-	return findURLByRel(c.apiLinks["links"], "pack/listPacks")
+	return c.apiURL(RelListPacks)
 }
 
 // GetFlyteHealthCheckURL finds out the flyte healthcheck url
 func (c *client) GetFlyteHealthCheckURL() (*url.URL, error) {
-	return findURLByRel(c.apiLinks["links"], "info/health")
+	return c.apiURL(RelHealth)
 }
 
 // PostEvent posts events to the flyte server
 func (c client) PostEvent(event Event) error {
 	event.CreatedAt = time.Now().UTC()
-	if c.eventsURL == nil {
+	eventsURL := c.packLinks.events()
+	if eventsURL == nil {
 		return errors.New("eventsURL not initialised - you must post a pack def first")
 	}
-	resp, err := c.post(c.eventsURL, event)
+	body := c.eventBody(event)
+	resp, err := c.retryPost(OpPostEvent, eventsURL, c.eventRetries, c.eventRetryBackoff, func(attempt int) (*http.Response, error) {
+		return c.withAttempt(attempt).postPayload(OpPostEvent, eventsURL, body)
+	})
 	if err != nil {
-		return fmt.Errorf("error posting event %+v to %s: %v", event, c.eventsURL.String(), err)
+		return fmt.Errorf("error posting event %+v to %s: %w", event, eventsURL.String(), err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusAccepted {
-		return fmt.Errorf("event %+v not accepted, response was: %+v", event, resp)
+	if !c.accepts(OpPostEvent, resp.StatusCode) {
+		return fmt.Errorf("event %+v not accepted, response was: %w", event, newResponseError(resp))
 	}
+	c.stats.eventPosted()
 	return nil
 }
 
 // TakeAction takes the next action the pack should process. If no action is available, nil is returned.
 func (c client) TakeAction() (*Action, error) {
-	if c.takeActionURL == nil {
+	takeActionURL := c.packLinks.takeAction()
+	if takeActionURL == nil {
 		return nil, errors.New("takeActionURL not initialised - you must post a pack def first")
 	}
 
-	resp, err := c.post(c.takeActionURL, nil)
+	resp, err := c.retryTransient(OpTakeAction, takeActionURL, func(attempt int) (*http.Response, error) {
+		return c.withAttempt(attempt).post(OpTakeAction, takeActionURL, nil)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error taking action from %s: %v", c.takeActionURL.String(), err)
+		return nil, fmt.Errorf("error taking action from %s: %w", takeActionURL.String(), err)
 	}
 	defer resp.Body.Close()
 
 	switch resp.StatusCode {
 	case http.StatusOK:
 		a := &Action{}
-		err = json.NewDecoder(resp.Body).Decode(a)
-		return a, err
+		if err := c.decodeResponse(resp, a); err != nil {
+			return nil, err
+		}
+		return a, nil
 	case http.StatusNoContent:
 		return nil, nil
 	case http.StatusNotFound:
-		return nil, NotFoundError{fmt.Sprintf("resource not found at %s", c.takeActionURL.String())}
+		return nil, NotFoundError{fmt.Sprintf("resource not found at %s", takeActionURL.String())}
 	default:
-		return nil, fmt.Errorf("error taking action from %s, response was: %+v", c.takeActionURL.String(), resp)
+		return nil, fmt.Errorf("error taking action from %s, response was: %w", takeActionURL.String(), newResponseError(resp))
 	}
 }
 
 // CompleteAction posts the action result to the flyte server.
 func (c client) CompleteAction(action Action, event Event) error {
 	event.CreatedAt = time.Now().UTC()
-	resultURL, err := findURLByRel(action.Links, "actionResult")
+	resultURL, err := c.getActionResultURL(action)
 	if err != nil {
 		return err
 	}
-	resp, err := c.post(resultURL, event)
+	body := c.eventBody(event)
+	resp, err := c.retryPost(OpCompleteAction, resultURL, c.resultRetries, c.resultRetryBackoff, func(attempt int) (*http.Response, error) {
+		return c.withAttempt(attempt).postPayload(OpCompleteAction, resultURL, body)
+	})
 	if err != nil {
-		return fmt.Errorf("error posting action result %+v to %s: %v", event, resultURL.String(), err)
+		return fmt.Errorf("error posting action result %+v to %s: %w", event, resultURL.String(), err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusAccepted {
-		return fmt.Errorf("action result event %+v not processed successfully by flyte api, response was: %+v", event, resp)
+	if !c.accepts(OpCompleteAction, resp.StatusCode) {
+		return fmt.Errorf("action result event %+v not processed successfully by flyte api, response was: %w", event, newResponseError(resp))
 	}
 	return nil
 }
 
-// findURLByRel returns a link URL if found from the links passed in, else it will return an error.
-func findURLByRel(links []Link, rel string) (*url.URL, error) {
-	for _, l := range links {
-		if strings.HasSuffix(l.Rel, rel) {
-			return l.Href, nil
-		}
+// getActionResultURL finds out where the action result should be posted to. Some flyte api versions send actions
+// without an actionResult link, in which case the url is constructed from the action result template.
+func (c client) getActionResultURL(action Action) (*url.URL, error) {
+	resultURL, err := c.linkURL(action.Links, RelActionResult)
+	if err == nil {
+		return resultURL, nil
+	}
+	packName := c.packLinks.name()
+	if c.actionResultTemplate == "" || action.ID == "" || packName == "" {
+		return nil, err
 	}
-	return nil, fmt.Errorf("could not find link with rel %q in %v", rel, links)
+
+	baseURL := ""
+	if c.baseURL != nil {
+		baseURL = strings.TrimSuffix(c.baseURL.String(), "/")
+	}
+	u := strings.NewReplacer(
+		"{baseURL}", baseURL,
+		"{packName}", url.PathEscape(packName),
+		"{actionId}", url.PathEscape(action.ID),
+	).Replace(c.actionResultTemplate)
+
+	resultURL, perr := url.Parse(u)
+	if perr != nil {
+		return nil, fmt.Errorf("%w, and the fallback action result url %q is invalid: %v", err, u, perr)
+	}
+	log.Warn().Msgf("action %q for command %q has no actionResult link, falling back to %s", action.ID, action.CommandName, resultURL)
+	return resultURL, nil
+}
+
+// findURLByRel returns a link URL if found from the links passed in, else it will return an error.
+func findURLByRel(links []Link, rel Rel) (*url.URL, error) {
+	return Links(links).URL(rel)
 }
 
 type NotFoundError struct {
@@ -264,3 +594,21 @@ type NotFoundError struct {
 func (e NotFoundError) Error() string {
 	return e.Message
 }
+
+// Is reports whether the target is ErrNotFound.
+func (e NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+type ConflictError struct {
+	Message string
+}
+
+func (e ConflictError) Error() string {
+	return e.Message
+}
+
+// Is reports whether the target is ErrConflict.
+func (e ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
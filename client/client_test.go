@@ -19,6 +19,7 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/ExpediaGroup/flyte-client/config"
 	"github.com/stretchr/testify/assert"
@@ -27,6 +28,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -55,6 +57,27 @@ func Test_NewClient_ShouldSendAuthorizationHeaderWhenRetrievingApiLinks(t *testi
 	assert.Equal(t, "Bearer a.jwt.token", rec.reqs[0].Header.Get("Authorization"))
 }
 
+func Test_NewClient_ShouldSendTheAuthorizationHeaderOfTheJWTOption(t *testing.T) {
+	// given a jwt in the environment
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+	setEnv(config.FlyteJWTEnvName, "a.jwt.token")
+
+	ts, rec := mockServerWithRecorder(http.StatusCreated, flyteApiLinksResponse)
+	defer ts.Close()
+	baseUrl, _ := url.Parse(ts.URL)
+
+	// when clients are created with their own jwt, or none
+	NewClient(baseUrl, 10*time.Second, WithJWT("staging.jwt.token"))
+	NewClient(baseUrl, 10*time.Second, WithJWT(""))
+
+	// then the jwt in the environment is not used
+	require.Len(t, rec.reqs, 2)
+	assert.Equal(t, "Bearer staging.jwt.token", rec.reqs[0].Header.Get("Authorization"))
+	assert.Equal(t, "", rec.reqs[1].Header.Get("Authorization"))
+}
+
 func Test_NewClient_ShouldNotSendAuthorizationHeaderWhenRetrievingApiLinks(t *testing.T) {
 	// given the jwt environment variable does not exist and we have a running server set to respond with flyte api links
 	ts, rec := mockServerWithRecorder(http.StatusCreated, flyteApiLinksResponse)
@@ -194,12 +217,12 @@ func Test_CreatePack_ShouldRegisterPackWithApiAndPopulateClientWithLinks(t *test
 	err := c.CreatePack(Pack{Name: "Slack"})
 	require.NoError(t, err)
 
-	assert.NotNil(t, c.takeActionURL)
-	assert.Equal(t, "http://example.com/v1/packs/Slack/actions/take", c.takeActionURL.String())
+	assert.NotNil(t, c.packLinks.takeActionURL)
+	assert.Equal(t, "http://example.com/v1/packs/Slack/actions/take", c.packLinks.takeActionURL.String())
 	assert.Len(t, rec.reqs, 1)
 
-	assert.NotNil(t, c.eventsURL)
-	assert.Equal(t, "http://example.com/v1/packs/Slack/events", c.eventsURL.String())
+	assert.NotNil(t, c.packLinks.eventsURL)
+	assert.Equal(t, "http://example.com/v1/packs/Slack/events", c.packLinks.eventsURL.String())
 }
 
 func Test_CreatePack_ShouldReturnErrorIfTakeActionsLinksAreNotSet(t *testing.T) {
@@ -231,6 +254,7 @@ func Test_CreatePack_ShouldReturnErrorWhenLinksDoNotContainCorrectRel(t *testing
 	c := &client{
 		httpClient: &http.Client{Timeout: 5 * time.Second},
 		apiLinks:   emptyLinks,
+		packLinks:  &packLinks{},
 	}
 
 	err := c.CreatePack(Pack{Name: "Slack"})
@@ -253,7 +277,8 @@ func Test_CreatePack_ShouldReturnErrorIfPostingPackFails(t *testing.T) {
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
-		apiLinks: map[string][]Link{"links": {{Href: baseUrl, Rel: "pack/listPacks"}}},
+		apiLinks:  map[string][]Link{"links": {{Href: baseUrl, Rel: "pack/listPacks"}}},
+		packLinks: &packLinks{},
 	}
 
 	// when
@@ -276,6 +301,18 @@ func Test_CreatePack_ShouldReturnErrorIfStatusCodeIsNotStatusCreated(t *testing.
 	assert.Contains(t, err.Error(), "pack not created, response was")
 }
 
+func Test_CreatePack_ShouldReturnConflictErrorIfPackIsAlreadyRegistered(t *testing.T) {
+	ts := mockServer(http.StatusConflict, "")
+	defer ts.Close()
+
+	c := newTestClient(ts.URL, t)
+
+	err := c.CreatePack(Pack{Name: "Slack"})
+
+	require.IsType(t, ConflictError{}, err)
+	assert.EqualError(t, err, fmt.Sprintf("pack \"Slack\" is already registered at %s", ts.URL))
+}
+
 func Test_CreatePack_ShouldReturnErrorIfResponseCannotBeDecoded(t *testing.T) {
 	ts := mockServer(http.StatusCreated, "invalidjson")
 	defer ts.Close()
@@ -286,6 +323,148 @@ func Test_CreatePack_ShouldReturnErrorIfResponseCannotBeDecoded(t *testing.T) {
 	assert.EqualError(t, err, "could not deserialise response: invalid character 'i' looking for beginning of value")
 }
 
+/**
+UpdatePack tests
+*/
+
+func Test_UpdatePack_ShouldRegisterPackWhenItDoesNotExist(t *testing.T) {
+	ts, rec := mockServerWithRecorder(http.StatusCreated, slackPackResponse)
+	defer ts.Close()
+
+	c := newTestClient(ts.URL, t)
+
+	err := c.UpdatePack(Pack{Name: "Slack"})
+	require.NoError(t, err)
+
+	require.Len(t, rec.reqs, 1)
+	assert.Equal(t, http.MethodPost, rec.reqs[0].Method)
+	assert.Equal(t, "http://example.com/v1/packs/Slack/actions/take", c.packLinks.takeActionURL.String())
+	assert.Equal(t, "http://example.com/v1/packs/Slack/events", c.packLinks.eventsURL.String())
+}
+
+func Test_UpdatePack_ShouldReplaceExistingDefinitionOnConflict(t *testing.T) {
+	// given a server that already has a different definition of the pack registered
+	var methods []string
+	var putBody Pack
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusConflict)
+		case r.Method == http.MethodGet && r.URL.Path == "/":
+			fmt.Fprintf(w, `{"packs": [{"name": "Slack", "links": [{"href": "%s/Slack", "rel": "self"}]}]}`, ts.URL)
+		case r.Method == http.MethodGet && r.URL.Path == "/Slack":
+			w.Write([]byte(`{"name": "Slack", "commands": [{"name": "sendMessage", "events": ["MessageSent"]}]}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/Slack":
+			json.NewDecoder(r.Body).Decode(&putBody)
+			w.Write([]byte(slackPackResponse))
+		}
+	}))
+	defer ts.Close()
+
+	c := newTestClient(ts.URL, t)
+
+	// when the pack is updated with a new command
+	err := c.UpdatePack(Pack{
+		Name:     "Slack",
+		Commands: []Command{{Name: "sendMessage", EventNames: []string{"MessageSent"}}, {Name: "getHistory", EventNames: []string{"History"}}},
+	})
+
+	// then the new definition replaces the existing one
+	require.NoError(t, err)
+	assert.Equal(t, []string{"POST /", "GET /", "GET /Slack", "PUT /Slack"}, methods)
+	assert.Len(t, putBody.Commands, 2)
+	assert.Equal(t, "http://example.com/v1/packs/Slack/actions/take", c.packLinks.takeActionURL.String())
+	assert.Equal(t, "http://example.com/v1/packs/Slack/events", c.packLinks.eventsURL.String())
+}
+
+func Test_UpdatePack_ShouldNotReplaceIdenticalDefinitionOnConflict(t *testing.T) {
+	// given a server that already has the same definition of the pack registered
+	var methods []string
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusConflict)
+		case r.Method == http.MethodGet && r.URL.Path == "/":
+			fmt.Fprintf(w, `{"packs": [{"name": "Slack", "links": [{"href": "%s/Slack", "rel": "self"}]}]}`, ts.URL)
+		case r.Method == http.MethodGet && r.URL.Path == "/Slack":
+			w.Write([]byte(slackPackResponse))
+		}
+	}))
+	defer ts.Close()
+
+	c := newTestClient(ts.URL, t)
+
+	// when
+	err := c.UpdatePack(Pack{Name: "Slack"})
+
+	// then the existing definition is used as is
+	require.NoError(t, err)
+	assert.Equal(t, []string{"POST /", "GET /", "GET /Slack"}, methods)
+	assert.Equal(t, "http://example.com/v1/packs/Slack/actions/take", c.packLinks.takeActionURL.String())
+}
+
+func Test_UpdatePack_ShouldReturnErrorIfConflictingPackCannotBeFound(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.Write([]byte(`{"packs": []}`))
+	}))
+	defer ts.Close()
+
+	c := newTestClient(ts.URL, t)
+
+	err := c.UpdatePack(Pack{Name: "Slack"})
+
+	require.IsType(t, NotFoundError{}, err)
+	assert.EqualError(t, err, fmt.Sprintf("pack \"Slack\" not found at %s", ts.URL))
+}
+
+func Test_GetPack_ShouldReturnTheRegistrationWithMatchingLabels(t *testing.T) {
+	// given the pack is registered twice, with different labels
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprintf(w, `{"packs": [{"name": "Slack", "labels": {"env": "test"}, "links": [{"href": "%[1]s/Slack-test", "rel": "self"}]},`+
+				`{"name": "Slack", "labels": {"env": "prod"}, "links": [{"href": "%[1]s/Slack-prod", "rel": "self"}]}]}`, ts.URL)
+		case "/Slack-prod":
+			w.Write([]byte(`{"name": "Slack", "labels": {"env": "prod"}, "commands": [{"name": "sendMessage", "events": ["MessageSent"]}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	c := newTestClient(ts.URL, t)
+
+	// when
+	pack, err := c.GetPack(Pack{Name: "Slack", Labels: map[string]string{"env": "prod"}})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "prod"}, pack.Labels)
+	assert.Equal(t, []Command{{Name: "sendMessage", EventNames: []string{"MessageSent"}}}, pack.Commands)
+}
+
+func Test_GetPack_ShouldReturnNotFoundWhenThePackIsNotRegistered(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"packs": []}`))
+	}))
+	defer ts.Close()
+
+	c := newTestClient(ts.URL, t)
+
+	_, err := c.GetPack(Pack{Name: "Slack"})
+
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
 /**
 PostEvent tests
 */
@@ -305,7 +484,7 @@ func Test_PostEvent_ShouldPostEvent(t *testing.T) {
 
 	// and an events url set
 	u, _ := url.Parse(fmt.Sprintf("%s/v1/packs/Slack/events", ts.URL))
-	c.eventsURL = u
+	c.packLinks.eventsURL = u
 
 	// when
 	want := Event{Name: "Dave", Payload: `{"some":"thing"}`}
@@ -342,7 +521,7 @@ func Test_PostEvent_ShouldSendAuthorizationHeader(t *testing.T) {
 
 	// and an events url set
 	u, _ := url.Parse(fmt.Sprintf("%s/v1/packs/Slack/events", ts.URL))
-	c.eventsURL = u
+	c.packLinks.eventsURL = u
 
 	// when
 	err := c.PostEvent(Event{Name: "Dave", Payload: `{"some":"thing"}`})
@@ -353,6 +532,45 @@ func Test_PostEvent_ShouldSendAuthorizationHeader(t *testing.T) {
 	assert.Equal(t, "Bearer a.jwt.token", rec.reqs[0].Header.Get("Authorization"))
 }
 
+func Test_WithTransport_ShouldSendRequestsWithTheTransportAndKeepTheAuthorizationHeader(t *testing.T) {
+	// given we have a running server
+	ts, rec := mockServerWithRecorder(http.StatusAccepted, `{"some":"response"}`)
+	defer ts.Close()
+
+	// and the jwt environment variable exists
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+	setEnv(config.FlyteJWTEnvName, "a.jwt.token")
+
+	// and a client with its own transport
+	var sent int32
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&sent, 1)
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	c := newTestClient(ts.URL, t)
+	c.httpClient = newHttpClient(5*time.Second, false, config.GetJWT())
+	WithTransport(transport)(c)
+	u, _ := url.Parse(fmt.Sprintf("%s/v1/packs/Slack/events", ts.URL))
+	c.packLinks.eventsURL = u
+
+	// when
+	err := c.PostEvent(Event{Name: "Dave", Payload: `{"some":"thing"}`})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&sent))
+	require.Len(t, rec.reqs, 1)
+	assert.Equal(t, "Bearer a.jwt.token", rec.reqs[0].Header.Get("Authorization"))
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
 func Test_PostEvent_ShouldNotSendAuthorizationHeader(t *testing.T) {
 	// given we have a running server
 	ts, rec := mockServerWithRecorder(http.StatusAccepted, `{"some":"response"}`)
@@ -363,7 +581,7 @@ func Test_PostEvent_ShouldNotSendAuthorizationHeader(t *testing.T) {
 
 	// and an events url set
 	u, _ := url.Parse(fmt.Sprintf("%s/v1/packs/Slack/events", ts.URL))
-	c.eventsURL = u
+	c.packLinks.eventsURL = u
 
 	// when
 	err := c.PostEvent(Event{Name: "Dave", Payload: `{"some":"thing"}`})
@@ -394,7 +612,7 @@ func Test_TakeAction_ShouldSendAuthorizationHeader(t *testing.T) {
 
 	// and a take action url set
 	u, _ := url.Parse(fmt.Sprintf("%s/v1/packs/Slack/actions/take", ts.URL))
-	c.takeActionURL = u
+	c.packLinks.takeActionURL = u
 
 	// when
 	_, err := c.TakeAction()
@@ -405,6 +623,21 @@ func Test_TakeAction_ShouldSendAuthorizationHeader(t *testing.T) {
 	assert.Equal(t, "Bearer a.jwt.token", rec.reqs[0].Header.Get("Authorization"))
 }
 
+func Test_TakeAction_ShouldReturnTheActionCorrelationIdentifiers(t *testing.T) {
+	// given
+	ts := mockServer(http.StatusOK, `{"id": "42", "command": "Deploy", "correlationId": "c-1", "flowName": "deploy-flow", "stepId": "deploy"}`)
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	c.packLinks.takeActionURL, _ = url.Parse(ts.URL + "/v1/packs/Slack/actions/take")
+
+	// when
+	a, err := c.TakeAction()
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, Correlation{ID: "c-1", ActionID: "42", FlowName: "deploy-flow", StepID: "deploy"}, a.Correlation())
+}
+
 func Test_TakeAction_ShouldNotSendAuthorizationHeader(t *testing.T) {
 	// given we have a running server
 	ts, rec := mockServerWithRecorder(http.StatusOK, `{"some":"response"}`)
@@ -415,7 +648,7 @@ func Test_TakeAction_ShouldNotSendAuthorizationHeader(t *testing.T) {
 
 	// and a take action url set
 	u, _ := url.Parse(fmt.Sprintf("%s/v1/packs/Slack/actions/take", ts.URL))
-	c.takeActionURL = u
+	c.packLinks.takeActionURL = u
 
 	// when
 	_, err := c.TakeAction()
@@ -434,7 +667,7 @@ func Test_TakeAction_ShouldReturnSpecificErrorTypeAndMessageWhenResourceIsNotFou
 	u, err := url.Parse(ts.URL + "/take/action/url")
 	require.NoError(t, err)
 
-	c.takeActionURL = u
+	c.packLinks.takeActionURL = u
 	_, err = c.TakeAction()
 
 	require.IsType(t, NotFoundError{}, err)
@@ -493,6 +726,40 @@ func Test_CompleteAction_ShouldNotSendAuthorizationHeader(t *testing.T) {
 	assert.Equal(t, "", rec.reqs[0].Header.Get("Authorization"))
 }
 
+func Test_CompleteAction_ShouldFallBackToActionResultTemplateWhenActionHasNoActionResultLink(t *testing.T) {
+	// given we have a running server
+	ts, rec := mockServerWithRecorder(http.StatusAccepted, "")
+	defer ts.Close()
+
+	// and a client for a registered pack, using the default action result template
+	c := newTestClient(ts.URL, t)
+	c.baseURL, _ = url.Parse(ts.URL + "/v1")
+	c.packLinks.packName = "Slack"
+	c.actionResultTemplate = DefaultActionResultURLTemplate
+
+	// when an action without an actionResult link is completed
+	err := c.CompleteAction(Action{ID: "a1", CommandName: "sendMessage"}, Event{Name: "MessageSent"})
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, rec.reqs, 1)
+	assert.Equal(t, "/v1/packs/Slack/actions/a1/result", rec.reqs[0].URL.Path)
+}
+
+func Test_CompleteAction_ShouldReturnErrorWhenActionHasNoActionResultLinkAndFallbackIsDisabled(t *testing.T) {
+	ts, rec := mockServerWithRecorder(http.StatusAccepted, "")
+	defer ts.Close()
+
+	c := newTestClient(ts.URL, t)
+	c.packLinks.packName = "Slack"
+	WithActionResultURLTemplate("")(c)
+
+	err := c.CompleteAction(Action{ID: "a1", CommandName: "sendMessage"}, Event{Name: "MessageSent"})
+
+	assert.EqualError(t, err, `could not find link with rel "actionResult" in []`)
+	assert.Empty(t, rec.reqs)
+}
+
 /**
   GetFlyteHealthCheckURL tests
 */
@@ -643,8 +910,9 @@ func newTestClient(serverURL string, t *testing.T) *client {
 	require.NoError(t, err)
 
 	return &client{
-		httpClient: newHttpClient(5*time.Second, false),
+		httpClient: newHttpClient(5*time.Second, false, config.GetJWT()),
 		apiLinks:   map[string][]Link{"links": {{Href: u, Rel: "pack/listPacks"}}},
+		packLinks:  &packLinks{},
 	}
 }
 
@@ -0,0 +1,103 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// CloudEventsContentType is the content type of events posted in CloudEvents structured mode.
+const CloudEventsContentType = "application/cloudevents+json"
+
+// CloudEvent is an event in the CloudEvents 1.0 JSON format, as posted by clients created with WithCloudEvents.
+// The correlation identifiers of the event, if it has any, are sent as extension attributes.
+type CloudEvent struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Time            time.Time   `json:"time"`
+	DataContentType string      `json:"datacontenttype"`
+	Data            interface{} `json:"data"`
+	CorrelationID   string      `json:"correlationid,omitempty"`
+	ActionID        string      `json:"actionid,omitempty"`
+	FlowName        string      `json:"flowname,omitempty"`
+	StepID          string      `json:"stepid,omitempty"`
+	TraceParent     string      `json:"traceparent,omitempty"` // the distributed tracing extension attribute
+}
+
+// traceParent returns the traceparent the event is posted with, if it has one
+func (e CloudEvent) traceParent() string {
+	return e.TraceParent
+}
+
+// ContentType returns CloudEventsContentType, the content type the event is posted with.
+func (CloudEvent) ContentType() string {
+	return CloudEventsContentType
+}
+
+// WithCloudEvents posts events, and action result events, in the CloudEvents 1.0 structured format rather than the
+// flyte event format, for flyte apis that pass events on to event meshes. Each event has a random id, its type is
+// "flyte.<pack name>.<event name>" and its source is the source passed in, or "flyte/packs/<pack name>" if it is
+// empty.
+func WithCloudEvents(source string) Option {
+	return func(c *client) {
+		c.cloudEvents = true
+		c.cloudEventSource = source
+	}
+}
+
+// eventBody returns the body to post for the event
+func (c client) eventBody(e Event) interface{} {
+	if !c.cloudEvents {
+		return e
+	}
+	packName := c.packLinks.name()
+	source := c.cloudEventSource
+	if source == "" {
+		source = "flyte/packs/" + packName
+	}
+	ce := CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              newEventID(),
+		Source:          source,
+		Type:            fmt.Sprintf("flyte.%s.%s", packName, e.Name),
+		Time:            e.CreatedAt,
+		DataContentType: "application/json",
+		Data:            e.Payload,
+	}
+	if e.Correlation != nil {
+		ce.CorrelationID = e.Correlation.ID
+		ce.ActionID = e.Correlation.ActionID
+		ce.FlowName = e.Correlation.FlowName
+		ce.StepID = e.Correlation.StepID
+		ce.TraceParent = e.Correlation.TraceParent
+	}
+	return ce
+}
+
+// newEventID returns a random 128 bit event id
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_WithCloudEvents_ShouldPostEventsInCloudEventsStructuredMode(t *testing.T) {
+	// given
+	ts, rec := mockServerWithRecorder(http.StatusAccepted, "")
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	c.packLinks.packName = "Slack"
+	c.packLinks.eventsURL, _ = url.Parse(ts.URL + "/events")
+	WithCloudEvents("")(c)
+
+	// when
+	err := c.PostEvent(Event{
+		Name:        "MessageSent",
+		Payload:     map[string]string{"text": "hello"},
+		Correlation: &Correlation{ID: "c-1", ActionID: "42"},
+	})
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, rec.reqs, 1)
+	assert.Equal(t, CloudEventsContentType, rec.reqs[0].Header.Get("Content-Type"))
+	var ce map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.body[0], &ce))
+	assert.Equal(t, "1.0", ce["specversion"])
+	assert.Equal(t, "flyte/packs/Slack", ce["source"])
+	assert.Equal(t, "flyte.Slack.MessageSent", ce["type"])
+	assert.Equal(t, "application/json", ce["datacontenttype"])
+	assert.Equal(t, map[string]interface{}{"text": "hello"}, ce["data"])
+	assert.Equal(t, "c-1", ce["correlationid"])
+	assert.Equal(t, "42", ce["actionid"])
+	assert.Len(t, ce["id"], 32)
+	eventTime, err := time.Parse(time.RFC3339Nano, ce["time"].(string))
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), eventTime, time.Minute)
+}
+
+func Test_WithCloudEvents_ShouldUseTheSourcePassedIn(t *testing.T) {
+	c := newTestClient("http://localhost:1", t)
+	c.packLinks.packName = "Slack"
+	WithCloudEvents("https://packs.example.com/slack")(c)
+
+	ce := c.eventBody(Event{Name: "MessageSent"}).(CloudEvent)
+
+	assert.Equal(t, "https://packs.example.com/slack", ce.Source)
+	assert.NotEqual(t, ce.ID, c.eventBody(Event{Name: "MessageSent"}).(CloudEvent).ID, "each event should have its own id")
+}
+
+func Test_PostEvent_ShouldPostFlyteEventsByDefault(t *testing.T) {
+	ts, rec := mockServerWithRecorder(http.StatusAccepted, "")
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	c.packLinks.eventsURL, _ = url.Parse(ts.URL + "/events")
+
+	require.NoError(t, c.PostEvent(Event{Name: "MessageSent"}))
+
+	assert.Equal(t, "application/json", rec.reqs[0].Header.Get("Content-Type"))
+	var e map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.body[0], &e))
+	assert.Equal(t, "MessageSent", e["event"])
+}
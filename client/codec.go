@@ -0,0 +1,151 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog/log"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Codec encodes and decodes event and action payloads in a media type, such as JSON. Codecs for compact binary
+// formats, such as msgpack or protobuf, can be registered with RegisterCodec and used with a flyte api that
+// supports them.
+type Codec interface {
+	// ContentType is the media type, as used in the Content-Type and Accept headers, e.g. "application/msgpack".
+	ContentType() string
+	// Marshal encodes v.
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal decodes data into v. Actions are decoded into an *Action, whose Input holds the command input as
+	// the codec encodes it, for the command handler to decode. Unmarshal may keep references to data, which is
+	// not reused once it has been handed to a registered codec.
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var codecs = struct {
+	sync.RWMutex
+	m map[string]Codec
+}{m: map[string]Codec{"application/json": jsonCodec{}}}
+
+// RegisterCodec makes the codec available to WithCodec, and for decoding responses with its content type, replacing
+// any codec already registered for its content type. JSON is registered by default.
+func RegisterCodec(c Codec) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	codecs.m[mediaType(c.ContentType())] = c
+}
+
+func lookupCodec(contentType string) (Codec, bool) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	c, ok := codecs.m[mediaType(contentType)]
+	return c, ok
+}
+
+// mediaType returns the lower case media type of a Content-Type, without any parameters such as the charset
+func mediaType(contentType string) string {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mt
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// WithCodec encodes event payloads, including action results, with the registered codec for the content type, and
+// asks the flyte api for actions in it, falling back to JSON. The flyte api must support the content type. Pack
+// registration and the other flyte api resources are still sent as JSON, as are CloudEvents (see WithCloudEvents).
+// A content type that has no registered codec is logged and JSON is used.
+func WithCodec(contentType string) Option {
+	return func(c *client) {
+		codec, ok := lookupCodec(contentType)
+		if !ok {
+			log.Warn().Msgf("no codec is registered for content type %q, payloads will be sent as JSON", contentType)
+			return
+		}
+		c.codec = codec
+	}
+}
+
+// payloadCodec returns the codec events are sent with
+func (c client) payloadCodec() Codec {
+	if c.codec == nil || c.cloudEvents {
+		return jsonCodec{}
+	}
+	return c.codec
+}
+
+// accept returns the Accept header for requests, preferring the client codec to JSON
+func (c client) accept() string {
+	if c.codec == nil || mediaType(c.codec.ContentType()) == "application/json" {
+		return "application/json"
+	}
+	return c.codec.ContentType() + ", application/json"
+}
+
+// responseCodec returns the registered codec for the response Content-Type, or JSON if there is none
+func (c client) responseCodec(resp *http.Response) Codec {
+	codec, ok := lookupCodec(resp.Header.Get("Content-Type"))
+	if _, isJSON := codec.(jsonCodec); !ok || isJSON {
+		return jsonCodec{strict: c.strictJSON}
+	}
+	return codec
+}
+
+// jsonCodec is the default codec. Strict codecs reject unknown fields and trailing data, and decode numbers into
+// interface{} values as json.Number, see WithStrictJSON
+type jsonCodec struct {
+	strict bool
+}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (j jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	if !j.strict {
+		return json.Unmarshal(data, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
+
+// WithStrictJSON decodes actions and flyte api responses strictly, so schema drift between flyte api versions is
+// surfaced as an error rather than silently ignored: fields the client does not know of, and data after the JSON
+// value, are rejected, and numbers decoded into interface{} values are kept as json.Number rather than losing
+// precision as float64. Action inputs are passed to command handlers undecoded. Off by default.
+func WithStrictJSON() Option {
+	return func(c *client) {
+		c.strictJSON = true
+	}
+}
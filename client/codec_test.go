@@ -0,0 +1,207 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// prefixCodec stands in for a binary codec registered by a pack, such as msgpack: it is JSON with a leading '~'
+type prefixCodec struct{}
+
+func (prefixCodec) ContentType() string { return "application/x-prefixed" }
+
+func (prefixCodec) Marshal(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	return append([]byte("~"), b...), err
+}
+
+func (prefixCodec) Unmarshal(data []byte, v interface{}) error {
+	if len(data) == 0 || data[0] != '~' {
+		return errors.New("not prefixed")
+	}
+	return json.Unmarshal(data[1:], v)
+}
+
+func Test_WithCodec_ShouldEncodeEventsWithTheCodec(t *testing.T) {
+	// given
+	RegisterCodec(prefixCodec{})
+	var contentType string
+	var body []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	WithCodec("application/x-prefixed")(c)
+	c.packLinks.eventsURL, _ = url.Parse(ts.URL + "/events")
+
+	// when
+	err := c.PostEvent(Event{Name: "Sent", Payload: map[string]string{"id": "1"}})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, "application/x-prefixed", contentType)
+	require.NotEmpty(t, body)
+	assert.Equal(t, byte('~'), body[0])
+	assert.Contains(t, string(body), `"payload":{"id":"1"}`)
+}
+
+func Test_WithCodec_ShouldNegotiateTheActionContentType(t *testing.T) {
+	// given a flyte api that replies in the content type the client prefers
+	RegisterCodec(prefixCodec{})
+	var accept string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		w.Header().Set("Content-Type", "application/x-prefixed")
+		w.Write([]byte(`~{"command": "SendMessage", "input": {"channel": "ops"}}`))
+	}))
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	WithCodec("application/x-prefixed")(c)
+	c.packLinks.takeActionURL, _ = url.Parse(ts.URL + "/take")
+
+	// when
+	a, err := c.TakeAction()
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, "application/x-prefixed, application/json", accept)
+	assert.Equal(t, "SendMessage", a.CommandName)
+	assert.JSONEq(t, `{"channel": "ops"}`, string(a.Input))
+}
+
+func Test_WithCodec_ShouldDecodeJSONResponsesFromApisWithoutTheCodec(t *testing.T) {
+	RegisterCodec(prefixCodec{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(`{"command": "SendMessage"}`))
+	}))
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	WithCodec("application/x-prefixed")(c)
+	c.packLinks.takeActionURL, _ = url.Parse(ts.URL + "/take")
+
+	a, err := c.TakeAction()
+
+	require.NoError(t, err)
+	assert.Equal(t, "SendMessage", a.CommandName)
+}
+
+func Test_WithCodec_ShouldSendCloudEventsAsJSON(t *testing.T) {
+	RegisterCodec(prefixCodec{})
+	c := newTestClient("http://example.com", t)
+	WithCodec("application/x-prefixed")(c)
+	WithCloudEvents("")(c)
+
+	assert.Equal(t, jsonCodec{}, c.payloadCodec())
+}
+
+func Test_WithCodec_ShouldUseJSONForUnregisteredContentTypes(t *testing.T) {
+	c := newTestClient("http://example.com", t)
+	WithCodec("application/x-unknown")(c)
+
+	assert.Nil(t, c.codec)
+	assert.Equal(t, jsonCodec{}, c.payloadCodec())
+	assert.Equal(t, "application/json", c.accept())
+}
+
+func Test_WithStrictJSON_ShouldRejectUnknownFields(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"command": "SendMessage", "priority": 1}`))
+	}))
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	c.packLinks.takeActionURL, _ = url.Parse(ts.URL + "/take")
+
+	_, err := c.TakeAction()
+	require.NoError(t, err)
+
+	WithStrictJSON()(c)
+	_, err = c.TakeAction()
+	assert.EqualError(t, err, `could not deserialise response: json: unknown field "priority"`)
+}
+
+func Test_WithStrictJSON_ShouldRejectTrailingData(t *testing.T) {
+	err := jsonCodec{strict: true}.Unmarshal([]byte(`{"command": "SendMessage"} {}`), &Action{})
+
+	assert.EqualError(t, err, "unexpected data after the JSON value")
+}
+
+func Test_WithStrictJSON_ShouldKeepNumbersAsJSONNumbers(t *testing.T) {
+	var v map[string]interface{}
+	err := jsonCodec{strict: true}.Unmarshal([]byte(`{"id": 9007199254740993}`), &v)
+
+	require.NoError(t, err)
+	assert.Equal(t, json.Number("9007199254740993"), v["id"])
+}
+
+func Test_WithStrictJSON_ShouldNotApplyToOtherCodecs(t *testing.T) {
+	RegisterCodec(prefixCodec{})
+	c := client{strictJSON: true}
+	resp := &http.Response{Header: http.Header{"Content-Type": []string{"application/x-prefixed"}}}
+
+	assert.Equal(t, prefixCodec{}, c.responseCodec(resp))
+	resp.Header.Set("Content-Type", "application/json")
+	assert.Equal(t, jsonCodec{strict: true}, c.responseCodec(resp))
+}
+
+// retainingCodec keeps the data it decodes, as a zero-copy binary codec may
+type retainingCodec struct{ retained *[]byte }
+
+func (retainingCodec) ContentType() string { return "application/x-retained" }
+
+func (retainingCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func (c retainingCodec) Unmarshal(data []byte, v interface{}) error {
+	*c.retained = data
+	return json.Unmarshal(data, v)
+}
+
+func Test_decodeResponse_ShouldNotHandPooledBuffersToRegisteredCodecs(t *testing.T) {
+	// given a codec that keeps the body it decoded
+	var retained []byte
+	RegisterCodec(retainingCodec{retained: &retained})
+	c := client{}
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": []string{"application/x-retained"}},
+		Body:   ioutil.NopCloser(strings.NewReader(`{"command": "SendMessage"}`)),
+	}
+	var a Action
+	require.NoError(t, c.decodeResponse(resp, &a))
+
+	// when the pooled buffer is reused
+	buf := responseBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	buf.WriteString(`{"command": "Overwritten"}`)
+	releaseBuffer(buf)
+
+	// then
+	assert.Equal(t, `{"command": "SendMessage"}`, string(retained))
+}
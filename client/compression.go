@@ -0,0 +1,166 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Compressor compresses and decompresses request and response bodies with a HTTP content coding, such as gzip.
+// Compressors for other codings, such as zstd or snappy, can be registered with RegisterCompressor.
+type Compressor interface {
+	// Encoding is the content coding, as used in the Content-Encoding and Accept-Encoding headers, e.g. "zstd".
+	Encoding() string
+	// NewWriter returns a writer that compresses what is written to it into w. Closing it flushes any buffered data,
+	// but does not close w.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	// NewReader returns a reader that decompresses what is read from r.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+var compressors = struct {
+	sync.RWMutex
+	m map[string]Compressor
+}{m: map[string]Compressor{"gzip": gzipCompressor{}}}
+
+// RegisterCompressor makes the compressor available to WithCompression, and for decompressing responses, replacing
+// any compressor already registered for its encoding. gzip is registered by default.
+func RegisterCompressor(c Compressor) {
+	compressors.Lock()
+	defer compressors.Unlock()
+	compressors.m[strings.ToLower(c.Encoding())] = c
+}
+
+func lookupCompressor(encoding string) (Compressor, bool) {
+	compressors.RLock()
+	defer compressors.RUnlock()
+	c, ok := compressors.m[strings.ToLower(strings.TrimSpace(encoding))]
+	return c, ok
+}
+
+// acceptEncoding lists the registered encodings for the Accept-Encoding header, preferring the encoding passed in
+func acceptEncoding(preferred string) string {
+	compressors.RLock()
+	defer compressors.RUnlock()
+	encodings := make([]string, 0, len(compressors.m))
+	for e := range compressors.m {
+		if e != preferred {
+			encodings = append(encodings, e)
+		}
+	}
+	sort.Strings(encodings)
+	return strings.Join(append([]string{preferred}, encodings...), ", ")
+}
+
+// WithCompression compresses request bodies with the registered compressor for the encoding, e.g. "gzip", and asks
+// the flyte api for responses compressed with any registered encoding, preferring the same one. The flyte api must
+// support the encoding. An encoding that has not been registered is logged and compression is left off.
+func WithCompression(encoding string) Option {
+	return func(c *client) {
+		compressor, ok := lookupCompressor(encoding)
+		if !ok {
+			log.Warn().Msgf("no compressor is registered for encoding %q, requests will not be compressed", encoding)
+			return
+		}
+		c.compressor = compressor
+	}
+}
+
+// WithCompressionThreshold only compresses request bodies (see WithCompression) of at least minBytes, so small
+// requests, such as most action results, are not slowed down for little gain while large payloads are still compressed.
+// Bodies of any size are compressed by default.
+func WithCompressionThreshold(minBytes int) Option {
+	return func(c *client) {
+		c.compressionThreshold = minBytes
+	}
+}
+
+// compress compresses the body with the client compressor, if it has one and the body is not below the compression
+// threshold, returning the body and its encoding
+func (c client) compress(body []byte) ([]byte, string, error) {
+	if c.compressor == nil || len(body) < c.compressionThreshold {
+		return body, "", nil
+	}
+	var buf bytes.Buffer
+	w, err := c.compressor.NewWriter(&buf)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, "", err
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), c.compressor.Encoding(), nil
+}
+
+// decompress replaces the response body with one that decompresses it, if it has a Content-Encoding
+func decompress(resp *http.Response) error {
+	encoding := resp.Header.Get("Content-Encoding")
+	if encoding == "" || strings.EqualFold(encoding, "identity") {
+		return nil
+	}
+	compressor, ok := lookupCompressor(encoding)
+	if !ok {
+		resp.Body.Close()
+		return fmt.Errorf("response from %s has unsupported content encoding %q", resp.Request.URL, encoding)
+	}
+	r, err := compressor.NewReader(resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		return fmt.Errorf("cannot decompress %s response from %s: %w", encoding, resp.Request.URL, err)
+	}
+	resp.Body = decompressedBody{ReadCloser: r, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	return nil
+}
+
+// decompressedBody closes the decompressing reader and the response body it reads from
+type decompressedBody struct {
+	io.ReadCloser
+	body io.ReadCloser
+}
+
+func (b decompressedBody) Close() error {
+	b.ReadCloser.Close()
+	return b.body.Close()
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Encoding() string {
+	return "gzip"
+}
+
+func (gzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (gzipCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// deflateCompressor stands in for a compressor registered by a pack, such as zstd
+type deflateCompressor struct{}
+
+func (deflateCompressor) Encoding() string { return "deflate" }
+
+func (deflateCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(w, flate.DefaultCompression)
+}
+
+func (deflateCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return flate.NewReader(r), nil
+}
+
+func Test_WithCompression_ShouldCompressRequestsAndDecompressResponses(t *testing.T) {
+	// given a flyte api that replies with a deflate compressed action
+	RegisterCompressor(deflateCompressor{})
+	var encoding, accept string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		accept = r.Header.Get("Accept-Encoding")
+		body, _ = ioutil.ReadAll(flate.NewReader(r.Body))
+		w.Header().Set("Content-Encoding", "deflate")
+		fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+		fw.Write([]byte(`{"command": "SendMessage", "input": {}}`))
+		fw.Close()
+	}))
+	defer server.Close()
+	c := newTestClient(server.URL, t)
+	WithCompression("deflate")(c)
+	c.packLinks.takeActionURL, _ = url.Parse(server.URL + "/take")
+
+	// when
+	a, err := c.TakeAction()
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, "SendMessage", a.CommandName)
+	assert.Equal(t, "deflate", encoding)
+	assert.Equal(t, "null", string(body))
+	assert.Equal(t, "deflate, gzip", accept)
+}
+
+func Test_WithCompression_ShouldDecompressResponsesInAnyRegisteredEncoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		gw.Write([]byte(`{"key": "token", "contentType": "text/plain"}`))
+		gw.Close()
+	}))
+	defer server.Close()
+	c := newTestClient(server.URL, t)
+	WithCompression("gzip")(c)
+	c.packLinks.takeActionURL, _ = url.Parse(server.URL + "/take")
+
+	_, err := c.TakeAction()
+
+	require.NoError(t, err)
+}
+
+func Test_WithCompressionThreshold_ShouldOnlyCompressLargeBodies(t *testing.T) {
+	// given
+	var encodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	c := newTestClient(server.URL, t)
+	WithCompression("gzip")(c)
+	WithCompressionThreshold(1024)(c)
+	c.packLinks.eventsURL, _ = url.Parse(server.URL + "/events")
+
+	// when
+	require.NoError(t, c.PostEvent(Event{Name: "Small", Payload: "ok"}))
+	require.NoError(t, c.PostEvent(Event{Name: "Diagnostics", Payload: string(bytes.Repeat([]byte("x"), 2048))}))
+
+	// then
+	assert.Equal(t, []string{"", "gzip"}, encodings)
+}
+
+func Test_WithCompression_ShouldLeaveCompressionOff_WhenTheEncodingIsNotRegistered(t *testing.T) {
+	c := newTestClient("http://localhost:1", t)
+
+	WithCompression("brotli")(c)
+
+	assert.Nil(t, c.compressor)
+	b, encoding, err := c.compress([]byte("{}"))
+	require.NoError(t, err)
+	assert.Equal(t, "", encoding)
+	assert.Equal(t, []byte("{}"), b)
+}
+
+func Test_decompress_ShouldFailForUnsupportedEncodings(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://flyte/v1", nil)
+	resp := &http.Response{
+		Header:  http.Header{"Content-Encoding": []string{"br"}},
+		Body:    ioutil.NopCloser(bytes.NewReader(nil)),
+		Request: req,
+	}
+
+	err := decompress(resp)
+
+	assert.EqualError(t, err, `response from http://flyte/v1 has unsupported content encoding "br"`)
+}
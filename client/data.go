@@ -18,35 +18,42 @@ package client
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/url"
+	"strings"
 	"time"
 )
 
 // the client Pack struct is used when registering with the flyte api.
 type Pack struct {
-	Name      string            `json:"name"`               // pack name
-	Labels    map[string]string `json:"labels,omitempty"`   // pack labels - these act as a filter that determines when the pack will execute against a flow
-	EventDefs []EventDef        `json:"events"`             // the event definitions of a pack. These can be events a pack observes and sends spontaneously
-	Commands  []Command         `json:"commands,omitempty"` // the commands a pack exposes
-	Links     []Link            `json:"links, omitempty"`   // contains links the pack uses, such as the take action url and the events url, or links the pack exposes such as the pack help url
+	Name        string            `json:"name"`                  // pack name
+	Labels      map[string]string `json:"labels,omitempty"`      // pack labels - these act as a filter that determines when the pack will execute against a flow
+	EventDefs   []EventDef        `json:"events"`                // the event definitions of a pack. These can be events a pack observes and sends spontaneously
+	Commands    []Command         `json:"commands,omitempty"`    // the commands a pack exposes
+	Links       []Link            `json:"links, omitempty"`      // contains links the pack uses, such as the take action url and the events url, or links the pack exposes such as the pack help url
+	Description string            `json:"description,omitempty"` // what the pack does, optional. Shown in the flyte UI
 }
 
 // the event definition, this describes events a pack can send
 type EventDef struct {
-	Name  string `json:"name"`            // the event name
-	Links []Link `json:"links,omitempty"` // the event link/s, optional. Could be a help link or anything related to the event
+	Name        string `json:"name"`                  // the event name
+	Links       []Link `json:"links,omitempty"`       // the event link/s, optional. Could be a help link or anything related to the event
+	Description string `json:"description,omitempty"` // what the event means, optional. Shown in the flyte UI
 }
 
 // the command struct represents the commands a pack exposes
 type Command struct {
-	Name       string   `json:"name"`            // command name
-	EventNames []string `json:"events"`          // the command output events
-	Links      []Link   `json:"links,omitempty"` // the command link/s, optional. Normally a help url
+	Name        string   `json:"name"`                  // command name
+	EventNames  []string `json:"events"`                // the command output events
+	Links       []Link   `json:"links,omitempty"`       // the command link/s, optional. Normally a help url
+	Description string   `json:"description,omitempty"` // what the command does, optional. Shown in the flyte UI
 }
 
 type Link struct {
 	Href *url.URL
 	Rel  string
+	// the href of templated links, as an RFC 6570 URI template e.g. "http://flyte/v1/datastore/{key}", see Expand
+	Template string
 }
 
 // custom marshaller to avoid marshalling all url.URL fields
@@ -55,7 +62,7 @@ func (l Link) MarshalJSON() ([]byte, error) {
 		Href string `json:"href"`
 		Rel  string `json:"rel"`
 	}{
-		Href: l.Href.String(),
+		Href: l.href(),
 		Rel:  l.Rel,
 	})
 }
@@ -68,23 +75,79 @@ func (l *Link) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, linkRaw); err != nil {
 		return err
 	}
+	templated := strings.ContainsRune(linkRaw.Href, '{')
 	href, err := url.Parse(linkRaw.Href)
-	if err != nil {
+	if err != nil && !templated {
 		return err
 	}
+	if err != nil {
+		// templates such as "{scheme}://{host}/packs" are not urls until they are expanded
+		href = &url.URL{}
+	}
 	l.Href = href
 	l.Rel = linkRaw.Rel
+	if templated {
+		l.Template = linkRaw.Href
+	}
 	return nil
 }
 
+// String formats the link as its href, or its template, and rel e.g. "{http://flyte/v1/packs pack/listPacks}".
+func (l Link) String() string {
+	if l.Template != "" {
+		return fmt.Sprintf("{%s %s}", l.Template, l.Rel)
+	}
+	return fmt.Sprintf("{%v %s}", l.Href, l.Rel)
+}
+
+func (l Link) href() string {
+	if l.Template != "" {
+		return l.Template
+	}
+	return l.Href.String()
+}
+
 type Event struct {
-	Name    string      `json:"event"`
-	Payload interface{} `json:"payload"`
-	CreatedAt time.Time `json:"createdAt"`
+	Name      string      `json:"event"`
+	Payload   interface{} `json:"payload"`
+	CreatedAt time.Time   `json:"createdAt"`
+	// identifies the action, and the flow execution, the event results from, if it does
+	Correlation *Correlation `json:"correlation,omitempty"`
 }
 
 type Action struct {
+	ID          string          `json:"id,omitempty"`
 	CommandName string          `json:"command"`
 	Input       json.RawMessage `json:"input"`
 	Links       []Link          `json:"links"`
+	// identify the flow execution and step the action is for, when the flyte api sends them
+	CorrelationID string `json:"correlationId,omitempty"`
+	FlowName      string `json:"flowName,omitempty"`
+	StepID        string `json:"stepId,omitempty"`
+	// the W3C trace context traceparent of the flow execution, when the flyte api sends one
+	TraceParent string `json:"traceparent,omitempty"`
+}
+
+// Correlation identifies the action an event results from, and the flow execution and step the action is for, so
+// flow executions can be traced end to end.
+type Correlation struct {
+	ID       string `json:"id,omitempty"`       // the correlation id of the flow execution
+	ActionID string `json:"actionId,omitempty"` // the action id
+	FlowName string `json:"flowName,omitempty"` // the name of the flow
+	StepID   string `json:"stepId,omitempty"`   // the id of the flow step
+	// the W3C trace context traceparent the event is sent with, see TraceParent
+	TraceParent string `json:"traceparent,omitempty"`
+}
+
+// Correlation returns the identifiers of the action, and of the flow execution and step it is for.
+func (a Action) Correlation() Correlation {
+	return Correlation{ID: a.CorrelationID, ActionID: a.ID, FlowName: a.FlowName, StepID: a.StepID, TraceParent: a.TraceParent}
+}
+
+// traceParent returns the traceparent the event is posted with, if it has one
+func (e Event) traceParent() string {
+	if e.Correlation == nil {
+		return ""
+	}
+	return e.Correlation.TraceParent
 }
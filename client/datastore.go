@@ -0,0 +1,173 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+)
+
+// Datastore is the set of client operations for reading and writing items held in the flyte api datastore.
+type Datastore interface {
+	// GetDatastoreItem gets the item stored against the key. If there is no such item a NotFoundError is returned.
+	GetDatastoreItem(key string) (*DatastoreItem, error)
+	// PutDatastoreItem creates the item, or replaces it if an item with the same key already exists.
+	PutDatastoreItem(DatastoreItem) error
+	// DeleteDatastoreItem deletes the item stored against the key. If there is no such item a NotFoundError is returned.
+	DeleteDatastoreItem(key string) error
+}
+
+// the DatastoreItem struct represents a single value held in the flyte api datastore.
+type DatastoreItem struct {
+	Key         string // the key the item is stored against
+	Description string // optional description of the item
+	ContentType string // the media type of the value e.g. "application/json"
+	Value       []byte // the raw value
+}
+
+// GetDatastoreItem gets the item stored against the key.
+func (c client) GetDatastoreItem(key string) (*DatastoreItem, error) {
+	itemURL, err := c.getDatastoreItemURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.get(OpGetDatastoreItem, itemURL)
+	if err != nil {
+		return nil, fmt.Errorf("error getting datastore item from %s: %w", itemURL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		value, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("could not read datastore item from %s: %w", itemURL.String(), err)
+		}
+		return &DatastoreItem{Key: key, ContentType: resp.Header.Get("Content-Type"), Value: value}, nil
+	case http.StatusNotFound:
+		return nil, NotFoundError{fmt.Sprintf("datastore item not found at %s", itemURL.String())}
+	default:
+		return nil, fmt.Errorf("error getting datastore item from %s, response was: %w", itemURL.String(), newResponseError(resp))
+	}
+}
+
+// PutDatastoreItem creates or replaces the item stored against the item key.
+func (c client) PutDatastoreItem(item DatastoreItem) error {
+	itemURL, err := c.getDatastoreItemURL(item.Key)
+	if err != nil {
+		return err
+	}
+
+	body, contentType, err := datastoreItemForm(item)
+	if err != nil {
+		return fmt.Errorf("cannot create datastore item form: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, itemURL.String(), body)
+	if err != nil {
+		return fmt.Errorf("cannot create request: %v", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.do(OpPutDatastoreItem, req)
+	if err != nil {
+		return fmt.Errorf("error putting datastore item to %s: %w", itemURL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	if !c.accepts(OpPutDatastoreItem, resp.StatusCode) {
+		return fmt.Errorf("datastore item not stored at %s, response was: %w", itemURL.String(), newResponseError(resp))
+	}
+	return nil
+}
+
+// DeleteDatastoreItem deletes the item stored against the key.
+func (c client) DeleteDatastoreItem(key string) error {
+	itemURL, err := c.getDatastoreItemURL(key)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, itemURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("cannot create request: %v", err)
+	}
+
+	resp, err := c.do(OpDeleteDatastoreItem, req)
+	if err != nil {
+		return fmt.Errorf("error deleting datastore item at %s: %w", itemURL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case c.accepts(OpDeleteDatastoreItem, resp.StatusCode):
+		return nil
+	case resp.StatusCode == http.StatusNotFound:
+		return NotFoundError{fmt.Sprintf("datastore item not found at %s", itemURL.String())}
+	default:
+		return fmt.Errorf("datastore item at %s not deleted, response was: %w", itemURL.String(), newResponseError(resp))
+	}
+}
+
+// getDatastoreItemURL finds out where the item with the key passed in is stored
+func (c client) getDatastoreItemURL(key string) (*url.URL, error) {
+	datastoreURL, err := c.apiURL(RelDatastore)
+	if err != nil {
+		return nil, err
+	}
+	u, err := resourceURL(datastoreURL, key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the url of datastore item %q: %w", key, err)
+	}
+	return u, nil
+}
+
+// creates the multipart form the flyte api expects when storing a datastore item, returning the form and its content type
+func datastoreItemForm(item DatastoreItem) (*bytes.Buffer, string, error) {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+
+	if err := w.WriteField("description", item.Description); err != nil {
+		return nil, "", err
+	}
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="value"; filename=%q`, item.Key))
+	contentType := item.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h.Set("Content-Type", contentType)
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := part.Write(item.Value); err != nil {
+		return nil, "", err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return body, w.FormDataContentType(), nil
+}
@@ -0,0 +1,139 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func Test_GetDatastoreItem_ShouldReturnItemValueAndContentType(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/datastore/config", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"some":"config"}`))
+	}))
+	defer ts.Close()
+
+	c := newTestDatastoreClient(ts.URL, t)
+
+	item, err := c.GetDatastoreItem("config")
+
+	require.NoError(t, err)
+	assert.Equal(t, "config", item.Key)
+	assert.Equal(t, "application/json", item.ContentType)
+	assert.Equal(t, `{"some":"config"}`, string(item.Value))
+}
+
+func Test_GetDatastoreItem_ShouldReturnNotFoundErrorWhenItemDoesNotExist(t *testing.T) {
+	ts := mockServer(http.StatusNotFound, "")
+	defer ts.Close()
+
+	c := newTestDatastoreClient(ts.URL, t)
+
+	_, err := c.GetDatastoreItem("missing")
+
+	require.IsType(t, NotFoundError{}, err)
+	assert.EqualError(t, err, fmt.Sprintf("datastore item not found at %s/v1/datastore/missing", ts.URL))
+}
+
+func Test_PutDatastoreItem_ShouldPutItemAsMultipartForm(t *testing.T) {
+	var description, contentType, value string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/v1/datastore/config", r.URL.Path)
+		require.NoError(t, r.ParseMultipartForm(1024))
+		description = r.FormValue("description")
+		f, h, err := r.FormFile("value")
+		require.NoError(t, err)
+		contentType = h.Header.Get("Content-Type")
+		b, _ := ioutil.ReadAll(f)
+		value = string(b)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	c := newTestDatastoreClient(ts.URL, t)
+
+	err := c.PutDatastoreItem(DatastoreItem{Key: "config", Description: "pack config", ContentType: "application/json", Value: []byte(`{"a":1}`)})
+
+	require.NoError(t, err)
+	assert.Equal(t, "pack config", description)
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, `{"a":1}`, value)
+}
+
+func Test_PutDatastoreItem_ShouldReturnErrorOnUnexpectedStatus(t *testing.T) {
+	ts := mockServer(http.StatusBadRequest, "")
+	defer ts.Close()
+
+	c := newTestDatastoreClient(ts.URL, t)
+
+	err := c.PutDatastoreItem(DatastoreItem{Key: "config"})
+
+	assert.Contains(t, err.Error(), "datastore item not stored at")
+}
+
+func Test_DeleteDatastoreItem_ShouldDeleteItem(t *testing.T) {
+	ts, rec := mockServerWithRecorder(http.StatusNoContent, "")
+	defer ts.Close()
+
+	c := newTestDatastoreClient(ts.URL, t)
+
+	err := c.DeleteDatastoreItem("config")
+
+	require.NoError(t, err)
+	require.Len(t, rec.reqs, 1)
+	assert.Equal(t, http.MethodDelete, rec.reqs[0].Method)
+	assert.Equal(t, "/v1/datastore/config", rec.reqs[0].URL.Path)
+}
+
+func Test_DatastoreOperations_ShouldEscapeKeysAndRejectInvalidOnes(t *testing.T) {
+	ts, rec := mockServerWithRecorder(http.StatusNoContent, "")
+	defer ts.Close()
+
+	c := newTestDatastoreClient(ts.URL, t)
+
+	require.NoError(t, c.DeleteDatastoreItem("team/config"))
+	assert.Error(t, c.DeleteDatastoreItem(""))
+	assert.Error(t, c.DeleteDatastoreItem(".."))
+
+	require.Len(t, rec.reqs, 1)
+	assert.Equal(t, "/v1/datastore/team%2Fconfig", rec.reqs[0].URL.EscapedPath())
+}
+
+func Test_DatastoreOperations_ShouldReturnErrorWhenDatastoreLinkIsMissing(t *testing.T) {
+	c := &client{apiLinks: map[string][]Link{}}
+
+	_, err := c.GetDatastoreItem("config")
+
+	assert.Contains(t, err.Error(), `could not find link with rel "datastore/listDataItems"`)
+}
+
+func newTestDatastoreClient(serverURL string, t *testing.T) *client {
+	c := newTestClient(serverURL, t)
+	u, err := url.Parse(serverURL + "/v1/datastore")
+	require.NoError(t, err)
+	c.apiLinks["links"] = append(c.apiLinks["links"], Link{Href: u, Rel: "http://example.com/swagger#!/datastore/listDataItems"})
+	return c
+}
@@ -0,0 +1,178 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// CachedDatastore is a Datastore that caches the items read through it, so packs that look up the same items for
+// every action don't have to call the flyte api each time. Items are cached until their TTL expires, they are
+// written or deleted through the cache, or they are explicitly invalidated. When the cache is full the least
+// recently used item is evicted. A CachedDatastore is safe for concurrent use.
+type CachedDatastore struct {
+	datastore  Datastore
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // front is most recently used
+	stats   DatastoreCacheStats
+	// the keys being read from the underlying datastore, so reads that an invalidation overtakes are not cached
+	fetching map[string]*fetchState
+}
+
+// DatastoreCacheStats describes how effective a CachedDatastore is.
+type DatastoreCacheStats struct {
+	Hits      uint64 // reads served from the cache
+	Misses    uint64 // reads that went to the underlying datastore
+	Evictions uint64 // items removed to make room for others
+	Entries   int    // items currently cached
+}
+
+type cacheEntry struct {
+	item    DatastoreItem
+	expires time.Time
+}
+
+type fetchState struct {
+	inFlight   int
+	generation uint64 // incremented each time the key is invalidated
+}
+
+// NewCachedDatastore creates a cache in front of the datastore passed in (normally a Client). Items are cached for
+// the ttl and at most maxEntries items are held. A maxEntries of zero means there is no limit.
+func NewCachedDatastore(datastore Datastore, ttl time.Duration, maxEntries int) *CachedDatastore {
+	return &CachedDatastore{
+		datastore:  datastore,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		fetching:   make(map[string]*fetchState),
+	}
+}
+
+// GetDatastoreItem returns the cached item if it has not expired, otherwise it reads the item from the underlying
+// datastore and caches it, unless the item was written, deleted or invalidated while it was being read.
+func (c *CachedDatastore) GetDatastoreItem(key string) (*DatastoreItem, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		entry := e.Value.(*cacheEntry)
+		if c.now().Before(entry.expires) {
+			c.lru.MoveToFront(e)
+			c.stats.Hits++
+			item := copyItem(entry.item)
+			c.mu.Unlock()
+			return &item, nil
+		}
+		c.remove(e)
+	}
+	c.stats.Misses++
+	f, ok := c.fetching[key]
+	if !ok {
+		f = &fetchState{}
+		c.fetching[key] = f
+	}
+	f.inFlight++
+	generation := f.generation
+	c.mu.Unlock()
+
+	item, err := c.datastore.GetDatastoreItem(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if f.inFlight--; f.inFlight == 0 {
+		delete(c.fetching, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f.generation != generation {
+		return item, nil
+	}
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{item: copyItem(*item), expires: c.now().Add(c.ttl)})
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+	return item, nil
+}
+
+// PutDatastoreItem writes the item to the underlying datastore and invalidates any cached copy.
+func (c *CachedDatastore) PutDatastoreItem(item DatastoreItem) error {
+	defer c.Invalidate(item.Key)
+	return c.datastore.PutDatastoreItem(item)
+}
+
+// DeleteDatastoreItem deletes the item from the underlying datastore and invalidates any cached copy.
+func (c *CachedDatastore) DeleteDatastoreItem(key string) error {
+	defer c.Invalidate(key)
+	return c.datastore.DeleteDatastoreItem(key)
+}
+
+// Invalidate removes the item with the key from the cache, so the next read goes to the underlying datastore.
+func (c *CachedDatastore) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+	}
+	if f, ok := c.fetching[key]; ok {
+		f.generation++
+	}
+}
+
+// InvalidateAll empties the cache.
+func (c *CachedDatastore) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	for _, f := range c.fetching {
+		f.generation++
+	}
+}
+
+// Stats returns the cache hit, miss and eviction counts.
+func (c *CachedDatastore) Stats() DatastoreCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Entries = c.lru.Len()
+	return s
+}
+
+// removes the list element from the cache. must be called with the lock held
+func (c *CachedDatastore) remove(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.entries, e.Value.(*cacheEntry).item.Key)
+}
+
+// copies the item so callers cannot modify the cached value
+func copyItem(item DatastoreItem) DatastoreItem {
+	item.Value = append([]byte(nil), item.Value...)
+	return item
+}
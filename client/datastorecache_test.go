@@ -0,0 +1,140 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func Test_CachedDatastore_ShouldServeReadsFromCacheUntilTTLExpires(t *testing.T) {
+	ds := &mockDatastore{items: map[string]string{"config": "v1"}}
+	now := time.Now()
+	cache := NewCachedDatastore(ds, time.Minute, 0)
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		item, err := cache.GetDatastoreItem("config")
+		require.NoError(t, err)
+		assert.Equal(t, "v1", string(item.Value))
+	}
+	assert.Equal(t, 1, ds.gets)
+
+	now = now.Add(time.Minute)
+	_, err := cache.GetDatastoreItem("config")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, ds.gets)
+	assert.Equal(t, DatastoreCacheStats{Hits: 2, Misses: 2, Entries: 1}, cache.Stats())
+}
+
+func Test_CachedDatastore_ShouldEvictLeastRecentlyUsedItemWhenFull(t *testing.T) {
+	ds := &mockDatastore{items: map[string]string{"a": "1", "b": "2", "c": "3"}}
+	cache := NewCachedDatastore(ds, time.Hour, 2)
+
+	cache.GetDatastoreItem("a")
+	cache.GetDatastoreItem("b")
+	cache.GetDatastoreItem("a") // a is now the most recently used
+	cache.GetDatastoreItem("c") // b is evicted
+	cache.GetDatastoreItem("a")
+	cache.GetDatastoreItem("b")
+
+	assert.Equal(t, 4, ds.gets)
+	assert.Equal(t, DatastoreCacheStats{Hits: 2, Misses: 4, Evictions: 2, Entries: 2}, cache.Stats())
+}
+
+func Test_CachedDatastore_ShouldInvalidateItemsOnWriteDeleteAndRequest(t *testing.T) {
+	ds := &mockDatastore{items: map[string]string{"config": "v1"}}
+	cache := NewCachedDatastore(ds, time.Hour, 0)
+
+	cache.GetDatastoreItem("config")
+	require.NoError(t, cache.PutDatastoreItem(DatastoreItem{Key: "config", Value: []byte("v2")}))
+	item, _ := cache.GetDatastoreItem("config")
+	assert.Equal(t, "v2", string(item.Value))
+
+	cache.Invalidate("config")
+	cache.GetDatastoreItem("config")
+
+	require.NoError(t, cache.DeleteDatastoreItem("config"))
+	_, err := cache.GetDatastoreItem("config")
+
+	assert.IsType(t, NotFoundError{}, err)
+	assert.Equal(t, 4, ds.gets)
+}
+
+func Test_CachedDatastore_ShouldNotAllowCachedValuesToBeModified(t *testing.T) {
+	ds := &mockDatastore{items: map[string]string{"config": "v1"}}
+	cache := NewCachedDatastore(ds, time.Hour, 0)
+
+	item, _ := cache.GetDatastoreItem("config")
+	item.Value[0] = 'x'
+	item, _ = cache.GetDatastoreItem("config")
+
+	assert.Equal(t, "v1", string(item.Value))
+}
+
+func Test_CachedDatastore_ShouldNotCacheReadsOvertakenByAnInvalidation(t *testing.T) {
+	ds := &mockDatastore{items: map[string]string{"config": "v1"}}
+	cache := NewCachedDatastore(ds, time.Hour, 0)
+	// the item is written while it is being read, after the old value was read
+	ds.afterGet = func() {
+		ds.afterGet = nil
+		require.NoError(t, cache.PutDatastoreItem(DatastoreItem{Key: "config", Value: []byte("v2")}))
+	}
+
+	item, err := cache.GetDatastoreItem("config")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(item.Value))
+	item, err = cache.GetDatastoreItem("config")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v2", string(item.Value))
+	assert.Equal(t, 2, ds.gets)
+	assert.Empty(t, cache.fetching)
+}
+
+// mockDatastore is an in memory Datastore that counts reads
+type mockDatastore struct {
+	items    map[string]string
+	gets     int
+	afterGet func()
+}
+
+func (d *mockDatastore) GetDatastoreItem(key string) (*DatastoreItem, error) {
+	d.gets++
+	v, ok := d.items[key]
+	if d.afterGet != nil {
+		d.afterGet()
+	}
+	if !ok {
+		return nil, NotFoundError{fmt.Sprintf("%s not found", key)}
+	}
+	return &DatastoreItem{Key: key, Value: []byte(v)}, nil
+}
+
+func (d *mockDatastore) PutDatastoreItem(item DatastoreItem) error {
+	d.items[item.Key] = string(item.Value)
+	return nil
+}
+
+func (d *mockDatastore) DeleteDatastoreItem(key string) error {
+	delete(d.items, key)
+	return nil
+}
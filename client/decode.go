@@ -0,0 +1,59 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+)
+
+// maxPooledBufferSize is the largest response buffer kept for reuse. Buffers grown past it by unusually large
+// responses are left to the garbage collector rather than held on to by the pool.
+const maxPooledBufferSize = 16 << 20
+
+// responseBuffers holds the buffers response bodies are read into before being deserialised, so packs taking
+// multi-megabyte actions do not allocate, and collect, a new buffer for every action.
+var responseBuffers = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// readResponse reads the response body into a pooled buffer, sized up front from the Content-Length header when the
+// flyte api sends one. The buffer must be handed back with releaseBuffer once the body has been deserialised:
+// json.Unmarshal copies what it keeps, including json.RawMessage values such as the action input, and registered
+// codecs are handed a copy of the body, as they may not.
+func readResponse(resp *http.Response) (*bytes.Buffer, error) {
+	buf := responseBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	if resp.ContentLength > 0 && resp.ContentLength <= maxPooledBufferSize {
+		buf.Grow(int(resp.ContentLength) + bytes.MinRead)
+	}
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		releaseBuffer(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+func releaseBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	responseBuffers.Put(buf)
+}
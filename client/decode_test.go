@@ -0,0 +1,108 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func Test_decodeResponse_ShouldNotShareThePooledBufferWithDecodedValues(t *testing.T) {
+	first := &Action{}
+	require.NoError(t, (client{}).decodeResponse(actionResponse(`{"command": "First", "input": {"n": 1}}`), first))
+	second := &Action{}
+	require.NoError(t, (client{}).decodeResponse(actionResponse(`{"command": "Second", "input": {"n": 2}}`), second))
+
+	assert.Equal(t, "First", first.CommandName)
+	assert.JSONEq(t, `{"n": 1}`, string(first.Input))
+	assert.Equal(t, "Second", second.CommandName)
+	assert.JSONEq(t, `{"n": 2}`, string(second.Input))
+}
+
+func Test_readResponse_ShouldNotPoolOversizedBuffers(t *testing.T) {
+	buf := bytes.NewBuffer(make([]byte, 0, maxPooledBufferSize+1))
+	releaseBuffer(buf)
+
+	for i := 0; i < 10; i++ {
+		assert.True(t, responseBuffers.Get().(*bytes.Buffer).Cap() <= maxPooledBufferSize)
+	}
+}
+
+// largeAction is a 4MB action, such as an action carrying a file or a large diagnostic payload.
+var largeAction = []byte(`{"id": "1", "command": "Upload", "input": {"data": "` + strings.Repeat("x", 4<<20) + `"}}`)
+
+func actionResponse(body string) *http.Response {
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+}
+
+func largeActionResponse() *http.Response {
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(largeAction)),
+		ContentLength: int64(len(largeAction)),
+	}
+}
+
+func Benchmark_decodeResponse(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(largeAction)))
+	for i := 0; i < b.N; i++ {
+		if err := (client{}).decodeResponse(largeActionResponse(), &Action{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Benchmark_decodeResponse_ReadAll is the previous implementation, reading each response into a new buffer, kept
+// to compare against.
+func Benchmark_decodeResponse_ReadAll(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(largeAction)))
+	for i := 0; i < b.N; i++ {
+		body, err := ioutil.ReadAll(largeActionResponse().Body)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := json.Unmarshal(body, &Action{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Benchmark_decodeResponse_Decoder decodes straight from the response body with a json.Decoder, kept to compare
+// against: the decoder buffers each whole value before unmarshalling it, in a buffer of its own that is not reused.
+func Benchmark_decodeResponse_Decoder(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(largeAction)))
+	for i := 0; i < b.N; i++ {
+		if err := json.NewDecoder(largeActionResponse().Body).Decode(&Action{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
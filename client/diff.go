@@ -0,0 +1,211 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PackDiff describes the differences between two definitions of the same pack.
+type PackDiff struct {
+	AddedCommands   []string // commands only present in the new definition
+	RemovedCommands []string // commands only present in the old definition
+	ChangedCommands []string // commands present in both definitions but with different output events
+	AddedEvents     []string // event definitions only present in the new definition
+	RemovedEvents   []string // event definitions only present in the old definition
+	LabelsChanged   bool     // true if the pack labels differ
+	// commands and event definitions present in both definitions but with a different description or help link
+	ChangedCommandDocs []string
+	ChangedEventDocs   []string
+	DescriptionChanged bool // true if the pack description differs
+	HelpLinkChanged    bool // true if the pack help link differs
+}
+
+// DiffPacks compares the commands, event definitions, labels, descriptions and help links of two pack definitions.
+func DiffPacks(old, new Pack) PackDiff {
+	var diff PackDiff
+
+	oldCommands := commandEvents(old.Commands)
+	newCommands := commandEvents(new.Commands)
+	for name, events := range newCommands {
+		oldEvents, ok := oldCommands[name]
+		if !ok {
+			diff.AddedCommands = append(diff.AddedCommands, name)
+			continue
+		}
+		if !equalStringSets(oldEvents, events) {
+			diff.ChangedCommands = append(diff.ChangedCommands, name)
+		}
+	}
+	for name := range oldCommands {
+		if _, ok := newCommands[name]; !ok {
+			diff.RemovedCommands = append(diff.RemovedCommands, name)
+		}
+	}
+
+	oldEvents := eventDefNames(old.EventDefs)
+	newEvents := eventDefNames(new.EventDefs)
+	diff.AddedEvents = difference(newEvents, oldEvents)
+	diff.RemovedEvents = difference(oldEvents, newEvents)
+
+	diff.LabelsChanged = !equalLabels(old.Labels, new.Labels)
+
+	oldCommandDocs := commandDocs(old.Commands)
+	for name, d := range commandDocs(new.Commands) {
+		if oldDocs, ok := oldCommandDocs[name]; ok && oldDocs != d {
+			diff.ChangedCommandDocs = append(diff.ChangedCommandDocs, name)
+		}
+	}
+	oldEventDocs := eventDocs(old.EventDefs)
+	for name, d := range eventDocs(new.EventDefs) {
+		if oldDocs, ok := oldEventDocs[name]; ok && oldDocs != d {
+			diff.ChangedEventDocs = append(diff.ChangedEventDocs, name)
+		}
+	}
+	diff.DescriptionChanged = old.Description != new.Description
+	diff.HelpLinkChanged = helpLink(old.Links) != helpLink(new.Links)
+
+	sort.Strings(diff.AddedCommands)
+	sort.Strings(diff.RemovedCommands)
+	sort.Strings(diff.ChangedCommands)
+	sort.Strings(diff.ChangedCommandDocs)
+	sort.Strings(diff.ChangedEventDocs)
+	return diff
+}
+
+// Empty returns true if there are no differences.
+func (d PackDiff) Empty() bool {
+	return len(d.AddedCommands) == 0 && len(d.RemovedCommands) == 0 && len(d.ChangedCommands) == 0 &&
+		len(d.AddedEvents) == 0 && len(d.RemovedEvents) == 0 && !d.LabelsChanged &&
+		len(d.ChangedCommandDocs) == 0 && len(d.ChangedEventDocs) == 0 && !d.DescriptionChanged && !d.HelpLinkChanged
+}
+
+func (d PackDiff) String() string {
+	if d.Empty() {
+		return "no changes"
+	}
+	var parts []string
+	add := func(desc string, names []string) {
+		if len(names) > 0 {
+			parts = append(parts, fmt.Sprintf("%s %v", desc, names))
+		}
+	}
+	add("added commands", d.AddedCommands)
+	add("removed commands", d.RemovedCommands)
+	add("changed commands", d.ChangedCommands)
+	add("added events", d.AddedEvents)
+	add("removed events", d.RemovedEvents)
+	if d.LabelsChanged {
+		parts = append(parts, "labels changed")
+	}
+	add("changed command docs", d.ChangedCommandDocs)
+	add("changed event docs", d.ChangedEventDocs)
+	if d.DescriptionChanged {
+		parts = append(parts, "description changed")
+	}
+	if d.HelpLinkChanged {
+		parts = append(parts, "help link changed")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// creates a map of command name -> output event names
+func commandEvents(commands []Command) map[string][]string {
+	m := make(map[string][]string, len(commands))
+	for _, c := range commands {
+		m[c.Name] = c.EventNames
+	}
+	return m
+}
+
+// docs is the description and help link of a command or event definition
+type docs struct {
+	description string
+	help        string
+}
+
+// creates a map of command name -> command docs
+func commandDocs(commands []Command) map[string]docs {
+	m := make(map[string]docs, len(commands))
+	for _, c := range commands {
+		m[c.Name] = docs{description: c.Description, help: helpLink(c.Links)}
+	}
+	return m
+}
+
+// creates a map of event name -> event docs
+func eventDocs(eventDefs []EventDef) map[string]docs {
+	m := make(map[string]docs, len(eventDefs))
+	for _, e := range eventDefs {
+		m[e.Name] = docs{description: e.Description, help: helpLink(e.Links)}
+	}
+	return m
+}
+
+// returns the href of the help link, or "" if there is none
+func helpLink(links []Link) string {
+	link, ok := Links(links).FindLink(RelHelp)
+	if !ok {
+		return ""
+	}
+	if link.Href == nil {
+		return link.Template
+	}
+	return link.Href.String()
+}
+
+func eventDefNames(eventDefs []EventDef) []string {
+	names := make([]string, len(eventDefs))
+	for i, e := range eventDefs {
+		names[i] = e.Name
+	}
+	return names
+}
+
+// returns the sorted values in a that are not in b
+func difference(a, b []string) []string {
+	set := make(map[string]bool, len(b))
+	for _, s := range b {
+		set[s] = true
+	}
+	var diff []string
+	for _, s := range a {
+		if !set[s] {
+			diff = append(diff, s)
+		}
+	}
+	sort.Strings(diff)
+	return diff
+}
+
+func equalStringSets(a, b []string) bool {
+	return len(difference(a, b)) == 0 && len(difference(b, a)) == 0
+}
+
+func equalLabels(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func Test_DiffPacks_ShouldBeEmptyForIdenticalPacks(t *testing.T) {
+	p := Pack{
+		Name:      "Slack",
+		Labels:    map[string]string{"env": "prod"},
+		EventDefs: []EventDef{{Name: "MessageSent"}, {Name: "MessageReceived"}},
+		Commands:  []Command{{Name: "sendMessage", EventNames: []string{"MessageSent"}}},
+	}
+
+	diff := DiffPacks(p, p)
+
+	assert.True(t, diff.Empty())
+	assert.Equal(t, "no changes", diff.String())
+}
+
+func Test_DiffPacks_ShouldReportCommandEventAndLabelChanges(t *testing.T) {
+	old := Pack{
+		Name:      "Slack",
+		Labels:    map[string]string{"env": "prod"},
+		EventDefs: []EventDef{{Name: "MessageSent"}, {Name: "Error"}},
+		Commands: []Command{
+			{Name: "sendMessage", EventNames: []string{"MessageSent"}},
+			{Name: "deleteMessage", EventNames: []string{"MessageDeleted"}},
+		},
+	}
+	new := Pack{
+		Name:      "Slack",
+		Labels:    map[string]string{"env": "staging"},
+		EventDefs: []EventDef{{Name: "MessageSent"}, {Name: "MessageReceived"}},
+		Commands: []Command{
+			{Name: "sendMessage", EventNames: []string{"MessageSent", "Error"}},
+			{Name: "getHistory", EventNames: []string{"History"}},
+		},
+	}
+
+	diff := DiffPacks(old, new)
+
+	assert.False(t, diff.Empty())
+	assert.Equal(t, []string{"getHistory"}, diff.AddedCommands)
+	assert.Equal(t, []string{"deleteMessage"}, diff.RemovedCommands)
+	assert.Equal(t, []string{"sendMessage"}, diff.ChangedCommands)
+	assert.Equal(t, []string{"MessageReceived"}, diff.AddedEvents)
+	assert.Equal(t, []string{"Error"}, diff.RemovedEvents)
+	assert.True(t, diff.LabelsChanged)
+	assert.Equal(t, "added commands [getHistory], removed commands [deleteMessage], changed commands [sendMessage], "+
+		"added events [MessageReceived], removed events [Error], labels changed", diff.String())
+}
+
+func Test_DiffPacks_ShouldReportDescriptionChanges(t *testing.T) {
+	old := Pack{
+		Name:        "Slack",
+		Description: "Sends messages to Slack",
+		EventDefs:   []EventDef{{Name: "MessageSent", Description: "The message was sent"}, {Name: "Error"}},
+		Commands:    []Command{{Name: "sendMessage", EventNames: []string{"MessageSent"}, Description: "Sends a message"}},
+	}
+	new := Pack{
+		Name:        "Slack",
+		Description: "Sends messages to Slack channels",
+		EventDefs:   []EventDef{{Name: "MessageSent", Description: "The message was posted"}, {Name: "Error"}},
+		Commands:    []Command{{Name: "sendMessage", EventNames: []string{"MessageSent"}, Description: "Posts a message"}},
+	}
+
+	diff := DiffPacks(old, new)
+
+	assert.False(t, diff.Empty())
+	assert.True(t, diff.DescriptionChanged)
+	assert.False(t, diff.HelpLinkChanged)
+	assert.Equal(t, []string{"sendMessage"}, diff.ChangedCommandDocs)
+	assert.Equal(t, []string{"MessageSent"}, diff.ChangedEventDocs)
+	assert.Empty(t, diff.ChangedCommands)
+	assert.Equal(t, "changed command docs [sendMessage], changed event docs [MessageSent], description changed", diff.String())
+}
+
+func Test_DiffPacks_ShouldReportHelpLinkChanges(t *testing.T) {
+	help := func(href string) []Link {
+		u, _ := url.Parse(href)
+		return []Link{{Href: u, Rel: "help"}}
+	}
+	self, _ := url.Parse("http://flyte/v1/packs/Slack")
+	old := Pack{
+		Name:      "Slack",
+		Links:     append(help("http://slack/help"), Link{Href: self, Rel: "self"}),
+		EventDefs: []EventDef{{Name: "MessageSent", Links: help("http://slack/help/events")}},
+		Commands:  []Command{{Name: "sendMessage", EventNames: []string{"MessageSent"}, Links: help("http://slack/help/send")}},
+	}
+	new := Pack{
+		Name:      "Slack",
+		Links:     help("http://slack/docs"),
+		EventDefs: []EventDef{{Name: "MessageSent", Links: help("http://slack/help/events")}},
+		Commands:  []Command{{Name: "sendMessage", EventNames: []string{"MessageSent"}}},
+	}
+
+	diff := DiffPacks(old, new)
+
+	assert.False(t, diff.Empty())
+	assert.True(t, diff.HelpLinkChanged)
+	assert.False(t, diff.DescriptionChanged)
+	assert.Equal(t, []string{"sendMessage"}, diff.ChangedCommandDocs)
+	assert.Empty(t, diff.ChangedEventDocs)
+	assert.Equal(t, "changed command docs [sendMessage], help link changed", diff.String())
+
+	// while links the flyte server adds, such as self, are not compared
+	assert.True(t, DiffPacks(old, Pack{Name: "Slack", Links: help("http://slack/help"), EventDefs: old.EventDefs, Commands: old.Commands}).Empty())
+}
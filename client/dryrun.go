@@ -0,0 +1,211 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// ErrDryRun is returned by the operations of a dry-run client that have no result without the flyte api.
+var ErrDryRun = errors.New("there is no flyte api in dry-run mode")
+
+// dryRunClient is the Client created by NewDryRunClient
+type dryRunClient struct {
+	mu        sync.Mutex
+	actions   []Action
+	taken     int
+	datastore map[string]DatastoreItem
+	flows     map[string]Flow
+}
+
+var (
+	_ Client            = (*dryRunClient)(nil)
+	_ PackUpdater       = (*dryRunClient)(nil)
+	_ PackStatusUpdater = (*dryRunClient)(nil)
+	_ StatsReporter     = (*dryRunClient)(nil)
+	_ Datastore         = (*dryRunClient)(nil)
+	_ Flows             = (*dryRunClient)(nil)
+	_ ActionHeartbeater = (*dryRunClient)(nil)
+)
+
+// NewDryRunClient creates a Client that makes no calls to the flyte api, so a pack can be run safely against
+// production configuration. The pack registrations, events, action results and status updates it would send are
+// logged instead, and datastore items and flows are held in memory, so what the pack writes can be read back.
+// TakeAction takes the actions scripted in the actionsFile, a JSON array of actions such as
+// [{"command": "SendMessage", "input": {"message": "hello"}}], in order, and then returns no action. Actions without an
+// id are given one. An actionsFile of "" takes no actions.
+func NewDryRunClient(actionsFile string) (Client, error) {
+	c := &dryRunClient{datastore: map[string]DatastoreItem{}, flows: map[string]Flow{}}
+	if actionsFile == "" {
+		return c, nil
+	}
+	data, err := ioutil.ReadFile(actionsFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the dry-run actions file: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.actions); err != nil {
+		return nil, fmt.Errorf("invalid dry-run actions file %s: %w", actionsFile, err)
+	}
+	for i := range c.actions {
+		if c.actions[i].ID == "" {
+			c.actions[i].ID = "dry-run-" + strconv.Itoa(i+1)
+		}
+	}
+	log.Info().Msgf("dry-run mode is enabled, nothing is sent to the flyte api and %d actions are scripted in %s", len(c.actions), actionsFile)
+	return c, nil
+}
+
+// logNotSent logs the body of the operation that would have been sent to the flyte api
+func logNotSent(op Operation, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		log.Info().Msgf("dry run, not sending %s: %+v", op, v)
+		return
+	}
+	log.Info().Msgf("dry run, not sending %s: %s", op, body)
+}
+
+func (c *dryRunClient) CreatePack(pack Pack) error {
+	logNotSent(OpRegisterPack, pack)
+	return nil
+}
+
+func (c *dryRunClient) UpdatePack(pack Pack) error {
+	logNotSent(OpReplacePack, pack)
+	return nil
+}
+
+func (c *dryRunClient) PostEvent(event Event) error {
+	logNotSent(OpPostEvent, event)
+	return nil
+}
+
+// TakeAction returns the next scripted action, or nil once they have all been taken.
+func (c *dryRunClient) TakeAction() (*Action, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.taken == len(c.actions) {
+		return nil, nil
+	}
+	a := c.actions[c.taken]
+	c.taken++
+	log.Info().Msgf("dry run, taking scripted action %q: %s", a.ID, a.CommandName)
+	return &a, nil
+}
+
+func (c *dryRunClient) CompleteAction(action Action, event Event) error {
+	log.Info().Msgf("dry run, action %q (%s) completed", action.ID, action.CommandName)
+	logNotSent(OpCompleteAction, event)
+	return nil
+}
+
+func (c *dryRunClient) UpdatePackStatus(status PackStatus) error {
+	logNotSent(OpUpdatePackStatus, status)
+	return nil
+}
+
+func (c *dryRunClient) HeartbeatAction(action Action) error {
+	log.Debug().Msgf("dry run, not sending %s for action %q", OpHeartbeatAction, action.ID)
+	return nil
+}
+
+// GetFlyteHealthCheckURL returns ErrDryRun, as there is no flyte api to check.
+func (c *dryRunClient) GetFlyteHealthCheckURL() (*url.URL, error) {
+	return nil, ErrDryRun
+}
+
+// Stats returns empty stats, as no requests are sent.
+func (c *dryRunClient) Stats() Stats {
+	return Stats{}
+}
+
+func (c *dryRunClient) GetDatastoreItem(key string) (*DatastoreItem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.datastore[key]
+	if !ok {
+		return nil, NotFoundError{fmt.Sprintf("datastore item %q not found in dry-run mode", key)}
+	}
+	item.Value = append([]byte(nil), item.Value...)
+	return &item, nil
+}
+
+func (c *dryRunClient) PutDatastoreItem(item DatastoreItem) error {
+	log.Info().Msgf("dry run, not sending %s: %q (%s, %d bytes)", OpPutDatastoreItem, item.Key, item.ContentType, len(item.Value))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item.Value = append([]byte(nil), item.Value...)
+	c.datastore[item.Key] = item
+	return nil
+}
+
+func (c *dryRunClient) DeleteDatastoreItem(key string) error {
+	log.Info().Msgf("dry run, not sending %s: %q", OpDeleteDatastoreItem, key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.datastore[key]; !ok {
+		return NotFoundError{fmt.Sprintf("datastore item %q not found in dry-run mode", key)}
+	}
+	delete(c.datastore, key)
+	return nil
+}
+
+func (c *dryRunClient) ListFlows() ([]Flow, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	flows := make([]Flow, 0, len(c.flows))
+	for _, f := range c.flows {
+		flows = append(flows, f)
+	}
+	return flows, nil
+}
+
+func (c *dryRunClient) GetFlow(name string) (*Flow, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f, ok := c.flows[name]
+	if !ok {
+		return nil, NotFoundError{fmt.Sprintf("flow %q not found in dry-run mode", name)}
+	}
+	return &f, nil
+}
+
+func (c *dryRunClient) PutFlow(flow Flow) error {
+	logNotSent(OpReplaceFlow, flow)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flows[flow.Name] = flow
+	return nil
+}
+
+func (c *dryRunClient) DeleteFlow(name string) error {
+	log.Info().Msgf("dry run, not sending %s: %q", OpDeleteFlow, name)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.flows[name]; !ok {
+		return NotFoundError{fmt.Sprintf("flow %q not found in dry-run mode", name)}
+	}
+	delete(c.flows, name)
+	return nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeActions(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "dryrun")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	name := filepath.Join(dir, "actions.json")
+	require.NoError(t, ioutil.WriteFile(name, []byte(content), 0600))
+	return name
+}
+
+func TestDryRunClientShouldTakeTheScriptedActionsInOrder(t *testing.T) {
+	c, err := NewDryRunClient(writeActions(t, `[
+		{"command": "SendMessage", "input": {"message": "hello"}},
+		{"id": "second", "command": "CreateIssue", "input": {"title": "bug"}}
+	]`))
+	require.NoError(t, err)
+
+	a, err := c.TakeAction()
+	require.NoError(t, err)
+	require.NotNil(t, a)
+	assert.Equal(t, "dry-run-1", a.ID)
+	assert.Equal(t, "SendMessage", a.CommandName)
+	assert.JSONEq(t, `{"message": "hello"}`, string(a.Input))
+
+	a, err = c.TakeAction()
+	require.NoError(t, err)
+	require.NotNil(t, a)
+	assert.Equal(t, "second", a.ID)
+	assert.Equal(t, "CreateIssue", a.CommandName)
+
+	a, err = c.TakeAction()
+	assert.NoError(t, err)
+	assert.Nil(t, a)
+}
+
+func TestDryRunClientShouldTakeNoActionsWithoutAScript(t *testing.T) {
+	c, err := NewDryRunClient("")
+	require.NoError(t, err)
+
+	a, err := c.TakeAction()
+
+	assert.NoError(t, err)
+	assert.Nil(t, a)
+}
+
+func TestDryRunClientShouldReturnAnErrorForAnInvalidScript(t *testing.T) {
+	_, err := NewDryRunClient(writeActions(t, `{"command": "SendMessage"}`))
+	assert.Error(t, err)
+
+	_, err = NewDryRunClient(filepath.Join(os.TempDir(), "no-such-actions.json"))
+	assert.Error(t, err)
+}
+
+func TestDryRunClientShouldAcceptWritesWithoutSendingThem(t *testing.T) {
+	c, err := NewDryRunClient("")
+	require.NoError(t, err)
+
+	assert.NoError(t, c.CreatePack(Pack{Name: "DryRunPack"}))
+	assert.NoError(t, c.(PackUpdater).UpdatePack(Pack{Name: "DryRunPack"}))
+	assert.NoError(t, c.PostEvent(Event{Name: "MessageSent", Payload: map[string]string{"message": "hello"}}))
+	assert.NoError(t, c.CompleteAction(Action{ID: "1", CommandName: "SendMessage"}, Event{Name: "MessageSent"}))
+	assert.NoError(t, c.(PackStatusUpdater).UpdatePackStatus(PackStatus{InFlight: 1}))
+	assert.NoError(t, c.(ActionHeartbeater).HeartbeatAction(Action{ID: "1"}))
+	_, err = c.GetFlyteHealthCheckURL()
+	assert.Equal(t, ErrDryRun, err)
+}
+
+func TestDryRunClientShouldHoldDatastoreItemsAndFlowsInMemory(t *testing.T) {
+	dc, err := NewDryRunClient("")
+	require.NoError(t, err)
+	c := dc.(*dryRunClient)
+
+	_, err = c.GetDatastoreItem("key")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	require.NoError(t, c.PutDatastoreItem(DatastoreItem{Key: "key", ContentType: "text/plain", Value: []byte("value")}))
+	item, err := c.GetDatastoreItem("key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), item.Value)
+	assert.NoError(t, c.DeleteDatastoreItem("key"))
+	assert.True(t, errors.Is(c.DeleteDatastoreItem("key"), ErrNotFound))
+
+	require.NoError(t, c.PutFlow(Flow{Name: "flow"}))
+	flow, err := c.GetFlow("flow")
+	require.NoError(t, err)
+	assert.Equal(t, "flow", flow.Name)
+	flows, err := c.ListFlows()
+	require.NoError(t, err)
+	assert.Len(t, flows, 1)
+	assert.NoError(t, c.DeleteFlow("flow"))
+	_, err = c.GetFlow("flow")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
@@ -0,0 +1,296 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// the number of bytes of a response body included in a ResponseError
+	responseErrorBodyLimit = 200
+	// the number of bytes of an error response body read, so the error message can be parsed from it
+	errorBodyReadLimit = 64 << 10
+)
+
+// The kinds of error returned by the client, for use with errors.Is. Errors caused by a response from the flyte api
+// match the kind for their status code as well, for example a ResponseError with a 401 status matches ErrUnauthorized.
+var (
+	// ErrNotFound matches NotFoundError, and 404 responses.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict matches ConflictError, and 409 responses.
+	ErrConflict = errors.New("conflict")
+	// ErrUnauthorized matches UnauthorizedError, and 401 and 403 responses.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrBadRequest matches BadRequestError, and 400 and 422 responses.
+	ErrBadRequest = errors.New("bad request")
+	// ErrTimeout matches TimeoutError, and 408 and 504 responses.
+	ErrTimeout = errors.New("timeout")
+	// ErrLinkNotFound matches LinkNotFoundError.
+	ErrLinkNotFound = errors.New("link not found")
+	// ErrPayloadTooLarge matches RegistrationLimitError, and 413 responses.
+	ErrPayloadTooLarge = errors.New("payload too large")
+	// ErrResponseTooLarge matches ResponseTooLargeError.
+	ErrResponseTooLarge = errors.New("response too large")
+)
+
+// ResponseError describes a response from the flyte api (or a gateway in front of it) that the client did not
+// expect, such as an error status or an HTML error page.
+type ResponseError struct {
+	URL         string // the url requested
+	StatusCode  int    // the response status code, e.g. 502
+	Status      string // the response status, e.g. "502 Bad Gateway"
+	ContentType string // the response content type
+	Body        string // the first 200 bytes of the response body
+	// how long the server asked the client to wait before retrying, from the Retry-After header of 429 and 503
+	// responses. Zero if the server did not say.
+	RetryAfter time.Duration
+	// the error the flyte api described in the response body, if it did
+	ApiError *ApiError
+}
+
+func (e ResponseError) Error() string {
+	body := e.Body
+	if body == "" {
+		body = "<empty>"
+	}
+	return fmt.Sprintf("http status '%s' from %s, content type %q, body: %s", e.Status, e.URL, e.ContentType, body)
+}
+
+// Unwrap returns the error the flyte api described in the response body, if it did.
+func (e ResponseError) Unwrap() error {
+	if e.ApiError == nil {
+		return nil
+	}
+	return e.ApiError
+}
+
+// Is reports whether the target is the kind of error for the response status code.
+func (e ResponseError) Is(target error) bool {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusConflict:
+		return target == ErrConflict
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return target == ErrUnauthorized
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return target == ErrBadRequest
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return target == ErrTimeout
+	case http.StatusRequestEntityTooLarge:
+		return target == ErrPayloadTooLarge
+	}
+	return false
+}
+
+// UnauthorizedError is returned when the flyte api rejects the client credentials, with a 401 or 403 response.
+type UnauthorizedError struct {
+	ResponseError
+}
+
+// Is reports whether the target is ErrUnauthorized.
+func (e UnauthorizedError) Is(target error) bool {
+	return target == ErrUnauthorized
+}
+
+// Unwrap returns the ResponseError.
+func (e UnauthorizedError) Unwrap() error {
+	return e.ResponseError
+}
+
+// BadRequestError is returned when the flyte api rejects a request as invalid, with a 400 or 422 response.
+type BadRequestError struct {
+	ResponseError
+	// the error message from the flyte api error response body, if there is one
+	Message string
+}
+
+func (e BadRequestError) Error() string {
+	if e.Message == "" {
+		return e.ResponseError.Error()
+	}
+	return fmt.Sprintf("bad request to %s: %s", e.URL, e.Message)
+}
+
+// Is reports whether the target is ErrBadRequest.
+func (e BadRequestError) Is(target error) bool {
+	return target == ErrBadRequest
+}
+
+// Unwrap returns the ResponseError.
+func (e BadRequestError) Unwrap() error {
+	return e.ResponseError
+}
+
+// TimeoutError is returned when a request to the flyte api times out before a response is received.
+type TimeoutError struct {
+	Err error
+}
+
+func (e TimeoutError) Error() string {
+	return e.Err.Error()
+}
+
+// Is reports whether the target is ErrTimeout.
+func (e TimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// Unwrap returns the underlying transport error.
+func (e TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// LinkNotFoundError is returned when a link the client needs is missing from the links returned by the flyte api.
+type LinkNotFoundError struct {
+	Rel   Rel    // the rel of the missing link
+	Links []Link // the links searched
+}
+
+func (e LinkNotFoundError) Error() string {
+	return fmt.Sprintf("could not find link with rel %q in %v", e.Rel, e.Links)
+}
+
+// Is reports whether the target is ErrLinkNotFound.
+func (e LinkNotFoundError) Is(target error) bool {
+	return target == ErrLinkNotFound
+}
+
+// newResponseError creates an error for the response, reading the body if it has not been read. Error responses
+// the client can do something about are returned as typed errors, such as UnauthorizedError, and otherwise a
+// ResponseError is returned.
+func newResponseError(resp *http.Response) error {
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, errorBodyReadLimit))
+	e := responseError(resp, b)
+	if resp.StatusCode >= 400 {
+		e.ApiError = parseApiError(resp.StatusCode, b)
+	}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return UnauthorizedError{e}
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		badRequest := BadRequestError{ResponseError: e}
+		if e.ApiError != nil {
+			badRequest.Message = e.ApiError.Message
+		}
+		return badRequest
+	}
+	return e
+}
+
+// ApiError is the error the flyte api describes in the body of an error response, such as the validation failures
+// when registering a pack. It is attached to the ResponseError for the response, so can be retrieved with errors.As.
+type ApiError struct {
+	Status  int             `json:"status"`            // the response status code
+	Code    string          `json:"code,omitempty"`    // identifies the kind of error, if the flyte api sets it
+	Message string          `json:"message"`           // describes the error
+	Details json.RawMessage `json:"details,omitempty"` // any further details, such as the fields that failed validation
+}
+
+func (e *ApiError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("flyte api error (status %d): %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("flyte api error %s (status %d): %s", e.Code, e.Status, e.Message)
+}
+
+// parseApiError parses the error from a flyte api error response body, returning nil if the body does not describe one
+func parseApiError(statusCode int, body []byte) *ApiError {
+	var e struct {
+		ApiError
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(bytes.TrimSpace(body), &e) != nil {
+		return nil
+	}
+	if e.Message == "" {
+		e.Message = e.Error
+	}
+	if e.Message == "" && e.Code == "" {
+		return nil
+	}
+	if e.Status == 0 {
+		e.Status = statusCode
+	}
+	return &e.ApiError
+}
+
+// timeoutError returns a TimeoutError for errors sending requests that timed out, and otherwise the error as is
+func timeoutError(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return TimeoutError{Err: err}
+	}
+	return err
+}
+
+func responseError(resp *http.Response, body []byte) ResponseError {
+	if len(body) > responseErrorBodyLimit {
+		body = body[:responseErrorBodyLimit]
+	}
+	e := ResponseError{
+		StatusCode:  resp.StatusCode,
+		Status:      resp.Status,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        strings.TrimSpace(string(body)),
+		RetryAfter:  retryAfter(resp, time.Now()),
+	}
+	if resp.Request != nil && resp.Request.URL != nil {
+		e.URL = resp.Request.URL.String()
+	}
+	return e
+}
+
+// decodeResponse deserialises the response body into the supplied interface, with the registered codec for its
+// Content-Type or as JSON, strictly if the client has WithStrictJSON. Empty bodies and HTML bodies (e.g. gateway error pages) result in a ResponseError rather
+// than a syntax error.
+func (c client) decodeResponse(resp *http.Response, v interface{}) error {
+	buf, err := readResponse(resp)
+	if err != nil {
+		return fmt.Errorf("could not read response: %w", err)
+	}
+	defer releaseBuffer(buf)
+
+	body := buf.Bytes()
+	codec := c.responseCodec(resp)
+	if _, ok := codec.(jsonCodec); !ok {
+		// only json.Unmarshal is known not to retain the pooled buffer, registered codecs get their own copy
+		body = append([]byte(nil), body...)
+	}
+	if err := codec.Unmarshal(body, v); err != nil {
+		if isEmptyOrHTML(resp, body) {
+			return fmt.Errorf("could not deserialise response: %w", responseError(resp, body))
+		}
+		return fmt.Errorf("could not deserialise response: %s", err)
+	}
+	return nil
+}
+
+func isEmptyOrHTML(resp *http.Response, body []byte) bool {
+	body = bytes.TrimSpace(body)
+	return len(body) == 0 || body[0] == '<' || strings.Contains(resp.Header.Get("Content-Type"), "html")
+}
@@ -0,0 +1,202 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const gatewayErrorPage = `<html>
+<head><title>502 Bad Gateway</title></head>
+<body><center><h1>502 Bad Gateway</h1></center></body>
+</html>`
+
+func Test_PostEvent_ShouldReturnResponseErrorForHTMLErrorPage(t *testing.T) {
+	// given a gateway returning an html error page
+	ts := htmlServer(http.StatusBadGateway, gatewayErrorPage)
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	c.packLinks.eventsURL, _ = url.Parse(ts.URL + "/events")
+
+	// when
+	err := c.PostEvent(Event{Name: "MessageSent"})
+
+	// then
+	var respErr ResponseError
+	require.True(t, errors.As(err, &respErr), "expected a ResponseError, got %v", err)
+	assert.Equal(t, http.StatusBadGateway, respErr.StatusCode)
+	assert.Equal(t, "502 Bad Gateway", respErr.Status)
+	assert.Equal(t, "text/html", respErr.ContentType)
+	assert.Equal(t, ts.URL+"/events", respErr.URL)
+	assert.True(t, strings.HasPrefix(respErr.Body, "<html>"))
+}
+
+func Test_TakeAction_ShouldReturnResponseErrorInsteadOfJSONErrorForHTMLBody(t *testing.T) {
+	// given a gateway returning an html page with a 200 status
+	ts := htmlServer(http.StatusOK, gatewayErrorPage)
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	c.packLinks.takeActionURL, _ = url.Parse(ts.URL + "/take")
+
+	// when
+	_, err := c.TakeAction()
+
+	// then
+	var respErr ResponseError
+	require.True(t, errors.As(err, &respErr), "expected a ResponseError, got %v", err)
+	assert.NotContains(t, err.Error(), "invalid character")
+}
+
+func Test_CreatePack_ShouldReturnResponseErrorForEmptyBody(t *testing.T) {
+	ts := mockServer(http.StatusCreated, "")
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+
+	err := c.CreatePack(Pack{Name: "Slack"})
+
+	var respErr ResponseError
+	require.True(t, errors.As(err, &respErr), "expected a ResponseError, got %v", err)
+	assert.Equal(t, http.StatusCreated, respErr.StatusCode)
+	assert.Contains(t, err.Error(), "body: <empty>")
+}
+
+func Test_ResponseError_ShouldOnlyIncludeTheStartOfTheBody(t *testing.T) {
+	ts := htmlServer(http.StatusServiceUnavailable, "<html>"+strings.Repeat("x", 1000)+"</html>")
+	defer ts.Close()
+	c := newTestDatastoreClient(ts.URL, t)
+
+	_, err := c.GetDatastoreItem("key")
+
+	var respErr ResponseError
+	require.True(t, errors.As(err, &respErr), "expected a ResponseError, got %v", err)
+	assert.Len(t, respErr.Body, 200)
+}
+
+func Test_CreatePack_ShouldReturnBadRequestErrorWithTheFlyteApiErrorMessage(t *testing.T) {
+	ts := mockServer(http.StatusBadRequest, `{"message": "command name must not be empty"}`)
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+
+	err := c.CreatePack(Pack{Name: "Slack"})
+
+	var badRequest BadRequestError
+	require.True(t, errors.As(err, &badRequest), "expected a BadRequestError, got %v", err)
+	assert.Equal(t, "command name must not be empty", badRequest.Message)
+	assert.True(t, errors.Is(err, ErrBadRequest))
+	var respErr ResponseError
+	require.True(t, errors.As(err, &respErr), "a BadRequestError should also be a ResponseError")
+	assert.Equal(t, http.StatusBadRequest, respErr.StatusCode)
+}
+
+func Test_PostEvent_ShouldReturnUnauthorizedError(t *testing.T) {
+	ts := mockServer(http.StatusForbidden, "")
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	c.packLinks.eventsURL, _ = url.Parse(ts.URL + "/events")
+
+	err := c.PostEvent(Event{Name: "MessageSent"})
+
+	var unauthorized UnauthorizedError
+	require.True(t, errors.As(err, &unauthorized), "expected an UnauthorizedError, got %v", err)
+	assert.Equal(t, http.StatusForbidden, unauthorized.StatusCode)
+	assert.True(t, errors.Is(err, ErrUnauthorized))
+	assert.False(t, errors.Is(err, ErrBadRequest))
+}
+
+func Test_TakeAction_ShouldReturnTimeoutError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	c.httpClient.Timeout = 10 * time.Millisecond
+	c.packLinks.takeActionURL, _ = url.Parse(ts.URL + "/take")
+
+	_, err := c.TakeAction()
+
+	var timeout TimeoutError
+	require.True(t, errors.As(err, &timeout), "expected a TimeoutError, got %v", err)
+	assert.True(t, errors.Is(err, ErrTimeout))
+}
+
+func Test_CompleteAction_ShouldReturnLinkNotFoundError(t *testing.T) {
+	c := newTestClient("http://localhost:1", t)
+
+	err := c.CompleteAction(Action{}, Event{Name: "MessageSent"})
+
+	var linkNotFound LinkNotFoundError
+	require.True(t, errors.As(err, &linkNotFound), "expected a LinkNotFoundError, got %v", err)
+	assert.Equal(t, RelActionResult, linkNotFound.Rel)
+	assert.True(t, errors.Is(err, ErrLinkNotFound))
+}
+
+func Test_ResponseError_ShouldMatchTheKindOfErrorForItsStatusCode(t *testing.T) {
+	assert.True(t, errors.Is(ResponseError{StatusCode: http.StatusNotFound}, ErrNotFound))
+	assert.True(t, errors.Is(ResponseError{StatusCode: http.StatusConflict}, ErrConflict))
+	assert.True(t, errors.Is(ResponseError{StatusCode: http.StatusGatewayTimeout}, ErrTimeout))
+	assert.False(t, errors.Is(ResponseError{StatusCode: http.StatusBadGateway}, ErrTimeout))
+	assert.True(t, errors.Is(ResponseError{StatusCode: http.StatusRequestEntityTooLarge}, ErrPayloadTooLarge))
+	assert.True(t, errors.Is(NotFoundError{"pack not found"}, ErrNotFound))
+	assert.True(t, errors.Is(ConflictError{"pack already registered"}, ErrConflict))
+}
+
+func Test_CreatePack_ShouldAttachTheFlyteApiErrorFromTheResponseBody(t *testing.T) {
+	ts := mockServer(http.StatusUnprocessableEntity, `{"code": "INVALID_PACK", "message": "pack is invalid", "details": [{"field": "commands[0].name", "reason": "must not be empty"}]}`)
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+
+	err := c.CreatePack(Pack{Name: "Slack"})
+
+	var apiErr *ApiError
+	require.True(t, errors.As(err, &apiErr), "expected an ApiError, got %v", err)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.Status)
+	assert.Equal(t, "INVALID_PACK", apiErr.Code)
+	assert.Equal(t, "pack is invalid", apiErr.Message)
+	assert.JSONEq(t, `[{"field": "commands[0].name", "reason": "must not be empty"}]`, string(apiErr.Details))
+	assert.True(t, errors.Is(err, ErrBadRequest))
+}
+
+func Test_ResponseError_ShouldNotHaveAnApiError_WhenTheBodyDoesNotDescribeOne(t *testing.T) {
+	ts := htmlServer(http.StatusInternalServerError, gatewayErrorPage)
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	c.packLinks.eventsURL, _ = url.Parse(ts.URL + "/events")
+
+	err := c.PostEvent(Event{Name: "MessageSent"})
+
+	var apiErr *ApiError
+	assert.False(t, errors.As(err, &apiErr))
+	var respErr ResponseError
+	require.True(t, errors.As(err, &respErr))
+	assert.Nil(t, respErr.ApiError)
+}
+
+func htmlServer(status int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
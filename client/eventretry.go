@@ -0,0 +1,102 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// DefaultEventRetries is how many times PostEvent is retried after a connection error or a 502, 503 or 504
+	// response.
+	DefaultEventRetries = 2
+	// DefaultEventRetryBackoff is how long PostEvent waits before its first retry. The wait doubles with each retry.
+	DefaultEventRetryBackoff = 200 * time.Millisecond
+	// DefaultActionResultRetries is how many times CompleteAction is retried after a connection error or a 502, 503 or
+	// 504 response. It is retried more than PostEvent, as the flow execution waits on the action result.
+	DefaultActionResultRetries = 4
+	// DefaultActionResultRetryBackoff is how long CompleteAction waits before its first retry. The wait doubles with
+	// each retry.
+	DefaultActionResultRetryBackoff = 500 * time.Millisecond
+)
+
+// the longest PostEvent and CompleteAction wait between retries, unless the flyte api asks for longer with a
+// Retry-After header
+const maxEventRetryBackoff = 10 * time.Second
+
+// WithEventRetries sets how many times PostEvent is retried when the event may not have reached the flyte api: after a
+// transient connection error, or a 502, 503 or 504 response from a gateway or an overloaded flyte api. It waits
+// backoff before the first retry, doubling the wait with each retry up to 10 seconds, or longer if the flyte api asks
+// for it with a Retry-After header. Other errors, such as 4xx responses, are returned straight away. Zero retries
+// disables retrying. Defaults to DefaultEventRetries and DefaultEventRetryBackoff.
+func WithEventRetries(retries int, backoff time.Duration) Option {
+	return func(c *client) {
+		if retries < 0 {
+			retries = 0
+		}
+		c.eventRetries = retries
+		c.eventRetryBackoff = backoff
+	}
+}
+
+// WithActionResultRetries sets how many times CompleteAction is retried when the action result may not have reached
+// the flyte api, in the same way as WithEventRetries does for PostEvent. Zero retries disables retrying. Defaults to
+// DefaultActionResultRetries and DefaultActionResultRetryBackoff.
+func WithActionResultRetries(retries int, backoff time.Duration) Option {
+	return func(c *client) {
+		if retries < 0 {
+			retries = 0
+		}
+		c.resultRetries = retries
+		c.resultRetryBackoff = backoff
+	}
+}
+
+// retryableEventStatus reports whether a response with the status code says the event did not reach the flyte api,
+// or could not be handled by it for now
+func retryableEventStatus(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+// retryPost sends an event or action result, sending it again up to retries times with backoff when it fails with a
+// transient transport error or a retryable response
+func (c client) retryPost(op Operation, u *url.URL, retries int, backoff time.Duration, send func(attempt int) (*http.Response, error)) (*http.Response, error) {
+	wait := backoff
+	for attempt := 1; ; attempt++ {
+		resp, err := send(attempt)
+		retryable := err == nil && retryableEventStatus(resp.StatusCode) || err != nil && IsTransient(err)
+		if !retryable || attempt > retries {
+			return resp, err
+		}
+		if err == nil {
+			err = newResponseError(resp)
+			resp.Body.Close()
+		}
+		d := wait
+		if after, ok := RetryAfter(err); ok && after > d {
+			d = after
+		}
+		c.stats.transientRetry()
+		c.hooks.retry(retryInfo(op, http.MethodPost, u, attempt, err, d))
+		time.Sleep(d)
+		if wait *= 2; wait > maxEventRetryBackoff {
+			wait = maxEventRetryBackoff
+		}
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// eventServer responds to each event posted with the next status code, and then with 202
+func eventServer(t *testing.T, codes ...int) (*client, *[][]byte) {
+	var bodies [][]byte
+	rec := &requestsRec{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.add(r)
+		bodies = rec.body
+		if len(codes) > 0 {
+			w.WriteHeader(codes[0])
+			codes = codes[1:]
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(ts.Close)
+	c := newTestClient(ts.URL, t)
+	c.packLinks.eventsURL, _ = url.Parse(ts.URL + "/v1/packs/Slack/events")
+	WithEventRetries(DefaultEventRetries, time.Millisecond)(c)
+	return c, &bodies
+}
+
+func Test_PostEvent_ShouldRetryA503Response(t *testing.T) {
+	c, bodies := eventServer(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+
+	err := c.PostEvent(Event{Name: "Dave"})
+
+	require.NoError(t, err)
+	require.Len(t, *bodies, 3)
+	assert.Equal(t, (*bodies)[0], (*bodies)[2], "the same event is posted each time")
+}
+
+func Test_PostEvent_ShouldReturnTheLastErrorOnceTheRetriesRunOut(t *testing.T) {
+	c, bodies := eventServer(t, http.StatusGatewayTimeout, http.StatusGatewayTimeout, http.StatusGatewayTimeout)
+
+	err := c.PostEvent(Event{Name: "Dave"})
+
+	var re ResponseError
+	require.True(t, errors.As(err, &re))
+	assert.Equal(t, http.StatusGatewayTimeout, re.StatusCode)
+	assert.Len(t, *bodies, 3)
+}
+
+func Test_PostEvent_ShouldNotRetryA4xxResponse(t *testing.T) {
+	c, bodies := eventServer(t, http.StatusBadRequest)
+
+	err := c.PostEvent(Event{Name: "Dave"})
+
+	assert.Error(t, err)
+	assert.Len(t, *bodies, 1)
+}
+
+func Test_PostEvent_ShouldNotRetryWhenRetriesAreDisabled(t *testing.T) {
+	c, bodies := eventServer(t, http.StatusServiceUnavailable)
+	WithEventRetries(0, time.Millisecond)(c)
+
+	err := c.PostEvent(Event{Name: "Dave"})
+
+	assert.Error(t, err)
+	assert.Len(t, *bodies, 1)
+}
+
+func Test_CompleteAction_ShouldRetryA502Response(t *testing.T) {
+	c, bodies := eventServer(t, http.StatusBadGateway)
+	WithActionResultRetries(DefaultActionResultRetries, time.Millisecond)(c)
+	action := Action{Links: []Link{{Href: c.packLinks.eventsURL, Rel: "actionResult"}}}
+
+	err := c.CompleteAction(action, Event{Name: "Dave"})
+
+	require.NoError(t, err)
+	assert.Len(t, *bodies, 2)
+}
+
+func Test_CompleteAction_ShouldNotRetryA404Response(t *testing.T) {
+	c, bodies := eventServer(t, http.StatusNotFound)
+	WithActionResultRetries(DefaultActionResultRetries, time.Millisecond)(c)
+	action := Action{Links: []Link{{Href: c.packLinks.eventsURL, Rel: "actionResult"}}}
+
+	err := c.CompleteAction(action, Event{Name: "Dave"})
+
+	assert.Error(t, err)
+	assert.Len(t, *bodies, 1)
+}
@@ -0,0 +1,194 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultFailbackInterval is how often, by default, the client checks whether a flyte api endpoint it failed over
+// from is healthy again, see WithEndpoints.
+const DefaultFailbackInterval = 30 * time.Second
+
+// WithEndpoints adds flyte api urls the client fails over to when the one it is using becomes unreachable, for
+// multi-region flyte deployments. The endpoints must serve the same flyte deployment, so the pack registration and
+// actions are the same whichever is used. The api links are got from the first healthy endpoint, in the order of the
+// url passed to NewClient and then these. When a request to the endpoint in use fails without a response, the
+// endpoints are probed in order and requests are sent to the first healthy one, whatever url they were for. While the
+// client is not using the first endpoint, the endpoints before the one in use are re-probed every failbackInterval,
+// or DefaultFailbackInterval if it is zero or less, and the client fails back to them once they are healthy.
+func WithEndpoints(failbackInterval time.Duration, rootURLs ...*url.URL) Option {
+	return func(c *client) {
+		c.fallbackURLs = append(append([]*url.URL(nil), c.fallbackURLs...), rootURLs...)
+		c.failbackInterval = failbackInterval
+	}
+}
+
+// endpoints are the flyte api base urls a client can use, and the one in use. They are shared by all copies of a
+// client.
+type endpoints struct {
+	mu        sync.Mutex
+	bases     []*url.URL
+	active    int
+	failovers uint64
+	// when the endpoints before the active one were last probed, and whether they are being probed
+	lastProbe time.Time
+	probing   int32
+	interval  time.Duration
+}
+
+func newEndpoints(bases []*url.URL, interval time.Duration) *endpoints {
+	if interval <= 0 {
+		interval = DefaultFailbackInterval
+	}
+	return &endpoints{bases: bases, interval: interval}
+}
+
+// current returns the index and base url of the endpoint in use
+func (e *endpoints) current() (int, *url.URL) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.active, e.bases[e.active]
+}
+
+// use switches to the endpoint with the index passed in, returning whether it was not already in use
+func (e *endpoints) use(i int) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lastProbe = time.Now()
+	if e.active == i {
+		return false
+	}
+	e.active = i
+	e.failovers++
+	return true
+}
+
+// rewrite points the url at the endpoint in use if it is under one of the other endpoints, returning whether it did
+func (e *endpoints) rewrite(u *url.URL) bool {
+	active, base := e.current()
+	for i, b := range e.bases {
+		if i == active || !underEndpoint(u, b) {
+			continue
+		}
+		u.Scheme, u.Host = base.Scheme, base.Host
+		from, to := endpointPrefix(b), endpointPrefix(base)
+		u.Path = to + strings.TrimPrefix(u.Path, from)
+		if u.RawPath != "" {
+			u.RawPath = to + strings.TrimPrefix(u.RawPath, from)
+		}
+		return true
+	}
+	return false
+}
+
+// underEndpoint reports whether the url is on the endpoint host and under its path prefix
+func underEndpoint(u, base *url.URL) bool {
+	if !strings.EqualFold(u.Scheme, base.Scheme) || !strings.EqualFold(u.Host, base.Host) {
+		return false
+	}
+	prefix := endpointPrefix(base)
+	return prefix == "" || u.Path == prefix || strings.HasPrefix(u.Path, prefix+"/")
+}
+
+// endpointPrefix returns the path prefix of the endpoint, without the api version, e.g. "/flyte"
+func endpointPrefix(base *url.URL) string {
+	return strings.TrimSuffix(absolutePath(base.Path), "/"+ApiVersion)
+}
+
+// failover probes the endpoints, in order, when a request to the endpoint in use fails without a response, and
+// switches to the first healthy one
+func (c client) failover(req *http.Request, err error) {
+	if c.endpoints == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	if _, base := c.endpoints.current(); underEndpoint(req.URL, base) {
+		c.useHealthyEndpoint()
+	}
+}
+
+// useHealthyEndpoint switches to the first healthy endpoint, in order, other than the one in use
+func (c client) useHealthyEndpoint() {
+	active, base := c.endpoints.current()
+	for i, b := range c.endpoints.bases {
+		if i != active && c.probe(b) {
+			c.switchEndpoint(i, b)
+			return
+		}
+	}
+	log.Warn().Msgf("flyte api at %s is failing, and no other endpoint is healthy", base)
+}
+
+// failback probes the endpoints before the one in use, if they are due a probe, switching back to the first healthy
+// one. The probe runs in the background, so requests are not held up.
+func (c client) failback() {
+	e := c.endpoints
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	due := e.active > 0 && time.Since(e.lastProbe) >= e.interval
+	active := e.active
+	e.mu.Unlock()
+	if !due || !atomic.CompareAndSwapInt32(&e.probing, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&e.probing, 0)
+		for i, b := range e.bases[:active] {
+			if c.probe(b) {
+				c.switchEndpoint(i, b)
+				return
+			}
+		}
+		e.use(active)
+	}()
+}
+
+func (c client) switchEndpoint(i int, base *url.URL) {
+	if !c.endpoints.use(i) {
+		return
+	}
+	log.Warn().Msgf("switched to the flyte api at %s", base)
+	if c.linksCache != nil {
+		c.linksCache.expire()
+	}
+}
+
+// probe reports whether the flyte api at the base url responds to a request for its api links
+func (c client) probe(base *url.URL) bool {
+	req, err := http.NewRequest(http.MethodGet, base.String(), nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Accept", c.accept())
+	req.Header.Set("User-Agent", c.getUserAgent())
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode <= 299
+}
@@ -0,0 +1,156 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// regionServer is a flyte api endpoint publishing absolute links on its own host, that can be made unhealthy
+type regionServer struct {
+	*httptest.Server
+	unhealthy int32
+	takes     int32
+}
+
+func newRegionServer() *regionServer {
+	s := &regionServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&s.unhealthy) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1":
+			fmt.Fprintf(w, `{"links": [{"href": "http://%s/v1/packs", "rel": "pack/listPacks"}]}`, r.Host)
+		case "/v1/packs":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"name": "Slack", "links": [
+				{"href": "http://%s/v1/packs/Slack/actions/take", "rel": "takeAction"},
+				{"href": "http://%s/v1/packs/Slack/events", "rel": "event"}
+			]}`, r.Host, r.Host)
+		case "/v1/packs/Slack/actions/take":
+			atomic.AddInt32(&s.takes, 1)
+			w.Write([]byte(`{"command": "SendMessage"}`))
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	return s
+}
+
+func (s *regionServer) setHealthy(healthy bool) {
+	if healthy {
+		atomic.StoreInt32(&s.unhealthy, 0)
+	} else {
+		atomic.StoreInt32(&s.unhealthy, 1)
+	}
+}
+
+func Test_WithEndpoints_ShouldGetTheLinksFromTheFirstHealthyEndpoint(t *testing.T) {
+	// given an unreachable primary endpoint
+	primary := newRegionServer()
+	primary.Close()
+	secondary := newRegionServer()
+	defer secondary.Close()
+	primaryURL, _ := url.Parse(primary.URL)
+	secondaryURL, _ := url.Parse(secondary.URL)
+
+	// when
+	c := NewClient(primaryURL, time.Second, WithRetryWait(time.Millisecond), WithEndpoints(time.Hour, secondaryURL))
+
+	// then
+	require.NoError(t, c.CreatePack(Pack{Name: "Slack"}))
+	assert.Equal(t, secondary.URL+"/v1", c.(StatsReporter).Stats().Endpoint)
+}
+
+func Test_WithEndpoints_ShouldFailOverWhenTheEndpointInUseIsUnreachable(t *testing.T) {
+	// given
+	primary := newRegionServer()
+	secondary := newRegionServer()
+	defer secondary.Close()
+	primaryURL, _ := url.Parse(primary.URL)
+	secondaryURL, _ := url.Parse(secondary.URL)
+	c := NewClient(primaryURL, time.Second, WithTransientRetries(1, time.Millisecond), WithEndpoints(time.Hour, secondaryURL))
+	require.NoError(t, c.CreatePack(Pack{Name: "Slack"}))
+	_, err := c.TakeAction()
+	require.NoError(t, err)
+
+	// when the primary endpoint goes down
+	primary.Close()
+	a, err := c.TakeAction()
+
+	// then the action is taken from the same path on the secondary endpoint
+	require.NoError(t, err)
+	assert.Equal(t, "SendMessage", a.CommandName)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&primary.takes))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&secondary.takes))
+	assert.Equal(t, secondary.URL+"/v1", c.(StatsReporter).Stats().Endpoint)
+	assert.Equal(t, uint64(1), c.(StatsReporter).Stats().Failovers)
+}
+
+func Test_WithEndpoints_ShouldFailBackOnceTheFirstEndpointIsHealthyAgain(t *testing.T) {
+	// given a client that failed over from an unhealthy primary endpoint
+	primary := newRegionServer()
+	defer primary.Close()
+	primary.setHealthy(false)
+	secondary := newRegionServer()
+	defer secondary.Close()
+	primaryURL, _ := url.Parse(primary.URL)
+	secondaryURL, _ := url.Parse(secondary.URL)
+	c := NewClient(primaryURL, time.Second, WithRetryWait(time.Millisecond), WithEndpoints(time.Millisecond, secondaryURL))
+	require.NoError(t, c.CreatePack(Pack{Name: "Slack"}))
+	require.Equal(t, secondary.URL+"/v1", c.(StatsReporter).Stats().Endpoint)
+
+	// when the primary endpoint is healthy again
+	primary.setHealthy(true)
+
+	// then the client fails back to it
+	assert.Eventually(t, func() bool {
+		c.TakeAction()
+		return c.(StatsReporter).Stats().Endpoint == primary.URL+"/v1"
+	}, time.Second, 5*time.Millisecond)
+	_, err := c.TakeAction()
+	require.NoError(t, err)
+	assert.True(t, atomic.LoadInt32(&primary.takes) > 0)
+}
+
+func Test_endpoints_rewrite_ShouldPointUrlsAtTheEndpointInUse(t *testing.T) {
+	primary, _ := url.Parse("https://eu.example.com/flyte/v1")
+	secondary, _ := url.Parse("https://us.example.com/v1")
+	e := newEndpoints([]*url.URL{primary, secondary}, 0)
+	e.use(1)
+
+	for in, want := range map[string]string{
+		"https://eu.example.com/flyte/v1/packs/Slack/events": "https://us.example.com/v1/packs/Slack/events",
+		"https://eu.example.com/other/v1/packs":              "https://eu.example.com/other/v1/packs",
+		"https://us.example.com/v1/packs":                    "https://us.example.com/v1/packs",
+		"https://elsewhere.example.com/flyte/v1/packs":       "https://elsewhere.example.com/flyte/v1/packs",
+	} {
+		u, _ := url.Parse(in)
+		e.rewrite(u)
+		assert.Equal(t, want, u.String(), in)
+	}
+}
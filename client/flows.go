@@ -0,0 +1,200 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Flows is the set of client operations for managing flows on the flyte server.
+type Flows interface {
+	// ListFlows lists the flows held on the flyte server. Only the name, description and links of each flow are populated.
+	ListFlows() ([]Flow, error)
+	// GetFlow gets the flow with the name passed in. If there is no such flow a NotFoundError is returned.
+	GetFlow(name string) (*Flow, error)
+	// PutFlow creates the flow, or replaces it if a flow with the same name already exists.
+	PutFlow(Flow) error
+	// DeleteFlow deletes the flow with the name passed in. If there is no such flow a NotFoundError is returned.
+	DeleteFlow(name string) error
+}
+
+// the Flow struct describes a flow: the steps that are executed when packs raise events.
+type Flow struct {
+	Name        string     `json:"name"`                  // flow name
+	Description string     `json:"description,omitempty"` // flow description
+	Steps       []FlowStep `json:"steps,omitempty"`       // the steps that make up the flow
+	Links       []Link     `json:"links,omitempty"`       // links returned by the flyte server, such as the flow url
+}
+
+// the FlowStep struct describes the command that is executed when an event matching the step is raised.
+type FlowStep struct {
+	ID        string            `json:"id,omitempty"`        // step id, used by other steps to depend on this one
+	DependsOn []string          `json:"dependsOn,omitempty"` // the ids of the steps that must have run before this one
+	Event     FlowEvent         `json:"event"`               // the event that triggers the step
+	Context   map[string]string `json:"context,omitempty"`   // templated values that are added to the flow context
+	Criteria  string            `json:"criteria,omitempty"`  // templated expression that must evaluate to true for the step to run
+	Command   FlowCommand       `json:"command"`             // the command the step executes
+}
+
+// the FlowEvent struct identifies the pack event that triggers a flow step.
+type FlowEvent struct {
+	PackName   string            `json:"packName"`
+	PackLabels map[string]string `json:"packLabels,omitempty"`
+	Name       string            `json:"name"`
+}
+
+// the FlowCommand struct identifies the pack command a flow step executes, and the templated input it is given.
+type FlowCommand struct {
+	PackName   string            `json:"packName"`
+	PackLabels map[string]string `json:"packLabels,omitempty"`
+	Name       string            `json:"name"`
+	Input      json.RawMessage   `json:"input,omitempty"`
+}
+
+// ListFlows lists the flows held on the flyte server.
+func (c client) ListFlows() ([]Flow, error) {
+	flowsURL, err := c.getFlowsURL()
+	if err != nil {
+		return nil, err
+	}
+
+	var flows struct {
+		Flows []Flow `json:"flows"`
+	}
+	if err := c.getStruct(OpListFlows, flowsURL, &flows); err != nil {
+		return nil, err
+	}
+	return flows.Flows, nil
+}
+
+// GetFlow gets the flow with the name passed in.
+func (c client) GetFlow(name string) (*Flow, error) {
+	flowURL, err := c.getFlowURL(name)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.get(OpGetFlow, flowURL)
+	if err != nil {
+		return nil, fmt.Errorf("error getting flow from %s: %w", flowURL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		flow := &Flow{}
+		if err := c.decodeResponse(resp, flow); err != nil {
+			return nil, fmt.Errorf("error getting flow from %s: %w", flowURL.String(), err)
+		}
+		return flow, nil
+	case http.StatusNotFound:
+		return nil, NotFoundError{fmt.Sprintf("flow not found at %s", flowURL.String())}
+	default:
+		return nil, fmt.Errorf("error getting flow from %s, response was: %w", flowURL.String(), newResponseError(resp))
+	}
+}
+
+// PutFlow posts the flow to the flyte server. If a flow with the same name already exists it is replaced.
+func (c client) PutFlow(flow Flow) error {
+	flowsURL, err := c.getFlowsURL()
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.post(OpCreateFlow, flowsURL, flow)
+	if err != nil {
+		return fmt.Errorf("error posting flow %q to %s: %w", flow.Name, flowsURL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case c.accepts(OpCreateFlow, resp.StatusCode):
+		return nil
+	case resp.StatusCode == http.StatusConflict:
+		return c.replaceFlow(flow)
+	default:
+		return fmt.Errorf("flow %q not created, response was: %w", flow.Name, newResponseError(resp))
+	}
+}
+
+// replaceFlow puts the flow to the url of the existing flow with the same name
+func (c client) replaceFlow(flow Flow) error {
+	flowURL, err := c.getFlowURL(flow.Name)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.put(OpReplaceFlow, flowURL, flow)
+	if err != nil {
+		return fmt.Errorf("error putting flow %q to %s: %w", flow.Name, flowURL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	if !c.accepts(OpReplaceFlow, resp.StatusCode) {
+		return fmt.Errorf("flow %q not updated, response was: %w", flow.Name, newResponseError(resp))
+	}
+	return nil
+}
+
+// DeleteFlow deletes the flow with the name passed in.
+func (c client) DeleteFlow(name string) error {
+	flowURL, err := c.getFlowURL(name)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, flowURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("cannot create request: %v", err)
+	}
+
+	resp, err := c.do(OpDeleteFlow, req)
+	if err != nil {
+		return fmt.Errorf("error deleting flow at %s: %w", flowURL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case c.accepts(OpDeleteFlow, resp.StatusCode):
+		return nil
+	case resp.StatusCode == http.StatusNotFound:
+		return NotFoundError{fmt.Sprintf("flow not found at %s", flowURL.String())}
+	default:
+		return fmt.Errorf("flow at %s not deleted, response was: %w", flowURL.String(), newResponseError(resp))
+	}
+}
+
+// getFlowsURL finds out where flows are listed and posted to
+func (c client) getFlowsURL() (*url.URL, error) {
+	return c.apiURL(RelListFlows)
+}
+
+// getFlowURL finds out where the flow with the name passed in is held
+func (c client) getFlowURL(name string) (*url.URL, error) {
+	flowsURL, err := c.getFlowsURL()
+	if err != nil {
+		return nil, err
+	}
+	u, err := resourceURL(flowsURL, name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the url of flow %q: %w", name, err)
+	}
+	return u, nil
+}
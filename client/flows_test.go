@@ -0,0 +1,171 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func Test_ListFlows_ShouldReturnFlowsFromFlyteApi(t *testing.T) {
+	ts := mockServer(http.StatusOK, `{"flows": [{"name": "build_failed", "description": "notify on failed builds"}, {"name": "deploy"}]}`)
+	defer ts.Close()
+
+	c := newTestFlowsClient(ts.URL, t)
+
+	flows, err := c.ListFlows()
+
+	require.NoError(t, err)
+	require.Len(t, flows, 2)
+	assert.Equal(t, "build_failed", flows[0].Name)
+	assert.Equal(t, "notify on failed builds", flows[0].Description)
+	assert.Equal(t, "deploy", flows[1].Name)
+}
+
+func Test_GetFlow_ShouldReturnTypedFlow(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/flows/build_failed", r.URL.Path)
+		w.Write([]byte(buildFailedFlow))
+	}))
+	defer ts.Close()
+
+	c := newTestFlowsClient(ts.URL, t)
+
+	flow, err := c.GetFlow("build_failed")
+
+	require.NoError(t, err)
+	assert.Equal(t, "build_failed", flow.Name)
+	require.Len(t, flow.Steps, 1)
+	step := flow.Steps[0]
+	assert.Equal(t, FlowEvent{PackName: "Bamboo", Name: "BuildFailed"}, step.Event)
+	assert.Equal(t, "Slack", step.Command.PackName)
+	assert.Equal(t, map[string]string{"env": "prod"}, step.Command.PackLabels)
+	assert.JSONEq(t, `{"channel": "builds"}`, string(step.Command.Input))
+}
+
+func Test_GetFlow_ShouldReturnNotFoundErrorWhenFlowDoesNotExist(t *testing.T) {
+	ts := mockServer(http.StatusNotFound, "")
+	defer ts.Close()
+
+	c := newTestFlowsClient(ts.URL, t)
+
+	_, err := c.GetFlow("missing")
+
+	require.IsType(t, NotFoundError{}, err)
+	assert.EqualError(t, err, fmt.Sprintf("flow not found at %s/v1/flows/missing", ts.URL))
+}
+
+func Test_PutFlow_ShouldPostNewFlow(t *testing.T) {
+	ts, rec := mockServerWithRecorder(http.StatusCreated, "")
+	defer ts.Close()
+
+	c := newTestFlowsClient(ts.URL, t)
+	var flow Flow
+	require.NoError(t, json.Unmarshal([]byte(buildFailedFlow), &flow))
+
+	err := c.PutFlow(flow)
+
+	require.NoError(t, err)
+	require.Len(t, rec.reqs, 1)
+	assert.Equal(t, http.MethodPost, rec.reqs[0].Method)
+	assert.JSONEq(t, buildFailedFlow, string(rec.body[0]))
+}
+
+func Test_PutFlow_ShouldReplaceFlowThatAlreadyExists(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	c := newTestFlowsClient(ts.URL, t)
+
+	err := c.PutFlow(Flow{Name: "build_failed"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"POST /v1/flows", "PUT /v1/flows/build_failed"}, requests)
+}
+
+func Test_DeleteFlow_ShouldDeleteFlow(t *testing.T) {
+	ts, rec := mockServerWithRecorder(http.StatusNoContent, "")
+	defer ts.Close()
+
+	c := newTestFlowsClient(ts.URL, t)
+
+	err := c.DeleteFlow("build_failed")
+
+	require.NoError(t, err)
+	require.Len(t, rec.reqs, 1)
+	assert.Equal(t, http.MethodDelete, rec.reqs[0].Method)
+	assert.Equal(t, "/v1/flows/build_failed", rec.reqs[0].URL.Path)
+}
+
+func Test_FlowOperations_ShouldEscapeFlowNamesAndRejectInvalidOnes(t *testing.T) {
+	ts, rec := mockServerWithRecorder(http.StatusNoContent, "")
+	defer ts.Close()
+
+	c := newTestFlowsClient(ts.URL, t)
+
+	require.NoError(t, c.DeleteFlow("../packs"))
+	for _, name := range []string{"", ".", ".."} {
+		assert.Error(t, c.DeleteFlow(name))
+		_, err := c.GetFlow(name)
+		assert.Error(t, err)
+	}
+
+	require.Len(t, rec.reqs, 1)
+	assert.Equal(t, "/v1/flows/..%2Fpacks", rec.reqs[0].URL.EscapedPath())
+}
+
+func Test_FlowOperations_ShouldReturnErrorWhenFlowsLinkIsMissing(t *testing.T) {
+	c := &client{apiLinks: map[string][]Link{}}
+
+	_, err := c.ListFlows()
+
+	assert.Contains(t, err.Error(), `could not find link with rel "flow/listFlows"`)
+}
+
+var buildFailedFlow = `{
+	"name": "build_failed",
+	"steps": [
+		{
+			"id": "notify",
+			"event": {"packName": "Bamboo", "name": "BuildFailed"},
+			"criteria": "{{ Event.Payload.branch == 'master' }}",
+			"command": {"packName": "Slack", "packLabels": {"env": "prod"}, "name": "SendMessage", "input": {"channel": "builds"}}
+		}
+	]
+}`
+
+func newTestFlowsClient(serverURL string, t *testing.T) *client {
+	c := newTestClient(serverURL, t)
+	u, err := url.Parse(serverURL + "/v1/flows")
+	require.NoError(t, err)
+	c.apiLinks["links"] = append(c.apiLinks["links"], Link{Href: u, Rel: "http://example.com/swagger#!/flow/listFlows"})
+	return c
+}
@@ -0,0 +1,61 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrHeartbeatNotSupported is returned by HeartbeatAction when the flyte server does not support action heartbeats.
+var ErrHeartbeatNotSupported = errors.New("the flyte server does not support action heartbeats")
+
+// ActionHeartbeater is implemented by clients that can tell the flyte server an action is still being handled, so it
+// can tell a slow handler from a dead pack. The client returned by NewClient implements it.
+type ActionHeartbeater interface {
+	// HeartbeatAction tells the flyte server the action is still being handled, extending its lease. Heartbeats are
+	// posted to the "actionHeartbeat" action link: if the action has no such link, ErrHeartbeatNotSupported is
+	// returned. A NotFoundError is returned if the flyte server no longer knows the action, for example because its
+	// lease has expired.
+	HeartbeatAction(Action) error
+}
+
+// HeartbeatAction posts a heartbeat for the action to the flyte server.
+func (c client) HeartbeatAction(action Action) error {
+	heartbeatURL, err := c.linkURL(action.Links, RelActionHeartbeat)
+	if err != nil {
+		return ErrHeartbeatNotSupported
+	}
+
+	resp, err := c.post(OpHeartbeatAction, heartbeatURL, nil)
+	if err != nil {
+		return fmt.Errorf("error posting heartbeat for action %q to %s: %w", action.ID, heartbeatURL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case c.accepts(OpHeartbeatAction, resp.StatusCode):
+		return nil
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusGone:
+		return NotFoundError{fmt.Sprintf("action not found at %s", heartbeatURL.String())}
+	case resp.StatusCode == http.StatusMethodNotAllowed, resp.StatusCode == http.StatusNotImplemented:
+		return ErrHeartbeatNotSupported
+	default:
+		return fmt.Errorf("heartbeat for action %q not accepted, response was: %w", action.ID, newResponseError(resp))
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func heartbeatAction(ts string) Action {
+	u, _ := url.Parse(ts + "/v1/packs/Slack/actions/a1/heartbeat")
+	return Action{ID: "a1", Links: []Link{{Href: u, Rel: "http://example.com/swagger#!/action/actionHeartbeat"}}}
+}
+
+func Test_HeartbeatAction_ShouldPostToTheHeartbeatLink(t *testing.T) {
+	ts, rec := mockServerWithRecorder(http.StatusNoContent, "")
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+
+	err := c.HeartbeatAction(heartbeatAction(ts.URL))
+
+	require.NoError(t, err)
+	require.Len(t, rec.reqs, 1)
+	assert.Equal(t, http.MethodPost, rec.reqs[0].Method)
+	assert.Equal(t, "/v1/packs/Slack/actions/a1/heartbeat", rec.reqs[0].URL.Path)
+}
+
+func Test_HeartbeatAction_ShouldReturnNotSupportedWithoutAHeartbeatLink(t *testing.T) {
+	ts, rec := mockServerWithRecorder(http.StatusNoContent, "")
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+
+	err := c.HeartbeatAction(Action{ID: "a1"})
+
+	assert.Equal(t, ErrHeartbeatNotSupported, err)
+	assert.Empty(t, rec.reqs)
+}
+
+func Test_HeartbeatAction_ShouldReturnNotFoundWhenTheFlyteServerNoLongerKnowsTheAction(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusGone} {
+		ts, _ := mockServerWithRecorder(status, "")
+		c := newTestClient(ts.URL, t)
+
+		err := c.HeartbeatAction(heartbeatAction(ts.URL))
+
+		assert.True(t, errors.Is(err, ErrNotFound), "status %d", status)
+		ts.Close()
+	}
+}
+
+func Test_HeartbeatAction_ShouldReturnAResponseErrorForOtherResponses(t *testing.T) {
+	ts, _ := mockServerWithRecorder(http.StatusInternalServerError, "")
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+
+	err := c.HeartbeatAction(heartbeatAction(ts.URL))
+
+	var re ResponseError
+	require.True(t, errors.As(err, &re))
+	assert.Equal(t, http.StatusInternalServerError, re.StatusCode)
+}
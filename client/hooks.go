@@ -0,0 +1,114 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Hooks are called by the client at key points of each request to the flyte api, so requests can be recorded in
+// metrics, logging or alerting systems of your choosing. Hooks are called synchronously, so should return quickly.
+// Embed NoHooks to implement only some of them.
+type Hooks interface {
+	// OnRequest is called before a request is sent.
+	OnRequest(RequestInfo)
+	// OnResponse is called when a response is received, whatever its status code.
+	OnResponse(RequestInfo)
+	// OnRetry is called when a failed request will be retried, after the RequestInfo Wait.
+	OnRetry(RequestInfo)
+	// OnError is called when a request fails without a response, e.g. because the connection was reset or timed out.
+	OnError(RequestInfo)
+}
+
+// RequestInfo describes a request to the flyte api, for Hooks.
+type RequestInfo struct {
+	Operation Operation     // the operation the request is for
+	Method    string        // the request method
+	URL       string        // the request url
+	Attempt   int           // 1 for the first attempt at the request, 2 for the first retry and so on
+	Duration  time.Duration // how long the request took, for OnResponse and OnError
+	// the response status code, for OnResponse
+	StatusCode int
+	// why the request failed, for OnError and OnRetry
+	Err error
+	// how long the client waits before retrying, for OnRetry
+	Wait time.Duration
+}
+
+// NoHooks implements Hooks by doing nothing, and can be embedded to implement only some hooks.
+type NoHooks struct{}
+
+func (NoHooks) OnRequest(RequestInfo)  {}
+func (NoHooks) OnResponse(RequestInfo) {}
+func (NoHooks) OnRetry(RequestInfo)    {}
+func (NoHooks) OnError(RequestInfo)    {}
+
+// WithHooks adds hooks the client calls for each request to the flyte api. Hooks are called in the order they were
+// added.
+func WithHooks(hooks ...Hooks) Option {
+	return func(c *client) {
+		c.hooks = append(c.hooks, hooks...)
+	}
+}
+
+type hookList []Hooks
+
+func (h hookList) request(info RequestInfo) {
+	for _, hook := range h {
+		hook.OnRequest(info)
+	}
+}
+
+func (h hookList) response(info RequestInfo) {
+	for _, hook := range h {
+		hook.OnResponse(info)
+	}
+}
+
+func (h hookList) retry(info RequestInfo) {
+	for _, hook := range h {
+		hook.OnRetry(info)
+	}
+}
+
+func (h hookList) error(info RequestInfo) {
+	for _, hook := range h {
+		hook.OnError(info)
+	}
+}
+
+// requestInfo describes the request for the operation, for hooks
+func (c client) requestInfo(op Operation, req *http.Request) RequestInfo {
+	attempt := c.attempt
+	if attempt < 1 {
+		attempt = 1
+	}
+	return RequestInfo{Operation: op, Method: req.Method, URL: req.URL.String(), Attempt: attempt}
+}
+
+// retryInfo describes a failed attempt at a request that will be retried after the wait, for hooks
+func retryInfo(op Operation, method string, u *url.URL, attempt int, err error, wait time.Duration) RequestInfo {
+	return RequestInfo{Operation: op, Method: method, URL: u.String(), Attempt: attempt, Err: err, Wait: wait}
+}
+
+// withAttempt returns a copy of the client that reports the requests it sends as the attempt passed in
+func (c client) withAttempt(attempt int) *client {
+	c.attempt = attempt
+	return &c
+}
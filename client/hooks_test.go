@@ -0,0 +1,118 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type recordingHooks struct {
+	calls []string
+	infos []RequestInfo
+}
+
+func (h *recordingHooks) record(call string, info RequestInfo) {
+	h.calls = append(h.calls, call)
+	h.infos = append(h.infos, info)
+}
+
+func (h *recordingHooks) OnRequest(info RequestInfo)  { h.record("request", info) }
+func (h *recordingHooks) OnResponse(info RequestInfo) { h.record("response", info) }
+func (h *recordingHooks) OnRetry(info RequestInfo)    { h.record("retry", info) }
+func (h *recordingHooks) OnError(info RequestInfo)    { h.record("error", info) }
+
+func Test_WithHooks_ShouldCallHooksForRequestsResponsesRetriesAndErrors(t *testing.T) {
+	// given a flyte api whose connection is reset once before it returns an action
+	server := httptest.NewServer(resetConnections(t, 1))
+	defer server.Close()
+	hooks := &recordingHooks{}
+	c := newTestClient(server.URL, t)
+	WithTransientRetries(1, time.Millisecond)(c)
+	WithHooks(hooks)(c)
+	c.packLinks.takeActionURL, _ = url.Parse(server.URL + "/take")
+
+	// when
+	_, err := c.TakeAction()
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"request", "error", "retry", "request", "response"}, hooks.calls)
+	for _, info := range hooks.infos {
+		assert.Equal(t, OpTakeAction, info.Operation)
+		assert.Equal(t, http.MethodPost, info.Method)
+		assert.Equal(t, server.URL+"/take", info.URL)
+	}
+	assert.Equal(t, 1, hooks.infos[0].Attempt)
+	assert.Error(t, hooks.infos[1].Err)
+	assert.Equal(t, 1, hooks.infos[2].Attempt)
+	assert.Equal(t, time.Millisecond, hooks.infos[2].Wait)
+	assert.Equal(t, 2, hooks.infos[3].Attempt)
+	assert.Equal(t, http.StatusOK, hooks.infos[4].StatusCode)
+	assert.Equal(t, 2, hooks.infos[4].Attempt)
+	assert.True(t, hooks.infos[4].Duration > 0)
+}
+
+func Test_WithHooks_ShouldCallHooksWhenRetryingTheApiLinks(t *testing.T) {
+	// given a flyte api that fails to return the api links once
+	prevFlyteApiRetryWait := flyteApiRetryWait
+	defer func() { flyteApiRetryWait = prevFlyteApiRetryWait }()
+	flyteApiRetryWait = 0
+	failures := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(flyteApiLinksResponse))
+	}))
+	defer server.Close()
+	baseURL, _ := url.Parse(server.URL)
+	hooks := &recordingHooks{}
+
+	// when
+	NewClient(baseURL, 10*time.Second, WithHooks(hooks))
+
+	// then
+	assert.Equal(t, []string{"request", "response", "retry", "request", "response"}, hooks.calls)
+	assert.Equal(t, http.StatusInternalServerError, hooks.infos[1].StatusCode)
+	assert.Equal(t, OpGetApiLinks, hooks.infos[2].Operation)
+	assert.Equal(t, 1, hooks.infos[2].Attempt)
+	assert.Equal(t, 2, hooks.infos[4].Attempt)
+}
+
+func Test_NoHooks_ShouldLetHooksBePartiallyImplemented(t *testing.T) {
+	hooks := &responseCounter{}
+
+	hookList{hooks}.request(RequestInfo{})
+	hookList{hooks}.response(RequestInfo{})
+
+	assert.Equal(t, 1, hooks.responses)
+}
+
+type responseCounter struct {
+	NoHooks
+	responses int
+}
+
+func (c *responseCounter) OnResponse(RequestInfo) { c.responses++ }
@@ -18,53 +18,134 @@ package client
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 // marshalls the body passed in into JSON then posts to the specified url, returning a http response
 // will return error if cannot marshall JSON, cannot create a http request or for a httpClient posting error
-func (c client) post(u *url.URL, body interface{}) (*http.Response, error) {
-	b, err := json.Marshal(body)
+func (c client) post(op Operation, u *url.URL, body interface{}) (*http.Response, error) {
+	return c.sendJSON(op, http.MethodPost, u, body)
+}
+
+// marshalls the body passed in into JSON then puts to the specified url, returning a http response
+// will return error if cannot marshall JSON, cannot create a http request or for a httpClient error
+func (c client) put(op Operation, u *url.URL, body interface{}) (*http.Response, error) {
+	return c.sendJSON(op, http.MethodPut, u, body)
+}
+
+// encodes the event passed in with the payload codec then posts it to the specified url, returning a http response
+// will return error if cannot encode the event, cannot create a http request or for a httpClient posting error
+func (c client) postPayload(op Operation, u *url.URL, body interface{}) (*http.Response, error) {
+	return c.send(op, http.MethodPost, u, body, c.payloadCodec())
+}
+
+func (c client) sendJSON(op Operation, method string, u *url.URL, body interface{}) (*http.Response, error) {
+	return c.send(op, method, u, body, jsonCodec{})
+}
+
+func (c client) send(op Operation, method string, u *url.URL, body interface{}, codec Codec) (*http.Response, error) {
+	b, err := codec.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("cannot marshal body '%+v': %v", body, err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewBuffer(b))
+	b, encoding, err := c.compress(b)
+	if err != nil {
+		return nil, fmt.Errorf("cannot compress body: %v", err)
+	}
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewBuffer(b))
 	if err != nil {
 		return nil, fmt.Errorf("cannot create request: %v", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	contentType := codec.ContentType()
+	if ct, ok := body.(interface{ ContentType() string }); ok {
+		contentType = ct.ContentType()
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", c.accept())
+	if tp, ok := body.(interface{ traceParent() string }); ok && tp.traceParent() != "" {
+		req.Header.Set(TraceParentHeader, tp.traceParent())
+	}
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
 
-	return c.httpClient.Do(req)
+	return c.do(op, req)
 }
 
 // performs a http get on the specified url, returning the http response.
 // will return error if there is a problem creating the http request or if there is a httpClient error
-func (c client) get(u *url.URL) (*http.Response, error) {
+func (c client) get(op Operation, u *url.URL) (*http.Response, error) {
 	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create request: %v", err)
 	}
-	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept", c.accept())
+
+	return c.do(op, req)
+}
 
-	return c.httpClient.Do(req)
+// sends the request for the operation once allowed by the rate limits, recording it in the client stats
+func (c client) do(op Operation, req *http.Request) (*http.Response, error) {
+	c.rateLimiter.wait(op)
+	req.Header.Set("User-Agent", c.getUserAgent())
+	if c.compressor != nil {
+		// setting Accept-Encoding stops the transport transparently decompressing gzip, so responses are
+		// decompressed here, whichever registered encoding the flyte api chose
+		req.Header.Set("Accept-Encoding", acceptEncoding(c.compressor.Encoding()))
+	}
+	if c.endpoints != nil {
+		c.failback()
+		if c.endpoints.rewrite(req.URL) {
+			req.Host = ""
+		}
+	}
+	info := c.requestInfo(op, req)
+	c.hooks.request(info)
+	start := time.Now()
+	resp, err := c.httpClient.Do(c.withConnTrace(req))
+	info.Duration = time.Since(start)
+	c.stats.request(op, err)
+	if err != nil {
+		c.failover(req, err)
+		err = timeoutError(err)
+		info.Err = err
+		c.hooks.error(info)
+		return nil, err
+	}
+	info.StatusCode = resp.StatusCode
+	if resp.StatusCode == http.StatusNotFound && c.linksCache != nil {
+		c.linksCache.notFound(req.URL, c.resolveLinks(c.linksCache.get()["links"]))
+	}
+	c.recordProtocol(req.URL.Host, resp.Proto)
+	c.hooks.response(info)
+	if c.compressor != nil {
+		if err := decompress(resp); err != nil {
+			return nil, err
+		}
+	}
+	c.limitResponse(resp)
+	return resp, nil
 }
 
 // gets a struct from the specified url and deserialises it into the supplied interface
 // will return error if there is a problem getting the struct or if it cannot deserialise into the supplied interface
-func (c *client) getStruct(u *url.URL, s interface{}) error {
-	resp, err := c.get(u)
+func (c *client) getStruct(op Operation, u *url.URL, s interface{}) error {
+	resp, err := c.get(op, u)
 	if err != nil {
-		return fmt.Errorf("error getting url %q: %s", u.String(), err)
+		return fmt.Errorf("error getting url %q: %w", u.String(), err)
 	}
 	defer resp.Body.Close()
 
-	err = json.NewDecoder(resp.Body).Decode(s)
-	if err != nil {
-		return fmt.Errorf("could not deserialise response from %q: %s", u.String(), err)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("error getting url %q, response was: %w", u.String(), newResponseError(resp))
+	}
+	if err := c.decodeResponse(resp, s); err != nil {
+		return fmt.Errorf("error getting url %q: %w", u.String(), err)
 	}
 	return nil
 }
@@ -0,0 +1,158 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLinksRefreshInterval is how often the api links are refreshed by default, see WithLinksRefresh.
+const DefaultLinksRefreshInterval = 5 * time.Minute
+
+// linksNotFoundRefreshInterval is the least time between refreshes of the api links caused by 404 responses, so
+// requests for missing items, such as datastore items, do not refresh the links every time.
+var linksNotFoundRefreshInterval = 30 * time.Second
+
+// WithLinksRefresh sets how often the api links are refreshed from the flyte api, so a flyte api re-deployed with
+// different link hrefs is followed. The links are refreshed when next used once the interval has passed, and after a
+// 404 response from any of them, with an If-None-Match request so unchanged links are not transferred again. An
+// interval of zero or less only refreshes them after 404 responses. Defaults to DefaultLinksRefreshInterval.
+func WithLinksRefresh(interval time.Duration) Option {
+	return func(c *client) {
+		c.linksRefreshInterval = interval
+	}
+}
+
+// linksCache holds the api links and their ETag. It is shared by all copies of a client.
+type linksCache struct {
+	mu         sync.Mutex
+	links      map[string][]Link
+	etag       string
+	fetched    time.Time
+	stale      bool // whether a 404 response from an api link has been received since the links were fetched
+	refreshing bool
+}
+
+func newLinksCache(links map[string][]Link, etag string) *linksCache {
+	return &linksCache{links: links, etag: etag, fetched: time.Now()}
+}
+
+// startRefresh reports whether the links are due a refresh, in which case the caller must refresh them and call
+// refreshed. The links are refreshed by one caller at a time, while others carry on with the current links.
+func (l *linksCache) startRefresh(interval time.Duration) (etag string, due bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.refreshing || (!l.stale && (interval <= 0 || time.Since(l.fetched) < interval)) {
+		return "", false
+	}
+	l.refreshing = true
+	return l.etag, true
+}
+
+// refreshed ends a refresh, replacing the links if they have changed
+func (l *linksCache) refreshed(links map[string][]Link, etag string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if links != nil {
+		l.links = links
+		l.etag = etag
+	}
+	l.fetched = time.Now()
+	l.stale = false
+	l.refreshing = false
+}
+
+// expire has the links refreshed when next used
+func (l *linksCache) expire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stale = true
+}
+
+func (l *linksCache) get() map[string][]Link {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.links
+}
+
+// notFound marks the links stale if the url is under one of the links, as resolved against the base url
+func (l *linksCache) notFound(u *url.URL, resolved Links) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if time.Since(l.fetched) < linksNotFoundRefreshInterval {
+		return
+	}
+	for _, link := range resolved {
+		if link.Href != nil && strings.HasPrefix(u.String(), link.Href.String()) {
+			l.stale = true
+			return
+		}
+	}
+}
+
+// currentApiLinks returns the api links, refreshing them first if they are due a refresh
+func (c *client) currentApiLinks() map[string][]Link {
+	if c.linksCache == nil {
+		return c.apiLinks
+	}
+	if etag, due := c.linksCache.startRefresh(c.linksRefreshInterval); due {
+		links, newETag, err := c.fetchApiLinks(etag)
+		if err != nil {
+			log.Err(err).Msg("cannot refresh api links, carrying on with the current links")
+		} else {
+			c.stats.linksRefreshed()
+		}
+		c.linksCache.refreshed(links, newETag)
+	}
+	return c.linksCache.get()
+}
+
+// fetchApiLinks gets the api links from the flyte api. If the links have the ETag passed in the flyte api can reply
+// 304 Not Modified, in which case nil links are returned.
+func (c *client) fetchApiLinks(etag string) (map[string][]Link, string, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL.String(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot create request: %v", err)
+	}
+	req.Header.Set("Accept", c.accept())
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := c.do(OpGetApiLinks, req)
+	if err != nil {
+		return nil, "", fmt.Errorf("error getting url %q: %w", c.baseURL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, etag, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, "", fmt.Errorf("error getting url %q, response was: %w", c.baseURL.String(), newResponseError(resp))
+	}
+	var links map[string][]Link
+	if err := c.decodeResponse(resp, &links); err != nil {
+		return nil, "", fmt.Errorf("error getting url %q: %w", c.baseURL.String(), err)
+	}
+	return links, resp.Header.Get("ETag"), nil
+}
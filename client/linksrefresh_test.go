@@ -0,0 +1,134 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// linksServer serves api links with an ETag, replying 304 Not Modified to requests for the current version
+type linksServer struct {
+	mu          sync.Mutex
+	version     int
+	ifNoneMatch []string
+}
+
+func (s *linksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.URL.Path != "/v1" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	s.ifNoneMatch = append(s.ifNoneMatch, r.Header.Get("If-None-Match"))
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	fmt.Fprintf(w, `{"links": [
+		{"href": "http://flyte/v%d/health", "rel": "http://example.com/swagger#!/info/health"},
+		{"href": "http://%s/v%d/datastore", "rel": "http://example.com/swagger#!/datastore/listDataItems"}
+	]}`, s.version, r.Host, s.version)
+}
+
+func (s *linksServer) redeploy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+}
+
+func (s *linksServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.ifNoneMatch...)
+}
+
+func Test_WithLinksRefresh_ShouldRefreshTheLinksOnceTheIntervalHasPassed(t *testing.T) {
+	// given
+	links := &linksServer{version: 1}
+	ts := httptest.NewServer(links)
+	defer ts.Close()
+	rootURL, _ := url.Parse(ts.URL)
+	c := NewClient(rootURL, time.Second, WithLinksRefresh(time.Millisecond))
+
+	// when the links have not changed
+	time.Sleep(5 * time.Millisecond)
+	u, err := c.GetFlyteHealthCheckURL()
+
+	// then they are not transferred again
+	require.NoError(t, err)
+	assert.Equal(t, "http://flyte/v1/health", u.String())
+	assert.Equal(t, []string{"", `"v1"`}, links.requests())
+
+	// when the flyte api is re-deployed with different links
+	links.redeploy()
+	time.Sleep(5 * time.Millisecond)
+	u, err = c.GetFlyteHealthCheckURL()
+
+	// then the new links are used
+	require.NoError(t, err)
+	assert.Equal(t, "http://flyte/v2/health", u.String())
+	assert.Equal(t, []string{"", `"v1"`, `"v1"`}, links.requests())
+}
+
+func Test_WithLinksRefresh_ShouldNotRefreshTheLinksBeforeTheInterval(t *testing.T) {
+	links := &linksServer{version: 1}
+	ts := httptest.NewServer(links)
+	defer ts.Close()
+	rootURL, _ := url.Parse(ts.URL)
+	c := NewClient(rootURL, time.Second)
+
+	for i := 0; i < 3; i++ {
+		_, err := c.GetFlyteHealthCheckURL()
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{""}, links.requests())
+}
+
+func Test_WithLinksRefresh_ShouldRefreshTheLinksAfterANotFoundResponse(t *testing.T) {
+	// given a client that only refreshes the links after 404 responses
+	orig := linksNotFoundRefreshInterval
+	linksNotFoundRefreshInterval = 0
+	defer func() { linksNotFoundRefreshInterval = orig }()
+	links := &linksServer{version: 1}
+	ts := httptest.NewServer(links)
+	defer ts.Close()
+	rootURL, _ := url.Parse(ts.URL)
+	c := NewClient(rootURL, time.Second, WithLinksRefresh(0))
+
+	// when the flyte api is re-deployed, and the old datastore link is not found
+	links.redeploy()
+	_, err := c.(Datastore).GetDatastoreItem("key")
+	require.Error(t, err)
+
+	// then the links are refreshed when next used
+	u, err := c.GetFlyteHealthCheckURL()
+	require.NoError(t, err)
+	assert.Equal(t, "http://flyte/v2/health", u.String())
+	assert.Equal(t, []string{"", `"v1"`}, links.requests())
+}
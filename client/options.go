@@ -0,0 +1,71 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option configures optional client behaviour. Options are passed to NewClient and NewInsecureClient.
+type Option func(*client)
+
+// WithActionResultURLTemplate sets the template used to construct the action result url for actions that arrive
+// without an "actionResult" link. The placeholders {baseURL} (the flyte api url, including the api version),
+// {packName} and {actionId} are replaced with their values. An empty template disables the fallback, so completing
+// such actions fails. Defaults to DefaultActionResultURLTemplate.
+func WithActionResultURLTemplate(template string) Option {
+	return func(c *client) {
+		c.actionResultTemplate = template
+	}
+}
+
+// WithRetryWait sets how long the client waits before retrying to get the api links from the flyte api, unless the
+// api asks for a different delay with a Retry-After header. Defaults to 3 seconds.
+func WithRetryWait(wait time.Duration) Option {
+	return func(c *client) {
+		c.retryWait = wait
+	}
+}
+
+// WithJWT sets the JSON Web Token sent in the Authorization header of requests, in place of the one read from the
+// FLYTE_JWT environment variable, e.g. so clients of different flyte apis in one process authenticate separately. An
+// empty token sends no Authorization header.
+func WithJWT(token string) Option {
+	return func(c *client) {
+		rt := c.httpClient.Transport
+		if t, ok := rt.(transportWithHeader); ok {
+			rt = t.rt
+		}
+		c.httpClient.Transport = withJWT(rt, token)
+	}
+}
+
+// WithTransport sets the transport the client sends requests with, e.g. so several clients in one process share a
+// connection pool to the flyte api. The JWT authorisation header is still added to requests when one is configured.
+// Options that change the settings of an *http.Transport, such as WithDNS or WithTLSConfig, apply them to a copy of the
+// transport, which the client then uses in its place.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *client) {
+		if t, ok := c.httpClient.Transport.(transportWithHeader); ok {
+			t.rt = rt
+			c.httpClient.Transport = t
+			return
+		}
+		c.httpClient.Transport = rt
+	}
+}
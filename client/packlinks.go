@@ -0,0 +1,96 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/url"
+	"sync"
+)
+
+// packLinks holds the name of the registered pack and the links the client needs from its registration. They are
+// replaced whenever the pack is registered again, e.g. by a definition resync, while other goroutines are posting
+// events and taking actions with them. It is shared by all copies of a client, and a nil packLinks has no links.
+type packLinks struct {
+	mu            sync.RWMutex
+	packName      string
+	eventsURL     *url.URL
+	takeActionURL *url.URL
+	statusURL     *url.URL
+	streamURL     *url.URL
+}
+
+func (l *packLinks) name() string {
+	if l == nil {
+		return ""
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.packName
+}
+
+func (l *packLinks) events() *url.URL {
+	if l == nil {
+		return nil
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.eventsURL
+}
+
+func (l *packLinks) takeAction() *url.URL {
+	if l == nil {
+		return nil
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.takeActionURL
+}
+
+func (l *packLinks) status() *url.URL {
+	if l == nil {
+		return nil
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.statusURL
+}
+
+func (l *packLinks) stream() *url.URL {
+	if l == nil {
+		return nil
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.streamURL
+}
+
+func (l *packLinks) setName(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.packName = name
+}
+
+// set replaces the name and links all at once, so they are never seen half replaced
+func (l *packLinks) set(name string, events, takeAction, status, stream *url.URL) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.packName = name
+	l.eventsURL = events
+	l.takeActionURL = takeAction
+	l.statusURL = status
+	l.streamURL = stream
+}
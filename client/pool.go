@@ -0,0 +1,66 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+// ConnectionPool tunes the pool of connections the client keeps to the flyte api, see WithConnectionPool. Zero leaves
+// a setting at the transport's value.
+type ConnectionPool struct {
+	MaxIdleConns        int           // the most idle connections kept open, across all hosts
+	MaxIdleConnsPerHost int           // the most idle connections kept open to a host. The transport keeps 2 by default
+	MaxConnsPerHost     int           // the most connections to a host, whether active or idle. Further requests wait
+	IdleConnTimeout     time.Duration // how long an idle connection is kept open for reuse, as Timeouts.IdleConn
+}
+
+// WithConnectionPool tunes the connection pool of the client transport. The default transport settings suit clients
+// talking to many hosts: a pack sending many requests to the flyte api alone closes most of its connections as soon
+// as they are idle, and can run out of ephemeral ports opening new ones. Raising MaxIdleConnsPerHost to the number of
+// concurrent requests (e.g. the pack's worker count) lets connections be reused instead. The pool is applied to the
+// client transport, including one set with WithTransport, if it is an *http.Transport.
+func WithConnectionPool(pool ConnectionPool) Option {
+	return func(c *client) {
+		c.pool = pool
+	}
+}
+
+// applyConnectionPool sets the connection pool settings on the client transport, once all the options have been applied
+func (c *client) applyConnectionPool() {
+	if c.pool == (ConnectionPool{}) {
+		return
+	}
+	t := c.transport()
+	if t == nil {
+		log.Warn().Msg("the client transport is not an *http.Transport, connection pool settings are not applied")
+		return
+	}
+	if c.pool.MaxIdleConns > 0 {
+		t.MaxIdleConns = c.pool.MaxIdleConns
+	}
+	if c.pool.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = c.pool.MaxIdleConnsPerHost
+	}
+	if c.pool.MaxConnsPerHost > 0 {
+		t.MaxConnsPerHost = c.pool.MaxConnsPerHost
+	}
+	if c.pool.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = c.pool.IdleConnTimeout
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_WithConnectionPool_ShouldTuneTheClientTransport(t *testing.T) {
+	c := newTestClient("http://example.com", t)
+	WithTimeouts(Timeouts{IdleConn: time.Minute})(c)
+
+	WithConnectionPool(ConnectionPool{MaxIdleConns: 200, MaxIdleConnsPerHost: 100, MaxConnsPerHost: 150})(c)
+	c.applyTimeouts()
+	c.applyConnectionPool()
+
+	tr := c.transport()
+	assert.Equal(t, 200, tr.MaxIdleConns)
+	assert.Equal(t, 100, tr.MaxIdleConnsPerHost)
+	assert.Equal(t, 150, tr.MaxConnsPerHost)
+	assert.Equal(t, time.Minute, tr.IdleConnTimeout, "settings that are not set should be left as they are")
+}
+
+func Test_WithConnectionPool_ShouldOverrideTheIdleTimeout(t *testing.T) {
+	c := newTestClient("http://example.com", t)
+	WithTimeouts(Timeouts{IdleConn: time.Minute})(c)
+
+	WithConnectionPool(ConnectionPool{IdleConnTimeout: 5 * time.Minute})(c)
+	c.applyTimeouts()
+	c.applyConnectionPool()
+
+	assert.Equal(t, 5*time.Minute, c.transport().IdleConnTimeout)
+}
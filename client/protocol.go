@@ -0,0 +1,80 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/tls"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"net/http/httptrace"
+)
+
+// WithHTTP1 forces the client to use HTTP/1.1. By default the client negotiates HTTP/2 with flyte apis served over TLS
+// that support it, which some proxies and load balancers mishandle, e.g. by resetting streams or not reusing
+// connections. The protocol in use and how often connections are reused are reported in the client Stats.
+func WithHTTP1() Option {
+	return func(c *client) {
+		c.http1 = true
+	}
+}
+
+// applyProtocol stops the client transport negotiating HTTP/2 when HTTP/1.1 is forced, once all the options have been
+// applied
+func (c *client) applyProtocol() {
+	if !c.http1 {
+		return
+	}
+	t := c.transport()
+	if t == nil {
+		log.Warn().Msg("the client transport is not an *http.Transport, HTTP/1.1 cannot be forced")
+		return
+	}
+	t.ForceAttemptHTTP2 = false
+	// a non-nil empty map disables HTTP/2
+	t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	// cloning the transport, see transport, may already have added h2 to the protocols offered in the TLS handshake
+	if t.TLSClientConfig != nil {
+		cfg := t.TLSClientConfig.Clone()
+		cfg.NextProtos = nil
+		for _, p := range t.TLSClientConfig.NextProtos {
+			if p != "h2" {
+				cfg.NextProtos = append(cfg.NextProtos, p)
+			}
+		}
+		t.TLSClientConfig = cfg
+	}
+}
+
+// withConnTrace records whether the request is sent on a new or a reused connection in the client stats
+func (c client) withConnTrace(req *http.Request) *http.Request {
+	if c.stats == nil {
+		return req
+	}
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			c.stats.gotConn(info.Reused)
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+// recordProtocol records the protocol of a response from the host, logging the protocol when the client starts using it
+func (c client) recordProtocol(host, proto string) {
+	if c.stats.protocolUsed(proto) {
+		log.Info().Msgf("using %s with the flyte api at %s", proto, host)
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func Test_NewClient_ShouldNegotiateHTTP2WithTLSServersThatSupportIt(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"links": []}`))
+	}))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+
+	c := NewInsecureClient(u, 0).(*client)
+	_, err := c.get(OpGetApiLinks, c.baseURL)
+
+	require.NoError(t, err)
+	stats := c.Stats()
+	assert.Equal(t, "HTTP/2.0", stats.Protocol)
+	assert.Equal(t, uint64(1), stats.ConnectionsOpened)
+	assert.Equal(t, uint64(1), stats.ConnectionsReused, "the connection should be reused after getting the api links")
+}
+
+func Test_WithHTTP1_ShouldForceHTTP1(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"links": []}`))
+	}))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+
+	c := NewInsecureClient(u, 0, WithHTTP1()).(*client)
+
+	assert.Equal(t, "HTTP/1.1", c.Stats().Protocol)
+}
@@ -0,0 +1,135 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// the operations a pack performs continuously, which are rate limited by WithRateLimit
+var rateLimitedOperations = []Operation{OpPostEvent, OpTakeAction, OpCompleteAction}
+
+// WithRateLimit limits the rate of PostEvent, TakeAction and CompleteAction requests, so that a misbehaving pack
+// cannot overwhelm flyte-api. Requests share a token bucket that allows rps requests per second on average, with bursts
+// of up to burst requests. Requests over the limit wait until they are allowed. TakeAction can be given its own limit
+// with WithOperationRateLimit, as the polling cadence differs from event posting.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *client) {
+		b := newTokenBucket(rps, burst)
+		for _, op := range rateLimitedOperations {
+			c.rateLimits().buckets[op] = b
+		}
+	}
+}
+
+// WithOperationRateLimit limits the rate of requests for the operation, overriding WithRateLimit for that operation.
+// The operation gets its own token bucket allowing rps requests per second on average, with bursts of up to burst
+// requests.
+func WithOperationRateLimit(op Operation, rps float64, burst int) Option {
+	return func(c *client) {
+		c.rateLimits().buckets[op] = newTokenBucket(rps, burst)
+	}
+}
+
+// SetRateLimit changes the rate limit of WithRateLimit on a running client, e.g. when its settings are reloaded,
+// replacing the limits of PostEvent, TakeAction and CompleteAction requests. A rate of zero or less removes the limit.
+// It returns false if the client was not created by NewClient, and cannot be changed.
+func SetRateLimit(c Client, rps float64, burst int) bool {
+	cl, ok := c.(*client)
+	if !ok {
+		return false
+	}
+	l := cl.rateLimits()
+	b := newTokenBucket(rps, burst)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, op := range rateLimitedOperations {
+		l.buckets[op] = b
+	}
+	return true
+}
+
+// rateLimiter holds the token buckets that limit requests, by operation
+type rateLimiter struct {
+	mu      sync.RWMutex
+	buckets map[Operation]*tokenBucket
+}
+
+func (c *client) rateLimits() *rateLimiter {
+	if c.rateLimiter == nil {
+		c.rateLimiter = &rateLimiter{buckets: map[Operation]*tokenBucket{}}
+	}
+	return c.rateLimiter
+}
+
+// wait blocks until a request for the operation is allowed
+func (l *rateLimiter) wait(op Operation) {
+	if l == nil {
+		return
+	}
+	l.mu.RLock()
+	b, ok := l.buckets[op]
+	l.mu.RUnlock()
+	if ok {
+		if d := b.reserve(); d > 0 {
+			time.Sleep(d)
+		}
+	}
+}
+
+// tokenBucket holds up to burst tokens, refilled at rate tokens per second. Each request takes a token.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newTokenBucket(rps float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rps, burst: float64(burst), tokens: float64(burst), now: time.Now}
+}
+
+// reserve takes a token, returning how long to wait before the token is available. A rate of zero or less is unlimited.
+func (b *tokenBucket) reserve() time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+
+	// tokens can go negative, reserving future tokens for requests that are waiting
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
@@ -0,0 +1,99 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_TokenBucket_ShouldAllowBurstThenLimitToRate(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(10, 2)
+	b.now = func() time.Time { return now }
+
+	// the burst is allowed straight away
+	assert.Equal(t, time.Duration(0), b.reserve())
+	assert.Equal(t, time.Duration(0), b.reserve())
+
+	// then requests wait for tokens at 10 per second, each waiting behind the previous one
+	assert.Equal(t, 100*time.Millisecond, b.reserve())
+	assert.Equal(t, 200*time.Millisecond, b.reserve())
+
+	// and once time passes, tokens are refilled
+	now = now.Add(time.Second)
+	assert.Equal(t, time.Duration(0), b.reserve())
+}
+
+func Test_TokenBucket_ShouldNotLimitWhenRateIsZero(t *testing.T) {
+	b := newTokenBucket(0, 1)
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, time.Duration(0), b.reserve())
+	}
+}
+
+func Test_WithRateLimit_ShouldShareLimitAcrossPackOperationsUnlessOverridden(t *testing.T) {
+	c := newTestClient("http://localhost", t)
+
+	WithRateLimit(5, 10)(c)
+	WithOperationRateLimit(OpTakeAction, 1, 1)(c)
+
+	limits := c.rateLimiter.buckets
+	assert.Same(t, limits[OpPostEvent], limits[OpCompleteAction])
+	assert.NotSame(t, limits[OpPostEvent], limits[OpTakeAction])
+	assert.Equal(t, float64(1), limits[OpTakeAction].rate)
+	assert.NotContains(t, limits, OpGetApiLinks)
+}
+
+func Test_WithRateLimit_ShouldDelayRequestsOverTheLimit(t *testing.T) {
+	// given a client limited to one event per 50ms
+	ts := mockServer(http.StatusAccepted, "")
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	c.packLinks.eventsURL, _ = url.Parse(ts.URL + "/events")
+	WithRateLimit(20, 1)(c)
+
+	// when
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, c.PostEvent(Event{Name: "MessageSent"}))
+	}
+
+	// then
+	assert.True(t, time.Since(start) >= 100*time.Millisecond, "3 requests should take at least 100ms, took %v", time.Since(start))
+}
+
+func Test_SetRateLimit_ShouldChangeTheLimitOfARunningClient(t *testing.T) {
+	c := &client{}
+	WithRateLimit(1, 1)(c)
+
+	assert.True(t, SetRateLimit(c, 100, 5))
+
+	b := c.rateLimiter.buckets[OpPostEvent]
+	assert.Equal(t, float64(100), b.rate)
+	assert.Equal(t, float64(5), b.burst)
+	assert.Same(t, b, c.rateLimiter.buckets[OpCompleteAction])
+}
+
+func Test_SetRateLimit_ShouldNotChangeAClientNotCreatedByNewClient(t *testing.T) {
+	assert.False(t, SetRateLimit(nil, 100, 5))
+}
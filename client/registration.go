@@ -0,0 +1,183 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// the number of largest commands listed when the flyte api rejects a registration as too large
+const largestCommandsReported = 3
+
+// RegistrationLimits limit the size of the pack registration payload. They are checked before the pack is
+// registered, so a pack that is too big for the flyte api fails with an error saying what to trim, rather than with
+// a 413 response that does not. Zero means no limit.
+type RegistrationLimits struct {
+	MaxBytes    int // the size of the registration JSON, in bytes
+	MaxCommands int // the number of commands
+	MaxEvents   int // the number of event definitions
+}
+
+// WithRegistrationLimits checks the pack registration payload against the limits passed in before registering the
+// pack, returning a RegistrationLimitError instead of registering a pack that exceeds them.
+func WithRegistrationLimits(limits RegistrationLimits) Option {
+	return func(c *client) {
+		c.registrationLimits = limits
+	}
+}
+
+// RegistrationSize describes the size of a pack registration payload.
+type RegistrationSize struct {
+	Bytes    int           // the size of the registration JSON, in bytes
+	Events   int           // the number of event definitions
+	Commands []CommandSize // the size of each command, in the order they are registered
+}
+
+// CommandSize is the number of bytes a command adds to the registration payload.
+type CommandSize struct {
+	Name  string
+	Bytes int
+}
+
+// MeasureRegistration returns the size of the registration payload for the pack passed in.
+func MeasureRegistration(pack Pack) (RegistrationSize, error) {
+	b, err := json.Marshal(pack)
+	if err != nil {
+		return RegistrationSize{}, fmt.Errorf("cannot marshal pack %q: %v", pack.Name, err)
+	}
+	size := RegistrationSize{Bytes: len(b), Events: len(pack.EventDefs)}
+	for _, command := range pack.Commands {
+		b, err := json.Marshal(command)
+		if err != nil {
+			return RegistrationSize{}, fmt.Errorf("cannot marshal command %q: %v", command.Name, err)
+		}
+		// each command is separated from the next by a comma
+		size.Commands = append(size.Commands, CommandSize{Name: command.Name, Bytes: len(b) + 1})
+	}
+	return size, nil
+}
+
+// RegistrationLimitError is returned when a pack registration payload exceeds the registration limits (see
+// WithRegistrationLimits), or when the flyte api rejects it as too large with a 413 response.
+type RegistrationLimitError struct {
+	Pack   string
+	Size   RegistrationSize
+	Limits RegistrationLimits
+	// the limits exceeded, or the rejection by the flyte api
+	Problems []string
+	// the commands that take the payload over the byte limit: without these, the rest of the pack is within it. When
+	// the flyte api rejected the payload it is the largest commands, as its limit is not known
+	Commands []CommandSize
+	// the error for the 413 response from the flyte api, if the payload was rejected by it
+	Response error
+}
+
+func (e RegistrationLimitError) Error() string {
+	msg := fmt.Sprintf("pack %q registration is %d bytes with %d commands and %d events: %s", e.Pack, e.Size.Bytes,
+		len(e.Size.Commands), e.Size.Events, strings.Join(e.Problems, ", "))
+	if len(e.Commands) == 0 {
+		return msg
+	}
+	var commands []string
+	for _, c := range e.Commands {
+		commands = append(commands, fmt.Sprintf("%s (%d bytes)", c.Name, c.Bytes))
+	}
+	if e.Response != nil {
+		return fmt.Sprintf("%s; the largest commands are %s", msg, strings.Join(commands, ", "))
+	}
+	return fmt.Sprintf("%s; the commands that take it over the limit are %s", msg, strings.Join(commands, ", "))
+}
+
+// Is reports whether the target is ErrPayloadTooLarge.
+func (e RegistrationLimitError) Is(target error) bool {
+	return target == ErrPayloadTooLarge
+}
+
+// Unwrap returns the error for the 413 response from the flyte api, if the payload was rejected by it.
+func (e RegistrationLimitError) Unwrap() error {
+	return e.Response
+}
+
+// checkRegistrationLimits returns a RegistrationLimitError if the pack registration exceeds the limits
+func checkRegistrationLimits(pack Pack, limits RegistrationLimits) error {
+	if limits == (RegistrationLimits{}) {
+		return nil
+	}
+	size, err := MeasureRegistration(pack)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	var over []CommandSize
+	if limits.MaxBytes > 0 && size.Bytes > limits.MaxBytes {
+		problems = append(problems, fmt.Sprintf("%d bytes is over the limit of %d bytes", size.Bytes, limits.MaxBytes))
+		over = commandsOverLimit(size, limits.MaxBytes)
+	}
+	if limits.MaxCommands > 0 && len(size.Commands) > limits.MaxCommands {
+		problems = append(problems, fmt.Sprintf("%d commands is over the limit of %d", len(size.Commands), limits.MaxCommands))
+	}
+	if limits.MaxEvents > 0 && size.Events > limits.MaxEvents {
+		problems = append(problems, fmt.Sprintf("%d events is over the limit of %d", size.Events, limits.MaxEvents))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return RegistrationLimitError{Pack: pack.Name, Size: size, Limits: limits, Problems: problems, Commands: over}
+}
+
+// commandsOverLimit returns the commands, in registration order, from the first one that takes the payload over the
+// byte limit onwards
+func commandsOverLimit(size RegistrationSize, maxBytes int) []CommandSize {
+	total := size.Bytes
+	for _, c := range size.Commands {
+		total -= c.Bytes
+	}
+	for i, c := range size.Commands {
+		total += c.Bytes
+		if total > maxBytes {
+			return size.Commands[i:]
+		}
+	}
+	return nil
+}
+
+// payloadTooLargeError describes the 413 response the flyte api rejected the pack registration with
+func payloadTooLargeError(pack Pack, limits RegistrationLimits, resp *http.Response) error {
+	respErr := newResponseError(resp)
+	size, err := MeasureRegistration(pack)
+	if err != nil {
+		return fmt.Errorf("pack not created, response was: %w", respErr)
+	}
+	largest := append([]CommandSize(nil), size.Commands...)
+	sort.SliceStable(largest, func(i, j int) bool { return largest[i].Bytes > largest[j].Bytes })
+	if len(largest) > largestCommandsReported {
+		largest = largest[:largestCommandsReported]
+	}
+	return RegistrationLimitError{
+		Pack:     pack.Name,
+		Size:     size,
+		Limits:   limits,
+		Problems: []string{"the flyte api rejected it as too large"},
+		Commands: largest,
+		Response: respErr,
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// bigPack returns a pack with a small command, then a big one, then another small one
+func bigPack() Pack {
+	return Pack{
+		Name:      "Jira",
+		EventDefs: []EventDef{{Name: "IssueCreated"}, {Name: "IssueUpdated"}},
+		Commands: []Command{
+			{Name: "GetIssue", EventNames: []string{"IssueFound"}},
+			{Name: "CreateIssue", EventNames: []string{"IssueCreated"}, Description: strings.Repeat("x", 1000)},
+			{Name: "UpdateIssue", EventNames: []string{"IssueUpdated"}},
+		},
+	}
+}
+
+func Test_MeasureRegistration_ShouldMeasureThePayloadAndEachCommand(t *testing.T) {
+	size, err := MeasureRegistration(bigPack())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, size.Events)
+	require.Len(t, size.Commands, 3)
+	assert.Equal(t, "CreateIssue", size.Commands[1].Name)
+	assert.True(t, size.Commands[1].Bytes > 1000)
+	assert.True(t, size.Bytes > size.Commands[0].Bytes+size.Commands[1].Bytes+size.Commands[2].Bytes)
+}
+
+func Test_CreatePack_ShouldNotRegisterAPackOverTheLimits(t *testing.T) {
+	ts, rec := mockServerWithRecorder(http.StatusCreated, slackPackResponse)
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	WithRegistrationLimits(RegistrationLimits{MaxBytes: 500, MaxCommands: 2, MaxEvents: 5})(c)
+
+	err := c.CreatePack(bigPack())
+
+	var limitErr RegistrationLimitError
+	require.True(t, errors.As(err, &limitErr))
+	assert.True(t, errors.Is(err, ErrPayloadTooLarge))
+	assert.Len(t, limitErr.Problems, 2)
+	assert.Equal(t, []string{"CreateIssue", "UpdateIssue"}, commandNames(limitErr.Commands))
+	assert.Contains(t, err.Error(), "3 commands is over the limit of 2")
+	assert.Contains(t, err.Error(), "the commands that take it over the limit are CreateIssue (")
+	assert.Empty(t, rec.reqs, "the pack should not have been posted")
+}
+
+func Test_CreatePack_ShouldRegisterAPackWithinTheLimits(t *testing.T) {
+	ts, rec := mockServerWithRecorder(http.StatusCreated, slackPackResponse)
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	WithRegistrationLimits(RegistrationLimits{MaxBytes: 64 << 10, MaxCommands: 10, MaxEvents: 10})(c)
+
+	require.NoError(t, c.CreatePack(bigPack()))
+	assert.Len(t, rec.reqs, 1)
+}
+
+func Test_CreatePack_ShouldReportTheLargestCommandsWhenTheFlyteApiRejectsThePackAsTooLarge(t *testing.T) {
+	ts, _ := mockServerWithRecorder(http.StatusRequestEntityTooLarge, "")
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+
+	err := c.CreatePack(bigPack())
+
+	var limitErr RegistrationLimitError
+	require.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, "CreateIssue", limitErr.Commands[0].Name)
+	assert.Contains(t, err.Error(), "the flyte api rejected it as too large; the largest commands are CreateIssue (")
+	var respErr ResponseError
+	require.True(t, errors.As(err, &respErr))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, respErr.StatusCode)
+}
+
+func commandNames(commands []CommandSize) []string {
+	var names []string
+	for _, c := range commands {
+		names = append(names, c.Name)
+	}
+	return names
+}
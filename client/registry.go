@@ -0,0 +1,211 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PackRegistry is implemented by clients that can manage the packs registered on the flyte server, rather than acting
+// as a pack. The client returned by NewClient implements it.
+type PackRegistry interface {
+	// ListPacks lists the packs registered on the flyte server.
+	ListPacks() ([]RegisteredPack, error)
+	// DeletePack deletes the pack registration. If there is no such pack a NotFoundError is returned.
+	DeletePack(RegisteredPack) error
+	// FindActions searches the flyte api audit for the actions of the pack with the name passed in since the time
+	// passed in, most recent first.
+	FindActions(packName string, since time.Time) ([]AuditAction, error)
+}
+
+// the RegisteredPack struct describes a pack registered on the flyte server.
+type RegisteredPack struct {
+	ID       string            `json:"id"`                 // the id of the registration
+	Name     string            `json:"name"`               // pack name
+	Labels   map[string]string `json:"labels,omitempty"`   // pack labels
+	LastSeen time.Time         `json:"lastSeen,omitempty"` // when the pack last polled the flyte server, if the server records it
+	Links    []Link            `json:"links,omitempty"`    // links returned by the flyte server, such as the pack url
+}
+
+// the AuditAction struct describes an action recorded in the flyte api audit.
+type AuditAction struct {
+	ID          string    `json:"id"`
+	FlowName    string    `json:"flowName"`
+	PackName    string    `json:"packName"`
+	CommandName string    `json:"commandName"`
+	State       string    `json:"state"`
+	Time        time.Time `json:"time"`
+}
+
+// ListPacks lists the packs registered on the flyte server.
+func (c *client) ListPacks() ([]RegisteredPack, error) {
+	packsURL, err := c.getPacksURL()
+	if err != nil {
+		return nil, err
+	}
+
+	var packs struct {
+		Packs []RegisteredPack `json:"packs"`
+	}
+	if err := c.getStruct(OpListPacks, packsURL, &packs); err != nil {
+		return nil, err
+	}
+	return packs.Packs, nil
+}
+
+// DeletePack deletes the pack registration.
+func (c *client) DeletePack(pack RegisteredPack) error {
+	packURL, err := c.getRegisteredPackURL(pack)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, packURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("cannot create request: %v", err)
+	}
+
+	resp, err := c.do(OpDeletePack, req)
+	if err != nil {
+		return fmt.Errorf("error deleting pack at %s: %w", packURL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case c.accepts(OpDeletePack, resp.StatusCode):
+		return nil
+	case resp.StatusCode == http.StatusNotFound:
+		return NotFoundError{fmt.Sprintf("pack not found at %s", packURL.String())}
+	default:
+		return fmt.Errorf("pack at %s not deleted, response was: %w", packURL.String(), newResponseError(resp))
+	}
+}
+
+// FindActions searches the flyte api audit for the actions of the pack since the time passed in.
+func (c *client) FindActions(packName string, since time.Time) ([]AuditAction, error) {
+	auditURL, err := c.apiURL(RelAudit)
+	if err != nil {
+		return nil, err
+	}
+	u := *auditURL
+	q := u.Query()
+	q.Set("actionPackName", packName)
+	if !since.IsZero() {
+		q.Set("start", since.UTC().Format(time.RFC3339))
+	}
+	u.RawQuery = q.Encode()
+
+	var audit struct {
+		Actions []AuditAction `json:"actions"`
+	}
+	if err := c.getStruct(OpFindActions, &u, &audit); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(audit.Actions, func(i, j int) bool {
+		return audit.Actions[i].Time.After(audit.Actions[j].Time)
+	})
+	return audit.Actions, nil
+}
+
+// getRegisteredPackURL finds out where the pack registration is held, which is its self link if it has one
+func (c *client) getRegisteredPackURL(pack RegisteredPack) (*url.URL, error) {
+	if selfURL, err := c.linkURL(pack.Links, RelSelf); err == nil {
+		return selfURL, nil
+	}
+	if pack.ID == "" {
+		return nil, fmt.Errorf("pack %q has neither a self link nor an id", pack.Name)
+	}
+	packsURL, err := c.getPacksURL()
+	if err != nil {
+		return nil, err
+	}
+	u, err := resourceURL(packsURL, pack.ID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the url of pack %q: %w", pack.Name, err)
+	}
+	return u, nil
+}
+
+// MinStalePackIdle is the shortest idle duration FindStalePacks and RemoveStalePacks accept, so a mistaken duration
+// cannot remove the registrations of packs that are running.
+const MinStalePackIdle = time.Hour
+
+// the StalePack struct describes a registered pack that has had no activity for a while.
+type StalePack struct {
+	Pack         RegisteredPack
+	LastActivity time.Time // when the pack was last seen polling the flyte server, zero if never or not recorded
+}
+
+// FindStalePacks lists the packs registered on the flyte server that have had no activity for the idle duration
+// passed in: they have not been seen polling the flyte server (where the server records it) and the audit holds no
+// actions for them. Registrations sharing a name are treated alike, as the audit records actions by pack name. An
+// error is returned if the idle duration is less than MinStalePackIdle.
+func FindStalePacks(r PackRegistry, idle time.Duration) ([]StalePack, error) {
+	if idle < MinStalePackIdle {
+		return nil, fmt.Errorf("idle duration %v is less than the minimum of %v", idle, MinStalePackIdle)
+	}
+	packs, err := r.ListPacks()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list packs: %w", err)
+	}
+
+	since := time.Now().Add(-idle)
+	var stale []StalePack
+	for _, p := range packs {
+		if p.LastSeen.After(since) {
+			continue
+		}
+		actions, err := r.FindActions(p.Name, since)
+		if err != nil {
+			return nil, fmt.Errorf("cannot find actions of pack %q: %w", p.Name, err)
+		}
+		if len(actions) > 0 {
+			continue
+		}
+		stale = append(stale, StalePack{Pack: p, LastActivity: p.LastSeen})
+	}
+	return stale, nil
+}
+
+// RemoveStalePacks deletes the registrations of the packs that have had no activity for the idle duration passed in
+// (see FindStalePacks), returning the packs deleted. Packs that cannot be deleted are skipped, and reported in the
+// error returned. Nothing is deleted if the idle duration is less than MinStalePackIdle.
+func RemoveStalePacks(r PackRegistry, idle time.Duration) ([]StalePack, error) {
+	stale, err := FindStalePacks(r, idle)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []StalePack
+	var failed []string
+	for _, s := range stale {
+		if err := r.DeletePack(s.Pack); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", s.Pack.Name, err))
+			continue
+		}
+		removed = append(removed, s)
+	}
+	if len(failed) > 0 {
+		return removed, fmt.Errorf("cannot delete %d stale packs: %s", len(failed), strings.Join(failed, "; "))
+	}
+	return removed, nil
+}
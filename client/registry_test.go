@@ -0,0 +1,191 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_ListPacks_ShouldReturnRegisteredPacksFromFlyteApi(t *testing.T) {
+	ts := mockServer(http.StatusOK, `{"packs": [
+		{"id": "Slack", "name": "Slack", "lastSeen": "2020-01-02T03:04:05Z", "links": [{"href": "http://example.com/v1/packs/Slack", "rel": "self"}]},
+		{"id": "Jira.env.dev", "name": "Jira", "labels": {"env": "dev"}}
+	]}`)
+	defer ts.Close()
+
+	c := newTestClient(ts.URL, t)
+
+	packs, err := c.ListPacks()
+
+	require.NoError(t, err)
+	require.Len(t, packs, 2)
+	assert.Equal(t, "Slack", packs[0].Name)
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), packs[0].LastSeen)
+	assert.Equal(t, "Jira.env.dev", packs[1].ID)
+	assert.Equal(t, map[string]string{"env": "dev"}, packs[1].Labels)
+}
+
+func Test_DeletePack_ShouldDeleteThePackAtItsSelfLinkOrId(t *testing.T) {
+	ts, rec := mockServerWithRecorder(http.StatusNoContent, "")
+	defer ts.Close()
+
+	c := newTestClient(ts.URL+"/v1/packs", t)
+	self, err := url.Parse(ts.URL + "/v1/packs/Slack")
+	require.NoError(t, err)
+
+	require.NoError(t, c.DeletePack(RegisteredPack{Name: "Slack", Links: []Link{{Href: self, Rel: "self"}}}))
+	require.NoError(t, c.DeletePack(RegisteredPack{ID: "Jira.env.dev", Name: "Jira"}))
+
+	require.Len(t, rec.reqs, 2)
+	assert.Equal(t, http.MethodDelete, rec.reqs[0].Method)
+	assert.Equal(t, "/v1/packs/Slack", rec.reqs[0].URL.Path)
+	assert.Equal(t, "/v1/packs/Jira.env.dev", rec.reqs[1].URL.Path)
+}
+
+func Test_DeletePack_ShouldReturnNotFoundErrorWhenPackDoesNotExist(t *testing.T) {
+	ts := mockServer(http.StatusNotFound, "")
+	defer ts.Close()
+
+	c := newTestClient(ts.URL+"/v1/packs", t)
+
+	err := c.DeletePack(RegisteredPack{ID: "Slack", Name: "Slack"})
+
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.EqualError(t, err, fmt.Sprintf("pack not found at %s/v1/packs/Slack", ts.URL))
+}
+
+func Test_FindActions_ShouldSearchTheAuditForThePackActionsMostRecentFirst(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/audit/flows", r.URL.Path)
+		assert.Equal(t, "Slack", r.URL.Query().Get("actionPackName"))
+		assert.Equal(t, "2020-01-01T00:00:00Z", r.URL.Query().Get("start"))
+		w.Write([]byte(`{"actions": [
+			{"id": "1", "packName": "Slack", "commandName": "SendMessage", "time": "2020-01-02T00:00:00Z"},
+			{"id": "2", "packName": "Slack", "commandName": "SendMessage", "time": "2020-01-03T00:00:00Z"}
+		]}`))
+	}))
+	defer ts.Close()
+
+	c := newTestAuditClient(ts.URL, t)
+
+	actions, err := c.FindActions("Slack", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	require.NoError(t, err)
+	require.Len(t, actions, 2)
+	assert.Equal(t, "2", actions[0].ID)
+	assert.Equal(t, "1", actions[1].ID)
+}
+
+// mockRegistry is an in memory PackRegistry
+type mockRegistry struct {
+	packs   []RegisteredPack
+	actions map[string][]AuditAction
+	deleted []string
+	failed  map[string]error
+}
+
+func (r *mockRegistry) ListPacks() ([]RegisteredPack, error) {
+	return r.packs, nil
+}
+
+func (r *mockRegistry) DeletePack(p RegisteredPack) error {
+	if err := r.failed[p.Name]; err != nil {
+		return err
+	}
+	r.deleted = append(r.deleted, p.Name)
+	return nil
+}
+
+func (r *mockRegistry) FindActions(packName string, since time.Time) ([]AuditAction, error) {
+	var found []AuditAction
+	for _, a := range r.actions[packName] {
+		if a.Time.After(since) {
+			found = append(found, a)
+		}
+	}
+	return found, nil
+}
+
+func newMockRegistry() *mockRegistry {
+	now := time.Now()
+	return &mockRegistry{
+		packs: []RegisteredPack{
+			{Name: "Polling", LastSeen: now.Add(-time.Minute)},
+			{Name: "Busy", LastSeen: now.Add(-30 * 24 * time.Hour)},
+			{Name: "Dead", LastSeen: now.Add(-30 * 24 * time.Hour)},
+			{Name: "NeverSeen"},
+		},
+		actions: map[string][]AuditAction{
+			"Busy": {{ID: "1", Time: now.Add(-time.Hour)}},
+			"Dead": {{ID: "2", Time: now.Add(-30 * 24 * time.Hour)}},
+		},
+	}
+}
+
+func Test_FindStalePacks_ShouldFindPacksNotSeenAndWithoutActionsForTheIdleDuration(t *testing.T) {
+	r := newMockRegistry()
+
+	stale, err := FindStalePacks(r, 7*24*time.Hour)
+
+	require.NoError(t, err)
+	require.Len(t, stale, 2)
+	assert.Equal(t, "Dead", stale[0].Pack.Name)
+	assert.Equal(t, r.packs[2].LastSeen, stale[0].LastActivity)
+	assert.Equal(t, "NeverSeen", stale[1].Pack.Name)
+	assert.True(t, stale[1].LastActivity.IsZero())
+	assert.Empty(t, r.deleted)
+}
+
+func Test_RemoveStalePacks_ShouldDeleteStalePacksAndReportThoseThatCannotBeDeleted(t *testing.T) {
+	r := newMockRegistry()
+	r.failed = map[string]error{"NeverSeen": errors.New("forbidden")}
+
+	removed, err := RemoveStalePacks(r, 7*24*time.Hour)
+
+	assert.EqualError(t, err, "cannot delete 1 stale packs: NeverSeen: forbidden")
+	require.Len(t, removed, 1)
+	assert.Equal(t, "Dead", removed[0].Pack.Name)
+	assert.Equal(t, []string{"Dead"}, r.deleted)
+}
+
+func Test_RemoveStalePacks_ShouldRejectAnIdleDurationBelowTheMinimum(t *testing.T) {
+	for _, idle := range []time.Duration{-time.Hour, 0, time.Minute} {
+		r := newMockRegistry()
+
+		removed, err := RemoveStalePacks(r, idle)
+
+		assert.Error(t, err)
+		assert.Empty(t, removed)
+		assert.Empty(t, r.deleted)
+	}
+}
+
+func newTestAuditClient(serverURL string, t *testing.T) *client {
+	c := newTestClient(serverURL, t)
+	u, err := url.Parse(serverURL + "/v1/audit/flows")
+	require.NoError(t, err)
+	c.apiLinks["links"] = append(c.apiLinks["links"], Link{Href: u, Rel: "http://example.com/swagger#!/audit/findFlows"})
+	return c
+}
@@ -0,0 +1,95 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Rel identifies a link relation published by the flyte api. The flyte api publishes fully qualified rels
+// (e.g. "http://example.com/swagger#!/action/takeAction"), so links are matched on the rel suffix.
+type Rel string
+
+// The link rels used by the client.
+const (
+	RelSelf         Rel = "self"
+	RelHelp         Rel = "help"
+	RelTakeAction   Rel = "takeAction"              // pack link used to take the next action
+	RelEvent        Rel = "event"                   // pack link used to post events
+	RelPackStatus   Rel = "status"                  // optional pack link used to report the pack status
+	RelActionResult Rel = "actionResult"            // action link used to post the action result
+	RelActionStream Rel = "actionStream"            // optional pack link to a server-sent events stream of actions
+	RelHealth       Rel = "info/health"             // api link to the flyte api healthcheck
+	RelListPacks    Rel = "pack/listPacks"          // api link used to register and list packs
+	RelListFlows    Rel = "flow/listFlows"          // api link used to manage flows
+	RelDatastore    Rel = "datastore/listDataItems" // api link used to manage datastore items
+	RelAudit        Rel = "audit/findFlows"         // api link used to search the flow audit
+	// optional action link used to tell the flyte server the action is still being handled
+	RelActionHeartbeat Rel = "actionHeartbeat"
+)
+
+// Matches reports whether the link rel passed in is this rel.
+func (r Rel) Matches(linkRel string) bool {
+	return strings.HasSuffix(linkRel, string(r))
+}
+
+// FindURL returns the URL of the first link with the rel, else it will return an error.
+func FindURL(links []Link, rel Rel) (*url.URL, error) {
+	return findURLByRel(links, rel)
+}
+
+// Links is a collection of links, such as the links of a pack or an action, that can be searched by rel:
+//
+//	help, ok := client.Links(pack.Links).FindLink(client.RelHelp)
+type Links []Link
+
+// FindLink returns the first link with the rel, and whether there is one.
+func (l Links) FindLink(rel Rel) (Link, bool) {
+	for _, link := range l {
+		if rel.Matches(link.Rel) {
+			return link, true
+		}
+	}
+	return Link{}, false
+}
+
+// URL returns the URL of the first link with the rel, or a LinkNotFoundError if there is none.
+func (l Links) URL(rel Rel) (*url.URL, error) {
+	link, ok := l.FindLink(rel)
+	if !ok {
+		return nil, LinkNotFoundError{Rel: rel, Links: l}
+	}
+	return link.Href, nil
+}
+
+// LinkProvider is implemented by clients that can give the api links published by the flyte api, for custom api
+// interactions. The client returned by NewClient implements it.
+type LinkProvider interface {
+	// ApiLinks returns the api links, as last refreshed, or the static links the client was configured with.
+	ApiLinks() Links
+}
+
+// ApiLinks returns the api links, with their hrefs resolved against the flyte api url.
+func (c *client) ApiLinks() Links {
+	return c.resolveLinks(c.currentApiLinks()["links"])
+}
+
+// apiURL finds the URL of the api link with the rel
+func (c *client) apiURL(rel Rel) (*url.URL, error) {
+	return c.ApiLinks().URL(rel)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+)
+
+func Test_FindURL_ShouldFindApiLinksByRel(t *testing.T) {
+	var links map[string][]Link
+	require.NoError(t, json.Unmarshal([]byte(flyteApiLinksResponse), &links))
+
+	for rel, want := range map[Rel]string{
+		RelSelf:      "http://example.com/v1",
+		RelHealth:    "http://example.com/v1/health",
+		RelListPacks: "http://example.com/v1/packs",
+		RelListFlows: "http://example.com/v1/flows",
+		RelDatastore: "http://example.com/v1/datastore",
+		RelAudit:     "http://example.com/v1/audit/flows",
+	} {
+		u, err := FindURL(links["links"], rel)
+		require.NoError(t, err, "rel %q", rel)
+		assert.Equal(t, want, u.String(), "rel %q", rel)
+	}
+}
+
+func Test_FindURL_ShouldReturnErrorWhenRelIsNotFound(t *testing.T) {
+	_, err := FindURL(nil, RelTakeAction)
+
+	assert.EqualError(t, err, `could not find link with rel "takeAction" in []`)
+}
+
+func Test_Links_FindLink_ShouldFindTheFirstLinkWithTheRel(t *testing.T) {
+	help, _ := url.Parse("http://example.com/help")
+	other, _ := url.Parse("http://example.com/other-help")
+	links := Links{{Href: help, Rel: "http://example.com/swagger#!/help"}, {Href: other, Rel: "help"}}
+
+	link, ok := links.FindLink(RelHelp)
+
+	assert.True(t, ok)
+	assert.Equal(t, help, link.Href)
+	_, ok = links.FindLink(RelTakeAction)
+	assert.False(t, ok)
+}
+
+func Test_Links_URL_ShouldReturnALinkNotFoundErrorWhenRelIsNotFound(t *testing.T) {
+	_, err := Links(nil).URL(RelEvent)
+
+	assert.True(t, errors.Is(err, ErrLinkNotFound))
+	var notFound LinkNotFoundError
+	require.True(t, errors.As(err, &notFound))
+	assert.Equal(t, RelEvent, notFound.Rel)
+}
+
+func Test_ApiLinks_ShouldReturnTheApiLinks(t *testing.T) {
+	var links map[string][]Link
+	require.NoError(t, json.Unmarshal([]byte(flyteApiLinksResponse), &links))
+	c := &client{apiLinks: links}
+
+	u, err := c.ApiLinks().URL(RelDatastore)
+
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com/v1/datastore", u.String())
+}
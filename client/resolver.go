@@ -0,0 +1,177 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DNSConfig configures how the client resolves the flyte api host name.
+type DNSConfig struct {
+	// the DNS servers to query (host:port, the port defaults to 53), instead of those configured for the host
+	Servers []string
+	// how long a lookup may take before it fails. Zero means the lookup is only limited by the request timeout
+	LookupTimeout time.Duration
+	// how long resolved addresses are cached. Cached addresses are also used, however old, if a lookup fails, so a
+	// DNS blip does not stall or fail requests. Zero disables caching
+	CacheTTL time.Duration
+}
+
+// WithDNS sets how the client resolves host names, e.g. to use specific DNS servers, time out slow lookups and cache
+// the results.
+func WithDNS(cfg DNSConfig) Option {
+	return func(c *client) {
+		t := c.transport()
+		if t == nil {
+			return
+		}
+		r := newResolver(cfg)
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		t.DialContext = r.dialContext(dialer)
+	}
+}
+
+// transport returns the http transport of the client for its settings to be changed, or nil if the client does not use
+// one. The first time it is called the transport is replaced with a clone, so the transport passed to WithTransport,
+// which may be shared with other clients, or http.DefaultTransport, is never changed.
+func (c *client) transport() *http.Transport {
+	if c.httpClient == nil {
+		return nil
+	}
+	switch t := c.httpClient.Transport.(type) {
+	case *http.Transport:
+		if t != c.ownTransport {
+			c.ownTransport = t.Clone()
+			c.httpClient.Transport = c.ownTransport
+		}
+		return c.ownTransport
+	case transportWithHeader:
+		if ht, ok := t.rt.(*http.Transport); ok {
+			if ht != c.ownTransport {
+				c.ownTransport = ht.Clone()
+				t.rt = c.ownTransport
+				c.httpClient.Transport = t
+			}
+			return c.ownTransport
+		}
+	}
+	return nil
+}
+
+type resolver struct {
+	cfg    DNSConfig
+	lookup func(ctx context.Context, host string) ([]string, error)
+	mu     sync.Mutex
+	cache  map[string]resolved
+	now    func() time.Time
+}
+
+type resolved struct {
+	addrs   []string
+	expires time.Time
+}
+
+func newResolver(cfg DNSConfig) *resolver {
+	r := &resolver{cfg: cfg, cache: make(map[string]resolved), now: time.Now}
+	nr := net.DefaultResolver
+	if len(cfg.Servers) > 0 {
+		servers := make([]string, len(cfg.Servers))
+		for i, s := range cfg.Servers {
+			if _, _, err := net.SplitHostPort(s); err != nil {
+				s = net.JoinHostPort(s, "53")
+			}
+			servers[i] = s
+		}
+		var next uint32
+		nr = &net.Resolver{
+			PreferGo: true,
+			// the servers are used in turn, so a server that is down only fails some of the lookups
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				s := servers[int(atomic.AddUint32(&next, 1)-1)%len(servers)]
+				var d net.Dialer
+				return d.DialContext(ctx, network, s)
+			},
+		}
+	}
+	r.lookup = nr.LookupHost
+	return r
+}
+
+// resolve returns the addresses of the host, from the cache if they have not expired
+func (r *resolver) resolve(ctx context.Context, host string) ([]string, error) {
+	r.mu.Lock()
+	cached, ok := r.cache[host]
+	r.mu.Unlock()
+	if ok && r.now().Before(cached.expires) {
+		return cached.addrs, nil
+	}
+
+	if r.cfg.LookupTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.cfg.LookupTimeout)
+		defer cancel()
+	}
+	addrs, err := r.lookup(ctx, host)
+	if err != nil {
+		if ok {
+			// a stale address is better than no address
+			return cached.addrs, nil
+		}
+		return nil, err
+	}
+	if r.cfg.CacheTTL > 0 {
+		r.mu.Lock()
+		r.cache[host] = resolved{addrs: addrs, expires: r.now().Add(r.cfg.CacheTTL)}
+		r.mu.Unlock()
+	}
+	return addrs, nil
+}
+
+// dialContext returns a dial function that resolves host names with the resolver, then dials each address in turn
+// until a connection is made
+func (r *resolver) dialContext(dialer *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		if net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, address)
+		}
+
+		addrs, err := r.resolve(ctx, host)
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve %s: %v", host, err)
+		}
+		for _, addr := range addrs {
+			var conn net.Conn
+			if conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(addr, port)); err == nil {
+				return conn, nil
+			}
+		}
+		if err == nil {
+			err = fmt.Errorf("no addresses found for %s", host)
+		}
+		return nil, err
+	}
+}
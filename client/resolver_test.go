@@ -0,0 +1,137 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_resolver_ShouldCacheAddressesForTheTTL(t *testing.T) {
+	// given
+	now := time.Now()
+	lookups := 0
+	r := newResolver(DNSConfig{CacheTTL: time.Minute})
+	r.now = func() time.Time { return now }
+	r.lookup = func(ctx context.Context, host string) ([]string, error) {
+		lookups++
+		return []string{"10.0.0.1"}, nil
+	}
+
+	// when
+	_, err := r.resolve(context.Background(), "flyte.example.com")
+	require.NoError(t, err)
+	addrs, err := r.resolve(context.Background(), "flyte.example.com")
+	require.NoError(t, err)
+	now = now.Add(2 * time.Minute)
+	_, err = r.resolve(context.Background(), "flyte.example.com")
+	require.NoError(t, err)
+
+	// then
+	assert.Equal(t, []string{"10.0.0.1"}, addrs)
+	assert.Equal(t, 2, lookups)
+}
+
+func Test_resolver_ShouldUseExpiredAddressesWhenTheLookupFails(t *testing.T) {
+	// given a cached address that has expired
+	now := time.Now()
+	r := newResolver(DNSConfig{CacheTTL: time.Minute})
+	r.now = func() time.Time { return now }
+	r.lookup = func(ctx context.Context, host string) ([]string, error) {
+		return []string{"10.0.0.1"}, nil
+	}
+	_, err := r.resolve(context.Background(), "flyte.example.com")
+	require.NoError(t, err)
+	now = now.Add(2 * time.Minute)
+
+	// when the lookup fails
+	r.lookup = func(ctx context.Context, host string) ([]string, error) {
+		return nil, errors.New("i/o timeout")
+	}
+	addrs, err := r.resolve(context.Background(), "flyte.example.com")
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, addrs)
+}
+
+func Test_resolver_ShouldTimeOutSlowLookups(t *testing.T) {
+	r := newResolver(DNSConfig{LookupTimeout: 10 * time.Millisecond})
+	r.lookup = func(ctx context.Context, host string) ([]string, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	start := time.Now()
+	_, err := r.resolve(context.Background(), "flyte.example.com")
+
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func Test_resolver_ShouldDialTheResolvedAddresses(t *testing.T) {
+	// given a server only reachable through the resolved address
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	_, port, _ := net.SplitHostPort(u.Host)
+
+	r := newResolver(DNSConfig{})
+	r.lookup = func(ctx context.Context, host string) ([]string, error) {
+		assert.Equal(t, "flyte.test", host)
+		return []string{"127.0.0.2", "127.0.0.1"}, nil
+	}
+	c := &http.Client{Transport: &http.Transport{DialContext: r.dialContext(&net.Dialer{Timeout: time.Second})}}
+
+	// when
+	resp, err := c.Get("http://" + net.JoinHostPort("flyte.test", port))
+
+	// then
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func Test_WithDNS_ShouldSetTheDialerOfTheClientTransport(t *testing.T) {
+	c := newTestClient("http://example.com", t)
+
+	WithDNS(DNSConfig{Servers: []string{"10.0.0.53"}, LookupTimeout: time.Second})(c)
+
+	assert.NotNil(t, c.transport().DialContext)
+}
+
+func Test_WithDNS_ShouldNotChangeTheTransportTheClientWasGiven(t *testing.T) {
+	c := newTestClient("http://example.com", t)
+	shared := &http.Transport{}
+	WithTransport(shared)(c)
+
+	WithDNS(DNSConfig{Servers: []string{"10.0.0.53"}})(c)
+
+	assert.Nil(t, shared.DialContext)
+	assert.NotSame(t, shared, c.transport())
+	assert.NotNil(t, c.transport().DialContext)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultMaxResponseBytes is the most bytes of a response body the client reads, after decompression, unless
+// WithMaxResponseSize sets a different limit.
+const DefaultMaxResponseBytes = 32 << 20
+
+// ResponseTooLargeError is returned when a response body from the flyte api is larger than the client reads, see
+// WithMaxResponseSize.
+type ResponseTooLargeError struct {
+	URL   string // the url the response came from
+	Limit int64  // the most bytes the client reads
+}
+
+func (e ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response from %s is larger than the limit of %d bytes", e.URL, e.Limit)
+}
+
+// Is reports whether the target is ErrResponseTooLarge.
+func (e ResponseTooLargeError) Is(target error) bool {
+	return target == ErrResponseTooLarge
+}
+
+// WithMaxResponseSize sets the most bytes of a response body the client reads, after decompression, to protect the
+// pack from a misbehaving or compromised endpoint returning huge responses. Reading past the limit fails with a
+// ResponseTooLargeError. Action streams are not limited, as they are long lived. A limit of zero or less removes the
+// limit. Defaults to DefaultMaxResponseBytes.
+func WithMaxResponseSize(maxBytes int64) Option {
+	return func(c *client) {
+		c.maxResponseBytes = maxBytes
+	}
+}
+
+// limitResponse replaces the response body with one that fails once more than the client limit has been read
+func (c client) limitResponse(resp *http.Response) {
+	if c.maxResponseBytes <= 0 {
+		return
+	}
+	err := ResponseTooLargeError{Limit: c.maxResponseBytes}
+	if resp.Request != nil && resp.Request.URL != nil {
+		err.URL = resp.Request.URL.String()
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: c.maxResponseBytes, err: err}
+}
+
+// limitedBody reads up to remaining bytes of a response body, then fails with err if there is any more to read
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	err       error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	// reading one more byte than remains is enough to tell whether the body is too large
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = 0
+		return n, b.err
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
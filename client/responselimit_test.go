@@ -0,0 +1,104 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func Test_WithMaxResponseSize_ShouldFailResponsesOverTheLimit(t *testing.T) {
+	// given a flyte api returning a huge action
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"command": "SendMessage", "input": "` + strings.Repeat("x", 4096) + `"}`))
+	}))
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	WithMaxResponseSize(1024)(c)
+	c.packLinks.takeActionURL, _ = url.Parse(ts.URL + "/take")
+
+	// when
+	_, err := c.TakeAction()
+
+	// then
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrResponseTooLarge))
+	var tooLarge ResponseTooLargeError
+	require.True(t, errors.As(err, &tooLarge))
+	assert.Equal(t, int64(1024), tooLarge.Limit)
+	assert.Equal(t, ts.URL+"/take", tooLarge.URL)
+}
+
+func Test_WithMaxResponseSize_ShouldLimitTheDecompressedSize(t *testing.T) {
+	// given a small gzip response that decompresses to more than the limit
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		gw.Write([]byte(`{"command": "SendMessage", "input": "` + strings.Repeat("x", 1<<20) + `"}`))
+		gw.Close()
+	}))
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	WithCompression("gzip")(c)
+	WithMaxResponseSize(64 << 10)(c)
+	c.packLinks.takeActionURL, _ = url.Parse(ts.URL + "/take")
+
+	// when
+	_, err := c.TakeAction()
+
+	// then
+	assert.True(t, errors.Is(err, ErrResponseTooLarge))
+}
+
+func Test_WithMaxResponseSize_ShouldReadResponsesUpToTheLimit(t *testing.T) {
+	body := `{"command": "SendMessage"}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	WithMaxResponseSize(int64(len(body)))(c)
+	c.packLinks.takeActionURL, _ = url.Parse(ts.URL + "/take")
+
+	a, err := c.TakeAction()
+
+	require.NoError(t, err)
+	assert.Equal(t, "SendMessage", a.CommandName)
+}
+
+func Test_limitedBody_ShouldReadExactlyTheLimit(t *testing.T) {
+	tooLarge := ResponseTooLargeError{Limit: 4}
+
+	b := &limitedBody{ReadCloser: ioutil.NopCloser(bytes.NewReader([]byte("abcd"))), remaining: 4, err: tooLarge}
+	read, err := ioutil.ReadAll(b)
+	require.NoError(t, err)
+	assert.Equal(t, "abcd", string(read))
+
+	b = &limitedBody{ReadCloser: ioutil.NopCloser(bytes.NewReader([]byte("abcde"))), remaining: 4, err: tooLarge}
+	read, err = ioutil.ReadAll(b)
+	assert.Equal(t, tooLarge, err)
+	assert.Equal(t, "abcd", string(read))
+}
@@ -0,0 +1,88 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_TakeAction_ShouldReturnTheRetryAfterDelayOfA429Response(t *testing.T) {
+	// given
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	c.packLinks.takeActionURL, _ = url.Parse(ts.URL + "/take")
+
+	// when
+	_, err := c.TakeAction()
+
+	// then
+	d, ok := RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Second, d)
+}
+
+func Test_retryAfter_ShouldParseSecondsAndHTTPDates(t *testing.T) {
+	now := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		status int
+		header string
+		want   time.Duration
+	}{
+		{http.StatusTooManyRequests, "120", 2 * time.Minute},
+		{http.StatusServiceUnavailable, now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{http.StatusServiceUnavailable, now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{http.StatusTooManyRequests, "soon", 0},
+		{http.StatusTooManyRequests, "", 0},
+		{http.StatusTooManyRequests, "86400", MaxRetryAfter},
+		{http.StatusInternalServerError, "120", 0},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		if tt.header != "" {
+			resp.Header.Set("Retry-After", tt.header)
+		}
+		assert.Equal(t, tt.want, retryAfter(resp, now), "status %d, Retry-After %q", tt.status, tt.header)
+	}
+}
+
+func Test_RetryAfter_ShouldFindWrappedResponseErrors(t *testing.T) {
+	err := fmt.Errorf("error taking action, response was: %w", ResponseError{StatusCode: 503, RetryAfter: time.Second})
+
+	d, ok := RetryAfter(err)
+
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, d)
+}
+
+func Test_RetryAfter_ShouldReturnFalseForOtherErrors(t *testing.T) {
+	_, ok := RetryAfter(ResponseError{StatusCode: 500})
+	assert.False(t, ok)
+
+	_, ok = RetryAfter(fmt.Errorf("connection refused"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,167 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSRVRefreshInterval is how long the targets of DNS SRV records are used before they are looked up again, by
+// default, see WithSRV.
+const DefaultSRVRefreshInterval = 30 * time.Second
+
+// SRVConfig configures the discovery of flyte api hosts from DNS SRV records, see WithSRV.
+type SRVConfig struct {
+	// the service name, e.g. "flyte-api" for the records of _flyte-api._tcp.<Name>
+	Service string
+	// the protocol, defaults to "tcp"
+	Proto string
+	// the domain name, defaults to the host name of the flyte api url
+	Name string
+	// how long the targets are used before they are looked up again, defaults to DefaultSRVRefreshInterval
+	RefreshInterval time.Duration
+}
+
+// WithSRV connects to the flyte api at the hosts and ports of its DNS SRV records, such as those Consul and Kubernetes
+// headless services publish, rather than at the host of the flyte api url. The records are looked up again every
+// refresh interval. Connections are spread across the targets with the most preferred (lowest) priority, moving on to
+// the other targets, in the order of their priority and weight, when none of those can be connected to. The flyte api url is still used for the Host header and TLS
+// server name. If the records cannot be looked up the last targets found are used, or else the flyte api url host.
+func WithSRV(cfg SRVConfig) Option {
+	return func(c *client) {
+		c.srv = &cfg
+	}
+}
+
+// applySRV has the client connect to the targets of the SRV records when it connects to the flyte api host
+func (c *client) applySRV() {
+	if c.srv == nil {
+		return
+	}
+	if c.unixSocket != "" {
+		log.Warn().Msg("the client connects over a Unix domain socket, DNS SRV records are not used")
+		return
+	}
+	t := c.transport()
+	if t == nil {
+		log.Warn().Msg("the client transport is not an *http.Transport, DNS SRV records are not used")
+		return
+	}
+	host := c.baseURL.Hostname()
+	r := newSRVResolver(*c.srv, host)
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	t.DialContext = r.dialContext(host, dial)
+}
+
+var lookupSRV = net.DefaultResolver.LookupSRV
+
+type srvResolver struct {
+	cfg     SRVConfig
+	lookup  func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	now     func() time.Time
+	mu      sync.Mutex
+	targets []string
+	// the number of targets, at the start of targets, with the most preferred priority
+	tier    int
+	expires time.Time
+	next    uint32
+}
+
+func newSRVResolver(cfg SRVConfig, host string) *srvResolver {
+	if cfg.Proto == "" {
+		cfg.Proto = "tcp"
+	}
+	if cfg.Name == "" {
+		cfg.Name = host
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = DefaultSRVRefreshInterval
+	}
+	return &srvResolver{cfg: cfg, lookup: lookupSRV, now: time.Now}
+}
+
+// resolve returns the targets, host:port, of the SRV records, in the order they were looked up in, and how many of
+// them have the most preferred priority, looking them up again once the refresh interval has passed
+func (r *srvResolver) resolve(ctx context.Context) ([]string, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.targets != nil && r.now().Before(r.expires) {
+		return r.targets, r.tier, nil
+	}
+
+	_, records, err := r.lookup(ctx, r.cfg.Service, r.cfg.Proto, r.cfg.Name)
+	if err == nil && len(records) == 0 {
+		err = fmt.Errorf("no SRV records found for _%s._%s.%s", r.cfg.Service, r.cfg.Proto, r.cfg.Name)
+	}
+	if err != nil {
+		if r.targets != nil {
+			log.Warn().Err(err).Msg("cannot look up flyte api SRV records, using the targets last found")
+			return r.targets, r.tier, nil
+		}
+		return nil, 0, err
+	}
+	// LookupSRV sorts the records by priority, and randomises them by weight within each priority
+	targets := make([]string, len(records))
+	tier := 0
+	for i, srv := range records {
+		targets[i] = net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port)))
+		if srv.Priority == records[0].Priority {
+			tier++
+		}
+	}
+	r.targets, r.tier, r.expires = targets, tier, r.now().Add(r.cfg.RefreshInterval)
+	return targets, tier, nil
+}
+
+// dialContext returns a dial function that connects to the targets of the SRV records in place of the host. Targets
+// with the most preferred priority are tried first, starting from the next of them in turn so connections are spread
+// across them, then the rest in the order they were looked up in
+func (r *srvResolver) dialContext(host string, dial func(ctx context.Context, network, address string) (net.Conn, error)) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if h, _, err := net.SplitHostPort(address); err != nil || h != host {
+			return dial(ctx, network, address)
+		}
+		targets, tier, err := r.resolve(ctx)
+		if err != nil {
+			log.Warn().Err(err).Msgf("cannot look up flyte api SRV records, connecting to %s", address)
+			return dial(ctx, network, address)
+		}
+		start := int(atomic.AddUint32(&r.next, 1) - 1)
+		for i := range targets {
+			target := targets[i]
+			if i < tier {
+				target = targets[(start+i)%tier]
+			}
+			var conn net.Conn
+			if conn, err = dial(ctx, network, target); err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+}
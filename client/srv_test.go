@@ -0,0 +1,141 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_WithSRV_ShouldConnectToTheTargetsOfTheSRVRecords(t *testing.T) {
+	// given a flyte api that is only found through its SRV records
+	var hosts []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.Host)
+		w.Write([]byte(`{"links": []}`))
+	}))
+	defer ts.Close()
+	host, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	var looked []string
+	defer func(orig func(context.Context, string, string, string) (string, []*net.SRV, error)) { lookupSRV = orig }(lookupSRV)
+	lookupSRV = func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		looked = append(looked, "_"+service+"._"+proto+"."+name)
+		return "", []*net.SRV{{Target: host + ".", Port: uint16(p)}}, nil
+	}
+	rootURL, _ := url.Parse("http://flyte-api.service.consul")
+
+	// when
+	c := NewClient(rootURL, time.Second, WithSRV(SRVConfig{Service: "flyte-api"}))
+
+	// then
+	assert.Equal(t, []string{"_flyte-api._tcp.flyte-api.service.consul"}, looked)
+	assert.Equal(t, []string{"flyte-api.service.consul"}, hosts)
+	assert.Equal(t, uint64(0), c.(StatsReporter).Stats().RequestErrors[OpGetApiLinks])
+}
+
+func Test_srvResolver_ShouldLookUpTheRecordsAgainOnceTheRefreshIntervalHasPassed(t *testing.T) {
+	now := time.Now()
+	lookups := 0
+	r := newSRVResolver(SRVConfig{Service: "flyte-api", RefreshInterval: time.Minute}, "flyte")
+	r.now = func() time.Time { return now }
+	r.lookup = func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		lookups++
+		if lookups > 2 {
+			return "", nil, errors.New("dns is down")
+		}
+		return "", []*net.SRV{{Target: "flyte-" + strconv.Itoa(lookups) + ".", Port: 8080}}, nil
+	}
+
+	targets, _, err := r.resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"flyte-1:8080"}, targets)
+	targets, _, _ = r.resolve(context.Background())
+	assert.Equal(t, []string{"flyte-1:8080"}, targets)
+
+	now = now.Add(time.Minute)
+	targets, _, _ = r.resolve(context.Background())
+	assert.Equal(t, []string{"flyte-2:8080"}, targets)
+
+	// the targets last found are used when the records cannot be looked up
+	now = now.Add(time.Minute)
+	targets, _, err = r.resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"flyte-2:8080"}, targets)
+	assert.Equal(t, 3, lookups)
+}
+
+func Test_srvResolver_ShouldRotateAcrossTargetsAndSkipThoseThatCannotBeConnectedTo(t *testing.T) {
+	r := newSRVResolver(SRVConfig{Service: "flyte-api"}, "flyte")
+	r.lookup = func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		return "", []*net.SRV{{Target: "a.", Port: 1}, {Target: "b.", Port: 2}, {Target: "down.", Port: 3}}, nil
+	}
+	var dialled []string
+	dial := r.dialContext("flyte", func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialled = append(dialled, address)
+		if address == "down:3" {
+			return nil, errors.New("connection refused")
+		}
+		return nil, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		_, err := dial(context.Background(), "tcp", "flyte:80")
+		require.NoError(t, err)
+	}
+	_, err := dial(context.Background(), "tcp", "elsewhere:80")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a:1", "b:2", "down:3", "a:1", "elsewhere:80"}, dialled)
+}
+
+func Test_srvResolver_ShouldOnlyRotateAcrossTargetsWithTheMostPreferredPriority(t *testing.T) {
+	r := newSRVResolver(SRVConfig{Service: "flyte-api"}, "flyte")
+	r.lookup = func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		return "", []*net.SRV{
+			{Target: "a.", Port: 1, Priority: 10},
+			{Target: "b.", Port: 2, Priority: 10},
+			{Target: "backup-1.", Port: 3, Priority: 20},
+			{Target: "backup-2.", Port: 4, Priority: 20},
+		}, nil
+	}
+	var dialled []string
+	down := map[string]bool{"a:1": true, "b:2": true}
+	dial := r.dialContext("flyte", func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialled = append(dialled, address)
+		if down[address] {
+			return nil, errors.New("connection refused")
+		}
+		return nil, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := dial(context.Background(), "tcp", "flyte:80")
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"a:1", "b:2", "backup-1:3", "b:2", "a:1", "backup-1:3"}, dialled)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/url"
+)
+
+// StaticLinks are flyte api urls configured up front rather than discovered from the flyte api, see WithStaticLinks.
+type StaticLinks struct {
+	TakeAction *url.URL // where the next action is taken from
+	Events     *url.URL // where events are posted to
+	Health     *url.URL // the flyte api healthcheck, optional
+	Packs      *url.URL // where the pack is registered, optional
+}
+
+// WithStaticLinks uses the urls passed in rather than getting the api links from the flyte api, for environments,
+// such as air-gapped or strictly firewalled ones, that only expose specific flyte api paths. The client does not
+// register the pack unless the packs url is set, in which case the takeAction and events urls, if set, replace the
+// links of the registered pack. Without a packs url, the takeAction and events urls are required.
+func WithStaticLinks(links StaticLinks) Option {
+	return func(c *client) {
+		c.staticLinks = &links
+	}
+}
+
+// useStaticLinks sets the api links from the static links, in place of getting them from the flyte api
+func (c *client) useStaticLinks() {
+	var links []Link
+	if c.staticLinks.Health != nil {
+		links = append(links, Link{Href: c.staticLinks.Health, Rel: string(RelHealth)})
+	}
+	if c.staticLinks.Packs != nil {
+		links = append(links, Link{Href: c.staticLinks.Packs, Rel: string(RelListPacks)})
+	}
+	c.apiLinks = map[string][]Link{"links": links}
+}
+
+// registers reports whether the client registers packs with the flyte api, which it does unless it has static
+// links without a packs url
+func (c *client) registers() bool {
+	return c.staticLinks == nil || c.staticLinks.Packs != nil
+}
+
+// packLink returns the static url for the pack link rel if there is one, or else the url of the pack link
+func (c *client) packLink(pack Pack, rel Rel) (*url.URL, error) {
+	if c.staticLinks != nil {
+		if rel == RelTakeAction && c.staticLinks.TakeAction != nil {
+			return c.staticLinks.TakeAction, nil
+		}
+		if rel == RelEvent && c.staticLinks.Events != nil {
+			return c.staticLinks.Events, nil
+		}
+	}
+	return c.linkURL(pack.Links, rel)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_WithStaticLinks_ShouldSkipLinkDiscoveryAndRegistration(t *testing.T) {
+	// given a flyte api that only exposes the action and event paths
+	var mu sync.Mutex
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/flyte/packs/Slack/actions/take":
+			w.Write([]byte(`{"command": "SendMessage"}`))
+		case "/flyte/packs/Slack/events":
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+	rootURL, _ := url.Parse(ts.URL)
+	takeActionURL, _ := url.Parse(ts.URL + "/flyte/packs/Slack/actions/take")
+	eventsURL, _ := url.Parse(ts.URL + "/flyte/packs/Slack/events")
+	healthURL, _ := url.Parse(ts.URL + "/flyte/health")
+
+	// when
+	c := NewClient(rootURL, time.Second, WithStaticLinks(StaticLinks{TakeAction: takeActionURL, Events: eventsURL, Health: healthURL}))
+	require.NoError(t, c.CreatePack(Pack{Name: "Slack"}))
+	a, err := c.TakeAction()
+	require.NoError(t, err)
+	require.NoError(t, c.PostEvent(Event{Name: "MessageSent"}))
+
+	// then
+	assert.Equal(t, "SendMessage", a.CommandName)
+	assert.Equal(t, []string{"POST /flyte/packs/Slack/actions/take", "POST /flyte/packs/Slack/events"}, paths)
+	u, err := c.GetFlyteHealthCheckURL()
+	require.NoError(t, err)
+	assert.Equal(t, healthURL, u)
+}
+
+func Test_WithStaticLinks_ShouldRegisterThePackWithThePacksURL(t *testing.T) {
+	// given
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"name": "Slack", "links": [
+			{"href": "http://flyte/packs/Slack/actions/take", "rel": "takeAction"},
+			{"href": "http://flyte/packs/Slack/events", "rel": "event"}
+		]}`))
+	}))
+	defer ts.Close()
+	rootURL, _ := url.Parse(ts.URL)
+	packsURL, _ := url.Parse(ts.URL + "/flyte/packs")
+	takeActionURL, _ := url.Parse("http://proxy/packs/Slack/actions/take")
+
+	// when
+	c := NewClient(rootURL, time.Second, WithStaticLinks(StaticLinks{Packs: packsURL, TakeAction: takeActionURL})).(*client)
+	err := c.CreatePack(Pack{Name: "Slack"})
+
+	// then the static takeAction url replaces the pack link
+	require.NoError(t, err)
+	assert.Equal(t, takeActionURL, c.packLinks.takeActionURL)
+	assert.Equal(t, "http://flyte/packs/Slack/events", c.packLinks.eventsURL.String())
+}
+
+func Test_WithStaticLinks_ShouldNeedTakeActionAndEventsURLsWithoutAPacksURL(t *testing.T) {
+	rootURL, _ := url.Parse("http://flyte")
+	eventsURL, _ := url.Parse("http://flyte/packs/Slack/events")
+
+	c := NewClient(rootURL, time.Second, WithStaticLinks(StaticLinks{Events: eventsURL}))
+
+	assert.Error(t, c.CreatePack(Pack{Name: "Slack"}))
+}
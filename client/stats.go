@@ -0,0 +1,240 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// StatsReporter is implemented by clients that keep stats of the requests they send. The client returned by NewClient
+// implements it.
+type StatsReporter interface {
+	// Stats returns a snapshot of the client state, for diagnostics.
+	Stats() Stats
+}
+
+// Operation identifies a flyte api operation performed by the client.
+type Operation string
+
+// The flyte api operations performed by the client.
+const (
+	OpGetApiLinks         Operation = "getApiLinks"
+	OpRegisterPack        Operation = "registerPack"
+	OpListPacks           Operation = "listPacks"
+	OpGetPack             Operation = "getPack"
+	OpReplacePack         Operation = "replacePack"
+	OpDeletePack          Operation = "deletePack"
+	OpUpdatePackStatus    Operation = "updatePackStatus"
+	OpPostEvent           Operation = "postEvent"
+	OpTakeAction          Operation = "takeAction"
+	OpStreamActions       Operation = "streamActions"
+	OpCompleteAction      Operation = "completeAction"
+	OpGetDatastoreItem    Operation = "getDatastoreItem"
+	OpPutDatastoreItem    Operation = "putDatastoreItem"
+	OpDeleteDatastoreItem Operation = "deleteDatastoreItem"
+	OpListFlows           Operation = "listFlows"
+	OpGetFlow             Operation = "getFlow"
+	OpCreateFlow          Operation = "createFlow"
+	OpReplaceFlow         Operation = "replaceFlow"
+	OpDeleteFlow          Operation = "deleteFlow"
+	OpFindActions         Operation = "findActions"
+	OpFindEvents          Operation = "findEvents"
+	OpHeartbeatAction     Operation = "heartbeatAction"
+)
+
+// Stats is a snapshot of the client state, for diagnostics.
+type Stats struct {
+	Requests         map[Operation]uint64 `json:"requests"`         // requests sent, by operation
+	RequestErrors    map[Operation]uint64 `json:"requestErrors"`    // requests that could not be sent or got no response, by operation
+	TransientErrors  map[Operation]uint64 `json:"transientErrors"`  // request errors that were transient, such as connection resets, by operation
+	Retries          uint64               `json:"retries"`          // requests retried after failing
+	LastLinksRefresh time.Time            `json:"lastLinksRefresh"` // when the api links were last retrieved
+	LastEventPosted  time.Time            `json:"lastEventPosted"`  // when an event was last accepted by the flyte api
+	Backoff          BackoffState         `json:"backoff"`          // the current backoff state
+	// the protocol of the last response from the flyte api, e.g. "HTTP/2.0"
+	Protocol string `json:"protocol,omitempty"`
+	// requests sent on a new connection, and on a connection reused from an earlier request
+	ConnectionsOpened uint64 `json:"connectionsOpened"`
+	ConnectionsReused uint64 `json:"connectionsReused"`
+	// the flyte api endpoint in use, and how many times the client has switched endpoint, see WithEndpoints
+	Endpoint  string `json:"endpoint,omitempty"`
+	Failovers uint64 `json:"failovers,omitempty"`
+}
+
+// BackoffState describes whether the client is backing off from the flyte api after failures.
+type BackoffState struct {
+	ConsecutiveFailures int       `json:"consecutiveFailures"` // zero when the client is not backing off
+	NextRetry           time.Time `json:"nextRetry,omitempty"` // when the client will next retry
+}
+
+// clientStats records the client state. It is shared by all copies of a client.
+type clientStats struct {
+	mu               sync.Mutex
+	requests         map[Operation]uint64
+	requestErrors    map[Operation]uint64
+	transientErrors  map[Operation]uint64
+	retries          uint64
+	lastLinksRefresh time.Time
+	lastEventPosted  time.Time
+	backoff          BackoffState
+	protocol         string
+	connsOpened      uint64
+	connsReused      uint64
+}
+
+func newClientStats() *clientStats {
+	return &clientStats{
+		requests:        map[Operation]uint64{},
+		requestErrors:   map[Operation]uint64{},
+		transientErrors: map[Operation]uint64{},
+	}
+}
+
+// WithExpvar publishes the client stats as an expvar variable with the name passed in, so they are served at
+// /debug/vars alongside the other expvar variables. If a variable with the name is already published it is left as is.
+func WithExpvar(name string) Option {
+	return func(c *client) {
+		if expvar.Get(name) != nil {
+			return
+		}
+		expvar.Publish(name, expvar.Func(func() interface{} {
+			return c.Stats()
+		}))
+	}
+}
+
+// Stats returns a snapshot of the client state.
+func (c *client) Stats() Stats {
+	s := c.stats
+	if s == nil {
+		return Stats{Requests: map[Operation]uint64{}, RequestErrors: map[Operation]uint64{}, TransientErrors: map[Operation]uint64{}}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := Stats{
+		Requests:         make(map[Operation]uint64, len(s.requests)),
+		RequestErrors:    make(map[Operation]uint64, len(s.requestErrors)),
+		TransientErrors:  make(map[Operation]uint64, len(s.transientErrors)),
+		Retries:          s.retries,
+		LastLinksRefresh: s.lastLinksRefresh,
+		LastEventPosted:  s.lastEventPosted,
+		Backoff:          s.backoff,
+	}
+	stats.Protocol, stats.ConnectionsOpened, stats.ConnectionsReused = s.protocol, s.connsOpened, s.connsReused
+	if c.endpoints != nil {
+		c.endpoints.mu.Lock()
+		stats.Endpoint, stats.Failovers = c.endpoints.bases[c.endpoints.active].String(), c.endpoints.failovers
+		c.endpoints.mu.Unlock()
+	}
+	for op, n := range s.requests {
+		stats.Requests[op] = n
+	}
+	for op, n := range s.requestErrors {
+		stats.RequestErrors[op] = n
+	}
+	for op, n := range s.transientErrors {
+		stats.TransientErrors[op] = n
+	}
+	return stats
+}
+
+// request records a request sent for the operation
+func (s *clientStats) request(op Operation, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[op]++
+	if err != nil {
+		s.requestErrors[op]++
+	}
+	if IsTransient(err) {
+		s.transientErrors[op]++
+	}
+}
+
+// retrying records that a failed request will be retried after the wait
+func (s *clientStats) retrying(wait time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retries++
+	s.backoff.ConsecutiveFailures++
+	s.backoff.NextRetry = time.Now().Add(wait)
+}
+
+// transientRetry records that a request that failed with a transient transport error will be retried
+func (s *clientStats) transientRetry() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retries++
+}
+
+// linksRefreshed records that the api links have been retrieved, ending any backoff
+func (s *clientStats) linksRefreshed() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastLinksRefresh = time.Now()
+	s.backoff = BackoffState{}
+}
+
+// gotConn records whether a request was sent on a new or a reused connection
+func (s *clientStats) gotConn(reused bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reused {
+		s.connsReused++
+	} else {
+		s.connsOpened++
+	}
+}
+
+// protocolUsed records the protocol of a response, returning true if it differs from that of the last response
+func (s *clientStats) protocolUsed(proto string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := s.protocol != proto
+	s.protocol = proto
+	return changed
+}
+
+// eventPosted records that an event has been accepted by the flyte api
+func (s *clientStats) eventPosted() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastEventPosted = time.Now()
+}
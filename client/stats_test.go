@@ -0,0 +1,89 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"expvar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_Stats_ShouldReportRequestsRetriesAndLinksRefresh(t *testing.T) {
+	// given a flyte-api that fails to return the api links once, then accepts events
+	prevFlyteApiRetryWait := flyteApiRetryWait
+	defer func() { flyteApiRetryWait = prevFlyteApiRetryWait }()
+	flyteApiRetryWait = 0
+	linksFailures := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/events" {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if linksFailures > 0 {
+			linksFailures--
+			w.Write([]byte(flyteApiErrorResponse))
+			return
+		}
+		w.Write([]byte(flyteApiLinksResponse))
+	}))
+	defer server.Close()
+	baseURL, _ := url.Parse(server.URL)
+
+	// when
+	start := time.Now()
+	c := NewClient(baseURL, 10*time.Second).(*client)
+	c.packLinks.eventsURL, _ = url.Parse(server.URL + "/events")
+	require.NoError(t, c.PostEvent(Event{Name: "MessageSent"}))
+
+	// then
+	stats := c.Stats()
+	assert.Equal(t, map[Operation]uint64{OpGetApiLinks: 2, OpPostEvent: 1}, stats.Requests)
+	assert.Empty(t, stats.RequestErrors)
+	assert.Equal(t, uint64(1), stats.Retries)
+	assert.True(t, !stats.LastLinksRefresh.Before(start))
+	assert.True(t, !stats.LastEventPosted.Before(stats.LastLinksRefresh))
+	assert.Equal(t, BackoffState{}, stats.Backoff, "the client should no longer be backing off")
+}
+
+func Test_Stats_ShouldCountRequestErrors(t *testing.T) {
+	c := newTestClient("http://localhost:1", t)
+	c.stats = newClientStats()
+	c.packLinks.takeActionURL, _ = url.Parse("http://localhost:1/take")
+
+	_, err := c.TakeAction()
+
+	require.Error(t, err)
+	assert.Equal(t, map[Operation]uint64{OpTakeAction: 1}, c.Stats().RequestErrors)
+}
+
+func Test_WithExpvar_ShouldPublishStats(t *testing.T) {
+	c := newTestClient("http://localhost:1", t)
+	c.stats = newClientStats()
+	c.stats.request(OpTakeAction, nil)
+
+	WithExpvar("flyte_client_test")(c)
+
+	var published Stats
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("flyte_client_test").String()), &published))
+	assert.Equal(t, map[Operation]uint64{OpTakeAction: 1}, published.Requests)
+}
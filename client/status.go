@@ -0,0 +1,64 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrPackStatusNotSupported is returned by UpdatePackStatus when the flyte server does not support pack status updates.
+var ErrPackStatusNotSupported = errors.New("flyte server does not support pack status updates")
+
+// PackStatusUpdater is implemented by clients that can report how loaded the pack is to the flyte server. The client
+// returned by NewClient implements it.
+type PackStatusUpdater interface {
+	// UpdatePackStatus reports how loaded the pack is to the flyte server, if the server supports it.
+	UpdatePackStatus(PackStatus) error
+}
+
+// PackStatus describes how loaded a pack replica is, so the flyte server can prefer less loaded replicas.
+type PackStatus struct {
+	InFlight   int `json:"inFlight"`   // actions currently being handled
+	QueueDepth int `json:"queueDepth"` // actions taken but waiting to be handled
+}
+
+// UpdatePackStatus reports the pack status to the flyte server. Servers that support status updates advertise a
+// "status" link when the pack is registered. If the server does not, or responds that the link does not exist,
+// ErrPackStatusNotSupported is returned.
+func (c *client) UpdatePackStatus(status PackStatus) error {
+	statusURL := c.packLinks.status()
+	if statusURL == nil {
+		return ErrPackStatusNotSupported
+	}
+
+	resp, err := c.put(OpUpdatePackStatus, statusURL, status)
+	if err != nil {
+		return fmt.Errorf("error putting pack status %+v to %s: %w", status, statusURL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case c.accepts(OpUpdatePackStatus, resp.StatusCode):
+		return nil
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusMethodNotAllowed, resp.StatusCode == http.StatusNotImplemented:
+		return ErrPackStatusNotSupported
+	default:
+		return fmt.Errorf("pack status %+v not accepted, response was: %w", status, newResponseError(resp))
+	}
+}
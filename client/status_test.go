@@ -0,0 +1,106 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func Test_CreatePack_ShouldSetStatusURLWhenServerSupportsPackStatusUpdates(t *testing.T) {
+	ts := mockServer(http.StatusCreated, slackPackResponseWithStatusLink)
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+
+	require.NoError(t, c.CreatePack(Pack{Name: "Slack"}))
+
+	require.NotNil(t, c.packLinks.statusURL)
+	assert.Equal(t, "http://example.com/v1/packs/Slack/status", c.packLinks.statusURL.String())
+}
+
+func Test_UpdatePackStatus_ShouldPutStatusToStatusURL(t *testing.T) {
+	ts, rec := mockServerWithRecorder(http.StatusNoContent, "")
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	c.packLinks.statusURL = c.apiLinks["links"][0].Href
+
+	err := c.UpdatePackStatus(PackStatus{InFlight: 3, QueueDepth: 7})
+
+	require.NoError(t, err)
+	require.Len(t, rec.reqs, 1)
+	assert.Equal(t, http.MethodPut, rec.reqs[0].Method)
+	assert.JSONEq(t, `{"inFlight": 3, "queueDepth": 7}`, string(rec.body[0]))
+}
+
+func Test_UpdatePackStatus_ShouldReturnNotSupportedErrorWhenServerHasNoStatusLink(t *testing.T) {
+	ts := mockServer(http.StatusCreated, slackPackResponse)
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	require.NoError(t, c.CreatePack(Pack{Name: "Slack"}))
+
+	err := c.UpdatePackStatus(PackStatus{})
+
+	assert.Equal(t, ErrPackStatusNotSupported, err)
+}
+
+func Test_UpdatePackStatus_ShouldReturnNotSupportedErrorWhenServerRejectsStatusUpdates(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented} {
+		ts := mockServer(status, "")
+		c := newTestClient(ts.URL, t)
+		c.packLinks.statusURL = c.apiLinks["links"][0].Href
+
+		err := c.UpdatePackStatus(PackStatus{})
+
+		assert.Equal(t, ErrPackStatusNotSupported, err, "status %d", status)
+		ts.Close()
+	}
+}
+
+func Test_UpdatePackStatus_ShouldReturnErrorOnUnexpectedResponse(t *testing.T) {
+	ts := mockServer(http.StatusInternalServerError, "")
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	c.packLinks.statusURL = c.apiLinks["links"][0].Href
+
+	err := c.UpdatePackStatus(PackStatus{})
+
+	require.Error(t, err)
+	assert.NotEqual(t, ErrPackStatusNotSupported, err)
+}
+
+var slackPackResponseWithStatusLink = `
+{
+    "id": "Slack",
+    "name": "Slack",
+    "links": [
+        {
+            "href": "http://example.com/v1/packs/Slack/actions/take",
+            "rel": "http://example.com/swagger#!/action/takeAction"
+        },
+        {
+            "href": "http://example.com/v1/packs/Slack/events",
+            "rel": "http://example.com/swagger#/event"
+        },
+        {
+            "href": "http://example.com/v1/packs/Slack/status",
+            "rel": "http://example.com/swagger#!/pack/status"
+        }
+    ]
+}
+`
@@ -0,0 +1,192 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultStreamIdleTimeout is how long an action stream may go without an action or a keepalive ping before the
+// client drops it, see WithStreamIdleTimeout.
+const DefaultStreamIdleTimeout = time.Minute
+
+// ErrActionStreamNotSupported is returned by StreamActions when the flyte server does not publish an action stream
+// for the pack, in which case actions have to be polled for with TakeAction.
+var ErrActionStreamNotSupported = errors.New("the flyte server does not support action streams")
+
+// ActionStreamer is implemented by clients that can have actions pushed to them by the flyte server, as an
+// alternative to polling with TakeAction. The client returned by NewClient implements it.
+type ActionStreamer interface {
+	// StreamActions subscribes to the server-sent events stream of actions for the pack, calling handle with each
+	// action received until done is closed, when it returns nil, or the stream fails. Streams are negotiated
+	// through the "actionStream" pack link: if the pack has no such link, ErrActionStreamNotSupported is returned.
+	StreamActions(done <-chan struct{}, handle func(*Action)) error
+}
+
+// WithStreamIdleTimeout sets how long an action stream may go without an action or a keepalive ping from the flyte
+// server before the client drops it, so a dead connection is noticed. Defaults to DefaultStreamIdleTimeout.
+func WithStreamIdleTimeout(timeout time.Duration) Option {
+	return func(c *client) {
+		c.streamIdleTimeout = timeout
+	}
+}
+
+// StreamActions receives the actions pushed by the flyte server until done is closed or the stream fails.
+// Actions are "action" events with the action JSON as their data; anything else, such as "ping" events and comments,
+// just keeps the stream alive.
+func (c client) StreamActions(done <-chan struct{}, handle func(*Action)) error {
+	streamURL := c.packLinks.stream()
+	if streamURL == nil {
+		return ErrActionStreamNotSupported
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var lastRead int64
+	go c.watchStream(ctx, cancel, done, &lastRead)
+
+	req, err := http.NewRequest(http.MethodGet, streamURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("cannot create request: %v", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	req.Header.Set("User-Agent", c.getUserAgent())
+
+	// the stream is long lived, so it cannot be subject to the client request timeout
+	streamClient := &http.Client{Transport: c.httpClient.Transport}
+	c.rateLimiter.wait(OpStreamActions)
+	resp, err := streamClient.Do(req)
+	c.stats.request(OpStreamActions, err)
+	if err != nil {
+		if isDone(done) {
+			return nil
+		}
+		return fmt.Errorf("error opening action stream at %s: %w", streamURL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNotImplemented:
+		return ErrActionStreamNotSupported
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("error opening action stream at %s, response was: %w", streamURL.String(), newResponseError(resp))
+	}
+
+	err = readEvents(resp, &lastRead, func(event, data string) error {
+		if event != "action" {
+			return nil
+		}
+		a := &Action{}
+		if err := json.Unmarshal([]byte(data), a); err != nil {
+			return fmt.Errorf("could not deserialise streamed action %q: %v", data, err)
+		}
+		handle(a)
+		return nil
+	})
+	if isDone(done) {
+		return nil
+	}
+	if err == nil {
+		err = errors.New("the flyte server closed the stream")
+	}
+	return fmt.Errorf("action stream at %s failed: %v", streamURL.String(), err)
+}
+
+// watchStream cancels the stream when done is closed, or when nothing has been read from it for the idle timeout
+func (c client) watchStream(ctx context.Context, cancel func(), done <-chan struct{}, lastRead *int64) {
+	idleTimeout := c.streamIdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultStreamIdleTimeout
+	}
+	atomic.StoreInt64(lastRead, time.Now().UnixNano())
+	ticker := time.NewTicker(idleTimeout / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			cancel()
+			return
+		case now := <-ticker.C:
+			if now.Sub(time.Unix(0, atomic.LoadInt64(lastRead))) > idleTimeout {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// readEvents reads server-sent events from the response, calling dispatch with the type and data of each one.
+// lastRead is updated with the time each line is read.
+func readEvents(resp *http.Response, lastRead *int64, dispatch func(event, data string) error) error {
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	event, data := "", []string(nil)
+	for scanner.Scan() {
+		atomic.StoreInt64(lastRead, time.Now().UnixNano())
+		line := scanner.Text()
+		if line == "" {
+			if data != nil {
+				if event == "" {
+					event = "message"
+				}
+				if err := dispatch(event, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+			event, data = "", nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			// a comment, which servers send as keepalive pings
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	return scanner.Err()
+}
+
+func isDone(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
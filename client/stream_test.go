@@ -0,0 +1,128 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newTestStreamClient(t *testing.T, handler http.HandlerFunc) (*client, func()) {
+	ts := httptest.NewServer(handler)
+	c := newTestClient(ts.URL, t)
+	u, err := url.Parse(ts.URL + "/v1/packs/Slack/actions/stream")
+	require.NoError(t, err)
+	c.packLinks.streamURL = u
+	c.stats = newClientStats()
+	return c, ts.Close
+}
+
+func Test_StreamActions_ShouldReturnNotSupportedWhenThePackHasNoActionStreamLink(t *testing.T) {
+	c := newTestClient("http://example.com", t)
+
+	err := c.StreamActions(make(chan struct{}), func(*Action) {})
+
+	assert.Equal(t, ErrActionStreamNotSupported, err)
+}
+
+func Test_StreamActions_ShouldReturnNotSupportedWhenTheServerHasNoActionStream(t *testing.T) {
+	c, closeServer := newTestStreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	defer closeServer()
+
+	err := c.StreamActions(make(chan struct{}), func(*Action) {})
+
+	assert.Equal(t, ErrActionStreamNotSupported, err)
+}
+
+func Test_StreamActions_ShouldHandleEachActionInTheStream(t *testing.T) {
+	// given a stream with two actions and a keepalive ping, which the server then closes
+	c, closeServer := newTestStreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: action\ndata: {\"id\":\"1\",\"command\":\"SendMessage\",\n")
+		fmt.Fprint(w, "data: \"input\":{\"text\":\"hi\"}}\n\n")
+		fmt.Fprint(w, ": ping\n\n")
+		fmt.Fprint(w, "event: ping\ndata: {}\n\n")
+		fmt.Fprint(w, "event: action\ndata: {\"id\":\"2\",\"command\":\"SendMessage\"}\n\n")
+	})
+	defer closeServer()
+
+	// when
+	var actions []*Action
+	err := c.StreamActions(make(chan struct{}), func(a *Action) { actions = append(actions, a) })
+
+	// then
+	assert.Error(t, err)
+	require.Len(t, actions, 2)
+	assert.Equal(t, "1", actions[0].ID)
+	assert.JSONEq(t, `{"text":"hi"}`, string(actions[0].Input))
+	assert.Equal(t, "2", actions[1].ID)
+	assert.Equal(t, uint64(1), c.Stats().Requests[OpStreamActions])
+}
+
+func Test_StreamActions_ShouldReturnNilWhenDone(t *testing.T) {
+	c, closeServer := newTestStreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	defer closeServer()
+	done := make(chan struct{})
+	time.AfterFunc(20*time.Millisecond, func() { close(done) })
+
+	err := c.StreamActions(done, func(*Action) {})
+
+	assert.NoError(t, err)
+}
+
+func Test_StreamActions_ShouldDropIdleStreams(t *testing.T) {
+	c, closeServer := newTestStreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	defer closeServer()
+	c.streamIdleTimeout = 40 * time.Millisecond
+
+	start := time.Now()
+	err := c.StreamActions(make(chan struct{}), func(*Action) {})
+
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func Test_CreatePack_ShouldSetTheOptionalActionStreamLink(t *testing.T) {
+	ts := mockServer(http.StatusCreated, `{"name":"Slack","links":[
+		{"href":"http://example.com/v1/packs/Slack/actions/take","rel":"http://example.com/swagger#!/action/takeAction"},
+		{"href":"http://example.com/v1/packs/Slack/events","rel":"http://example.com/swagger#/event"},
+		{"href":"http://example.com/v1/packs/Slack/actions/stream","rel":"http://example.com/swagger#!/action/actionStream"}]}`)
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+
+	require.NoError(t, c.CreatePack(Pack{Name: "Slack"}))
+
+	require.NotNil(t, c.packLinks.streamURL)
+	assert.Equal(t, "http://example.com/v1/packs/Slack/actions/stream", c.packLinks.streamURL.String())
+}
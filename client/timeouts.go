@@ -0,0 +1,77 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"github.com/rs/zerolog/log"
+	"net"
+	"time"
+)
+
+// Timeouts limits the stages of requests to the flyte api separately, see WithTimeouts. A zero timeout leaves the
+// stage unlimited, other than by the request timeout passed to NewClient.
+type Timeouts struct {
+	Dial           time.Duration // establishing the connection, including resolving the host name
+	TLSHandshake   time.Duration // the TLS handshake, for https connections
+	ResponseHeader time.Duration // waiting for the response headers once the request has been written
+	IdleConn       time.Duration // how long an idle keep-alive connection is kept open for reuse
+}
+
+// WithTimeouts limits the stages of a request rather than the request as a whole, so dead or slow connections are
+// noticed quickly without cutting short requests that take a while to send, such as large event posts. Pass a request
+// timeout of zero to NewClient to rely on these timeouts alone. They are applied to the client transport, including
+// one set with WithTransport, if it is an *http.Transport.
+func WithTimeouts(timeouts Timeouts) Option {
+	return func(c *client) {
+		c.timeouts = timeouts
+	}
+}
+
+// applyTimeouts sets the stage timeouts on the client transport. It is called once all the options have been applied,
+// so the dial timeout also limits a dialer set by another option, such as WithDNS
+func (c *client) applyTimeouts() {
+	if c.timeouts == (Timeouts{}) {
+		return
+	}
+	t := c.transport()
+	if t == nil {
+		log.Warn().Msg("the client transport is not an *http.Transport, request stage timeouts are not applied")
+		return
+	}
+	if c.timeouts.TLSHandshake > 0 {
+		t.TLSHandshakeTimeout = c.timeouts.TLSHandshake
+	}
+	if c.timeouts.ResponseHeader > 0 {
+		t.ResponseHeaderTimeout = c.timeouts.ResponseHeader
+	}
+	if c.timeouts.IdleConn > 0 {
+		t.IdleConnTimeout = c.timeouts.IdleConn
+	}
+	if c.timeouts.Dial > 0 {
+		dial := t.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{KeepAlive: 30 * time.Second}).DialContext
+		}
+		timeout := c.timeouts.Dial
+		t.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return dial(ctx, network, address)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_WithTimeouts_ShouldSetTheStageTimeoutsOfTheClientTransport(t *testing.T) {
+	c := newTestClient("http://example.com", t)
+
+	WithTimeouts(Timeouts{TLSHandshake: 2 * time.Second, ResponseHeader: 15 * time.Second, IdleConn: time.Minute})(c)
+	c.applyTimeouts()
+
+	tr := c.transport()
+	assert.Equal(t, 2*time.Second, tr.TLSHandshakeTimeout)
+	assert.Equal(t, 15*time.Second, tr.ResponseHeaderTimeout)
+	assert.Equal(t, time.Minute, tr.IdleConnTimeout)
+	assert.Nil(t, tr.DialContext, "the dialer should be left as it is without a dial timeout")
+}
+
+func Test_WithTimeouts_ShouldLimitTheDialerSetByOtherOptions(t *testing.T) {
+	c := newTestClient("http://example.com", t)
+	var deadline time.Time
+	c.transport().DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		deadline, _ = ctx.Deadline()
+		return nil, &net.OpError{Op: "dial"}
+	}
+
+	WithTimeouts(Timeouts{Dial: time.Second})(c)
+	c.applyTimeouts()
+	_, err := c.transport().DialContext(context.Background(), "tcp", "example.com:80")
+
+	require.Error(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+}
+
+func Test_WithTimeouts_ShouldFailRequestsWhoseResponseHeadersAreSlow(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	c.httpClient.Timeout = 0
+
+	WithTimeouts(Timeouts{ResponseHeader: 20 * time.Millisecond})(c)
+	c.applyTimeouts()
+	_, err := c.httpClient.Get(ts.URL)
+
+	assert.Error(t, err)
+}
+
+func Test_WithTimeouts_ShouldNotChangeTransportsThatAreNotHttpTransports(t *testing.T) {
+	c := newTestClient("http://example.com", t)
+	rt := roundTripperFunc(func(*http.Request) (*http.Response, error) { return nil, nil })
+	WithTransport(rt)(c)
+
+	WithTimeouts(Timeouts{Dial: time.Second})(c)
+	c.applyTimeouts()
+
+	assert.Nil(t, c.transport())
+}
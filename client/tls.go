@@ -0,0 +1,48 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/tls"
+	"github.com/rs/zerolog/log"
+)
+
+// WithTLSConfig connects to the flyte api with the TLS config passed in, e.g. to verify its certificate against a
+// private CA or to authenticate the client with a certificate. An insecure client still does not verify the flyte api
+// certificate.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *client) {
+		c.tlsConfig = cfg
+	}
+}
+
+// applyTLSConfig has the client transport use the TLS config of WithTLSConfig
+func (c *client) applyTLSConfig() {
+	if c.tlsConfig == nil {
+		return
+	}
+	t := c.transport()
+	if t == nil {
+		log.Warn().Msg("the client transport is not an *http.Transport, the TLS config is not applied")
+		return
+	}
+	cfg := c.tlsConfig.Clone()
+	if t.TLSClientConfig != nil && t.TLSClientConfig.InsecureSkipVerify {
+		cfg.InsecureSkipVerify = true
+	}
+	t.TLSClientConfig = cfg
+}
@@ -0,0 +1,54 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_WithTLSConfig_ShouldVerifyTheFlyteApiAgainstTheConfiguredCA(t *testing.T) {
+	// given a flyte api with a certificate from a private CA
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"links": []}`))
+	}))
+	defer ts.Close()
+	rootURL, _ := url.Parse(ts.URL)
+	cas := x509.NewCertPool()
+	cas.AddCert(ts.Certificate())
+
+	// when
+	c := NewClient(rootURL, time.Second, WithTLSConfig(&tls.Config{RootCAs: cas}))
+
+	// then
+	assert.Equal(t, uint64(0), c.(StatsReporter).Stats().RequestErrors[OpGetApiLinks])
+}
+
+func Test_WithTLSConfig_ShouldNotVerifyTheFlyteApiForAnInsecureClient(t *testing.T) {
+	c := &client{httpClient: newHttpClient(time.Second, true, ""), tlsConfig: &tls.Config{ServerName: "flyte"}}
+
+	c.applyTLSConfig()
+
+	assert.True(t, c.transport().TLSClientConfig.InsecureSkipVerify)
+	assert.Equal(t, "flyte", c.transport().TLSClientConfig.ServerName)
+}
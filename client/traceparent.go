@@ -0,0 +1,107 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TraceParentHeader is the W3C trace context header that events are posted with, when they have a trace parent.
+const TraceParentHeader = "traceparent"
+
+var (
+	zeroTraceID = strings.Repeat("0", 32)
+	zeroSpanID  = strings.Repeat("0", 16)
+)
+
+// TraceParent is a W3C trace context traceparent value (https://www.w3.org/TR/trace-context/#traceparent-header),
+// which identifies a trace and the span within it that is the parent of any work done on its behalf.
+type TraceParent struct {
+	TraceID string // the trace id, 32 lowercase hex characters
+	SpanID  string // the id of the parent span, 16 lowercase hex characters
+	Sampled bool   // whether the caller may have recorded the trace
+}
+
+// NewTraceParent returns a trace parent for a new, sampled trace with random trace and span ids.
+func NewTraceParent() TraceParent {
+	return TraceParent{TraceID: randomHex(16), SpanID: randomHex(8), Sampled: true}
+}
+
+// ParseTraceParent parses a traceparent value in the format "00-<trace id>-<span id>-<flags>". Values with a future
+// version are parsed as far as the version 00 fields, as the specification requires.
+func ParseTraceParent(s string) (TraceParent, error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || !isHex(parts[0]) || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return TraceParent{}, fmt.Errorf("invalid traceparent %q", s)
+	}
+	t := TraceParent{TraceID: parts[1], SpanID: parts[2]}
+	if len(t.TraceID) != 32 || !isHex(t.TraceID) || t.TraceID == zeroTraceID {
+		return TraceParent{}, fmt.Errorf("invalid trace id in traceparent %q", s)
+	}
+	if len(t.SpanID) != 16 || !isHex(t.SpanID) || t.SpanID == zeroSpanID {
+		return TraceParent{}, fmt.Errorf("invalid parent id in traceparent %q", s)
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 || !isHex(parts[3]) {
+		return TraceParent{}, fmt.Errorf("invalid flags in traceparent %q", s)
+	}
+	t.Sampled = flags[0]&1 == 1
+	return t, nil
+}
+
+// String returns the traceparent value, in the version 00 format.
+func (t TraceParent) String() string {
+	flags := "00"
+	if t.Sampled {
+		flags = "01"
+	}
+	return "00-" + t.TraceID + "-" + t.SpanID + "-" + flags
+}
+
+// Child returns a trace parent for a new span in the same trace, whose parent is this one.
+func (t TraceParent) Child() TraceParent {
+	return TraceParent{TraceID: t.TraceID, SpanID: randomHex(8), Sampled: t.Sampled}
+}
+
+// IsTraceID reports whether the id passed in is a valid W3C trace id, e.g. a correlation id generated as one.
+func IsTraceID(id string) bool {
+	return len(id) == 32 && isHex(id) && id != zeroTraceID
+}
+
+// isHex reports whether s only has lowercase hex characters, as trace context values must
+func isHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// randomHex returns n (at least 8) random bytes hex encoded, falling back to the current time if there is no randomness
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		binary.BigEndian.PutUint64(b[n-8:], uint64(time.Now().UnixNano()))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultTransientRetries is how many times TakeAction is retried after a transient transport error.
+	DefaultTransientRetries = 3
+	// DefaultTransientRetryBackoff is how long TakeAction waits before its first retry after a transient transport
+	// error. The wait doubles with each retry.
+	DefaultTransientRetryBackoff = 100 * time.Millisecond
+)
+
+// WithTransientRetries sets how many times TakeAction is retried after a transient transport error, such as a
+// connection reset by a load balancer while the flyte api is being deployed, and how long it waits before the first
+// retry. The wait doubles with each retry. Zero retries disables retrying. Defaults to DefaultTransientRetries and
+// DefaultTransientRetryBackoff.
+func WithTransientRetries(retries int, backoff time.Duration) Option {
+	return func(c *client) {
+		if retries < 0 {
+			retries = 0
+		}
+		c.transientRetries = retries
+		c.transientRetryBackoff = backoff
+	}
+}
+
+// IsTransient reports whether the error is a transient transport error, such as a connection reset or refused, or
+// the connection closing before a response was received, rather than an error from the flyte api itself.
+func IsTransient(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retryTransient sends the request, sending it again with backoff when it fails with a transient transport error
+func (c client) retryTransient(op Operation, u *url.URL, send func(attempt int) (*http.Response, error)) (*http.Response, error) {
+	wait := c.transientRetryBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err := send(attempt)
+		if err == nil || !IsTransient(err) || attempt > c.transientRetries {
+			return resp, err
+		}
+		c.stats.transientRetry()
+		c.hooks.retry(retryInfo(op, http.MethodPost, u, attempt, err, wait))
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// resetConnections returns a handler that resets the connection of the first n requests, as a load balancer does
+// while the flyte api is being deployed, and otherwise returns an action
+func resetConnections(t *testing.T, n int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if n > 0 {
+			n--
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			conn.(*net.TCPConn).SetLinger(0)
+			conn.Close()
+			return
+		}
+		w.Write([]byte(`{"command": "SendMessage", "input": {}}`))
+	}
+}
+
+func Test_TakeAction_ShouldRetryConnectionResets(t *testing.T) {
+	// given a flyte api whose connections are reset twice
+	server := httptest.NewServer(resetConnections(t, 2))
+	defer server.Close()
+	c := newTestClient(server.URL, t)
+	c.stats = newClientStats()
+	WithTransientRetries(3, time.Millisecond)(c)
+	c.packLinks.takeActionURL, _ = url.Parse(server.URL + "/take")
+
+	// when
+	a, err := c.TakeAction()
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, "SendMessage", a.CommandName)
+	stats := c.Stats()
+	assert.Equal(t, uint64(3), stats.Requests[OpTakeAction])
+	assert.Equal(t, uint64(2), stats.TransientErrors[OpTakeAction])
+	assert.Equal(t, uint64(2), stats.Retries)
+}
+
+func Test_TakeAction_ShouldReturnTransientError_WhenRetriesAreExhausted(t *testing.T) {
+	// given a flyte api whose connections are always reset
+	server := httptest.NewServer(resetConnections(t, 5))
+	defer server.Close()
+	c := newTestClient(server.URL, t)
+	WithTransientRetries(1, time.Millisecond)(c)
+	c.packLinks.takeActionURL, _ = url.Parse(server.URL + "/take")
+
+	// when
+	_, err := c.TakeAction()
+
+	// then
+	require.Error(t, err)
+	assert.True(t, IsTransient(err))
+}
+
+func Test_TakeAction_ShouldNotRetryErrorResponses(t *testing.T) {
+	// given
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	c := newTestClient(server.URL, t)
+	WithTransientRetries(3, time.Millisecond)(c)
+	c.packLinks.takeActionURL, _ = url.Parse(server.URL + "/take")
+
+	// when
+	_, err := c.TakeAction()
+
+	// then
+	require.Error(t, err)
+	assert.False(t, IsTransient(err))
+	assert.Equal(t, 1, requests)
+}
+
+func Test_IsTransient(t *testing.T) {
+	reset := &url.Error{Op: "Post", URL: "http://flyte", Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}}
+
+	assert.True(t, IsTransient(reset))
+	assert.True(t, IsTransient(fmt.Errorf("error taking action: %w", reset)))
+	assert.True(t, IsTransient(&url.Error{Op: "Post", URL: "http://flyte", Err: io.EOF}))
+	assert.False(t, IsTransient(errors.New("boom")))
+	assert.False(t, IsTransient(ResponseError{StatusCode: http.StatusBadGateway}))
+	assert.False(t, IsTransient(nil))
+}
@@ -0,0 +1,54 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"github.com/rs/zerolog/log"
+	"net"
+	"net/url"
+)
+
+// unixSocketHost is the host of the urls requests are made to when the client connects to the flyte api over a Unix
+// domain socket, it is sent as the Host header
+const unixSocketHost = "localhost"
+
+// unixSocket returns the url requests are made to, and the path of the socket they are sent over, for a flyte api url
+// of the form unix:///path/to/socket. Other urls are returned as they are, with no socket.
+func unixSocket(u *url.URL) (*url.URL, string) {
+	if u.Scheme != "unix" {
+		return u, ""
+	}
+	return &url.URL{Scheme: "http", Host: unixSocketHost}, u.Path
+}
+
+// applyUnixSocket has the client connect to the Unix domain socket of the flyte api url, if it has one
+func (c *client) applyUnixSocket() {
+	if c.unixSocket == "" {
+		return
+	}
+	t := c.transport()
+	if t == nil {
+		log.Warn().Msg("the client transport is not an *http.Transport, the Unix domain socket is not connected to")
+		return
+	}
+	socket := c.unixSocket
+	dialer := &net.Dialer{}
+	t.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", socket)
+	}
+}
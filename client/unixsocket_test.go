@@ -0,0 +1,67 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_NewClient_ShouldConnectToAUnixSocketURL(t *testing.T) {
+	// given a flyte api listening on a unix socket
+	dir, err := ioutil.TempDir("", "flyte")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "flyte.sock")
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	var requests []string
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Host+" "+r.URL.Path)
+		w.Write([]byte(`{"links": [{"href": "/v1/health", "rel": "info/health"}]}`))
+	})}
+	go srv.Serve(l)
+	defer srv.Close()
+	rootURL, _ := url.Parse("unix://" + socket)
+
+	// when
+	c := NewClient(rootURL, time.Second)
+	u, err := c.GetFlyteHealthCheckURL()
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"localhost /v1"}, requests)
+	assert.Equal(t, "http://localhost/v1/health", u.String())
+	assert.Equal(t, uint64(0), c.(StatsReporter).Stats().RequestErrors[OpGetApiLinks])
+}
+
+func Test_unixSocket_ShouldLeaveOtherURLsAsTheyAre(t *testing.T) {
+	u, _ := url.Parse("https://flyte.example.com/api")
+
+	got, socket := unixSocket(u)
+
+	assert.Equal(t, u, got)
+	assert.Equal(t, "", socket)
+}
@@ -0,0 +1,276 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// templateOperator describes how the expressions with an RFC 6570 operator, such as "?" for form-style queries, are
+// expanded
+type templateOperator struct {
+	first    string // added before the first value
+	sep      string // added between values
+	named    bool   // whether values are preceded by their name, as in "name=value"
+	ifEmpty  string // added after the name of an empty value
+	reserved bool   // whether reserved characters are allowed unencoded
+}
+
+// simpleExpansion is how expressions without an operator, such as "{var}", are expanded
+var simpleExpansion = templateOperator{sep: ","}
+
+var templateOperators = map[byte]templateOperator{
+	'+': {first: "", sep: ",", reserved: true},
+	'#': {first: "#", sep: ",", reserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "="},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "="},
+}
+
+// Expand expands the link href as an RFC 6570 URI template with the variables passed in, e.g. the href
+// "http://flyte/v1/datastore/{key}" with the variable key "token". Variables are strings, []string or
+// map[string]string values; any other value is formatted with fmt.Sprint. Links that are not templated expand to
+// their href.
+func (l Link) Expand(vars map[string]interface{}) (*url.URL, error) {
+	if l.Template == "" {
+		if l.Href == nil {
+			return nil, fmt.Errorf("link with rel %q has no href", l.Rel)
+		}
+		u := *l.Href
+		return &u, nil
+	}
+	expanded, err := ExpandTemplate(l.Template, vars)
+	if err != nil {
+		return nil, err
+	}
+	return url.Parse(expanded)
+}
+
+// Expand expands the href of the first link with the rel, see Link.Expand, or returns a LinkNotFoundError if there
+// is none.
+func (l Links) Expand(rel Rel, vars map[string]interface{}) (*url.URL, error) {
+	link, ok := l.FindLink(rel)
+	if !ok {
+		return nil, LinkNotFoundError{Rel: rel, Links: l}
+	}
+	return link.Expand(vars)
+}
+
+// ExpandTemplate expands the RFC 6570 URI template, up to level 4, with the variables passed in. Variables are
+// strings, []string or map[string]string values; any other value is formatted with fmt.Sprint. Undefined variables,
+// and empty lists and maps, are left out.
+func ExpandTemplate(template string, vars map[string]interface{}) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(template); {
+		start := strings.IndexByte(template[i:], '{')
+		if start < 0 {
+			b.WriteString(template[i:])
+			break
+		}
+		b.WriteString(template[i : i+start])
+		end := strings.IndexByte(template[i+start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("invalid uri template %q: unclosed expression", template)
+		}
+		if err := expandExpression(&b, template[i+start+1:i+start+end], vars); err != nil {
+			return "", fmt.Errorf("invalid uri template %q: %w", template, err)
+		}
+		i += start + end + 1
+	}
+	return b.String(), nil
+}
+
+// expandExpression expands the expression, without its braces, e.g. "?name,page"
+func expandExpression(b *strings.Builder, expression string, vars map[string]interface{}) error {
+	if expression == "" {
+		return fmt.Errorf("empty expression")
+	}
+	op, ok := templateOperators[expression[0]]
+	if ok {
+		expression = expression[1:]
+	} else {
+		op = simpleExpansion
+	}
+
+	first := true
+	for _, spec := range strings.Split(expression, ",") {
+		name, prefix, explode, err := parseVarSpec(spec)
+		if err != nil {
+			return err
+		}
+		values, keys, defined := templateValues(vars[name])
+		if !defined {
+			continue
+		}
+		if first {
+			b.WriteString(op.first)
+			first = false
+		} else {
+			b.WriteString(op.sep)
+		}
+		switch {
+		case keys == nil && len(values) == 1 && !isList(vars[name]):
+			v := values[0]
+			if prefix > 0 {
+				v = truncateRunes(v, prefix)
+			}
+			writeNamed(b, op, name, v)
+		case explode && keys != nil:
+			for i, k := range keys {
+				if i > 0 {
+					b.WriteString(op.sep)
+				}
+				b.WriteString(encodeTemplateValue(k, op.reserved))
+				if op.named && values[i] == "" {
+					b.WriteString(op.ifEmpty)
+				} else {
+					b.WriteString("=" + encodeTemplateValue(values[i], op.reserved))
+				}
+			}
+		case explode:
+			for i, v := range values {
+				if i > 0 {
+					b.WriteString(op.sep)
+				}
+				if op.named {
+					writeNamed(b, op, name, v)
+				} else {
+					b.WriteString(encodeTemplateValue(v, op.reserved))
+				}
+			}
+		default:
+			if op.named {
+				b.WriteString(name + "=")
+			}
+			for i, v := range values {
+				if i > 0 {
+					b.WriteString(",")
+				}
+				if keys != nil {
+					b.WriteString(encodeTemplateValue(keys[i], op.reserved) + ",")
+				}
+				b.WriteString(encodeTemplateValue(v, op.reserved))
+			}
+		}
+	}
+	return nil
+}
+
+// writeNamed writes a single value, preceded by its name for named operators
+func writeNamed(b *strings.Builder, op templateOperator, name, value string) {
+	if op.named {
+		b.WriteString(name)
+		if value == "" {
+			b.WriteString(op.ifEmpty)
+			return
+		}
+		b.WriteString("=")
+	}
+	b.WriteString(encodeTemplateValue(value, op.reserved))
+}
+
+// parseVarSpec parses a variable of an expression, e.g. "name", "name:3" or "list*"
+func parseVarSpec(spec string) (name string, prefix int, explode bool, err error) {
+	name = spec
+	if strings.HasSuffix(name, "*") {
+		name, explode = strings.TrimSuffix(name, "*"), true
+	}
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		prefix, err = strconv.Atoi(name[i+1:])
+		if err != nil || prefix <= 0 || prefix >= 10000 {
+			return "", 0, false, fmt.Errorf("invalid prefix in %q", spec)
+		}
+		name = name[:i]
+	}
+	if name == "" {
+		return "", 0, false, fmt.Errorf("empty variable name in %q", spec)
+	}
+	return name, prefix, explode, nil
+}
+
+// templateValues returns the values of a variable, with the sorted keys of maps, and whether it is defined
+func templateValues(v interface{}) (values, keys []string, defined bool) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil, false
+	case string:
+		return []string{v}, nil, true
+	case []string:
+		return v, nil, len(v) > 0
+	case map[string]string:
+		keys = make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		values = make([]string, len(keys))
+		for i, k := range keys {
+			values[i] = v[k]
+		}
+		return values, keys, len(v) > 0
+	default:
+		return []string{fmt.Sprint(v)}, nil, true
+	}
+}
+
+func isList(v interface{}) bool {
+	_, ok := v.([]string)
+	return ok
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// encodeTemplateValue percent-encodes the value, leaving unreserved characters, and reserved characters and
+// percent-encoded triplets if they are allowed, as they are
+func encodeTemplateValue(s string, reserved bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isUnreserved(c):
+			b.WriteByte(c)
+		case reserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0:
+			b.WriteByte(c)
+		case reserved && c == '%' && i+2 < len(s) && isHexDigit(s[i+1]) && isHexDigit(s[i+2]):
+			b.WriteString(s[i : i+3])
+			i += 2
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
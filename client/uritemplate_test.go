@@ -0,0 +1,153 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// the examples of RFC 6570 section 3.2
+var templateVars = map[string]interface{}{
+	"count":      []string{"one", "two", "three"},
+	"dom":        []string{"example", "com"},
+	"dub":        "me/too",
+	"hello":      "Hello World!",
+	"half":       "50%",
+	"var":        "value",
+	"who":        "fred",
+	"base":       "http://example.com/home/",
+	"path":       "/foo/bar",
+	"list":       []string{"red", "green", "blue"},
+	"keys":       map[string]string{"semi": ";", "dot": ".", "comma": ","},
+	"v":          "6",
+	"x":          "1024",
+	"y":          "768",
+	"empty":      "",
+	"empty_keys": map[string]string{},
+}
+
+func Test_ExpandTemplate_ShouldExpandTheRFC6570Examples(t *testing.T) {
+	for template, want := range map[string]string{
+		"{var}":               "value",
+		"{hello}":             "Hello%20World%21",
+		"{half}":              "50%25",
+		"O{empty}X":           "OX",
+		"O{undef}X":           "OX",
+		"{x,y}":               "1024,768",
+		"{x,hello,y}":         "1024,Hello%20World%21,768",
+		"?{x,empty}":          "?1024,",
+		"{var:3}":             "val",
+		"{list}":              "red,green,blue",
+		"{list*}":             "red,green,blue",
+		"{keys}":              "comma,%2C,dot,.,semi,%3B",
+		"{keys*}":             "comma=%2C,dot=.,semi=%3B",
+		"{+var}":              "value",
+		"{+hello}":            "Hello%20World!",
+		"{+half}":             "50%25",
+		"{base}index":         "http%3A%2F%2Fexample.com%2Fhome%2Findex",
+		"{+base}index":        "http://example.com/home/index",
+		"{+path}/here":        "/foo/bar/here",
+		"here?ref={+path}":    "here?ref=/foo/bar",
+		"{+path:6}/here":      "/foo/b/here",
+		"{#var}":              "#value",
+		"{#hello}":            "#Hello%20World!",
+		"{#keys*}":            "#comma=,,dot=.,semi=;",
+		"X{.var}":             "X.value",
+		"X{.x,y}":             "X.1024.768",
+		"X{.list*}":           "X.red.green.blue",
+		"{/who}":              "/fred",
+		"{/who,who}":          "/fred/fred",
+		"{/half,who}":         "/50%25/fred",
+		"{/var,x}/here":       "/value/1024/here",
+		"{/list*,path:4}":     "/red/green/blue/%2Ffoo",
+		"{;who}":              ";who=fred",
+		"{;v,empty,who}":      ";v=6;empty;who=fred",
+		"{;list*}":            ";list=red;list=green;list=blue",
+		"{;keys*}":            ";comma=%2C;dot=.;semi=%3B",
+		"{?who}":              "?who=fred",
+		"{?x,y,empty}":        "?x=1024&y=768&empty=",
+		"{?list}":             "?list=red,green,blue",
+		"{?list*}":            "?list=red&list=green&list=blue",
+		"{?keys*}":            "?comma=%2C&dot=.&semi=%3B",
+		"{?empty_keys*}":      "",
+		"?fixed=yes{&x}":      "?fixed=yes&x=1024",
+		"{&var:3}":            "&var=val",
+		"{?dub,count}":        "?dub=me%2Ftoo&count=one,two,three",
+		"http://flyte{/dom*}": "http://flyte/example/com",
+	} {
+		got, err := ExpandTemplate(template, templateVars)
+		require.NoError(t, err, template)
+		assert.Equal(t, want, got, template)
+	}
+}
+
+func Test_ExpandTemplate_ShouldFormatOtherValues(t *testing.T) {
+	got, err := ExpandTemplate("/flows{?page,size}", map[string]interface{}{"page": 2, "size": 50})
+
+	require.NoError(t, err)
+	assert.Equal(t, "/flows?page=2&size=50", got)
+}
+
+func Test_ExpandTemplate_ShouldRejectInvalidTemplates(t *testing.T) {
+	for _, template := range []string{"/items/{key", "/items/{}", "/items/{key:x}", "/items/{,}"} {
+		_, err := ExpandTemplate(template, nil)
+		assert.Error(t, err, template)
+	}
+}
+
+func Test_Links_Expand_ShouldExpandTemplatedLinks(t *testing.T) {
+	var links Links
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"href": "http://flyte/v1/datastore/{key}", "rel": "http://example.com/swagger#!/datastore/getDataItem"},
+		{"href": "http://flyte/v1/flows{?name}", "rel": "http://example.com/swagger#!/flow/listFlows"}
+	]`), &links))
+
+	item, err := links.Expand("datastore/getDataItem", map[string]interface{}{"key": "slack/token"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://flyte/v1/datastore/slack%2Ftoken", item.String())
+
+	flows, err := links.Expand(RelListFlows, map[string]interface{}{"name": "deploy app"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://flyte/v1/flows?name=deploy%20app", flows.String())
+	flows, err = links.Expand(RelListFlows, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "http://flyte/v1/flows", flows.String())
+}
+
+func Test_Link_ShouldKeepItsTemplateWhenMarshalled(t *testing.T) {
+	var link Link
+	require.NoError(t, json.Unmarshal([]byte(`{"href": "{scheme}://flyte/v1/datastore/{key}", "rel": "item"}`), &link))
+
+	b, err := json.Marshal(link)
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"href": "{scheme}://flyte/v1/datastore/{key}", "rel": "item"}`, string(b))
+	assert.Equal(t, "{{scheme}://flyte/v1/datastore/{key} item}", link.String())
+}
+
+func Test_Link_Expand_ShouldReturnTheHrefOfLinksThatAreNotTemplated(t *testing.T) {
+	var link Link
+	require.NoError(t, json.Unmarshal([]byte(`{"href": "http://flyte/v1/packs", "rel": "pack/listPacks"}`), &link))
+
+	u, err := link.Expand(map[string]interface{}{"key": "ignored"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "http://flyte/v1/packs", u.String())
+}
@@ -0,0 +1,76 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+const modulePath = "github.com/ExpediaGroup/flyte-client"
+
+// Version is the version of the flyte client, as recorded in the build info of the binary it is built into, or
+// "dev" when it cannot be determined (for example, when built from within this module).
+var Version = moduleVersion()
+
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "dev"
+}
+
+// WithUserAgent sets the User-Agent header sent with every request to the flyte api, replacing the default of
+// "flyte-client/<Version> pack=<pack name> version=<pack version>".
+func WithUserAgent(userAgent string) Option {
+	return func(c *client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithPackVersion adds the version of the pack to the default User-Agent header, so flyte api operators can tell
+// which versions of a pack are sending requests.
+func WithPackVersion(version string) Option {
+	return func(c *client) {
+		c.packVersion = version
+	}
+}
+
+// getUserAgent returns the User-Agent header for requests to the flyte api
+func (c client) getUserAgent() string {
+	if c.userAgent != "" {
+		return c.userAgent
+	}
+	ua := []string{fmt.Sprintf("flyte-client/%s", Version)}
+	if packName := c.packLinks.name(); packName != "" {
+		ua = append(ua, "pack="+packName)
+	}
+	if c.packVersion != "" {
+		ua = append(ua, "version="+c.packVersion)
+	}
+	return strings.Join(ua, " ")
+}
@@ -0,0 +1,51 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func Test_CreatePack_ShouldSendUserAgentWithPackNameAndVersion(t *testing.T) {
+	ts, rec := mockServerWithRecorder(http.StatusCreated, slackPackResponse)
+	defer ts.Close()
+	c := newTestClient(ts.URL, t)
+	WithPackVersion("2.3.1")(c)
+
+	require.NoError(t, c.CreatePack(Pack{Name: "Slack"}))
+
+	require.NotEmpty(t, rec.reqs)
+	assert.Equal(t, "flyte-client/"+Version+" pack=Slack version=2.3.1", rec.reqs[0].Header.Get("User-Agent"))
+}
+
+func Test_WithUserAgent_ShouldReplaceTheDefaultUserAgent(t *testing.T) {
+	c := newTestClient("http://localhost:1", t)
+	c.packLinks.packName = "Slack"
+
+	WithUserAgent("my-pack/1.0")(c)
+
+	assert.Equal(t, "my-pack/1.0", c.getUserAgent())
+}
+
+func Test_getUserAgent_ShouldOmitThePackBeforeItIsKnown(t *testing.T) {
+	c := newTestClient("http://localhost:1", t)
+
+	assert.Equal(t, "flyte-client/"+Version, c.getUserAgent())
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package config
 
 import (
+	"crypto/tls"
+	"fmt"
 	"github.com/rs/zerolog/log"
 	"net/url"
 	"os"
@@ -26,11 +28,13 @@ import (
 )
 
 const (
-	apiTimeoutOutDefault   = time.Second * 10
-	flyteApiEnvName        = "FLYTE_API"
-	FlyteJWTEnvName        = "FLYTE_JWT"
-	flyteLabelsEnvName     = "FLYTE_LABELS"
-	flyteApiTimeOutEnvName = "FLYTE_API_TIMEOUT"
+	apiTimeoutOutDefault    = time.Second * 10
+	flyteApiEnvName         = "FLYTE_API"
+	FlyteJWTEnvName         = "FLYTE_JWT"
+	flyteLabelsEnvName      = "FLYTE_LABELS"
+	flyteApiTimeOutEnvName  = "FLYTE_API_TIMEOUT"
+	flyteCompressionEnvName = "FLYTE_COMPRESSION"
+	flytePackVersionEnvName = "FLYTE_PACK_VERSION"
 )
 
 var GetEnv = os.Getenv
@@ -39,73 +43,197 @@ type Values struct {
 	Labels      map[string]string
 	FlyteApiUrl *url.URL
 	Timeout     time.Duration
+	// the content coding used to compress requests to the flyte api, e.g. "gzip", or "" for none
+	Compression string
+	// the size in bytes below which requests are not compressed
+	CompressionThreshold int
+	// the version of the pack, sent in the User-Agent header of requests to the flyte api
+	PackVersion string
+	// limits on the stages of requests to the flyte api, zero where they are not set
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	IdleConnTimeout       time.Duration
+	// flyte api urls used in place of link discovery, nil where they are not set
+	TakeActionURL *url.URL
+	EventsURL     *url.URL
+	HealthURL     *url.URL
+	PacksURL      *url.URL
+	// the flyte api urls failed over to, after FlyteApiUrl, when FLYTE_API lists several
+	FallbackApiUrls []*url.URL
+	// the service name of the DNS SRV records the flyte api hosts are discovered from, or "" to use the url host
+	SRVService string
+	// the PEM files the flyte api certificate is verified with and the client authenticates with, "" where they are
+	// not set, and the TLS config loaded from them, nil when none are set
+	CACertFile     string
+	ClientCertFile string
+	ClientKeyFile  string
+	TLSConfig      *tls.Config
+	// how often the pack polls for actions, how many actions it handles at once and the log level, zero where they
+	// are not set
+	PollingFrequency time.Duration
+	Concurrency      int
+	LogLevel         string
+	// the requests per second the client is limited to and the burst allowed over that, zero where they are not set
+	RateLimit      float64
+	RateLimitBurst int
+	// the JSON Web Token sent in the Authorization header of requests to the flyte api, "" for none
+	JWT string
+	// whether the pack logs what it would send to the flyte api instead of sending it, and the file of the actions it
+	// takes in dry-run mode, "" for none
+	DryRun            bool
+	DryRunActionsFile string
 }
 
-// returns the environment values
-func FromEnvironment() Values {
-	return Values{FlyteApiUrl: getFlyteApiUrl(), Labels: getLabels(), Timeout: getApiTimeOut()}
+// returns the environment values, or an error if any of them are missing or invalid. When FLYTE_CONFIG_FILE is set
+// the settings in that file are used for the environment variables that are not set. The error is a
+// *ValidationError with every problem found.
+func ReadEnvironment() (Values, error) {
+	return Env{}.ReadEnvironment()
 }
 
-// checks that the flyteApi env FLYTE_API is set
-func getFlyteApiUrl() *url.URL {
-	apiEnvUrl := GetEnv(flyteApiEnvName)
-	if apiEnvUrl == "" {
-		log.Fatal().Msgf("%s environment variable is not set", flyteApiEnvName)
+// readValues reads the environment values, adding any problems with them to p
+func (e *environment) readValues(p *problems) Values {
+	p.add(e.readConfigFile())
+	values := Values{Compression: e.get(flyteCompressionEnvName), PackVersion: e.get(flytePackVersionEnvName), JWT: e.get(FlyteJWTEnvName)}
+	flyteApiUrls, err := e.getFlyteApiUrls()
+	p.add(err)
+	if len(flyteApiUrls) > 0 {
+		values.FlyteApiUrl = flyteApiUrls[0]
+		values.FallbackApiUrls = flyteApiUrls[1:]
 	}
+	values.Labels, err = e.getLabels()
+	p.add(err)
+	e.readTimeouts(&values, p)
+	values.CompressionThreshold, err = e.getCompressionThreshold()
+	p.add(err)
+	e.readStaticLinks(&values, p)
+	values.SRVService = e.get(flyteApiSRVServiceEnvName)
+	e.readTLS(&values, p)
+	e.readPackSettings(&values, p)
+	e.readRateLimit(&values, p)
+	e.readDryRun(&values, p)
+	return values
+}
 
-	flyteApiUrl, err := url.Parse(apiEnvUrl)
+// returns the environment values, panicking if any of them are missing or invalid.
+//
+// Deprecated: use ReadEnvironment, which returns the error instead.
+func FromEnvironment() Values {
+	values, err := ReadEnvironment()
 	if err != nil {
-		log.Fatal().Err(err).Msgf("%s environment variable is not set to a valid URL", flyteApiEnvName)
+		panic(err)
 	}
+	return values
+}
 
-	return flyteApiUrl
+// checks that the flyteApi env FLYTE_API is set, to one url or to a comma separated list of urls to fail over
+// between
+func (e *environment) getFlyteApiUrls() ([]*url.URL, error) {
+	apiEnvUrl := e.get(flyteApiEnvName)
+	if apiEnvUrl == "" && LocalDev() {
+		log.Info().Msgf("%s environment variable is not set, using %s in local development mode", e.name(flyteApiEnvName), LocalDevApiURL)
+		apiEnvUrl = LocalDevApiURL
+	}
+	if apiEnvUrl == "" {
+		return nil, fmt.Errorf("%s environment variable is not set", e.name(flyteApiEnvName))
+	}
+
+	var flyteApiUrls []*url.URL
+	for _, u := range strings.Split(apiEnvUrl, ",") {
+		flyteApiUrl, err := url.Parse(strings.TrimSpace(u))
+		if err != nil {
+			return nil, fmt.Errorf("%s environment variable is not set to a valid URL: %w", e.name(flyteApiEnvName), err)
+		}
+		if err := validateURL(e.name(flyteApiEnvName), flyteApiUrl); err != nil {
+			return nil, err
+		}
+		flyteApiUrls = append(flyteApiUrls, flyteApiUrl)
+	}
+	return flyteApiUrls, nil
 }
 
 // checks that FLYTE_LABELS is set and it's value(s) are correct
-func getLabels() (labels map[string]string) {
-	labelsString := GetEnv(flyteLabelsEnvName)
-	labels = make(map[string]string)
+func (e *environment) getLabels() (map[string]string, error) {
+	labelsString := e.get(flyteLabelsEnvName)
+	labels := make(map[string]string)
 
 	if labelsString == "" {
-		log.Info().Msgf("%s environment variable is not set", flyteLabelsEnvName)
-		return labels
+		log.Info().Msgf("%s environment variable is not set", e.name(flyteLabelsEnvName))
+		return labels, nil
 	}
 
 	// labels format: 'key=value,key=value'
 	for _, label := range strings.Split(labelsString, ",") {
 		items := strings.SplitN(label, "=", 2)
 		if len(items) != 2 {
-			log.Fatal().Msgf("invalid format of %s environment variable: %v", flyteLabelsEnvName, labelsString)
+			return nil, fmt.Errorf("invalid format of %s environment variable: %v", e.name(flyteLabelsEnvName), labelsString)
 		}
 		labels[strings.TrimSpace(items[0])] = strings.TrimSpace(items[1])
 	}
-	return labels
+	return labels, nil
+}
+
+// checks that the FLYTE_API_TIMEOUT is set, and if not sets to the default value passed in.
+func (e *environment) getApiTimeOut(defaultTimeout time.Duration) (time.Duration, error) {
+
+	apiTimeOut, set, err := e.getSeconds(flyteApiTimeOutEnvName)
+	if err != nil {
+		return 0, err
+	}
+
+	if !set {
+		log.Info().Msgf("%s environment variable is not set, setting to default of %v", e.name(flyteApiTimeOutEnvName), defaultTimeout)
+		return defaultTimeout, nil
+	}
+
+	return apiTimeOut, nil
+}
+
+// reads a number of seconds from the environment variable, returning false if it is not set
+func (e *environment) getSeconds(name string) (time.Duration, bool, error) {
+	value := e.get(name)
+	if value == "" {
+		return 0, false, nil
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s is an invalid integer value: %w", e.name(name), err)
+	}
+
+	if seconds < 0 {
+		return 0, false, fmt.Errorf("%s has been set to an invalid value: %v", e.name(name), seconds)
+	}
+
+	return time.Second * time.Duration(seconds), true, nil
 }
 
-// checks that the FLYTE_API_TIMEOUT is set, and if not sets to the default value.
-func getApiTimeOut() time.Duration {
+const flyteCompressionThresholdEnvName = "FLYTE_COMPRESSION_THRESHOLD"
 
-	apiTimeOut := GetEnv(flyteApiTimeOutEnvName)
+const flyteApiSRVServiceEnvName = "FLYTE_API_SRV_SERVICE"
 
-	if apiTimeOut == "" {
-		log.Info().Msgf("FLYTE_API_TIMEOUT environment variable is not set, setting to default of %v", apiTimeoutOutDefault)
-		return apiTimeoutOutDefault
+// reads the size in bytes below which requests are not compressed, which is 0 if it is not set
+func (e *environment) getCompressionThreshold() (int, error) {
+	threshold := e.get(flyteCompressionThresholdEnvName)
+	if threshold == "" {
+		return 0, nil
 	}
 
-	apiTimeOutInt, err := strconv.Atoi(apiTimeOut)
+	bytes, err := strconv.Atoi(threshold)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("%s is an invalid integer value", flyteApiTimeOutEnvName)
+		return 0, fmt.Errorf("%s is an invalid integer value: %w", e.name(flyteCompressionThresholdEnvName), err)
 	}
 
-	if apiTimeOutInt < 0 {
-		log.Fatal().Msgf("%s has been set to an invalid value: %v", flyteApiTimeOutEnvName, apiTimeOutInt)
+	if bytes < 0 {
+		return 0, fmt.Errorf("%s has been set to an invalid value: %v", e.name(flyteCompressionThresholdEnvName), bytes)
 	}
 
-	return time.Second * time.Duration(apiTimeOutInt)
+	return bytes, nil
 }
 
 func GetJWT() string {
-	jwt := GetEnv(FlyteJWTEnvName)
+	jwt := getEnv(FlyteJWTEnvName)
 	if jwt != "" {
 		log.Info().Msgf("%s environment variable is set.", FlyteJWTEnvName)
 	}
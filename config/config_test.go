@@ -52,6 +52,9 @@ func TestShouldSetStandardSettingsFromEnvironment(t *testing.T) {
 	setEnv(flyteApiEnvName, "http://localhost:8080")
 	setEnv(flyteApiTimeOutEnvName, "10")
 	setEnv(flyteLabelsEnvName, "ABC=123,DEF=456")
+	setEnv(flyteCompressionEnvName, "zstd")
+	setEnv(flyteCompressionThresholdEnvName, "4096")
+	setEnv(flytePackVersionEnvName, "2.3.1")
 
 	cfg := FromEnvironment()
 
@@ -62,6 +65,9 @@ func TestShouldSetStandardSettingsFromEnvironment(t *testing.T) {
 
 	expectedLabels := map[string]string{"ABC": "123", "DEF": "456"}
 	assert.Equal(t, expectedLabels, cfg.Labels)
+	assert.Equal(t, "zstd", cfg.Compression)
+	assert.Equal(t, 4096, cfg.CompressionThreshold)
+	assert.Equal(t, "2.3.1", cfg.PackVersion)
 }
 
 func TestShouldGetJWTFromEnvironment(t *testing.T) {
@@ -83,3 +89,225 @@ func TestShouldNotGetJWTFromEnvironment(t *testing.T) {
 
 	assert.Equal(t, "", GetJWT())
 }
+
+func TestShouldSetProbeDefaultsWhenNotSetInEnvironment(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	probes, err := ReadProbes()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Probes{Port: "8091", LivenessPath: "/live", ReadinessPath: "/ready"}, probes)
+}
+
+func TestShouldSetProbesFromEnvironment(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteProbesPortEnvName, "9000")
+	setEnv(flyteLivenessPathEnvName, "healthz")
+	setEnv(flyteReadinessPathEnvName, "/readyz")
+	setEnv(flyteProbesMaxInFlightName, "20")
+
+	probes, err := ReadProbes()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Probes{Port: "9000", LivenessPath: "/healthz", ReadinessPath: "/readyz", MaxInFlight: 20}, probes)
+}
+
+func TestReadEnvironmentShouldReturnAnErrorWhenTheApiUrlIsNotSet(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	_, err := ReadEnvironment()
+
+	assert.EqualError(t, err, "FLYTE_API environment variable is not set")
+}
+
+func TestReadEnvironmentShouldReturnAnErrorForInvalidLabels(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "http://localhost:8080")
+	setEnv(flyteLabelsEnvName, "ABC=123,DEF")
+
+	_, err := ReadEnvironment()
+
+	assert.EqualError(t, err, "invalid format of FLYTE_LABELS environment variable: ABC=123,DEF")
+}
+
+func TestReadEnvironmentShouldReturnAnErrorForAnInvalidTimeout(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "http://localhost:8080")
+	setEnv(flyteApiTimeOutEnvName, "-1")
+
+	_, err := ReadEnvironment()
+
+	assert.EqualError(t, err, "FLYTE_API_TIMEOUT has been set to an invalid value: -1")
+}
+
+func TestReadEnvironmentShouldReplaceTheDefaultTimeoutWithStageTimeouts(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "http://localhost:8080")
+	setEnv(flyteApiDialTimeoutEnvName, "3")
+	setEnv(flyteApiResponseHeaderTimeoutEnvName, "30")
+
+	cfg, err := ReadEnvironment()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, cfg.DialTimeout)
+	assert.Equal(t, time.Duration(0), cfg.TLSHandshakeTimeout)
+	assert.Equal(t, 30*time.Second, cfg.ResponseHeaderTimeout)
+	assert.Equal(t, time.Duration(0), cfg.Timeout, "there should be no overall timeout unless it is set")
+
+	setEnv(flyteApiTimeOutEnvName, "120")
+	cfg, err = ReadEnvironment()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 120*time.Second, cfg.Timeout)
+}
+
+func TestReadEnvironmentShouldReturnAnErrorForAnInvalidStageTimeout(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "http://localhost:8080")
+	setEnv(flyteApiIdleConnTimeoutEnvName, "a minute")
+
+	_, err := ReadEnvironment()
+
+	assert.EqualError(t, err, `FLYTE_API_IDLE_CONN_TIMEOUT is an invalid integer value: strconv.Atoi: parsing "a minute": invalid syntax`)
+}
+
+func TestReadEnvironmentShouldReadSeveralFlyteApiUrls(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "https://flyte.eu.example.com, https://flyte.us.example.com")
+
+	cfg, err := ReadEnvironment()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://flyte.eu.example.com", cfg.FlyteApiUrl.String())
+	if assert.Len(t, cfg.FallbackApiUrls, 1) {
+		assert.Equal(t, "https://flyte.us.example.com", cfg.FallbackApiUrls[0].String())
+	}
+}
+
+func TestReadEnvironmentShouldReadTheSRVService(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "https://flyte-api.service.consul")
+	setEnv(flyteApiSRVServiceEnvName, "flyte-api")
+
+	cfg, err := ReadEnvironment()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "flyte-api", cfg.SRVService)
+}
+
+func TestReadEnvironmentShouldReadStaticLinks(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "http://localhost:8080")
+	setEnv(flyteTakeActionURLEnvName, "https://flyte.example.com/packs/Slack/actions/take")
+	setEnv(flyteEventsURLEnvName, "https://flyte.example.com/packs/Slack/events")
+	setEnv(flyteHealthURLEnvName, "https://flyte.example.com/health")
+
+	cfg, err := ReadEnvironment()
+
+	assert.NoError(t, err)
+	assert.True(t, cfg.StaticLinks())
+	assert.Equal(t, "https://flyte.example.com/packs/Slack/actions/take", cfg.TakeActionURL.String())
+	assert.Equal(t, "https://flyte.example.com/packs/Slack/events", cfg.EventsURL.String())
+	assert.Equal(t, "https://flyte.example.com/health", cfg.HealthURL.String())
+	assert.Nil(t, cfg.PacksURL)
+}
+
+func TestReadEnvironmentShouldNotHaveStaticLinksByDefault(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "http://localhost:8080")
+
+	cfg, err := ReadEnvironment()
+
+	assert.NoError(t, err)
+	assert.False(t, cfg.StaticLinks())
+}
+
+func TestReadEnvironmentShouldNeedTheTakeActionAndEventsURLsWithoutAPacksURL(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "http://localhost:8080")
+	setEnv(flyteEventsURLEnvName, "https://flyte.example.com/packs/Slack/events")
+
+	_, err := ReadEnvironment()
+
+	assert.EqualError(t, err, "FLYTE_TAKE_ACTION_URL and FLYTE_EVENTS_URL must both be set unless FLYTE_PACKS_URL is set")
+}
+
+func TestReadEnvironmentShouldReturnAnErrorForARelativeStaticLink(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "http://localhost:8080")
+	setEnv(flytePacksURLEnvName, "/packs")
+
+	_, err := ReadEnvironment()
+
+	assert.EqualError(t, err, `FLYTE_PACKS_URL environment variable is not set to an absolute URL: "/packs"`)
+}
+
+func TestFromEnvironmentShouldPanicWhenTheEnvironmentIsInvalid(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	assert.Panics(t, func() { FromEnvironment() })
+}
+
+func TestReadProbesShouldReturnAnErrorForAnInvalidMaxInFlight(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteProbesMaxInFlightName, "lots")
+
+	_, err := ReadProbes()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "FLYTE_PROBES_MAX_IN_FLIGHT is an invalid integer value")
+}
+
+func TestLabelsFromEnvShouldReadTheLabelsThatAreSet(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv("DEPLOY_ENV", "staging")
+
+	labels := LabelsFromEnv(map[string]string{"env": "DEPLOY_ENV", "region": "AWS_REGION"})
+
+	assert.Equal(t, map[string]string{"env": "staging"}, labels)
+}
@@ -0,0 +1,45 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const (
+	flyteDryRunEnvName        = "FLYTE_DRY_RUN"
+	flyteDryRunActionsEnvName = "FLYTE_DRY_RUN_ACTIONS"
+)
+
+// readDryRun reads whether dry-run mode is enabled and the file of the actions scripted for it, adding any problems
+// with them to p. The actions file is only checked when dry-run mode is enabled.
+func (e *environment) readDryRun(v *Values, p *problems) {
+	if dryRun := e.get(flyteDryRunEnvName); dryRun != "" {
+		var err error
+		if v.DryRun, err = strconv.ParseBool(dryRun); err != nil {
+			p.add(fmt.Errorf("%s is not a valid boolean value: %q", e.name(flyteDryRunEnvName), dryRun))
+		}
+	}
+	v.DryRunActionsFile = e.get(flyteDryRunActionsEnvName)
+	if v.DryRun && v.DryRunActionsFile != "" {
+		if _, err := os.Stat(v.DryRunActionsFile); err != nil {
+			p.add(fmt.Errorf("cannot read the %s file: %w", e.name(flyteDryRunActionsEnvName), err))
+		}
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadEnvironmentShouldReadDryRunMode(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	dir, err := ioutil.TempDir("", "dryrun")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	actions := filepath.Join(dir, "actions.json")
+	assert.NoError(t, ioutil.WriteFile(actions, []byte("[]"), 0600))
+	setEnv(flyteApiEnvName, "http://localhost:8080")
+	setEnv(flyteDryRunEnvName, "true")
+	setEnv(flyteDryRunActionsEnvName, actions)
+
+	cfg, err := ReadEnvironment()
+
+	assert.NoError(t, err)
+	assert.True(t, cfg.DryRun)
+	assert.Equal(t, actions, cfg.DryRunActionsFile)
+}
+
+func TestReadEnvironmentShouldNotBeInDryRunModeUnlessEnabled(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "http://localhost:8080")
+
+	cfg, err := ReadEnvironment()
+
+	assert.NoError(t, err)
+	assert.False(t, cfg.DryRun)
+}
+
+func TestReadEnvironmentShouldReturnAnErrorForAnInvalidDryRunValue(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "http://localhost:8080")
+	setEnv(flyteDryRunEnvName, "yes please")
+
+	_, err := ReadEnvironment()
+
+	assert.EqualError(t, err, `FLYTE_DRY_RUN is not a valid boolean value: "yes please"`)
+}
+
+func TestReadEnvironmentShouldReturnAnErrorWhenTheDryRunActionsFileIsMissing(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "http://localhost:8080")
+	setEnv(flyteDryRunEnvName, "true")
+	setEnv(flyteDryRunActionsEnvName, filepath.Join(os.TempDir(), "no-such-actions.json"))
+
+	_, err := ReadEnvironment()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot read the FLYTE_DRY_RUN_ACTIONS file")
+}
@@ -0,0 +1,100 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"strings"
+)
+
+// the prefix of the environment variables settings are read from by default
+const defaultEnvPrefix = "FLYTE_"
+
+// Env reads settings from environment variables named with a prefix other than FLYTE_, e.g. STAGING_API and
+// STAGING_API_TIMEOUT in place of FLYTE_API and FLYTE_API_TIMEOUT, so several clients in one process, such as
+// one for a staging and one for a production flyte api, can be configured independently. The config file is named by
+// the prefixed CONFIG_FILE variable, e.g. STAGING_CONFIG_FILE. The zero Env reads the FLYTE_ environment variables,
+// as the package functions do. Legacy names (see LegacyEnvReport) are only read with the FLYTE_ prefix.
+type Env struct {
+	Prefix string
+}
+
+// ReadEnvironment returns the values of the prefixed environment variables, as ReadEnvironment does.
+func (e Env) ReadEnvironment() (Values, error) {
+	var p problems
+	values := e.environment().readValues(&p)
+	if err := p.err(); err != nil {
+		return Values{}, err
+	}
+	return values, nil
+}
+
+// Load reads and validates all the settings of the prefixed environment variables, as Load does.
+func (e Env) Load() (Settings, error) {
+	var p problems
+	env := e.environment()
+	s := Settings{Values: env.readValues(&p)}
+	probes, err := env.readProbes()
+	p.add(err)
+	s.Probes = probes
+	if err := p.err(); err != nil {
+		return Settings{}, err
+	}
+	return s, nil
+}
+
+// ReadProbes returns the liveness and readiness endpoint settings of the prefixed environment variables, as
+// ReadProbes does.
+func (e Env) ReadProbes() (Probes, error) {
+	return e.environment().readProbes()
+}
+
+func (e Env) environment() *environment {
+	prefix := e.Prefix
+	if prefix == "" {
+		prefix = defaultEnvPrefix
+	}
+	return &environment{prefix: prefix}
+}
+
+// environment reads settings from the environment variables with its prefix, and from the config file they name
+type environment struct {
+	prefix string
+	// the settings of the config file, by the FLYTE_ name of their environment variable
+	file map[string]string
+}
+
+// name returns the name of the environment variable a setting is read from, e.g. STAGING_API for FLYTE_API
+func (e *environment) name(name string) string {
+	if e.prefix == defaultEnvPrefix {
+		return name
+	}
+	return e.prefix + strings.TrimPrefix(name, defaultEnvPrefix)
+}
+
+// get gets the environment variable of the setting, falling back to its legacy names and then to the config file
+func (e *environment) get(name string) string {
+	var v string
+	if e.prefix == defaultEnvPrefix {
+		v = getEnv(name)
+	} else {
+		v = GetEnv(e.name(name))
+	}
+	if v != "" {
+		return v
+	}
+	return e.file[name]
+}
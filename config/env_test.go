@@ -0,0 +1,111 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestEnvShouldReadTheEnvironmentVariablesWithItsPrefix(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "https://flyte.example.com")
+	setEnv(flyteApiTimeOutEnvName, "10")
+	setEnv("STAGING_API", "https://flyte-staging.example.com")
+	setEnv("STAGING_API_TIMEOUT", "30")
+	setEnv("STAGING_PROBES_PORT", "9001")
+
+	prod, err := ReadEnvironment()
+	require.NoError(t, err)
+	staging, err := Env{Prefix: "STAGING_"}.Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://flyte.example.com", prod.FlyteApiUrl.String())
+	assert.Equal(t, 10*time.Second, prod.Timeout)
+	assert.Equal(t, "https://flyte-staging.example.com", staging.FlyteApiUrl.String())
+	assert.Equal(t, 30*time.Second, staging.Timeout)
+	assert.Equal(t, "9001", staging.Probes.Port)
+}
+
+func TestEnvShouldReadTheJWTWithItsPrefix(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "https://flyte.example.com")
+	setEnv(FlyteJWTEnvName, "prod.jwt.token")
+	setEnv("STAGING_API", "https://flyte-staging.example.com")
+	setEnv("STAGING_JWT", "staging.jwt.token")
+
+	prod, err := ReadEnvironment()
+	require.NoError(t, err)
+	staging, err := Env{Prefix: "STAGING_"}.ReadEnvironment()
+	require.NoError(t, err)
+	other, err := Env{Prefix: "OTHER_"}.ReadEnvironment()
+	require.Error(t, err)
+
+	assert.Equal(t, "prod.jwt.token", prod.JWT)
+	assert.Equal(t, "staging.jwt.token", staging.JWT)
+	assert.Empty(t, other.JWT)
+}
+
+func TestEnvShouldNameItsEnvironmentVariablesInErrors(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "https://flyte.example.com")
+
+	_, err := Env{Prefix: "STAGING_"}.ReadEnvironment()
+
+	assert.EqualError(t, err, "STAGING_API environment variable is not set")
+}
+
+func TestEnvShouldNotReadLegacyNamesWithAnotherPrefix(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+	renameFlyteApi(t)
+
+	setEnv("FLYTE_OLD_API", "https://flyte.example.com")
+
+	_, err := Env{Prefix: "STAGING_"}.ReadEnvironment()
+
+	assert.EqualError(t, err, "STAGING_API environment variable is not set")
+}
+
+func TestEnvShouldReadTheConfigFileNamedWithItsPrefix(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "https://flyte.example.com")
+	setEnv("STAGING_CONFIG_FILE", writeConfigFile(t, "staging.yaml", "api:\n  url: https://flyte-staging.example.com\n"))
+
+	prod, err := ReadEnvironment()
+	require.NoError(t, err)
+	staging, err := Env{Prefix: "STAGING_"}.ReadEnvironment()
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://flyte.example.com", prod.FlyteApiUrl.String())
+	assert.Equal(t, "https://flyte-staging.example.com", staging.FlyteApiUrl.String())
+}
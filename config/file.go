@@ -0,0 +1,175 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const flyteConfigFileEnvName = "FLYTE_CONFIG_FILE"
+
+// fileSettings are the settings of the config file named by FLYTE_CONFIG_FILE. The file is YAML, or JSON, which YAML
+// is a superset of, and each setting is used for the environment variable noted when that is not set.
+type fileSettings struct {
+	Api struct {
+		URL                   string    `yaml:"url"`                   // FLYTE_API
+		URLs                  []string  `yaml:"urls"`                  // FLYTE_API, to fail over between
+		Timeout               *duration `yaml:"timeout"`               // FLYTE_API_TIMEOUT
+		DialTimeout           *duration `yaml:"dialTimeout"`           // FLYTE_API_DIAL_TIMEOUT
+		TLSHandshakeTimeout   *duration `yaml:"tlsHandshakeTimeout"`   // FLYTE_API_TLS_HANDSHAKE_TIMEOUT
+		ResponseHeaderTimeout *duration `yaml:"responseHeaderTimeout"` // FLYTE_API_RESPONSE_HEADER_TIMEOUT
+		IdleConnTimeout       *duration `yaml:"idleConnTimeout"`       // FLYTE_API_IDLE_CONN_TIMEOUT
+		SRVService            string    `yaml:"srvService"`            // FLYTE_API_SRV_SERVICE
+		RateLimit             *float64  `yaml:"rateLimit"`             // FLYTE_API_RATE_LIMIT
+		RateLimitBurst        *int      `yaml:"rateLimitBurst"`        // FLYTE_API_RATE_LIMIT_BURST
+	} `yaml:"api"`
+	TLS struct {
+		CACert     string `yaml:"caCert"`     // FLYTE_API_CA_CERT
+		ClientCert string `yaml:"clientCert"` // FLYTE_API_CLIENT_CERT
+		ClientKey  string `yaml:"clientKey"`  // FLYTE_API_CLIENT_KEY
+	} `yaml:"tls"`
+	PollingFrequency *duration         `yaml:"pollingFrequency"` // FLYTE_POLLING_FREQUENCY
+	Concurrency      *int              `yaml:"concurrency"`      // FLYTE_CONCURRENCY
+	LogLevel         string            `yaml:"logLevel"`         // FLYTE_LOG_LEVEL
+	Labels           map[string]string `yaml:"labels"`           // FLYTE_LABELS
+	PackVersion      string            `yaml:"packVersion"`      // FLYTE_PACK_VERSION
+	Compression      string            `yaml:"compression"`      // FLYTE_COMPRESSION
+}
+
+// duration is a duration in a config file, either a number of seconds or a duration string such as "1m30s"
+type duration time.Duration
+
+func (d *duration) UnmarshalYAML(node *yaml.Node) error {
+	if seconds, err := strconv.Atoi(node.Value); err == nil {
+		*d = duration(time.Duration(seconds) * time.Second)
+		return nil
+	}
+	v, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %q is not a number of seconds or a duration", node.Line, node.Value)
+	}
+	*d = duration(v)
+	return nil
+}
+
+// readConfigFile reads the config file named by FLYTE_CONFIG_FILE, if it is set, so its settings are used for the
+// environment variables that are not set. The file is read again each time, so changes to it are picked up.
+func (e *environment) readConfigFile() error {
+	name := GetEnv(e.name(flyteConfigFileEnvName))
+	if name == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("cannot read the %s config file: %w", e.name(flyteConfigFileEnvName), err)
+	}
+	values, err := parseConfigFile(data)
+	if err != nil {
+		return fmt.Errorf("invalid %s config file %s: %w", e.name(flyteConfigFileEnvName), name, err)
+	}
+	e.file = values
+	return nil
+}
+
+// parseConfigFile returns the environment variable values of the settings in the config file
+func parseConfigFile(data []byte) (map[string]string, error) {
+	var f fileSettings
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	set := func(name, value string) {
+		if value != "" {
+			values[name] = value
+		}
+	}
+	if f.Api.URL != "" && len(f.Api.URLs) > 0 {
+		return nil, fmt.Errorf("only one of api.url and api.urls can be set")
+	}
+	set(flyteApiEnvName, f.Api.URL)
+	set(flyteApiEnvName, strings.Join(f.Api.URLs, ","))
+	seconds := []struct {
+		name    string
+		setting string
+		value   *duration
+	}{
+		{flyteApiTimeOutEnvName, "api.timeout", f.Api.Timeout},
+		{flyteApiDialTimeoutEnvName, "api.dialTimeout", f.Api.DialTimeout},
+		{flyteApiTLSHandshakeTimeoutEnvName, "api.tlsHandshakeTimeout", f.Api.TLSHandshakeTimeout},
+		{flyteApiResponseHeaderTimeoutEnvName, "api.responseHeaderTimeout", f.Api.ResponseHeaderTimeout},
+		{flyteApiIdleConnTimeoutEnvName, "api.idleConnTimeout", f.Api.IdleConnTimeout},
+	}
+	for _, s := range seconds {
+		if s.value == nil {
+			continue
+		}
+		d := time.Duration(*s.value)
+		if d%time.Second != 0 {
+			return nil, fmt.Errorf("%s must be a whole number of seconds, not %v", s.setting, d)
+		}
+		values[s.name] = strconv.Itoa(int(d / time.Second))
+	}
+	set(flyteApiSRVServiceEnvName, f.Api.SRVService)
+	if f.Api.RateLimit != nil {
+		values[flyteApiRateLimitEnvName] = strconv.FormatFloat(*f.Api.RateLimit, 'g', -1, 64)
+	}
+	if f.Api.RateLimitBurst != nil {
+		values[flyteApiRateLimitBurstEnvName] = strconv.Itoa(*f.Api.RateLimitBurst)
+	}
+	set(flyteApiCACertEnvName, f.TLS.CACert)
+	set(flyteApiClientCertEnvName, f.TLS.ClientCert)
+	set(flyteApiClientKeyEnvName, f.TLS.ClientKey)
+	if f.PollingFrequency != nil {
+		values[flytePollingFrequencyEnvName] = time.Duration(*f.PollingFrequency).String()
+	}
+	if f.Concurrency != nil {
+		values[flyteConcurrencyEnvName] = strconv.Itoa(*f.Concurrency)
+	}
+	set(flyteLogLevelEnvName, f.LogLevel)
+	labels, err := formatLabels(f.Labels)
+	if err != nil {
+		return nil, err
+	}
+	set(flyteLabelsEnvName, labels)
+	set(flytePackVersionEnvName, f.PackVersion)
+	set(flyteCompressionEnvName, f.Compression)
+	return values, nil
+}
+
+// formatLabels formats the labels as FLYTE_LABELS is, 'key=value,key=value'
+func formatLabels(labels map[string]string) (string, error) {
+	var pairs []string
+	for k, v := range labels {
+		if strings.ContainsAny(k, ",=") || strings.Contains(v, ",") {
+			return "", fmt.Errorf("label %q: labels cannot contain commas, or equals signs in their names", k)
+		}
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ","), nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "flyte-config")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestReadEnvironmentShouldReadTheConfigFile(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteConfigFileEnvName, writeConfigFile(t, "flyte.yaml", `
+api:
+  urls:
+    - https://flyte-a.example.com
+    - https://flyte-b.example.com
+  timeout: 30
+  dialTimeout: 2s
+pollingFrequency: 1500ms
+concurrency: 4
+logLevel: warn
+labels:
+  env: prod
+  team: ops
+`))
+
+	cfg, err := ReadEnvironment()
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://flyte-a.example.com", cfg.FlyteApiUrl.String())
+	require.Len(t, cfg.FallbackApiUrls, 1)
+	assert.Equal(t, "https://flyte-b.example.com", cfg.FallbackApiUrls[0].String())
+	assert.Equal(t, 30*time.Second, cfg.Timeout)
+	assert.Equal(t, 2*time.Second, cfg.DialTimeout)
+	assert.Equal(t, 1500*time.Millisecond, cfg.PollingFrequency)
+	assert.Equal(t, 4, cfg.Concurrency)
+	assert.Equal(t, "warn", cfg.LogLevel)
+	assert.Equal(t, map[string]string{"env": "prod", "team": "ops"}, cfg.Labels)
+}
+
+func TestReadEnvironmentShouldPreferEnvironmentVariablesToTheConfigFile(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteConfigFileEnvName, writeConfigFile(t, "flyte.json", `{
+  "api": {"url": "https://flyte.example.com", "timeout": "1m"},
+  "logLevel": "warn"
+}`))
+	setEnv(flyteApiTimeOutEnvName, "5")
+
+	cfg, err := ReadEnvironment()
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://flyte.example.com", cfg.FlyteApiUrl.String())
+	assert.Equal(t, 5*time.Second, cfg.Timeout)
+	assert.Equal(t, "warn", cfg.LogLevel)
+}
+
+func TestReadEnvironmentShouldReturnAnErrorForAnUnknownConfigFileSetting(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "https://flyte.example.com")
+	setEnv(flyteConfigFileEnvName, writeConfigFile(t, "flyte.yaml", "api:\n  timeuot: 10\n"))
+
+	_, err := ReadEnvironment()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field timeuot not found")
+}
+
+func TestReadEnvironmentShouldReturnAnErrorForAMissingConfigFile(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "https://flyte.example.com")
+	setEnv(flyteConfigFileEnvName, filepath.Join(os.TempDir(), "no-such-flyte.yaml"))
+
+	_, err := ReadEnvironment()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot read the FLYTE_CONFIG_FILE config file")
+}
+
+func TestParseConfigFileShouldNeedWholeSecondsForTheApiTimeouts(t *testing.T) {
+	_, err := parseConfigFile([]byte("api:\n  timeout: 1500ms\n"))
+
+	assert.EqualError(t, err, "api.timeout must be a whole number of seconds, not 1.5s")
+}
+
+func TestReadEnvironmentShouldReturnAnErrorForAnInvalidLogLevel(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "https://flyte.example.com")
+	setEnv(flyteLogLevelEnvName, "loud")
+
+	_, err := ReadEnvironment()
+
+	assert.EqualError(t, err, `FLYTE_LOG_LEVEL has been set to an invalid log level: "loud"`)
+}
+
+func TestReadEnvironmentShouldNeedTheClientCertAndKeyTogether(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "https://flyte.example.com")
+	setEnv(flyteApiClientCertEnvName, "/etc/flyte/client.pem")
+
+	_, err := ReadEnvironment()
+
+	assert.EqualError(t, err, "FLYTE_API_CLIENT_CERT and FLYTE_API_CLIENT_KEY must both be set")
+}
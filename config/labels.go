@@ -0,0 +1,30 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+// LabelsFromEnv returns pack labels read from environment variables. The map passed in maps each label key to the name
+// of the environment variable holding its value, e.g. {"env": "DEPLOY_ENV", "region": "AWS_REGION"}. Labels whose
+// environment variable is not set are left out.
+func LabelsFromEnv(names map[string]string) map[string]string {
+	labels := make(map[string]string)
+	for key, name := range names {
+		if v := getEnv(name); v != "" {
+			labels[key] = v
+		}
+	}
+	return labels
+}
@@ -0,0 +1,103 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"github.com/rs/zerolog/log"
+	"sort"
+	"sync"
+)
+
+// legacyEnvNames maps environment variable names to the legacy names they replaced. Legacy names are still read when
+// the current name is not set, but a warning is logged and the use is counted, see LegacyEnvVarUses. No settings have
+// been renamed yet.
+var legacyEnvNames = map[string][]string{}
+
+var legacyUses = struct {
+	sync.Mutex
+	m map[legacyUse]uint64
+}{m: make(map[legacyUse]uint64)}
+
+type legacyUse struct {
+	name, replacement string
+}
+
+// LegacyEnvVar is a legacy environment variable found in the environment.
+type LegacyEnvVar struct {
+	Name        string `json:"name"`        // the legacy name
+	Replacement string `json:"replacement"` // the name to use instead
+	// true if the replacement is also set, in which case the legacy env var is ignored and can simply be removed
+	Shadowed bool `json:"shadowed"`
+}
+
+// LegacyEnvReport lists every legacy environment variable set in the environment, sorted by name, so deployments can
+// be migrated to the current names. It does not include the values, as they may be secrets.
+func LegacyEnvReport() []LegacyEnvVar {
+	var report []LegacyEnvVar
+	for current, legacyNames := range legacyEnvNames {
+		for _, legacy := range legacyNames {
+			if GetEnv(legacy) != "" {
+				report = append(report, LegacyEnvVar{Name: legacy, Replacement: current, Shadowed: GetEnv(current) != ""})
+			}
+		}
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Name < report[j].Name })
+	return report
+}
+
+// LegacyEnvVarUse is how many times settings have been read from a legacy environment variable name.
+type LegacyEnvVarUse struct {
+	Name        string // the legacy name
+	Replacement string // the name to use instead
+	Count       uint64
+}
+
+// LegacyEnvVarUses lists the legacy environment variable names settings have been read from since the process started,
+// sorted by name, with how many times they were, e.g. for the flyte_config_legacy_env_vars_used_total metric of the
+// github.com/ExpediaGroup/flyte-client/metrics module.
+func LegacyEnvVarUses() []LegacyEnvVarUse {
+	legacyUses.Lock()
+	defer legacyUses.Unlock()
+	var uses []LegacyEnvVarUse
+	for u, count := range legacyUses.m {
+		uses = append(uses, LegacyEnvVarUse{Name: u.name, Replacement: u.replacement, Count: count})
+	}
+	sort.Slice(uses, func(i, j int) bool { return uses[i].Name < uses[j].Name })
+	return uses
+}
+
+// getEnv gets the environment variable, falling back to its legacy names if it is not set
+func getEnv(name string) string {
+	if v := GetEnv(name); v != "" {
+		return v
+	}
+	for _, legacy := range legacyEnvNames[name] {
+		if v := GetEnv(legacy); v != "" {
+			legacyUses.Lock()
+			u := legacyUse{name: legacy, replacement: name}
+			legacyUses.m[u]++
+			first := legacyUses.m[u] == 1
+			legacyUses.Unlock()
+			if first {
+				log.Warn().Str("name", legacy).Str("replacement", name).
+					Msgf("%s environment variable is deprecated, use %s instead", legacy, name)
+			}
+			return v
+		}
+	}
+	return ""
+}
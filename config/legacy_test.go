@@ -0,0 +1,79 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// renameFlyteApi makes FLYTE_API a rename of FLYTE_OLD_API until the test ends, as no settings have been renamed yet
+func renameFlyteApi(t *testing.T) {
+	prev := legacyEnvNames
+	legacyEnvNames = map[string][]string{flyteApiEnvName: {"FLYTE_OLD_API"}}
+	t.Cleanup(func() { legacyEnvNames = prev })
+}
+
+func TestShouldReadLegacyEnvVarWhenTheCurrentNameIsNotSet(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+	renameFlyteApi(t)
+
+	before := legacyUseCount("FLYTE_OLD_API")
+	setEnv("FLYTE_OLD_API", "http://legacy:8080")
+
+	assert.Equal(t, "http://legacy:8080", FromEnvironment().FlyteApiUrl.String())
+	assert.Equal(t, before+1, legacyUseCount("FLYTE_OLD_API"))
+}
+
+func TestShouldPreferTheCurrentEnvVarNameOverTheLegacyName(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+	renameFlyteApi(t)
+
+	setEnv("FLYTE_OLD_API", "http://legacy:8080")
+	setEnv(flyteApiEnvName, "http://current:8080")
+
+	assert.Equal(t, "http://current:8080", FromEnvironment().FlyteApiUrl.String())
+}
+
+func TestLegacyEnvReportShouldListLegacyEnvVarsInTheEnvironment(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+	renameFlyteApi(t)
+
+	assert.Empty(t, LegacyEnvReport())
+
+	setEnv("FLYTE_OLD_API", "http://legacy:8080")
+	assert.Equal(t, []LegacyEnvVar{{Name: "FLYTE_OLD_API", Replacement: "FLYTE_API"}}, LegacyEnvReport())
+
+	setEnv(flyteApiEnvName, "http://current:8080")
+	assert.Equal(t, []LegacyEnvVar{{Name: "FLYTE_OLD_API", Replacement: "FLYTE_API", Shadowed: true}}, LegacyEnvReport())
+}
+
+// legacyUseCount returns the number of times settings were read from the legacy name
+func legacyUseCount(name string) uint64 {
+	for _, u := range LegacyEnvVarUses() {
+		if u.Name == name {
+			return u.Count
+		}
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const (
+	flyteTakeActionURLEnvName = "FLYTE_TAKE_ACTION_URL"
+	flyteEventsURLEnvName     = "FLYTE_EVENTS_URL"
+	flyteHealthURLEnvName     = "FLYTE_HEALTH_URL"
+	flytePacksURLEnvName      = "FLYTE_PACKS_URL"
+)
+
+// readStaticLinks reads the flyte api urls configured in place of link discovery. Without a packs url the pack is not
+// registered, so the takeAction and events urls must both be set. Any problems with them are added to p.
+func (e *environment) readStaticLinks(v *Values, p *problems) {
+	links := []struct {
+		name string
+		url  **url.URL
+	}{
+		{flyteTakeActionURLEnvName, &v.TakeActionURL},
+		{flyteEventsURLEnvName, &v.EventsURL},
+		{flyteHealthURLEnvName, &v.HealthURL},
+		{flytePacksURLEnvName, &v.PacksURL},
+	}
+	set := false
+	for _, l := range links {
+		u, err := e.getURL(l.name)
+		p.add(err)
+		*l.url = u
+		set = set || u != nil
+	}
+	if set && v.PacksURL == nil && (v.TakeActionURL == nil || v.EventsURL == nil) {
+		p.add(fmt.Errorf("%s and %s must both be set unless %s is set", e.name(flyteTakeActionURLEnvName), e.name(flyteEventsURLEnvName), e.name(flytePacksURLEnvName)))
+	}
+}
+
+// StaticLinks reports whether any flyte api urls are configured in place of link discovery.
+func (v Values) StaticLinks() bool {
+	return v.TakeActionURL != nil || v.EventsURL != nil || v.HealthURL != nil || v.PacksURL != nil
+}
+
+// gets an absolute url from the environment, or nil if it is not set
+func (e *environment) getURL(name string) (*url.URL, error) {
+	value := e.get(name)
+	if value == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s environment variable is not set to a valid URL: %w", e.name(name), err)
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return nil, fmt.Errorf("%s environment variable is not set to an absolute URL: %q", e.name(name), value)
+	}
+	return u, nil
+}
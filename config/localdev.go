@@ -0,0 +1,45 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"github.com/rs/zerolog/log"
+	"strconv"
+)
+
+const (
+	flyteLocalDevEnvName = "FLYTE_LOCAL_DEV"
+	// LocalDevApiURL is the flyte api url used in local development mode when FLYTE_API is not set.
+	LocalDevApiURL = "http://localhost:8080"
+)
+
+// LocalDev reports whether local development mode is enabled, by setting FLYTE_LOCAL_DEV to true. In local
+// development mode the flyte api url defaults to http://localhost:8080, TLS certificates are not verified, retries
+// are quicker and debug logging is enabled. Packs created with flyte.NewDefaultPack also start a fake flyte api if
+// nothing is listening at the url.
+func LocalDev() bool {
+	v := getEnv(flyteLocalDevEnvName)
+	if v == "" {
+		return false
+	}
+	localDev, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Warn().Msgf("%s is not a valid boolean value: %q, local development mode is disabled", flyteLocalDevEnvName, v)
+		return false
+	}
+	return localDev
+}
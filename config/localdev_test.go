@@ -0,0 +1,45 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestShouldDefaultTheFlyteApiUrlInLocalDevMode(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteLocalDevEnvName, "true")
+
+	assert.True(t, LocalDev())
+	assert.Equal(t, LocalDevApiURL, FromEnvironment().FlyteApiUrl.String())
+}
+
+func TestShouldNotBeInLocalDevModeUnlessEnabled(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	assert.False(t, LocalDev())
+	setEnv(flyteLocalDevEnvName, "false")
+	assert.False(t, LocalDev())
+	setEnv(flyteLocalDevEnvName, "yes please")
+	assert.False(t, LocalDev())
+}
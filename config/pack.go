@@ -0,0 +1,77 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"github.com/rs/zerolog"
+	"strconv"
+	"time"
+)
+
+const (
+	flytePollingFrequencyEnvName = "FLYTE_POLLING_FREQUENCY"
+	flyteConcurrencyEnvName      = "FLYTE_CONCURRENCY"
+	flyteLogLevelEnvName         = "FLYTE_LOG_LEVEL"
+)
+
+// the least time the pack waits between polls for actions
+const minPollingFrequency = 500 * time.Millisecond
+
+// readPackSettings reads how often the pack polls for actions, how many actions it handles at once and the log level,
+// leaving them zero where they are not set, and adding any problems with them to p
+func (e *environment) readPackSettings(v *Values, p *problems) {
+	var err error
+	v.PollingFrequency, err = e.getDuration(flytePollingFrequencyEnvName)
+	p.add(err)
+	if v.PollingFrequency > 0 && v.PollingFrequency < minPollingFrequency {
+		p.add(fmt.Errorf("%s must be at least %v, not %v", e.name(flytePollingFrequencyEnvName), minPollingFrequency, v.PollingFrequency))
+	}
+	if concurrency := e.get(flyteConcurrencyEnvName); concurrency != "" {
+		if v.Concurrency, err = strconv.Atoi(concurrency); err != nil {
+			p.add(fmt.Errorf("%s is an invalid integer value: %w", e.name(flyteConcurrencyEnvName), err))
+		} else if v.Concurrency < 1 {
+			p.add(fmt.Errorf("%s has been set to an invalid value: %v", e.name(flyteConcurrencyEnvName), v.Concurrency))
+		}
+	}
+	if v.LogLevel = e.get(flyteLogLevelEnvName); v.LogLevel != "" {
+		if _, err := zerolog.ParseLevel(v.LogLevel); err != nil {
+			p.add(fmt.Errorf("%s has been set to an invalid log level: %q", e.name(flyteLogLevelEnvName), v.LogLevel))
+		}
+	}
+}
+
+// reads a duration from the environment variable, either a number of seconds or a duration string such as "1m30s",
+// returning zero if it is not set
+func (e *environment) getDuration(name string) (time.Duration, error) {
+	value := e.get(name)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("%s is not a number of seconds or a duration: %q", e.name(name), value)
+		}
+		d = time.Duration(seconds) * time.Second
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%s has been set to an invalid value: %v", e.name(name), value)
+	}
+	return d, nil
+}
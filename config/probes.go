@@ -0,0 +1,92 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	probesPortDefault          = "8091"
+	livenessPathDefault        = "/live"
+	readinessPathDefault       = "/ready"
+	flyteProbesPortEnvName     = "FLYTE_PROBES_PORT"
+	flyteLivenessPathEnvName   = "FLYTE_PROBES_LIVENESS_PATH"
+	flyteReadinessPathEnvName  = "FLYTE_PROBES_READINESS_PATH"
+	flyteProbesMaxInFlightName = "FLYTE_PROBES_MAX_IN_FLIGHT"
+)
+
+// Probes configures the pack liveness and readiness endpoints.
+type Probes struct {
+	Port          string // the port the endpoints are served on
+	LivenessPath  string // the path of the liveness endpoint
+	ReadinessPath string // the path of the readiness endpoint
+	MaxInFlight   int    // the pack is not ready while this many actions are being handled. Zero means no limit
+}
+
+// returns the liveness and readiness endpoint settings from the environment, using defaults for any that are not set,
+// or an error if any of them are invalid
+func ReadProbes() (Probes, error) {
+	return Env{}.ReadProbes()
+}
+
+func (e *environment) readProbes() (Probes, error) {
+	maxInFlight, err := e.getMaxInFlight()
+	if err != nil {
+		return Probes{}, err
+	}
+	return Probes{
+		Port:          e.getEnvOrDefault(flyteProbesPortEnvName, probesPortDefault),
+		LivenessPath:  e.getPath(flyteLivenessPathEnvName, livenessPathDefault),
+		ReadinessPath: e.getPath(flyteReadinessPathEnvName, readinessPathDefault),
+		MaxInFlight:   maxInFlight,
+	}, nil
+}
+
+func (e *environment) getEnvOrDefault(name, defaultValue string) string {
+	if v := e.get(name); v != "" {
+		return v
+	}
+	return defaultValue
+}
+
+// gets a url path from the environment, making sure it starts with a "/"
+func (e *environment) getPath(name, defaultValue string) string {
+	p := e.getEnvOrDefault(name, defaultValue)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return p
+}
+
+func (e *environment) getMaxInFlight() (int, error) {
+	maxInFlight := e.get(flyteProbesMaxInFlightName)
+	if maxInFlight == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(maxInFlight)
+	if err != nil {
+		return 0, fmt.Errorf("%s is an invalid integer value: %w", e.name(flyteProbesMaxInFlightName), err)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%s has been set to an invalid value: %v", e.name(flyteProbesMaxInFlightName), n)
+	}
+	return n, nil
+}
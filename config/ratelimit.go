@@ -0,0 +1,48 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	flyteApiRateLimitEnvName      = "FLYTE_API_RATE_LIMIT"
+	flyteApiRateLimitBurstEnvName = "FLYTE_API_RATE_LIMIT_BURST"
+)
+
+// readRateLimit reads the requests per second the client is limited to, and the burst of requests allowed over that,
+// leaving them zero where they are not set, and adding any problems with them to p
+func (e *environment) readRateLimit(v *Values, p *problems) {
+	if rps := e.get(flyteApiRateLimitEnvName); rps != "" {
+		var err error
+		if v.RateLimit, err = strconv.ParseFloat(rps, 64); err != nil {
+			p.add(fmt.Errorf("%s is an invalid number: %q", e.name(flyteApiRateLimitEnvName), rps))
+		} else if v.RateLimit < 0 {
+			p.add(fmt.Errorf("%s has been set to an invalid value: %v", e.name(flyteApiRateLimitEnvName), rps))
+		}
+	}
+	if burst := e.get(flyteApiRateLimitBurstEnvName); burst != "" {
+		var err error
+		if v.RateLimitBurst, err = strconv.Atoi(burst); err != nil {
+			p.add(fmt.Errorf("%s is an invalid integer value: %w", e.name(flyteApiRateLimitBurstEnvName), err))
+		} else if v.RateLimitBurst < 0 {
+			p.add(fmt.Errorf("%s has been set to an invalid value: %v", e.name(flyteApiRateLimitBurstEnvName), v.RateLimitBurst))
+		}
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"time"
+)
+
+const (
+	flyteApiDialTimeoutEnvName           = "FLYTE_API_DIAL_TIMEOUT"
+	flyteApiTLSHandshakeTimeoutEnvName   = "FLYTE_API_TLS_HANDSHAKE_TIMEOUT"
+	flyteApiResponseHeaderTimeoutEnvName = "FLYTE_API_RESPONSE_HEADER_TIMEOUT"
+	flyteApiIdleConnTimeoutEnvName       = "FLYTE_API_IDLE_CONN_TIMEOUT"
+)
+
+// readTimeouts reads the api timeout and the timeouts of the stages of a request, in seconds. When any of the stage
+// timeouts is set they replace the overall request timeout, which then defaults to no timeout rather than 10 seconds,
+// so long requests are not cut short while dead connections are still noticed. Any problems with them are added to p.
+func (e *environment) readTimeouts(v *Values, p *problems) {
+	stages := []struct {
+		name    string
+		timeout *time.Duration
+	}{
+		{flyteApiDialTimeoutEnvName, &v.DialTimeout},
+		{flyteApiTLSHandshakeTimeoutEnvName, &v.TLSHandshakeTimeout},
+		{flyteApiResponseHeaderTimeoutEnvName, &v.ResponseHeaderTimeout},
+		{flyteApiIdleConnTimeoutEnvName, &v.IdleConnTimeout},
+	}
+	defaultTimeout := apiTimeoutOutDefault
+	for _, s := range stages {
+		timeout, set, err := e.getSeconds(s.name)
+		p.add(err)
+		if set {
+			*s.timeout = timeout
+			defaultTimeout = 0
+		}
+	}
+
+	var err error
+	v.Timeout, err = e.getApiTimeOut(defaultTimeout)
+	p.add(err)
+}
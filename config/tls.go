@@ -0,0 +1,69 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+const (
+	flyteApiCACertEnvName     = "FLYTE_API_CA_CERT"
+	flyteApiClientCertEnvName = "FLYTE_API_CLIENT_CERT"
+	flyteApiClientKeyEnvName  = "FLYTE_API_CLIENT_KEY"
+)
+
+// readTLS reads the paths of the PEM files the flyte api certificate is verified with and the client authenticates
+// with, and loads them into the TLS config. The client certificate and key must be set together. Any problems with
+// them are added to p.
+func (e *environment) readTLS(v *Values, p *problems) {
+	v.CACertFile = e.get(flyteApiCACertEnvName)
+	v.ClientCertFile = e.get(flyteApiClientCertEnvName)
+	v.ClientKeyFile = e.get(flyteApiClientKeyEnvName)
+	if v.CACertFile == "" && v.ClientCertFile == "" && v.ClientKeyFile == "" {
+		return
+	}
+
+	cfg := &tls.Config{}
+	valid := true
+	if v.CACertFile != "" {
+		pem, err := ioutil.ReadFile(v.CACertFile)
+		if err != nil {
+			p.add(fmt.Errorf("cannot read the %s file: %w", e.name(flyteApiCACertEnvName), err))
+			valid = false
+		} else if cfg.RootCAs = x509.NewCertPool(); !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			p.add(fmt.Errorf("%s file %s has no PEM encoded certificates", e.name(flyteApiCACertEnvName), v.CACertFile))
+			valid = false
+		}
+	}
+	if (v.ClientCertFile == "") != (v.ClientKeyFile == "") {
+		p.add(fmt.Errorf("%s and %s must both be set", e.name(flyteApiClientCertEnvName), flyteApiClientKeyEnvName))
+		valid = false
+	} else if v.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(v.ClientCertFile, v.ClientKeyFile)
+		if err != nil {
+			p.add(fmt.Errorf("cannot load the %s and %s files: %w", e.name(flyteApiClientCertEnvName), e.name(flyteApiClientKeyEnvName), err))
+			valid = false
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if valid {
+		v.TLSConfig = cfg
+	}
+}
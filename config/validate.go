@@ -0,0 +1,87 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Settings are all the flyte-client settings read from the environment and the config file.
+type Settings struct {
+	Values
+	Probes Probes
+}
+
+// Load reads and validates all the flyte-client settings up front, the settings of ReadEnvironment and ReadProbes,
+// so a misconfigured pack fails as it starts rather than at first use. Rather than stopping at the first problem, it
+// returns all of them in a *ValidationError.
+func Load() (Settings, error) {
+	return Env{}.Load()
+}
+
+// ValidationError is returned when settings are missing or invalid, with every problem found.
+type ValidationError struct {
+	Problems []error
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0].Error()
+	}
+	msgs := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		msgs[i] = p.Error()
+	}
+	return fmt.Sprintf("%d invalid flyte-client settings: %s", len(e.Problems), strings.Join(msgs, "; "))
+}
+
+// problems collects the problems found reading settings
+type problems []error
+
+func (p *problems) add(err error) {
+	if err != nil {
+		*p = append(*p, err)
+	}
+}
+
+// err returns the problems as a *ValidationError, or nil if there are none
+func (p problems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: p}
+}
+
+// checks that the url of the environment variable can be connected to: an http or https url with a host, or a
+// unix:///path/to/socket url
+func validateURL(name string, u *url.URL) error {
+	switch u.Scheme {
+	case "http", "https":
+		if u.Host == "" {
+			return fmt.Errorf("%s environment variable is not set to a URL with a host: %q", name, u.String())
+		}
+	case "unix":
+		if u.Path == "" {
+			return fmt.Errorf("%s environment variable is not set to a URL with a socket path: %q", name, u.String())
+		}
+	default:
+		return fmt.Errorf("%s environment variable is not set to an http, https or unix URL: %q", name, u.String())
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLoadShouldReadAllTheSettings(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "https://flyte.example.com")
+	setEnv(flyteApiTimeOutEnvName, "20")
+	setEnv(flyteProbesPortEnvName, "9000")
+
+	s, err := Load()
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://flyte.example.com", s.FlyteApiUrl.String())
+	assert.Equal(t, 20*time.Second, s.Timeout)
+	assert.Equal(t, "9000", s.Probes.Port)
+}
+
+func TestLoadShouldReturnAllTheProblemsAtOnce(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "flyte.example.com")
+	setEnv(flyteApiTimeOutEnvName, "-1")
+	setEnv(flyteConcurrencyEnvName, "0")
+	setEnv(flytePollingFrequencyEnvName, "100ms")
+	setEnv(flyteApiCACertEnvName, "/no/such/ca.pem")
+	setEnv(flyteProbesMaxInFlightName, "lots")
+
+	_, err := Load()
+
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	require.Len(t, verr.Problems, 6)
+	assert.EqualError(t, verr.Problems[0], `FLYTE_API environment variable is not set to an http, https or unix URL: "flyte.example.com"`)
+	assert.EqualError(t, verr.Problems[1], "FLYTE_API_TIMEOUT has been set to an invalid value: -1")
+	assert.Contains(t, verr.Problems[2].Error(), "cannot read the FLYTE_API_CA_CERT file")
+	assert.EqualError(t, verr.Problems[3], "FLYTE_POLLING_FREQUENCY must be at least 500ms, not 100ms")
+	assert.EqualError(t, verr.Problems[4], "FLYTE_CONCURRENCY has been set to an invalid value: 0")
+	assert.Contains(t, verr.Problems[5].Error(), "FLYTE_PROBES_MAX_IN_FLIGHT is an invalid integer value")
+	assert.Contains(t, err.Error(), "6 invalid flyte-client settings: FLYTE_API environment variable")
+}
+
+func TestReadEnvironmentShouldReturnAnErrorForAnApiUrlWithoutAHost(t *testing.T) {
+	defer restoreGetEnvFunc()
+	defer clearEnv()
+	initTestEnv()
+
+	setEnv(flyteApiEnvName, "https:///v1")
+
+	_, err := ReadEnvironment()
+
+	assert.EqualError(t, err, `FLYTE_API environment variable is not set to a URL with a host: "https:///v1"`)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// Test_Vectors runs every conformance vector against the go client.
+func Test_Vectors(t *testing.T) {
+	vectors, err := Load("vectors")
+	require.NoError(t, err)
+	require.NotEmpty(t, vectors)
+
+	for _, v := range vectors {
+		v := v
+		t.Run(v.Name, func(t *testing.T) {
+			s := NewServer(v)
+			defer s.Close()
+
+			u, err := url.Parse(s.URL)
+			require.NoError(t, err)
+			// each call makes a single exchange, so the client must not retry 5xx responses
+			c := client.NewClient(u, 5*time.Second, client.WithEventRetries(0, 0), client.WithActionResultRetries(0, 0))
+
+			for i, step := range v.Steps {
+				step.Input = s.Expand(step.Input)
+				result, err := call(c, step)
+				assert.NoError(t, checkError(step.Expect, err), "step %d (%s)", i, step.Call)
+				if step.Expect.Error == "" && step.Expect.Result != nil {
+					assert.NoError(t, MatchJSON(s.Expand(step.Expect.Result), result), "step %d (%s)", i, step.Call)
+				}
+			}
+			assert.Empty(t, s.Errors())
+		})
+	}
+}
+
+func Test_MatchJSON_ShouldIgnoreFieldsThatAreNotExpected(t *testing.T) {
+	assert.NoError(t, MatchJSON([]byte(`{"a":{"b":1}}`), []byte(`{"a":{"b":1,"c":2},"d":3}`)))
+}
+
+func Test_MatchJSON_ShouldReportThePathOfTheFirstMismatch(t *testing.T) {
+	err := MatchJSON([]byte(`{"a":[{"b":1}]}`), []byte(`{"a":[{"b":2}]}`))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "$.a[0].b")
+}
+
+func Test_Server_ShouldReportRequestsThatWereNotMade(t *testing.T) {
+	s := NewServer(Vector{Exchanges: []Exchange{{Request: Request{Method: "GET", Path: "/v1"}}}})
+	defer s.Close()
+
+	assert.Equal(t, []string{"expected request GET /v1 was not made"}, s.Errors())
+}
+
+// call makes the client call described by the step, returning its result as JSON
+func call(c client.Client, step Step) ([]byte, error) {
+	switch step.Call {
+	case CallCreatePack:
+		var p client.Pack
+		if err := json.Unmarshal(step.Input, &p); err != nil {
+			return nil, err
+		}
+		return nil, c.CreatePack(p)
+	case CallPostEvent:
+		var e client.Event
+		if err := json.Unmarshal(step.Input, &e); err != nil {
+			return nil, err
+		}
+		return nil, c.PostEvent(e)
+	case CallTakeAction:
+		a, err := c.TakeAction()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(a)
+	case CallCompleteAction:
+		var in struct {
+			Action client.Action `json:"action"`
+			Event  client.Event  `json:"event"`
+		}
+		if err := json.Unmarshal(step.Input, &in); err != nil {
+			return nil, err
+		}
+		return nil, c.CompleteAction(in.Action, in.Event)
+	default:
+		return nil, fmt.Errorf("unknown call %q", step.Call)
+	}
+}
+
+// checkError checks the error returned by a step is the kind of error expected
+func checkError(expect Expectation, err error) error {
+	var conflict client.ConflictError
+	var notFound client.NotFoundError
+	var response client.ResponseError
+
+	switch expect.Error {
+	case "":
+		return err
+	case ErrorConflict:
+		if errors.As(err, &conflict) {
+			return nil
+		}
+	case ErrorNotFound:
+		if errors.As(err, &notFound) {
+			return nil
+		}
+	case ErrorResponse:
+		if errors.As(err, &response) {
+			if response.StatusCode != expect.StatusCode {
+				return fmt.Errorf("expected status code %d, got %d", expect.StatusCode, response.StatusCode)
+			}
+			return nil
+		}
+	default:
+		return fmt.Errorf("unknown error kind %q", expect.Error)
+	}
+	return fmt.Errorf("expected a %s error, got %v", expect.Error, err)
+}
@@ -0,0 +1,262 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance loads the flyte client conformance test vectors and replays them over HTTP.
+//
+// The vectors themselves (in the vectors directory) are plain JSON so that flyte clients written in other languages
+// can be verified against the same flyte api contract. See the README in this directory for the format.
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ServerPlaceholder is replaced with the URL of the server replaying a vector wherever it appears in a response.
+const ServerPlaceholder = "{server}"
+
+// The client calls a vector step can make.
+const (
+	CallCreatePack     = "createPack"
+	CallPostEvent      = "postEvent"
+	CallTakeAction     = "takeAction"
+	CallCompleteAction = "completeAction"
+)
+
+// The kinds of error a vector step can expect. The Go client maps these onto its typed errors, other clients onto
+// their own equivalents.
+const (
+	ErrorConflict = "conflict" // the resource already exists, e.g. the pack is already registered
+	ErrorNotFound = "notFound" // the resource does not exist
+	ErrorResponse = "response" // the flyte api rejected the request; the status code is also checked
+)
+
+// Vector is a single conformance test case: the calls a client makes, and the HTTP exchanges the flyte api expects
+// as a result, in order.
+type Vector struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Steps       []Step     `json:"steps"`
+	Exchanges   []Exchange `json:"exchanges"`
+}
+
+// Step is a call made on the client under test, and what it is expected to return.
+type Step struct {
+	Call   string          `json:"call"`
+	Input  json.RawMessage `json:"input,omitempty"`
+	Expect Expectation     `json:"expect"`
+}
+
+// Expectation describes the outcome of a step. An empty Error means the call is expected to succeed.
+type Expectation struct {
+	Error      string          `json:"error,omitempty"`      // one of the Error kinds
+	StatusCode int             `json:"statusCode,omitempty"` // the status code of a response error
+	Result     json.RawMessage `json:"result,omitempty"`     // the expected result, matched as a subset of the actual result
+}
+
+// Exchange is a request the client is expected to make, and the response the flyte api returns.
+type Exchange struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request describes an expected request. Headers and Body are matched as subsets, so fields the client fills in
+// itself (such as event timestamps) can be left out.
+type Request struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Response describes the response to a request. A string Body is written as is, any other Body is written as JSON.
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Load reads every vector from the JSON files in the directory passed in. Each file holds an array of vectors, and
+// the vectors are returned in file name order.
+func Load(dir string) ([]Vector, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var vectors []Vector
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var vs []Vector
+		if err := json.Unmarshal(b, &vs); err != nil {
+			return nil, fmt.Errorf("cannot read vectors from %s: %v", f, err)
+		}
+		vectors = append(vectors, vs...)
+	}
+	return vectors, nil
+}
+
+// Server replays the exchanges of a vector, recording any request that does not match the one expected.
+type Server struct {
+	URL string
+
+	server    *httptest.Server
+	exchanges []Exchange
+	mu        sync.Mutex
+	next      int
+	errs      []string
+}
+
+// NewServer starts a server replaying the exchanges of the vector passed in. It should be closed once done with.
+func NewServer(v Vector) *Server {
+	s := &Server{exchanges: v.Exchanges}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = s.server.URL
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Errors returns the mismatches found so far, including any expected requests that have not been made.
+func (s *Server) Errors() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	errs := append([]string(nil), s.errs...)
+	for _, e := range s.exchanges[s.next:] {
+		errs = append(errs, fmt.Sprintf("expected request %s %s was not made", e.Request.Method, e.Request.Path))
+	}
+	return errs
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	if s.next >= len(s.exchanges) {
+		s.errs = append(s.errs, fmt.Sprintf("unexpected request %s %s", r.Method, r.URL.Path))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	e := s.exchanges[s.next]
+	s.next++
+
+	s.errs = append(s.errs, s.mismatches(e.Request, r, body)...)
+	for k, v := range e.Response.Headers {
+		w.Header().Set(k, s.expand(v))
+	}
+	w.WriteHeader(e.Response.Status)
+	w.Write(s.responseBody(e.Response.Body))
+}
+
+func (s *Server) mismatches(expected Request, r *http.Request, body []byte) []string {
+	var errs []string
+	if expected.Method != r.Method || expected.Path != r.URL.Path {
+		errs = append(errs, fmt.Sprintf("expected request %s %s, got %s %s", expected.Method, expected.Path, r.Method, r.URL.Path))
+	}
+	for k, v := range expected.Headers {
+		if got := r.Header.Get(k); got != s.expand(v) {
+			errs = append(errs, fmt.Sprintf("%s %s: expected header %s %q, got %q", r.Method, r.URL.Path, k, v, got))
+		}
+	}
+	if len(expected.Body) > 0 {
+		if err := MatchJSON([]byte(s.expand(string(expected.Body))), body); err != nil {
+			errs = append(errs, fmt.Sprintf("%s %s: %v", r.Method, r.URL.Path, err))
+		}
+	}
+	return errs
+}
+
+func (s *Server) responseBody(raw json.RawMessage) []byte {
+	if len(raw) == 0 {
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return []byte(s.expand(text))
+	}
+	return []byte(s.expand(string(raw)))
+}
+
+// Expand replaces the server placeholder in the JSON passed in, e.g. in the input or expected result of a step.
+func (s *Server) Expand(raw json.RawMessage) json.RawMessage {
+	return json.RawMessage(s.expand(string(raw)))
+}
+
+func (s *Server) expand(v string) string {
+	return strings.Replace(v, ServerPlaceholder, s.URL, -1)
+}
+
+// MatchJSON checks that the actual JSON document contains the expected one: objects must have every expected field,
+// with a matching value, but may have others; arrays must have the same length and matching elements.
+func MatchJSON(expected, actual []byte) error {
+	var e, a interface{}
+	if err := json.Unmarshal(expected, &e); err != nil {
+		return fmt.Errorf("invalid expected JSON: %v", err)
+	}
+	if err := json.Unmarshal(actual, &a); err != nil {
+		return fmt.Errorf("invalid JSON %q: %v", actual, err)
+	}
+	return match("$", e, a)
+}
+
+func match(path string, expected, actual interface{}) error {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %v", path, actual)
+		}
+		for k, v := range e {
+			if err := match(path+"."+k, v, a[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(e) {
+			return fmt.Errorf("%s: expected %v, got %v", path, expected, actual)
+		}
+		for i := range e {
+			if err := match(fmt.Sprintf("%s[%d]", path, i), e[i], a[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		if !reflect.DeepEqual(expected, actual) {
+			return fmt.Errorf("%s: expected %v, got %v", path, expected, actual)
+		}
+		return nil
+	}
+}
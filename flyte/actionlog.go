@@ -0,0 +1,273 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/rs/zerolog/log"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const actionLogFile = "actions.wal"
+
+// WithActionLog gives the pack at-least-once action handling. Each action taken from the flyte server is written to a
+// write-ahead log in dir before its handler is invoked, and marked as done once its result has been posted (or queued,
+// see WithResultQueue). When the pack starts, actions that were taken but never completed - because the process
+// crashed or was killed while handling them, or their results could be neither posted nor queued - are handled again
+// before any new action is taken. Handlers should be idempotent, as an action whose result was posted just before a
+// crash is handled twice, and the flyte server may reject its second result. Each pack must have its own dir.
+func WithActionLog(dir string) Option {
+	return func(p *pack) {
+		p.actions = &actionLog{path: filepath.Join(dir, actionLogFile), pending: make(map[*client.Action]uint64)}
+	}
+}
+
+// actionLog is an append only log of the actions taken and completed by the pack
+type actionLog struct {
+	path    string
+	mu      sync.Mutex
+	f       *os.File
+	seq     uint64
+	pending map[*client.Action]uint64 // the sequence numbers of the logged actions that have not been completed
+}
+
+// actionRecord is a line of the action log. An action that has been taken is logged with its sequence number, and
+// once it has been completed the sequence number is logged as done.
+type actionRecord struct {
+	Seq    uint64         `json:"seq"`
+	Action *client.Action `json:"action,omitempty"`
+	Done   bool           `json:"done,omitempty"`
+}
+
+// open reads the log, returning the actions that were taken but not completed in the order they were taken, and
+// opens it for appending. The log is compacted so it only holds the actions returned. A nil log has no actions.
+func (l *actionLog) open() ([]*client.Action, error) {
+	if l == nil {
+		return nil, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	records, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	if err := l.compact(records); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open action log: %w", err)
+	}
+	l.f = f
+
+	actions := make([]*client.Action, 0, len(records))
+	for _, r := range records {
+		l.pending[r.Action] = r.Seq
+		actions = append(actions, r.Action)
+	}
+	return actions, nil
+}
+
+// read returns the records of the actions in the log that have not been completed, in the order they were taken,
+// and moves the sequence number past those in the log. A line that cannot be read, such as one left half written by
+// a crash, is skipped.
+func (l *actionLog) read() ([]actionRecord, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read action log: %w", err)
+	}
+	defer f.Close()
+
+	taken := make(map[uint64]actionRecord)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		var r actionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			log.Warn().Err(err).Msgf("skipping invalid line in action log %q", l.path)
+			continue
+		}
+		if r.Seq > l.seq {
+			l.seq = r.Seq
+		}
+		if r.Done {
+			delete(taken, r.Seq)
+		} else if r.Action != nil {
+			taken[r.Seq] = r
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read action log: %w", err)
+	}
+
+	records := make([]actionRecord, 0, len(taken))
+	for _, r := range taken {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Seq < records[j].Seq })
+	return records, nil
+}
+
+// compact replaces the log with one holding just the records passed in. The new log is written under a temporary
+// name and then renamed, so a crash cannot lose the actions in the log.
+func (l *actionLog) compact(records []actionRecord) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("cannot create action log dir: %w", err)
+	}
+	f, err := ioutil.TempFile(filepath.Dir(l.path), actionLogFile+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot compact action log: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err = enc.Encode(r); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), l.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("cannot compact action log: %w", err)
+	}
+	return nil
+}
+
+// taken logs the action before it is handled, syncing the log to disk. Actions read from the log when it was opened
+// are already logged.
+func (l *actionLog) taken(a *client.Action) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.pending[a]; ok || l.f == nil {
+		return
+	}
+	l.seq++
+	if err := l.append(actionRecord{Seq: l.seq, Action: a}, true); err != nil {
+		log.Err(err).Msgf("cannot log action %q, it will not be handled again if the pack crashes", a.ID)
+		return
+	}
+	l.pending[a] = l.seq
+}
+
+// done logs the action as completed. An action completed once the log has been closed stays in the log, and is
+// handled again when the pack next starts.
+func (l *actionLog) done(a *client.Action) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	seq, ok := l.pending[a]
+	if !ok || l.f == nil {
+		return
+	}
+	delete(l.pending, a)
+	// the record is not synced, as losing it only means the action is handled again
+	if err := l.append(actionRecord{Seq: seq, Done: true}, false); err != nil {
+		log.Err(err).Msgf("cannot log action %q as done, it will be handled again when the pack restarts", a.ID)
+	}
+}
+
+// abandoned stops tracking the action, whose result could not be posted, without logging it as done, so it is
+// handled again when the pack next starts
+func (l *actionLog) abandoned(a *client.Action) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.pending[a]; !ok {
+		return
+	}
+	delete(l.pending, a)
+	log.Warn().Msgf("the result of action %q was abandoned, it will be handled again when the pack restarts", a.ID)
+}
+
+// append writes the record to the log, syncing it to disk if asked to. must be called with the lock held
+func (l *actionLog) append(r actionRecord, sync bool) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := l.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	if sync {
+		return l.f.Sync()
+	}
+	return nil
+}
+
+// close closes the log
+func (l *actionLog) close() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return
+	}
+	if err := l.f.Close(); err != nil {
+		log.Err(err).Msgf("cannot close action log %q", l.path)
+	}
+	l.f = nil
+}
+
+// redriveActions handles the actions that were taken but not completed by previous runs of the pack, returning false
+// if the pack is stopped while they are handed to the workers
+func (p pack) redriveActions(handlers map[string]ContextHandler) bool {
+	actions, err := p.actions.open()
+	if err != nil {
+		log.Err(err).Msg("cannot open the action log, actions will not be handled again if the pack crashes")
+		return true
+	}
+	for _, a := range actions {
+		if !p.workers.acquire(p.lifecycle.Done()) {
+			return false
+		}
+		log.Info().Str("command", a.CommandName).Msgf("handling action %q again, it was not completed before the pack last stopped", a.ID)
+		p.counters.add(actionsRedriven)
+		p.takeAction(a, handlers)
+	}
+	return true
+}
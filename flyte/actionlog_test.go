@@ -0,0 +1,192 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestActionLog(dir string) *actionLog {
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, MockClient{}, WithActionLog(dir)).(pack)
+	return p.actions
+}
+
+func Test_ActionLog_ShouldReturnTheActionsThatWereNotCompleted(t *testing.T) {
+	dir := tempDir(t)
+	l := newTestActionLog(dir)
+	actions, err := l.open()
+	require.NoError(t, err)
+	assert.Empty(t, actions)
+
+	// given three actions are taken and one is completed before the pack crashes
+	a1 := &client.Action{ID: "1", CommandName: "SendMessage", Input: json.RawMessage(`{"text":"hi"}`)}
+	a2 := &client.Action{ID: "2", CommandName: "SendMessage"}
+	a3 := &client.Action{ID: "3", CommandName: "SendMessage"}
+	l.taken(a1)
+	l.taken(a2)
+	l.taken(a3)
+	l.done(a2)
+	l.close()
+
+	// when the log is opened again
+	next := newTestActionLog(dir)
+	actions, err = next.open()
+	require.NoError(t, err)
+
+	// then the actions that were not completed are returned, in the order they were taken
+	require.Len(t, actions, 2)
+	assert.Equal(t, "1", actions[0].ID)
+	assert.JSONEq(t, `{"text":"hi"}`, string(actions[0].Input))
+	assert.Equal(t, "3", actions[1].ID)
+
+	// and they are not logged again when they are taken
+	next.taken(actions[0])
+	next.done(actions[0])
+	next.done(actions[1])
+	next.close()
+	actions, err = newTestActionLog(dir).open()
+	require.NoError(t, err)
+	assert.Empty(t, actions)
+}
+
+func Test_ActionLog_ShouldCompactTheLogWhenItIsOpened(t *testing.T) {
+	dir := tempDir(t)
+	l := newTestActionLog(dir)
+	_, err := l.open()
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		a := &client.Action{CommandName: "SendMessage"}
+		l.taken(a)
+		l.done(a)
+	}
+	pending := &client.Action{ID: "pending", CommandName: "SendMessage"}
+	l.taken(pending)
+	l.close()
+
+	_, err = newTestActionLog(dir).open()
+	require.NoError(t, err)
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, actionLogFile))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Len(t, lines, 1)
+	assert.JSONEq(t, `{"seq": 11, "action": {"id": "pending", "command": "SendMessage", "input": null, "links": null}}`, lines[0])
+}
+
+func Test_ActionLog_ShouldSkipALineLeftHalfWrittenByACrash(t *testing.T) {
+	dir := tempDir(t)
+	content := `{"seq":1,"action":{"id":"1","command":"SendMessage"}}` + "\n" + `{"seq":2,"action":{"id":"2","comm`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, actionLogFile), []byte(content), 0600))
+
+	actions, err := newTestActionLog(dir).open()
+
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	assert.Equal(t, "1", actions[0].ID)
+}
+
+func Test_ActionLog_ShouldHandleTheActionsThatWereNotCompletedWhenThePackStarts(t *testing.T) {
+	// given an action taken by a run of the pack that crashed
+	dir := tempDir(t)
+	crashed := newTestActionLog(dir)
+	_, err := crashed.open()
+	require.NoError(t, err)
+	crashed.taken(&client.Action{ID: "1", CommandName: "SendMessage", Input: json.RawMessage(`"hi"`)})
+	crashed.close()
+
+	completed := make(chan client.Action, 1)
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, MockClient{
+		completeAction: func(a client.Action, _ client.Event) error {
+			completed <- a
+			return nil
+		},
+	}, WithActionLog(dir)).(pack)
+	handlers := map[string]ContextHandler{"SendMessage": func(_ context.Context, input json.RawMessage) Event {
+		return Event{EventDef: EventDef{Name: "MessageSent"}, Payload: input}
+	}}
+
+	// when
+	assert.True(t, p.redriveActions(handlers))
+
+	// then the action is handled again
+	select {
+	case a := <-completed:
+		assert.Equal(t, "1", a.ID)
+	case <-time.After(time.Second):
+		t.Fatal("the action was not handled again")
+	}
+	assert.Equal(t, uint64(1), p.counters.snapshot().ActionsRedriven)
+
+	// and once it is done, it is not handled again by the next run
+	assert.Eventually(t, func() bool {
+		p.actions.mu.Lock()
+		defer p.actions.mu.Unlock()
+		return len(p.actions.pending) == 0
+	}, time.Second, 5*time.Millisecond)
+	p.actions.close()
+	actions, err := newTestActionLog(dir).open()
+	require.NoError(t, err)
+	assert.Empty(t, actions)
+}
+
+func Test_ActionLog_ShouldKeepActionsWhoseResultWasAbandoned(t *testing.T) {
+	dir := tempDir(t)
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, MockClient{
+		completeAction: func(client.Action, client.Event) error {
+			return errors.New("the flyte api rejected the result")
+		},
+	}, WithActionLog(dir)).(pack)
+	_, err := p.actions.open()
+	require.NoError(t, err)
+	handlers := map[string]ContextHandler{"SendMessage": func(context.Context, json.RawMessage) Event {
+		return Event{EventDef: EventDef{Name: "MessageSent"}}
+	}}
+
+	// when the result of an action cannot be posted, nor queued
+	a := &client.Action{ID: "1", CommandName: "SendMessage"}
+	p.actions.taken(a)
+	p.handleAction(a, handlers)
+	p.actions.close()
+
+	// then the action is handled again when the pack next starts
+	actions, err := newTestActionLog(dir).open()
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	assert.Equal(t, "1", actions[0].ID)
+	assert.Empty(t, p.actions.pending)
+}
+
+func Test_ActionLog_ShouldCreateTheLogDir(t *testing.T) {
+	dir := filepath.Join(tempDir(t), "slack", "wal")
+
+	_, err := newTestActionLog(dir).open()
+
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, actionLogFile))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,161 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/rs/zerolog/log"
+	"sync"
+	"time"
+)
+
+// AggregationPolicy describes how events with a given name are accumulated and rolled up into a single event.
+type AggregationPolicy struct {
+	EventName  string                      // the name of the events to aggregate
+	Window     time.Duration               // how long events are accumulated before the rollup event is sent
+	Rollup     EventDef                    // the definition of the rollup event
+	Value      func(Event) (float64, bool) // optional. Extracts the numeric value used for the rollup min and max
+	MaxSamples int                         // the maximum number of event payloads kept as samples in the rollup
+}
+
+// RollupPayload is the payload of the rollup events sent by an Aggregator.
+type RollupPayload struct {
+	Count   int           `json:"count"`             // the number of events accumulated
+	Min     *float64      `json:"min,omitempty"`     // the minimum value, if the policy has a Value function
+	Max     *float64      `json:"max,omitempty"`     // the maximum value, if the policy has a Value function
+	Samples []interface{} `json:"samples,omitempty"` // the payloads of the first MaxSamples events
+	Start   time.Time     `json:"start"`             // the time the first event was accumulated
+	End     time.Time     `json:"end"`               // the time the rollup was produced
+}
+
+// Aggregator is an EventSender that accumulates events over a window and sends a single rollup event in their place.
+// This is useful for high frequency, telemetry style events. Events without a policy are sent straight through.
+// An Aggregator is safe for concurrent use.
+type Aggregator struct {
+	sender   EventSender
+	policies map[string]AggregationPolicy
+	mu       sync.Mutex
+	pending  map[string]*rollup
+	stopped  bool
+}
+
+type rollup struct {
+	payload RollupPayload
+	timer   *time.Timer
+}
+
+// NewAggregator creates an Aggregator that sends rollup events through the sender passed in.
+func NewAggregator(sender EventSender, policies ...AggregationPolicy) *Aggregator {
+	a := &Aggregator{
+		sender:   sender,
+		policies: make(map[string]AggregationPolicy, len(policies)),
+		pending:  make(map[string]*rollup),
+	}
+	for _, p := range policies {
+		a.policies[p.EventName] = p
+	}
+	return a
+}
+
+// SendEvent accumulates the event into the current rollup for its name. The first event accumulated starts the
+// window, and the rollup event is sent once the window has passed.
+func (a *Aggregator) SendEvent(e Event) error {
+	policy, ok := a.policies[e.EventDef.Name]
+	if !ok {
+		return a.sender.SendEvent(e)
+	}
+
+	a.mu.Lock()
+	if a.stopped {
+		// sent without holding the lock, so other senders are not held up by the request
+		a.mu.Unlock()
+		return a.sender.SendEvent(e)
+	}
+
+	r, ok := a.pending[policy.EventName]
+	if !ok {
+		r = &rollup{payload: RollupPayload{Start: time.Now()}}
+		r.timer = time.AfterFunc(policy.Window, func() { a.flush(policy.EventName) })
+		a.pending[policy.EventName] = r
+	}
+	r.add(policy, e)
+	a.mu.Unlock()
+	return nil
+}
+
+// Flush sends all pending rollup events immediately.
+func (a *Aggregator) Flush() {
+	a.mu.Lock()
+	names := make([]string, 0, len(a.pending))
+	for name := range a.pending {
+		names = append(names, name)
+	}
+	a.mu.Unlock()
+
+	for _, name := range names {
+		a.flush(name)
+	}
+}
+
+// Stop flushes all pending rollup events. Events sent after the aggregator has been stopped are sent straight through.
+func (a *Aggregator) Stop() {
+	a.mu.Lock()
+	a.stopped = true
+	a.mu.Unlock()
+	a.Flush()
+}
+
+// flush sends the pending rollup event for the event name, if there is one
+func (a *Aggregator) flush(name string) {
+	a.mu.Lock()
+	r, ok := a.pending[name]
+	if ok {
+		delete(a.pending, name)
+		r.timer.Stop()
+	}
+	a.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	policy := a.policies[name]
+	r.payload.End = time.Now()
+	if err := a.sender.SendEvent(Event{EventDef: policy.Rollup, Payload: r.payload}); err != nil {
+		log.Err(err).Msgf("could not send %q rollup of %d %q event(s)", policy.Rollup.Name, r.payload.Count, name)
+	}
+}
+
+func (r *rollup) add(policy AggregationPolicy, e Event) {
+	r.payload.Count++
+	if len(r.payload.Samples) < policy.MaxSamples {
+		r.payload.Samples = append(r.payload.Samples, e.Payload)
+	}
+	if policy.Value == nil {
+		return
+	}
+	v, ok := policy.Value(e)
+	if !ok {
+		return
+	}
+	if r.payload.Min == nil || v < *r.payload.Min {
+		min := v
+		r.payload.Min = &min
+	}
+	if r.payload.Max == nil || v > *r.payload.Max {
+		max := v
+		r.payload.Max = &max
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func Test_Aggregator_ShouldSendRollupEventAfterWindow(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+
+	a := NewAggregator(h, AggregationPolicy{
+		EventName:  "CpuSample",
+		Window:     20 * time.Millisecond,
+		Rollup:     EventDef{Name: "CpuRollup"},
+		Value:      func(e Event) (float64, bool) { return e.Payload.(float64), true },
+		MaxSamples: 2,
+	})
+
+	for _, v := range []float64{0.5, 0.9, 0.1} {
+		require.NoError(t, a.SendEvent(Event{EventDef: EventDef{Name: "CpuSample"}, Payload: v}))
+	}
+	assert.Empty(t, h.payloads(), "events should be accumulated until the window has passed")
+
+	time.Sleep(50 * time.Millisecond)
+
+	payloads := h.payloads()
+	require.Len(t, payloads, 1)
+	rollup := payloads[0].(RollupPayload)
+	assert.Equal(t, 3, rollup.Count)
+	assert.Equal(t, 0.1, *rollup.Min)
+	assert.Equal(t, 0.9, *rollup.Max)
+	assert.Equal(t, []interface{}{0.5, 0.9}, rollup.Samples)
+	assert.False(t, rollup.End.Before(rollup.Start))
+}
+
+func Test_Aggregator_ShouldSendEventsWithoutPolicyStraightThrough(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+
+	a := NewAggregator(h, AggregationPolicy{EventName: "CpuSample", Window: time.Hour})
+
+	require.NoError(t, a.SendEvent(Event{EventDef: EventDef{Name: "BuildSuccess"}, Payload: "build"}))
+
+	assert.Equal(t, []interface{}{"build"}, h.payloads())
+}
+
+func Test_Aggregator_ShouldFlushPendingRollupsOnStop(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+
+	a := NewAggregator(h, AggregationPolicy{EventName: "CpuSample", Window: time.Hour, Rollup: EventDef{Name: "CpuRollup"}})
+	a.SendEvent(Event{EventDef: EventDef{Name: "CpuSample"}, Payload: 1.0})
+	a.SendEvent(Event{EventDef: EventDef{Name: "CpuSample"}, Payload: 2.0})
+
+	a.Stop()
+
+	payloads := h.payloads()
+	require.Len(t, payloads, 1)
+	rollup := payloads[0].(RollupPayload)
+	assert.Equal(t, 2, rollup.Count)
+	assert.Nil(t, rollup.Min)
+	assert.Empty(t, rollup.Samples)
+
+	// once stopped, events are no longer aggregated
+	a.SendEvent(Event{EventDef: EventDef{Name: "CpuSample"}, Payload: 3.0})
+	assert.Len(t, h.payloads(), 2)
+}
+
+// reentrantSender sends the events it is given back through the aggregator, once
+type reentrantSender struct {
+	aggregator **Aggregator
+	sent       chan Event
+}
+
+func (s reentrantSender) SendEvent(e Event) error {
+	if e.Payload == "again" {
+		s.sent <- e
+		return nil
+	}
+	return (*s.aggregator).SendEvent(Event{EventDef: e.EventDef, Payload: "again"})
+}
+
+func Test_Aggregator_ShouldNotHoldItsLockWhileSendingEventsOnceStopped(t *testing.T) {
+	var a *Aggregator
+	sender := reentrantSender{aggregator: &a, sent: make(chan Event, 1)}
+	a = NewAggregator(sender, AggregationPolicy{EventName: "CpuSample", Window: time.Hour})
+	a.Stop()
+
+	go a.SendEvent(Event{EventDef: EventDef{Name: "CpuSample"}, Payload: 1.0})
+
+	select {
+	case e := <-sender.sent:
+		assert.Equal(t, "again", e.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("the aggregator deadlocked sending an event once stopped")
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"math/rand"
+	"time"
+)
+
+// backoff doubles the base duration for each consecutive failure, up to the max duration
+func backoff(base, max time.Duration, failures int) time.Duration {
+	d := base
+	for i := 0; i < failures && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// withJitter randomly adjusts the duration by up to +/- the fraction passed in, e.g. a fraction of 0.1 on a duration
+// of 10 seconds returns a duration between 9 and 11 seconds. The duration is returned unchanged in deterministic mode
+// (see SetDeterministic)
+func withJitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || d <= 0 || jitterDisabled() {
+		return d
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	delta := float64(d) * fraction
+	return d + time.Duration(delta*(2*rand.Float64()-1))
+}
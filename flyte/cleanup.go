@@ -0,0 +1,163 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"sync"
+)
+
+// ErrNotActionContext is returned by AddCleanup when the context passed in is not that of an action being handled.
+var ErrNotActionContext = errors.New("the context is not the context of an action")
+
+// AddCleanup registers a function that cleans up a resource used to handle an action, such as a temporary file, a
+// child process or a lease. The context must be the one passed to a ContextHandler, or one derived from it.
+//
+// Cleanup functions are guaranteed to run once the action has finished, however it finished: after the handler has
+// returned and the action has been completed, after the handler has panicked, or when the pack is stopped and the
+// drain timeout expires while the handler is still running (see WithDrainTimeout), in which case the context is
+// cancelled as well. They run in the reverse order to the one they were registered in. Errors (and panics) from
+// cleanup functions are logged and counted (see Stats.CleanupsFailed). A cleanup function registered once the action
+// has already finished, e.g. from a goroutine the handler started, runs straight away.
+func AddCleanup(ctx context.Context, cleanup func() error) error {
+	s, ok := ctx.Value(actionScopeKey{}).(*actionScope)
+	if !ok {
+		return ErrNotActionContext
+	}
+	s.mu.Lock()
+	if !s.finished {
+		s.cleanups = append(s.cleanups, cleanup)
+		s.mu.Unlock()
+		return nil
+	}
+	s.mu.Unlock()
+	s.run(cleanup)
+	return nil
+}
+
+type actionScopeKey struct{}
+
+// actionScope holds the cleanup functions registered while an action is handled
+type actionScope struct {
+	command  string
+	cancel   context.CancelFunc
+	failed   func(command string)
+	scopes   *actionScopes
+	mu       sync.Mutex
+	cleanups []func() error
+	finished bool
+}
+
+// newActionScope returns a context for handling the action, derived from the one passed in, and the scope whose finish
+// method must be called once the action has finished
+func (p pack) newActionScope(ctx context.Context, command string) (context.Context, *actionScope) {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &actionScope{
+		command: command,
+		cancel:  cancel,
+		scopes:  p.scopes,
+		failed: func(command string) {
+			p.counters.add(cleanupsFailed)
+			p.metrics.cleanupFailed(command)
+		},
+	}
+	p.scopes.add(s)
+	return context.WithValue(ctx, actionScopeKey{}, s), s
+}
+
+// finish cancels the action context and runs the cleanup functions, if they have not already been run
+func (s *actionScope) finish() {
+	s.mu.Lock()
+	if s.finished {
+		s.mu.Unlock()
+		return
+	}
+	s.finished = true
+	cleanups := s.cleanups
+	s.cleanups = nil
+	s.mu.Unlock()
+
+	s.scopes.remove(s)
+	s.cancel()
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		s.run(cleanups[i])
+	}
+}
+
+// run runs the cleanup function, logging and counting it if it fails
+func (s *actionScope) run(cleanup func() error) {
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("cleanup panicked: %v", r)
+			}
+		}()
+		return cleanup()
+	}()
+	if err != nil {
+		s.failed(s.command)
+		log.Err(err).Msgf("could not clean up after handling an action for command %q", s.command)
+	}
+}
+
+// actionScopes tracks the scopes of the actions being handled, so their cleanup functions can be run if the pack is
+// stopped while they are still being handled. It is shared by all copies of a pack.
+type actionScopes struct {
+	mu     sync.Mutex
+	scopes map[*actionScope]struct{}
+}
+
+func newActionScopes() *actionScopes {
+	return &actionScopes{scopes: make(map[*actionScope]struct{})}
+}
+
+func (a *actionScopes) add(s *actionScope) {
+	if a != nil {
+		a.mu.Lock()
+		a.scopes[s] = struct{}{}
+		a.mu.Unlock()
+	}
+}
+
+func (a *actionScopes) remove(s *actionScope) {
+	if a != nil {
+		a.mu.Lock()
+		delete(a.scopes, s)
+		a.mu.Unlock()
+	}
+}
+
+// abandon finishes the scopes of the actions still being handled, cancelling their contexts and running their cleanup
+// functions
+func (a *actionScopes) abandon() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	var scopes []*actionScope
+	for s := range a.scopes {
+		scopes = append(scopes, s)
+	}
+	a.mu.Unlock()
+	for _, s := range scopes {
+		log.Warn().Msgf("an action for command %q was still being handled when the pack stopped, cleaning up", s.command)
+		s.finish()
+	}
+}
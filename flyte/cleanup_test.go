@@ -0,0 +1,143 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_AddCleanup_ShouldRunCleanupsInReverseOrderOnceTheActionIsCompleted(t *testing.T) {
+	// given a handler that registers cleanups, one of which fails
+	var mu sync.Mutex
+	var calls []string
+	record := func(call string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, call)
+	}
+	c := MockClient{completeAction: func(client.Action, client.Event) error {
+		record("complete")
+		return nil
+	}}
+	p := NewPack(PackDef{Name: "CleanupPack"}, c).(pack)
+	handler := func(ctx context.Context, _ json.RawMessage) Event {
+		require.NoError(t, AddCleanup(ctx, func() error {
+			record("remove temp file")
+			return nil
+		}))
+		require.NoError(t, AddCleanup(ctx, func() error {
+			record("release lease")
+			return errors.New("lease expired")
+		}))
+		return Event{EventDef: EventDef{Name: "Done"}}
+	}
+
+	// when
+	p.handleAction(&client.Action{CommandName: "Work"}, map[string]ContextHandler{"Work": handler})
+
+	// then
+	assert.Equal(t, []string{"complete", "release lease", "remove temp file"}, calls)
+	assert.Equal(t, uint64(1), p.Handle().Stats().CleanupsFailed)
+}
+
+func Test_AddCleanup_ShouldRunCleanupsWhenTheHandlerPanics(t *testing.T) {
+	c := MockClient{completeAction: func(client.Action, client.Event) error { return nil }}
+	p := NewPack(PackDef{Name: "CleanupPack"}, c).(pack)
+	cleaned := false
+	handler := func(ctx context.Context, _ json.RawMessage) Event {
+		AddCleanup(ctx, func() error {
+			cleaned = true
+			return nil
+		})
+		AddCleanup(ctx, func() error { panic("cleanup panicked") })
+		panic("handler panicked")
+	}
+
+	p.handleAction(&client.Action{CommandName: "Work"}, map[string]ContextHandler{"Work": handler})
+
+	assert.True(t, cleaned)
+	assert.Equal(t, uint64(1), p.Handle().Stats().CleanupsFailed)
+}
+
+func Test_AddCleanup_ShouldRunCleanupsAndCancelTheContextWhenThePackStopsBeforeTheHandlerFinishes(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	// given a handler that is still running when the drain timeout expires
+	c := MockClient{completeAction: func(client.Action, client.Event) error { return nil }}
+	p := NewPackWithOptions(PackDef{Name: "CleanupPack"}, c, WithDrainTimeout(20*time.Millisecond)).(pack)
+	cleaned := make(chan struct{})
+	cancelled := make(chan struct{})
+	registered := make(chan struct{})
+	handler := func(ctx context.Context, _ json.RawMessage) Event {
+		AddCleanup(ctx, func() error {
+			close(cleaned)
+			return nil
+		})
+		close(registered)
+		<-ctx.Done()
+		close(cancelled)
+		return Event{EventDef: EventDef{Name: "Cancelled"}}
+	}
+	p.counters.add(actionsTaken)
+	go p.handleAction(&client.Action{CommandName: "Work"}, map[string]ContextHandler{"Work": handler})
+	<-registered
+
+	// when
+	p.Stop()
+
+	// then the cleanup has run by the time Stop returns, and the handler is cancelled
+	select {
+	case <-cleaned:
+	default:
+		t.Fatal("cleanup did not run before Stop returned")
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("handler context was not cancelled")
+	}
+}
+
+func Test_AddCleanup_ShouldRunCleanupsRegisteredAfterTheActionFinishedStraightAway(t *testing.T) {
+	p := NewPack(PackDef{Name: "CleanupPack"}, MockClient{}).(pack)
+	ctx, scope := p.newActionScope(context.Background(), "Work")
+	scope.finish()
+
+	cleaned := false
+	err := AddCleanup(ctx, func() error {
+		cleaned = true
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.True(t, cleaned)
+	assert.Error(t, ctx.Err())
+}
+
+func Test_AddCleanup_ShouldReturnAnErrorForAContextThatIsNotAnActionContext(t *testing.T) {
+	err := AddCleanup(context.Background(), func() error { return nil })
+
+	assert.Equal(t, ErrNotActionContext, err)
+}
@@ -17,9 +17,12 @@ limitations under the License.
 package flyte
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/ExpediaGroup/flyte-client/client"
 	"github.com/rs/zerolog/log"
+	"runtime/debug"
 	"time"
 )
 
@@ -33,74 +36,224 @@ func (p pack) handleCommands() {
 // sends the output event to the flyte server
 func (p pack) handleCommandActions() {
 	handlers := p.createHandlersMap()
+	p.workers.start(p.lifecycle.Done())
+	if !p.redriveActions(handlers) || !p.waitBeforeFirstPoll() {
+		return
+	}
+	if streamer, ok := p.client.(client.ActionStreamer); ok && p.actionStream {
+		p.streamActions(streamer, handlers)
+		return
+	}
+	p.pollActions(handlers, time.Time{})
+}
+
+// polls for actions and handles them until the deadline passes, if there is one, returning false if the pack has
+// been stopped
+func (p pack) pollActions(handlers map[string]ContextHandler, deadline time.Time) bool {
 	for {
-		a := p.getNextAction()
-		// concurrently handle the incoming actions
-		go p.handleAction(a, handlers)
+		a, running := p.nextAction(deadline)
+		if a == nil {
+			return running
+		}
+		p.takeAction(a, handlers)
 	}
 }
 
+// logs and counts the action as taken and concurrently handles it
+func (p pack) takeAction(a *client.Action, handlers map[string]ContextHandler) {
+	p.actions.taken(a)
+	p.counters.add(actionsTaken)
+	p.workers.submit(p.lifecycle.Done(), func() { p.handleAction(a, handlers) })
+}
+
 // creates map of commandName -> handler, so incoming actions can be routed easily
-func (p pack) createHandlersMap() map[string]CommandHandler {
-	handlers := make(map[string]CommandHandler)
+func (p pack) createHandlersMap() map[string]ContextHandler {
+	handlers := make(map[string]ContextHandler)
 	for _, c := range p.Commands {
-		handlers[c.Name] = c.Handler
+		handlers[c.Name] = p.commandHandler(c)
 	}
 	return handlers
 }
 
-// gets the next action to process from the flyte server, if no action immediately available will start polling
+// gets the next action to process from the flyte server, if no action immediately available will start polling.
+// returns nil if the pack is stopped while polling
 func (p pack) getNextAction() *client.Action {
+	a, _ := p.nextAction(time.Time{})
+	return a
+}
+
+// polls for the next action until the deadline passes, if there is one. Returns nil and false if the pack is stopped
+// while polling, or nil and true if the deadline passes
+func (p pack) nextAction(deadline time.Time) (*client.Action, bool) {
+	emptyPolls := 0
+	notFounds := 0
 	for {
+		select {
+		case <-p.lifecycle.Done():
+			return nil, false
+		default:
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return nil, true
+		}
+
+		// when the workers are saturated, wait for capacity rather than taking actions that cannot be handled promptly
+		if !p.workers.acquire(p.lifecycle.Done()) {
+			return nil, false
+		}
+		p.lifecycle.markPolled()
 		a, err := p.client.TakeAction()
 		if err != nil {
-			if _, ok := err.(client.NotFoundError); ok {
-				log.Fatal().Msg("Pack not found while polling for actions. Exiting.")
+			if errors.Is(err, client.ErrNotFound) {
+				p.workers.release()
+				notFounds++
+				wait, polling := p.recoverNotFound(err, notFounds)
+				if !polling {
+					return nil, false
+				}
+				select {
+				case <-p.lifecycle.Done():
+					return nil, false
+				case <-time.After(wait):
+				}
+				continue
+			}
+			p.metrics.takeActionFailed(err)
+			p.usage.countError(err)
+			if client.IsTransient(err) {
+				log.Warn().Err(err).Msg("could not take action, the connection to the flyte server failed")
+			} else {
+				log.Err(err).Msg("could not take action")
 			}
-			log.Err(err).Msg("could not take action")
 		}
+		notFounds = 0
 		if a == nil || err != nil {
-			time.Sleep(p.pollingFrequency)
+			p.workers.release()
+			emptyPolls++
+			select {
+			case <-p.lifecycle.Done():
+				return nil, false
+			case <-time.After(retryWait(withJitter(p.pollInterval(emptyPolls), p.pollingJitter), err)):
+			}
 			continue
 		}
-		return a
+		return a, true
 	}
 }
 
+// retryWait returns how long to wait before trying the flyte api again, which is the wait passed in unless the
+// flyte api asked for a longer delay with a Retry-After header
+func retryWait(wait time.Duration, err error) time.Duration {
+	if d, ok := client.RetryAfter(err); ok && d > wait {
+		log.Info().Msgf("flyte api asked for a retry after %s", d)
+		return d
+	}
+	return wait
+}
+
 // invokes the relevant handler using the action input JSON and completes the action by posting the result to the flyte api
 // if no handler found, then the action will be completed using a fatal event
-func (p pack) handleAction(a *client.Action, handlers map[string]CommandHandler) {
+func (p pack) handleAction(a *client.Action, handlers map[string]ContextHandler) {
+	// the cleanup functions registered by the handler run once the action has been completed, even if it panics
+	ctx, scope := p.newActionScope(actionContext(a), a.CommandName)
+	defer scope.finish()
 	// ensure that a panicking CommandHandler is captured and handled
 	defer p.handlePanic(a)
 
 	handler, ok := handlers[a.CommandName]
 	if !ok {
 		err := fmt.Errorf("no handler could be found for command %q in %v", a.CommandName, handlers)
+		p.metrics.observe(a.CommandName, 0, true)
 		p.completeAction(a, NewFatalEvent(err.Error()))
 		log.Err(err).Send()
+		p.actionFailed(a, err)
+		return
+	}
+
+	if frozen, reason := p.frozen(a.CommandName); frozen {
+		log.Info().Str("command", a.CommandName).Str("input", string(a.Input)).
+			Msgf("execution is frozen, not running action %q", a.ID)
+		p.counters.add(actionsFrozen)
+		p.completeAction(a, NewExecutionFrozenEvent(a.CommandName, reason))
 		return
 	}
 
-	outputEvent := handler(a.Input)
+	outputEvent := p.invokeHandler(ctx, a, handler)
+	if outputEvent.EventDef.Name == fatalEventName {
+		p.actionFailed(a, fmt.Errorf("handler returned a %s event: %v", fatalEventName, outputEvent.Payload))
+	}
 	p.completeAction(a, outputEvent)
 }
 
+// invokes the handler with the action input JSON, recording how long it took and whether it failed, and sending
+// heartbeats for the action while it runs
+func (p pack) invokeHandler(ctx context.Context, a *client.Action, handler ContextHandler) Event {
+	ctx, heartbeat := p.heartbeats.begin(ctx, a)
+	defer heartbeat.stop()
+	start := time.Now()
+	failed := true // unless the handler returns, it has panicked
+	defer func() {
+		p.metrics.observe(a.CommandName, time.Since(start), failed)
+	}()
+
+	outputEvent := handler(ctx, a.Input)
+	failed = outputEvent.EventDef.Name == fatalEventName
+	return outputEvent
+}
+
 // used to ensure panicing command handlers can be recovered gracefully by completing the action with a new fatal event
 // populated by the error message returned
 func (p pack) handlePanic(a *client.Action) {
 	if r := recover(); r != nil {
 		p.completeAction(a, NewFatalEvent(fmt.Sprintf("%v", r)))
 		log.Error().Msgf("command handler for %q raised a panic: %s", a.CommandName, r)
+		p.sendPanicEvent(a, r, debug.Stack())
+		p.actionFailed(a, fmt.Errorf("handler panicked: %v", r))
 	}
 }
 
-// completes the action by posting an event to the flyte api
+// completes the action by posting an event to the flyte api. The action is logged as done once its result has been
+// posted or queued; if the result is abandoned the action stays in the action log, to be handled again.
 func (p pack) completeAction(a *client.Action, event Event) {
+	correlation := actionCorrelation(a)
 	e := client.Event{
-		Name:    event.EventDef.Name,
-		Payload: event.Payload,
+		Name:        event.EventDef.Name,
+		Payload:     event.Payload,
+		Correlation: &correlation,
 	}
 	if err := p.client.CompleteAction(*a, e); err != nil {
+		p.usage.countError(err)
+		if p.queueResult(a, e, err) {
+			p.actions.done(a)
+			return
+		}
+		p.counters.add(actionsFailed)
 		log.Err(err).Msgf("could not complete action %+v with event %+v", a, e)
+		p.actionFailed(a, fmt.Errorf("could not complete action: %w", err))
+		p.resultAbandoned(*a, e, time.Time{}, err)
+		p.actions.abandoned(a)
+		return
+	}
+	p.counters.add(actionsCompleted)
+	p.actions.done(a)
+}
+
+// queueResult puts the result of the action on the result queue, if the pack has one and posting it may succeed later,
+// returning true if it was queued
+func (p pack) queueResult(a *client.Action, e client.Event, err error) bool {
+	if !p.results.queueable(err) {
+		return false
+	}
+	if qerr := p.results.add(*a, e); qerr != nil {
+		log.Err(qerr).Msgf("cannot queue the result of action %q", a.ID)
+		return false
 	}
+	p.counters.add(resultsQueued)
+	log.Warn().Err(err).Msgf("could not complete action %q, its result %q is queued to be posted again", a.ID, e.Name)
+	return true
+}
+
+// actionFailed calls the OnActionError hooks for the action
+func (p pack) actionFailed(a *client.Action, err error) {
+	p.hooks.actionError(ActionError{Command: a.CommandName, ActionID: a.ID, Err: err})
 }
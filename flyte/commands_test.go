@@ -83,6 +83,33 @@ func TestGetNextActionShouldContinuePollingWhileReceivingUnexpectedErrorResponse
 	}
 }
 
+func TestGetNextActionShouldWaitForTheRetryAfterDelayWhenTheFlyteApiAsksForOne(t *testing.T) {
+	var polls []time.Time
+	mock := mockClient{takeAction: func() (*client.Action, error) {
+		polls = append(polls, time.Now())
+		if len(polls) == 2 {
+			return &client.Action{}, nil
+		}
+		return nil, fmt.Errorf("response was: %w", client.ResponseError{StatusCode: 429, RetryAfter: 100 * time.Millisecond})
+	}}
+
+	pack := pack{client: mock, pollingFrequency: 1 * time.Millisecond}
+
+	action := pack.getNextAction()
+
+	if assert.NotNil(t, action) {
+		assert.True(t, polls[1].Sub(polls[0]) >= 100*time.Millisecond, "should have waited for the Retry-After delay")
+	}
+}
+
+func TestRetryWaitShouldNotShortenTheWait(t *testing.T) {
+	err := client.ResponseError{StatusCode: 503, RetryAfter: time.Second}
+
+	assert.Equal(t, time.Minute, retryWait(time.Minute, err))
+	assert.Equal(t, time.Second, retryWait(time.Millisecond, err))
+	assert.Equal(t, time.Millisecond, retryWait(time.Millisecond, nil))
+}
+
 // Rest of methods required for Client interface
 
 func (mockClient) CreatePack(client.Pack) error {
@@ -0,0 +1,177 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/rs/zerolog/log"
+	"reflect"
+	"sync"
+)
+
+const (
+	configureCommandName           = "Configure"
+	configuredEventName            = "Configured"
+	configurationRejectedEventName = "ConfigurationRejected"
+)
+
+// Configuration makes a pack remotely configurable through flyte flows. When a pack has a configuration, a built in
+// "Configure" command is added to it. The command accepts a JSON patch of the settings, e.g. {"channel": "alerts"},
+// which is decoded on top of the current settings. Patches with unknown fields or values of the wrong type are
+// rejected, as are settings that fail validation. Accepted settings are applied to the running pack, persisted to the
+// flyte api datastore (so they are restored when the pack restarts) and returned in a "Configured" event.
+// Rejected patches result in a "ConfigurationRejected" event.
+type Configuration struct {
+	Settings interface{}                      // a pointer to a struct holding the default settings. Its type is the schema patches are checked against
+	Validate func(settings interface{}) error // optional. Checks the patched settings, which are passed as the same pointer type as Settings
+	Apply    func(settings interface{}) error // applies the patched settings to the running pack
+}
+
+// ConfigurationRejectedPayload is the payload of the "ConfigurationRejected" event.
+type ConfigurationRejectedPayload struct {
+	Error string `json:"error"`
+}
+
+// WithConfiguration adds a built in "Configure" command to the pack, making its settings remotely configurable.
+func WithConfiguration(cfg Configuration) Option {
+	return func(p *pack) {
+		c := &configurator{cfg: cfg, datastore: datastoreOf(p.client), key: p.Name + "-configuration", current: cfg.Settings}
+		p.configurator = c
+		p.Commands = append(p.Commands, Command{
+			Name:         configureCommandName,
+			OutputEvents: []EventDef{{Name: configuredEventName}, {Name: configurationRejectedEventName}},
+			Handler:      c.handle,
+		})
+	}
+}
+
+// configurator holds the current settings of a configurable pack
+type configurator struct {
+	cfg       Configuration
+	datastore client.Datastore
+	key       string
+	mu        sync.Mutex
+	current   interface{}
+}
+
+// handle is the "Configure" command handler
+func (c *configurator) handle(input json.RawMessage) Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	settings, err := c.patch(input)
+	if err == nil {
+		err = c.apply(settings)
+	}
+	if err != nil {
+		log.Err(err).Msg("configuration rejected")
+		return Event{EventDef: EventDef{Name: configurationRejectedEventName}, Payload: ConfigurationRejectedPayload{Error: err.Error()}}
+	}
+
+	if err := c.persist(settings); err != nil {
+		log.Err(err).Msgf("configuration applied but could not be persisted to datastore item %q", c.key)
+	}
+	return Event{EventDef: EventDef{Name: configuredEventName}, Payload: settings}
+}
+
+// restore applies the settings persisted by a previous run of the pack, if there are any
+func (c *configurator) restore() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, err := c.datastore.GetDatastoreItem(c.key)
+	if err != nil {
+		if _, ok := err.(client.NotFoundError); !ok {
+			log.Err(err).Msgf("could not get persisted configuration from datastore item %q", c.key)
+		}
+		return
+	}
+	if item == nil {
+		return
+	}
+
+	settings, err := c.patch(item.Value)
+	if err == nil {
+		err = c.apply(settings)
+	}
+	if err != nil {
+		log.Err(err).Msgf("could not restore persisted configuration from datastore item %q", c.key)
+		return
+	}
+	log.Info().Msgf("restored persisted configuration from datastore item %q", c.key)
+}
+
+// patch decodes the JSON patch on top of a copy of the current settings, rejecting unknown fields
+func (c *configurator) patch(patch json.RawMessage) (interface{}, error) {
+	t := reflect.TypeOf(c.cfg.Settings)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("configuration settings must be a pointer, got %T", c.cfg.Settings)
+	}
+	settings := reflect.New(t.Elem()).Interface()
+
+	current, err := json.Marshal(c.current)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal current settings: %v", err)
+	}
+	if err := json.Unmarshal(current, settings); err != nil {
+		return nil, fmt.Errorf("cannot copy current settings: %v", err)
+	}
+
+	d := json.NewDecoder(bytes.NewReader(patch))
+	d.DisallowUnknownFields()
+	if err := d.Decode(settings); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %v", err)
+	}
+
+	if c.cfg.Validate != nil {
+		if err := c.cfg.Validate(settings); err != nil {
+			return nil, fmt.Errorf("invalid configuration: %v", err)
+		}
+	}
+	return settings, nil
+}
+
+// apply applies the settings to the running pack, and makes them the current settings
+func (c *configurator) apply(settings interface{}) error {
+	if c.cfg.Apply != nil {
+		if err := c.cfg.Apply(settings); err != nil {
+			return fmt.Errorf("configuration could not be applied: %v", err)
+		}
+	}
+	c.current = settings
+	return nil
+}
+
+// persist stores the settings in the datastore, so they can be restored when the pack restarts
+func (c *configurator) persist(settings interface{}) error {
+	b, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return c.datastore.PutDatastoreItem(client.DatastoreItem{
+		Key:         c.key,
+		Description: "pack configuration, set by the Configure command",
+		ContentType: "application/json",
+		Value:       b,
+	})
+}
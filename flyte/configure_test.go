@@ -0,0 +1,130 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type testSettings struct {
+	Channel string `json:"channel"`
+	Retries int    `json:"retries"`
+}
+
+func Test_WithConfiguration_ShouldAddConfigureCommand(t *testing.T) {
+	packDef := PackDef{Name: "SlackPack", Commands: []Command{{Name: "sendMessage"}}}
+
+	p := NewPackWithOptions(packDef, MockClient{}, WithConfiguration(Configuration{Settings: &testSettings{}})).(pack)
+
+	require.Len(t, p.Commands, 2)
+	assert.Equal(t, "Configure", p.Commands[1].Name)
+	assert.Len(t, packDef.Commands, 1, "the pack definition passed in should not be modified")
+}
+
+func Test_ConfigureCommand_ShouldPatchValidateApplyAndPersistSettings(t *testing.T) {
+	var applied *testSettings
+	var persisted client.DatastoreItem
+	c := MockClient{
+		putDatastoreItem: func(item client.DatastoreItem) error {
+			persisted = item
+			return nil
+		},
+	}
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, c, WithConfiguration(Configuration{
+		Settings: &testSettings{Channel: "general", Retries: 1},
+		Apply: func(settings interface{}) error {
+			applied = settings.(*testSettings)
+			return nil
+		},
+	})).(pack)
+
+	event := p.Commands[0].Handler(json.RawMessage(`{"retries": 3}`))
+
+	assert.Equal(t, "Configured", event.EventDef.Name)
+	assert.Equal(t, &testSettings{Channel: "general", Retries: 3}, event.Payload)
+	assert.Equal(t, &testSettings{Channel: "general", Retries: 3}, applied)
+	assert.Equal(t, "SlackPack-configuration", persisted.Key)
+	assert.JSONEq(t, `{"channel": "general", "retries": 3}`, string(persisted.Value))
+
+	// patches build on the previously applied settings
+	event = p.Commands[0].Handler(json.RawMessage(`{"channel": "alerts"}`))
+	assert.Equal(t, &testSettings{Channel: "alerts", Retries: 3}, event.Payload)
+}
+
+func Test_ConfigureCommand_ShouldRejectPatchesThatDoNotMatchTheSchemaOrFailValidation(t *testing.T) {
+	applied := false
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, MockClient{}, WithConfiguration(Configuration{
+		Settings: &testSettings{},
+		Validate: func(settings interface{}) error {
+			if settings.(*testSettings).Retries > 5 {
+				return errors.New("retries must be 5 or less")
+			}
+			return nil
+		},
+		Apply: func(interface{}) error {
+			applied = true
+			return nil
+		},
+	})).(pack)
+	handler := p.Commands[0].Handler
+
+	for input, want := range map[string]string{
+		`{"unknown": true}`:    `invalid configuration: json: unknown field "unknown"`,
+		`{"retries": "three"}`: "invalid configuration: json: cannot unmarshal string into Go struct field testSettings.retries of type int",
+		`{"retries": 6}`:       "invalid configuration: retries must be 5 or less",
+	} {
+		event := handler(json.RawMessage(input))
+		assert.Equal(t, "ConfigurationRejected", event.EventDef.Name)
+		assert.Equal(t, ConfigurationRejectedPayload{Error: want}, event.Payload)
+	}
+	assert.False(t, applied)
+}
+
+func Test_Start_ShouldRestorePersistedConfiguration(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	var applied *testSettings
+	c := MockClient{
+		createPack: func(client.Pack) error {
+			return nil
+		},
+		takeAction: func() (*client.Action, error) {
+			return nil, nil
+		},
+		getDatastoreItem: func(key string) (*client.DatastoreItem, error) {
+			assert.Equal(t, "SlackPack-configuration", key)
+			return &client.DatastoreItem{Key: key, Value: []byte(`{"channel": "alerts", "retries": 2}`)}, nil
+		},
+	}
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, c, WithConfiguration(Configuration{
+		Settings: &testSettings{},
+		Apply: func(settings interface{}) error {
+			applied = settings.(*testSettings)
+			return nil
+		},
+	}))
+
+	p.Start()
+	defer p.(pack).Stop()
+
+	assert.Equal(t, &testSettings{Channel: "alerts", Retries: 2}, applied)
+}
@@ -0,0 +1,111 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-client/client"
+)
+
+// ContextHandler is a command handler that is passed a context as well as the input JSON. The context carries the
+// correlation identifiers of the action being handled (see CorrelationFromContext), which are attached to events sent
+// with PackHandle.SendEventWithContext.
+type ContextHandler func(ctx context.Context, input json.RawMessage) Event
+
+// withContext adapts the handler to a ContextHandler, leaving a nil handler nil
+func (h CommandHandler) withContext() ContextHandler {
+	if h == nil {
+		return nil
+	}
+	return func(_ context.Context, input json.RawMessage) Event {
+		return h(input)
+	}
+}
+
+// withContext adapts the handler to a ContextHandler that returns a FATAL event for any error, leaving a nil handler
+// nil
+func (h FallibleHandler) withContext() ContextHandler {
+	return h.withRetry(nil)
+}
+
+type correlationKey struct{}
+
+// CorrelationFromContext returns the correlation identifiers of the action a ContextHandler was called for. Its
+// TraceParent identifies the pack's handling of the action as a span in the W3C trace of the flow execution, so
+// handlers can start their own spans under it.
+func CorrelationFromContext(ctx context.Context) (client.Correlation, bool) {
+	c, ok := ctx.Value(correlationKey{}).(client.Correlation)
+	return c, ok
+}
+
+// actionContext returns the context the handler for the action is called with
+func actionContext(a *client.Action) context.Context {
+	return context.WithValue(context.Background(), correlationKey{}, actionCorrelation(a))
+}
+
+// actionCorrelation returns the correlation identifiers events resulting from the action are sent with. The
+// traceparent is that of a span for the handling of the action: a child of the traceparent the action was sent with,
+// if it was, otherwise in a trace for the flow execution. An action without a correlation id is given the trace id,
+// so correlation ids are always in the W3C trace context format when the flyte api does not set them.
+//
+// The span id is derived from the action, so the correlation is the same each time it is worked out for an action.
+func actionCorrelation(a *client.Action) client.Correlation {
+	c := a.Correlation()
+	parent, err := client.ParseTraceParent(a.TraceParent)
+	if err != nil {
+		parent = client.TraceParent{TraceID: traceID(a), Sampled: true}
+	}
+	span := sha256.Sum256([]byte(parent.String() + "/" + a.ID))
+	tp := client.TraceParent{TraceID: parent.TraceID, SpanID: hex.EncodeToString(span[:8]), Sampled: parent.Sampled}
+	c.TraceParent = tp.String()
+	if c.ID == "" {
+		c.ID = tp.TraceID
+	}
+	return c
+}
+
+// traceID returns the trace id of the flow execution an action without a traceparent is for: its correlation id if
+// that is a trace id, otherwise one derived from the correlation id or, failing that, the action id
+func traceID(a *client.Action) string {
+	if client.IsTraceID(a.CorrelationID) {
+		return a.CorrelationID
+	}
+	id := a.CorrelationID
+	if id == "" {
+		id = a.ID
+	}
+	if id == "" {
+		return client.NewTraceParent().TraceID
+	}
+	h := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(h[:16])
+}
+
+// correlation returns the correlation identifiers from the context, or nil if it has none
+func correlation(ctx context.Context) *client.Correlation {
+	if ctx == nil {
+		return nil
+	}
+	c, ok := CorrelationFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return &c
+}
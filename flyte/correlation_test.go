@@ -0,0 +1,181 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_ContextHandler_ShouldPropagateCorrelationToResultAndSentEvents(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	// given an action that is part of a flow execution
+	action := &client.Action{ID: "42", CommandName: "Deploy", CorrelationID: "c-1", FlowName: "deploy-flow", StepID: "deploy"}
+	var mu sync.Mutex
+	var posted []client.Event
+	completed := make(chan client.Event, 1)
+	taken := false
+	c := MockClient{
+		createPack: func(client.Pack) error { return nil },
+		takeAction: func() (*client.Action, error) {
+			if taken {
+				return nil, nil
+			}
+			taken = true
+			return action, nil
+		},
+		postEvent: func(e client.Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			posted = append(posted, e)
+			return nil
+		},
+		completeAction: func(_ client.Action, e client.Event) error {
+			completed <- e
+			return nil
+		},
+	}
+	var p Pack
+	handler := func(ctx context.Context, input json.RawMessage) Event {
+		p.(pack).Handle().SendEventWithContext(ctx, Event{EventDef: EventDef{Name: "DeployStarted"}})
+		return Event{EventDef: EventDef{Name: "Deployed"}}
+	}
+	p = NewPack(PackDef{Name: "DeployPack", Commands: []Command{{Name: "Deploy", ContextHandler: handler}}}, c)
+
+	// when
+	p.Start()
+	defer p.(pack).Stop()
+
+	// then
+	expected := &client.Correlation{ID: "c-1", ActionID: "42", FlowName: "deploy-flow", StepID: "deploy"}
+	expected.TraceParent = actionCorrelation(action).TraceParent
+	_, err := client.ParseTraceParent(expected.TraceParent)
+	require.NoError(t, err)
+	select {
+	case e := <-completed:
+		assert.Equal(t, expected, e.Correlation)
+	case <-time.After(time.Second):
+		t.Fatal("action was not completed")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, posted, 1)
+	assert.Equal(t, expected, posted[0].Correlation)
+}
+
+func Test_SendEvent_ShouldNotAttachCorrelation_WithoutAnActionContext(t *testing.T) {
+	var posted client.Event
+	p := NewPack(PackDef{Name: "DeployPack"}, MockClient{postEvent: func(e client.Event) error {
+		posted = e
+		return nil
+	}}).(pack)
+
+	require.NoError(t, p.SendEvent(Event{EventDef: EventDef{Name: "Observed"}}))
+
+	assert.Nil(t, posted.Correlation)
+}
+
+func Test_CorrelationFromContext(t *testing.T) {
+	ctx := actionContext(&client.Action{ID: "42", CorrelationID: "c-1"})
+
+	c, ok := CorrelationFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "c-1", c.ID)
+	assert.Equal(t, "42", c.ActionID)
+	assert.NotEmpty(t, c.TraceParent)
+
+	_, ok = CorrelationFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func Test_actionCorrelation_ShouldStartASpanInTheTraceTheActionWasSentWith(t *testing.T) {
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	a := &client.Action{ID: "42", CorrelationID: "c-1", TraceParent: parent}
+
+	c := actionCorrelation(a)
+
+	tp, err := client.ParseTraceParent(c.TraceParent)
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tp.TraceID)
+	assert.NotEqual(t, "00f067aa0ba902b7", tp.SpanID)
+	assert.True(t, tp.Sampled)
+	assert.Equal(t, "c-1", c.ID)
+	assert.Equal(t, c, actionCorrelation(a), "the correlation should be the same each time")
+}
+
+func Test_actionCorrelation_ShouldUseTheSameTraceForActionsOfTheSameFlowExecution(t *testing.T) {
+	first := actionCorrelation(&client.Action{ID: "1", CorrelationID: "c-1"})
+	second := actionCorrelation(&client.Action{ID: "2", CorrelationID: "c-1"})
+
+	firstTP, err := client.ParseTraceParent(first.TraceParent)
+	require.NoError(t, err)
+	secondTP, err := client.ParseTraceParent(second.TraceParent)
+	require.NoError(t, err)
+	assert.Equal(t, firstTP.TraceID, secondTP.TraceID)
+	assert.NotEqual(t, firstTP.SpanID, secondTP.SpanID)
+}
+
+func Test_actionCorrelation_ShouldGenerateATraceIdCorrelationIdWhenTheActionHasNone(t *testing.T) {
+	c := actionCorrelation(&client.Action{ID: "42"})
+
+	tp, err := client.ParseTraceParent(c.TraceParent)
+	require.NoError(t, err)
+	assert.True(t, client.IsTraceID(c.ID))
+	assert.Equal(t, tp.TraceID, c.ID)
+}
+
+func Test_FallibleHandler_ShouldCompleteTheActionWithAFatalEventForAnError(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	taken := false
+	completed := make(chan client.Event, 1)
+	c := MockClient{
+		createPack: func(client.Pack) error { return nil },
+		takeAction: func() (*client.Action, error) {
+			if taken {
+				return nil, nil
+			}
+			taken = true
+			return &client.Action{ID: "42", CommandName: "Deploy", CorrelationID: "c-1"}, nil
+		},
+		completeAction: func(_ client.Action, e client.Event) error {
+			completed <- e
+			return nil
+		},
+	}
+	handler := func(json.RawMessage) (Event, error) { return Event{}, errors.New("deployment failed") }
+	p := NewPack(PackDef{Name: "FalliblePack", Commands: []Command{{Name: "Deploy", FallibleHandler: handler}}}, c)
+
+	p.Start()
+	defer p.(pack).Stop()
+
+	select {
+	case e := <-completed:
+		assert.Equal(t, fatalEventName, e.Name)
+		assert.Equal(t, ErrorPayload{Message: "deployment failed", Type: "*errors.errorString", CorrelationID: "c-1"}, e.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("action was not completed")
+	}
+}
@@ -23,14 +23,20 @@ import (
 
 // this registers the pack with the flyte server
 func (p pack) register() error {
-	eventDefs, commands := aggregateAndConvert(p.EventDefs, p.Commands)
-	return p.client.CreatePack(client.Pack{
-		Name:      p.Name,
-		Labels:    p.Labels,
-		Links:     []client.Link{createLink(p.HelpURL, "help")},
-		EventDefs: eventDefs,
-		Commands:  commands,
-	})
+	return p.client.CreatePack(p.clientPack())
+}
+
+// returns the definition of the pack that is registered with the flyte server
+func (p pack) clientPack() client.Pack {
+	eventDefs, commands := aggregateAndConvert(p.EventDefs, p.freezableCommands())
+	return client.Pack{
+		Name:        p.Name,
+		Labels:      p.Labels,
+		Links:       helpLinks(p.HelpURL),
+		EventDefs:   eventDefs,
+		Commands:    commands,
+		Description: p.Description,
+	}
 }
 
 // deduplicates & converts the event definitions and converts the pack commands to the client commands type.
@@ -45,14 +51,12 @@ func aggregateAndConvert(eventDefs []EventDef, commands []Command) ([]client.Eve
 func processCommands(commands []Command, eventDefsSet map[string]client.EventDef) []client.Command {
 	c := make([]client.Command, len(commands))
 	for i, command := range commands {
-		clientCommand := client.Command{
-			Name:       command.Name,
-			EventNames: processCommandEventDefs(command.OutputEvents, eventDefsSet),
+		c[i] = client.Command{
+			Name:        command.Name,
+			EventNames:  processCommandEventDefs(command.OutputEvents, eventDefsSet),
+			Links:       helpLinks(command.HelpURL),
+			Description: command.Description,
 		}
-		if command.HelpURL != nil {
-			clientCommand.Links = []client.Link{createLink(command.HelpURL, "help")}
-		}
-		c[i] = clientCommand
 	}
 	return c
 }
@@ -74,20 +78,38 @@ func processEventDefs(eventDefs []EventDef, eventDefsSet map[string]client.Event
 	}
 }
 
-// creates a client EventDef from a flyte EventDef passed in to it, and adds it to the eventDefsSet also passed in
+// creates a client EventDef from a flyte EventDef passed in to it, and adds it to the eventDefsSet also passed in.
+// When the event is defined more than once, the help url and description are kept from wherever they are set
 func addToEventDefsSet(eventDef EventDef, eventDefsSet map[string]client.EventDef) {
-	clientEventDef := client.EventDef{Name: eventDef.Name}
-	if eventDef.HelpURL != nil {
-		clientEventDef.Links = []client.Link{createLink(eventDef.HelpURL, "help")}
+	clientEventDef := client.EventDef{
+		Name:        eventDef.Name,
+		Links:       helpLinks(eventDef.HelpURL),
+		Description: eventDef.Description,
+	}
+	if existing, ok := eventDefsSet[eventDef.Name]; ok {
+		if clientEventDef.Links == nil {
+			clientEventDef.Links = existing.Links
+		}
+		if clientEventDef.Description == "" {
+			clientEventDef.Description = existing.Description
+		}
 	}
 	eventDefsSet[eventDef.Name] = clientEventDef
 }
 
+// returns the help link for the url passed in, or no links if the help url is not set
+func helpLinks(helpURL *url.URL) []client.Link {
+	if helpURL == nil {
+		return nil
+	}
+	return []client.Link{createLink(helpURL, client.RelHelp)}
+}
+
 // creates a client.Link struct from the url passed in
-func createLink(u *url.URL, rel string) client.Link {
+func createLink(u *url.URL, rel client.Rel) client.Link {
 	return client.Link{
 		Href: u,
-		Rel:  rel,
+		Rel:  string(rel),
 	}
 }
 
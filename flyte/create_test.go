@@ -0,0 +1,64 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_register_ShouldSendTheDescriptionsAndHelpURLs(t *testing.T) {
+	var registered client.Pack
+	c := MockClient{createPack: func(p client.Pack) error {
+		registered = p
+		return nil
+	}}
+	issueCreated := EventDef{Name: "IssueCreated", HelpURL: createURL("http://jirapack/help#issue-created", t)}
+	packDef := PackDef{
+		Name:        "JiraPack",
+		Description: "Creates and tracks Jira issues",
+		EventDefs:   []EventDef{{Name: "IssueCreated", Description: "The issue was created"}},
+		Commands: []Command{{
+			Name:         "CreateIssue",
+			Description:  "Creates an issue",
+			HelpURL:      createURL("http://jirapack/help#create-issue", t),
+			OutputEvents: []EventDef{issueCreated},
+			Handler:      func(json.RawMessage) Event { return Event{} },
+		}},
+	}
+
+	require.NoError(t, NewPack(packDef, c).(pack).register())
+
+	assert.Equal(t, "Creates and tracks Jira issues", registered.Description)
+	assert.Nil(t, registered.Links, "there should be no help link when the pack has no help url")
+	require.Len(t, registered.Commands, 1)
+	assert.Equal(t, "Creates an issue", registered.Commands[0].Description)
+	assert.Equal(t, []client.Link{{Href: createURL("http://jirapack/help#create-issue", t), Rel: "help"}}, registered.Commands[0].Links)
+	// the event is defined twice, the help url and description are kept from both definitions
+	assert.Equal(t, []client.EventDef{{
+		Name:        "IssueCreated",
+		Links:       []client.Link{{Href: createURL("http://jirapack/help#issue-created", t), Rel: "help"}},
+		Description: "The issue was created",
+	}}, registered.EventDefs)
+
+	b, err := json.Marshal(registered)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"description":"Creates and tracks Jira issues"`)
+}
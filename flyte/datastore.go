@@ -0,0 +1,48 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+)
+
+// ErrDatastoreNotSupported is returned by the datastore of a pack whose client does not implement client.Datastore.
+var ErrDatastoreNotSupported = errors.New("the flyte client does not support the datastore")
+
+// datastoreOf returns the client's datastore, or a datastore whose operations return ErrDatastoreNotSupported if the
+// client does not implement client.Datastore
+func datastoreOf(c client.Client) client.Datastore {
+	if d, ok := c.(client.Datastore); ok {
+		return d
+	}
+	return unsupportedDatastore{}
+}
+
+type unsupportedDatastore struct{}
+
+func (unsupportedDatastore) GetDatastoreItem(string) (*client.DatastoreItem, error) {
+	return nil, ErrDatastoreNotSupported
+}
+
+func (unsupportedDatastore) PutDatastoreItem(client.DatastoreItem) error {
+	return ErrDatastoreNotSupported
+}
+
+func (unsupportedDatastore) DeleteDatastoreItem(string) error {
+	return ErrDatastoreNotSupported
+}
@@ -0,0 +1,106 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"time"
+)
+
+// WithEventDedup suppresses spontaneous events that are identical - the same name and payload - to an event the pack
+// sent within the window, so a pack emitting bursts of the same alert while a condition flaps does not flood the flyte
+// server. A suppressed event is not sent and SendEvent returns nil; the first identical event sent once the window has
+// passed is sent as usual. Events that could not be posted do not count. Every spontaneous event sent by the pack is
+// filtered, including health events, so the window should be shorter than the health event interval. A window of zero
+// or less disables deduplication. Suppressed events are counted in Stats().EventsDeduplicated.
+func WithEventDedup(window time.Duration) Option {
+	return func(p *pack) {
+		if window <= 0 {
+			p.dedup = nil
+			return
+		}
+		p.dedup = &eventDedup{window: window, now: time.Now, sent: make(map[eventDigest]time.Time)}
+	}
+}
+
+// eventDedup remembers when events were sent, by their name and a hash of their payload
+type eventDedup struct {
+	window time.Duration
+	now    func() time.Time
+	mu     sync.Mutex
+	sent   map[eventDigest]time.Time
+}
+
+type eventDigest struct {
+	name    string
+	payload [sha256.Size]byte
+}
+
+// digest returns the digest of the event, or false if its payload cannot be marshalled, in which case the event is
+// never suppressed
+func digest(e Event) (eventDigest, bool) {
+	b, err := json.Marshal(e.Payload)
+	if err != nil {
+		return eventDigest{}, false
+	}
+	return eventDigest{name: e.EventDef.Name, payload: sha256.Sum256(b)}, true
+}
+
+// send reports whether the event should be sent, and if it should, records it as sent. Sends that fail must be
+// forgotten, so the event is not suppressed when it is sent again. A nil dedup sends every event.
+func (d *eventDedup) send(e Event) (eventDigest, bool) {
+	if d == nil {
+		return eventDigest{}, true
+	}
+	k, ok := digest(e)
+	if !ok {
+		return k, true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	if sent, ok := d.sent[k]; ok && now.Sub(sent) < d.window {
+		log.Debug().Msgf("suppressed duplicate %q event", k.name)
+		return k, false
+	}
+	d.sent[k] = now
+	d.prune(now)
+	return k, true
+}
+
+// forget removes the record of an event that could not be sent
+func (d *eventDedup) forget(k eventDigest) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.sent, k)
+}
+
+// prune removes the events sent before the window, so payloads that are no longer seen don't build up.
+// must be called with the lock held
+func (d *eventDedup) prune(now time.Time) {
+	for k, sent := range d.sent {
+		if now.Sub(sent) >= d.window {
+			delete(d.sent, k)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newDedupPack(postEvent postEvent) (pack, *time.Time) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, MockClient{postEvent: postEvent}, WithEventDedup(time.Minute)).(pack)
+	p.dedup.now = func() time.Time { return now }
+	return p, &now
+}
+
+func Test_EventDedup_ShouldSuppressIdenticalEventsWithinTheWindow(t *testing.T) {
+	var posted []client.Event
+	p, now := newDedupPack(func(e client.Event) error {
+		posted = append(posted, e)
+		return nil
+	})
+	diskLow := Event{EventDef: EventDef{Name: "DiskSpaceLow"}, Payload: map[string]string{"host": "a"}}
+
+	// when the same event is sent three times in a minute
+	require.NoError(t, p.SendEvent(diskLow))
+	*now = now.Add(30 * time.Second)
+	require.NoError(t, p.SendEvent(diskLow))
+	require.NoError(t, p.SendEvent(Event{EventDef: EventDef{Name: "DiskSpaceLow"}, Payload: map[string]string{"host": "a"}}))
+
+	// then it is only posted once
+	assert.Len(t, posted, 1)
+	assert.Equal(t, uint64(2), p.counters.snapshot().EventsDeduplicated)
+
+	// and it is posted again once the window has passed
+	*now = now.Add(31 * time.Second)
+	require.NoError(t, p.SendEvent(diskLow))
+	assert.Len(t, posted, 2)
+}
+
+func Test_EventDedup_ShouldSendEventsWithADifferentNameOrPayload(t *testing.T) {
+	var posted []client.Event
+	p, _ := newDedupPack(func(e client.Event) error {
+		posted = append(posted, e)
+		return nil
+	})
+
+	require.NoError(t, p.SendEvent(Event{EventDef: EventDef{Name: "DiskSpaceLow"}, Payload: map[string]string{"host": "a"}}))
+	require.NoError(t, p.SendEvent(Event{EventDef: EventDef{Name: "DiskSpaceLow"}, Payload: map[string]string{"host": "b"}}))
+	require.NoError(t, p.SendEvent(Event{EventDef: EventDef{Name: "DiskSpaceOK"}, Payload: map[string]string{"host": "a"}}))
+
+	assert.Len(t, posted, 3)
+	assert.Zero(t, p.counters.snapshot().EventsDeduplicated)
+}
+
+func Test_EventDedup_ShouldNotSuppressAnEventWhosePostFailed(t *testing.T) {
+	fail := true
+	var posted int
+	p, _ := newDedupPack(func(e client.Event) error {
+		if fail {
+			return errors.New("flyte server unavailable")
+		}
+		posted++
+		return nil
+	})
+	diskLow := Event{EventDef: EventDef{Name: "DiskSpaceLow"}}
+
+	assert.Error(t, p.SendEvent(diskLow))
+	fail = false
+	require.NoError(t, p.SendEvent(diskLow))
+
+	assert.Equal(t, 1, posted)
+}
+
+func Test_WithEventDedup_ShouldNotDeduplicateWithoutAWindow(t *testing.T) {
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, MockClient{}, WithEventDedup(0)).(pack)
+
+	assert.Nil(t, p.dedup)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// random is where the package gets the random numbers it uses to spread out its timings
+var random = struct {
+	sync.Mutex
+	deterministic bool
+	source        *rand.Rand // the seeded source used in deterministic mode
+}{}
+
+// SetDeterministic turns deterministic mode on or off, for integration tests and record/replay runs that need timing
+// dependent sequences to be identical across executions. In deterministic mode no jitter is added to waits, so the
+// waits between polls, between watcher and subscription runs, and while backing off or reconnecting are always
+// exactly their nominal durations. Values that are still randomised, such as the delay before the first poll for
+// actions (see WithPollingJitter), come from a pseudo-random sequence seeded with the seed passed in.
+//
+// Deterministic mode applies to the whole package, so it should be set before any packs are started.
+func SetDeterministic(enabled bool, seed int64) {
+	random.Lock()
+	defer random.Unlock()
+	random.deterministic = enabled
+	random.source = nil
+	if enabled {
+		random.source = rand.New(rand.NewSource(seed))
+	}
+}
+
+// jitterDisabled returns whether waits must not be jittered, as the package is in deterministic mode
+func jitterDisabled() bool {
+	random.Lock()
+	defer random.Unlock()
+	return random.deterministic
+}
+
+// randomInt63n returns a random number in [0, n), from the seeded sequence in deterministic mode
+func randomInt63n(n int64) int64 {
+	random.Lock()
+	defer random.Unlock()
+	if random.deterministic {
+		return random.source.Int63n(n)
+	}
+	return rand.Int63n(n)
+}
@@ -0,0 +1,56 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_SetDeterministic_ShouldDisableJitter(t *testing.T) {
+	SetDeterministic(true, 42)
+	defer SetDeterministic(false, 0)
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, 10*time.Second, withJitter(10*time.Second, 0.5))
+	}
+}
+
+func Test_SetDeterministic_ShouldRepeatTheSameSequenceForTheSameSeed(t *testing.T) {
+	defer SetDeterministic(false, 0)
+	sequence := func(seed int64) []int64 {
+		SetDeterministic(true, seed)
+		var s []int64
+		for i := 0; i < 5; i++ {
+			s = append(s, randomInt63n(int64(time.Hour)))
+		}
+		return s
+	}
+
+	assert.Equal(t, sequence(42), sequence(42))
+	assert.NotEqual(t, sequence(42), sequence(43))
+}
+
+func Test_withJitter_ShouldJitterOutsideDeterministicMode(t *testing.T) {
+	jittered := false
+	for i := 0; i < 10 && !jittered; i++ {
+		jittered = withJitter(10*time.Second, 0.5) != 10*time.Second
+	}
+
+	assert.True(t, jittered)
+}
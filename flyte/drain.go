@@ -0,0 +1,125 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+const (
+	defaultDrainTimeout = 30 * time.Second
+	drainPollInterval   = 10 * time.Millisecond
+)
+
+// Flusher is implemented by components that hold back events, such as an Aggregator, so they can be flushed when the
+// pack stops.
+type Flusher interface {
+	Flush()
+}
+
+// FlusherFunc adapts a function to a Flusher. It is useful when the flusher needs the pack handle, which is only
+// available once the pack has been created.
+type FlusherFunc func()
+
+// Flush calls f().
+func (f FlusherFunc) Flush() {
+	f()
+}
+
+// DrainSummary describes what happened while a pack was stopping.
+type DrainSummary struct {
+	EventsFlushed    uint64        `json:"eventsFlushed"`    // spontaneous events sent while stopping, e.g. by flushers
+	EventsFailed     uint64        `json:"eventsFailed"`     // spontaneous events that could not be sent while stopping
+	ActionsCompleted uint64        `json:"actionsCompleted"` // in flight actions that completed while stopping
+	ActionsFailed    uint64        `json:"actionsFailed"`    // in flight actions whose result could not be posted while stopping
+	ActionsAborted   uint64        `json:"actionsAborted"`   // in flight actions still being handled when the drain timeout expired
+	Duration         time.Duration `json:"duration"`         // how long the pack took to stop
+}
+
+// WithDrainTimeout sets how long Stop() waits for actions that are being handled to complete. Defaults to 30 seconds.
+func WithDrainTimeout(timeout time.Duration) Option {
+	return func(p *pack) {
+		p.drainTimeout = timeout
+	}
+}
+
+// WithFlushOnStop flushes the flushers passed in (e.g. aggregators) when the pack is stopped, once the actions being
+// handled have completed and before it stops sending events (see ShutdownFlushEvents).
+func WithFlushOnStop(flushers ...Flusher) Option {
+	return func(p *pack) {
+		p.flushers = append(append([]Flusher(nil), p.flushers...), flushers...)
+	}
+}
+
+// stopAndDrain stops the pack taking actions, waits for the actions being handled to complete or the drain timeout to
+// expire, then flushes any flushers before the pack stops sending events. It returns false if the pack was already
+// stopped.
+func (p pack) stopAndDrain() (DrainSummary, bool) {
+	start := time.Now()
+	before := p.counters.snapshot()
+
+	if !p.lifecycle.markStopped() {
+		return DrainSummary{}, false
+	}
+	p.shutdownHooks.run(ShutdownStopIntake)
+
+	timeout := p.drainTimeout
+	if timeout <= 0 {
+		timeout = defaultDrainTimeout
+	}
+	deadline := start.Add(timeout)
+	for p.counters.inFlight() > 0 && time.Now().Before(deadline) {
+		time.Sleep(drainPollInterval)
+	}
+
+	aborted := p.counters.inFlight()
+	if aborted > 0 {
+		p.scopes.abandon()
+	}
+	p.shutdownHooks.run(ShutdownDrainHandlers)
+
+	for _, f := range p.flushers {
+		f.Flush()
+	}
+	p.shutdownHooks.run(ShutdownFlushEvents)
+	p.lifecycle.stopSending()
+
+	after := p.counters.snapshot()
+	summary := DrainSummary{
+		EventsFlushed:    after.EventsSent - before.EventsSent,
+		EventsFailed:     after.EventsFailed - before.EventsFailed,
+		ActionsCompleted: after.ActionsCompleted - before.ActionsCompleted,
+		ActionsFailed:    after.ActionsFailed - before.ActionsFailed,
+		ActionsAborted:   uint64(aborted),
+		Duration:         time.Since(start),
+	}
+	p.lifecycle.setDrainSummary(summary)
+	return summary, true
+}
+
+func logDrainSummary(name string, s DrainSummary) {
+	log.Info().
+		Str("pack", name).
+		Uint64("eventsFlushed", s.EventsFlushed).
+		Uint64("eventsFailed", s.EventsFailed).
+		Uint64("actionsCompleted", s.ActionsCompleted).
+		Uint64("actionsFailed", s.ActionsFailed).
+		Uint64("actionsAborted", s.ActionsAborted).
+		Dur("drainTime", s.Duration).
+		Msgf("pack %q stopped", name)
+}
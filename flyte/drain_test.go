@@ -0,0 +1,130 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func Test_Stop_ShouldWaitForInFlightActionsToComplete(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	// given a pack with an action being handled
+	p := NewPackWithOptions(PackDef{Name: "DrainPack"}, MockClient{}, WithDrainTimeout(time.Second)).(pack)
+	p.counters.add(actionsTaken)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		p.counters.add(actionsCompleted)
+	}()
+
+	// when
+	p.Stop()
+
+	// then the action completed before Stop returned
+	drain := p.Handle().Stats().Drain
+	require.NotNil(t, drain)
+	assert.Equal(t, uint64(1), drain.ActionsCompleted)
+	assert.Equal(t, uint64(0), drain.ActionsAborted)
+	assert.True(t, drain.Duration >= 50*time.Millisecond)
+}
+
+func Test_Stop_ShouldReportActionsStillInFlightAfterTheDrainTimeoutAsAborted(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	// given a pack with two actions that never complete
+	p := NewPackWithOptions(PackDef{Name: "DrainPack2"}, MockClient{}, WithDrainTimeout(20*time.Millisecond)).(pack)
+	p.counters.add(actionsTaken)
+	p.counters.add(actionsTaken)
+
+	// when
+	p.Stop()
+
+	// then
+	drain := p.Handle().Stats().Drain
+	require.NotNil(t, drain)
+	assert.Equal(t, uint64(2), drain.ActionsAborted)
+	assert.Equal(t, uint64(0), drain.ActionsCompleted)
+}
+
+func Test_Stop_ShouldFlushFlushersBeforeStoppingAndCountTheEventsFlushed(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	c := MockClient{
+		createPack: func(client.Pack) error { return nil },
+		postEvent:  func(client.Event) error { return nil },
+	}
+	f := &sendingFlusher{}
+	p := NewPackWithOptions(PackDef{Name: "DrainPack3"}, c, WithFlushOnStop(f)).(pack)
+	f.h = p.Handle()
+	p.lifecycle.markStarted()
+
+	// when
+	p.Stop()
+
+	// then the flushed event was sent while the pack could still send events
+	assert.NoError(t, f.err)
+	drain := p.Handle().Stats().Drain
+	require.NotNil(t, drain)
+	assert.Equal(t, uint64(1), drain.EventsFlushed)
+}
+
+func Test_Stop_ShouldOnlyDrainOnce(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	f := &sendingFlusher{}
+	p := NewPackWithOptions(PackDef{Name: "DrainPack4"}, MockClient{}, WithFlushOnStop(f)).(pack)
+
+	// when
+	p.Stop()
+	first := p.Handle().Stats().Drain
+	p.Stop()
+
+	// then
+	assert.Equal(t, first, p.Handle().Stats().Drain)
+	assert.Equal(t, 1, f.flushes)
+}
+
+func Test_FlusherFunc_ShouldCallTheFunction(t *testing.T) {
+	called := false
+
+	FlusherFunc(func() { called = true }).Flush()
+
+	assert.True(t, called)
+}
+
+func Test_Stats_ShouldNotHaveADrainSummaryBeforeThePackIsStopped(t *testing.T) {
+	p := NewPackWithOptions(PackDef{Name: "DrainPack5"}, MockClient{}).(pack)
+
+	assert.Nil(t, p.Handle().Stats().Drain)
+}
+
+type sendingFlusher struct {
+	h       PackHandle
+	err     error
+	flushes int
+}
+
+func (f *sendingFlusher) Flush() {
+	f.flushes++
+	if f.h != nil {
+		f.err = f.h.SendEvent(Event{EventDef: EventDef{Name: "Flushed"}})
+	}
+}
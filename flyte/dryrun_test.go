@@ -0,0 +1,68 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/ExpediaGroup/flyte-client/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+)
+
+func Test_NewDefaultPack_ShouldNotCallTheFlyteApiInDryRunMode(t *testing.T) {
+	actions := writePackFile(t, "actions.json", `[{"command": "SendMessage", "input": {"message": "hello"}}]`)
+	prevGetEnv := config.GetEnv
+	defer func() { config.GetEnv = prevGetEnv }()
+	config.GetEnv = func(name string) string {
+		switch name {
+		case "FLYTE_DRY_RUN":
+			return "true"
+		case "FLYTE_DRY_RUN_ACTIONS":
+			return actions
+		case "FLYTE_API":
+			return "https://flyte.invalid"
+		}
+		return ""
+	}
+
+	// when
+	helpURL, _ := url.Parse("http://example.com/help")
+	p := NewDefaultPack(PackDef{Name: "DryRunPack", HelpURL: helpURL}).(pack)
+
+	// then the pack registers without a flyte api and takes the scripted actions
+	assert.Zero(t, p.retryWait)
+	assert.NoError(t, p.register())
+	a, err := p.client.TakeAction()
+	require.NoError(t, err)
+	require.NotNil(t, a)
+	assert.Equal(t, "SendMessage", a.CommandName)
+	assert.JSONEq(t, `{"message": "hello"}`, string(a.Input))
+	a, err = p.client.TakeAction()
+	assert.NoError(t, err)
+	assert.Nil(t, a)
+}
+
+func Test_newDryRunClient_ShouldTakeNoActionsIfTheScriptIsInvalid(t *testing.T) {
+	actions := writePackFile(t, "actions.json", `{"command": "SendMessage"}`)
+
+	c := newDryRunClient(actions)
+
+	a, err := c.TakeAction()
+	assert.NoError(t, err)
+	assert.Nil(t, a)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrorEventDef is the definition of the event created by NewErrorEvent. Add it to the output events of commands that
+// return it.
+var ErrorEventDef = EventDef{Name: "Error"}
+
+// ErrorPayload is the payload of the events created by NewErrorEvent and NewFatalErrorEvent, so flows can handle the
+// failures of any pack in the same way.
+type ErrorPayload struct {
+	Code          string      `json:"code,omitempty"`          // identifies the kind of failure, e.g. "IssueNotFound"
+	Message       string      `json:"message"`                 // describes the failure
+	Type          string      `json:"type,omitempty"`          // the Go type of the error, when created from one
+	Details       interface{} `json:"details,omitempty"`       // any further details, e.g. the input that failed
+	Stack         string      `json:"stack,omitempty"`         // the stack trace, see ErrorWithStack
+	CorrelationID string      `json:"correlationId,omitempty"` // the flow execution correlation id, see ErrorWithCorrelation
+}
+
+// String returns the code and message, so failures are logged readably.
+func (p ErrorPayload) String() string {
+	if p.Code == "" {
+		return p.Message
+	}
+	return p.Code + ": " + p.Message
+}
+
+// ErrorOption adds optional information to the payload of an error event.
+type ErrorOption func(*ErrorPayload)
+
+// ErrorWithStack adds the stack trace of the goroutine creating the event to the payload.
+func ErrorWithStack() ErrorOption {
+	return func(p *ErrorPayload) {
+		p.Stack = string(debug.Stack())
+	}
+}
+
+// ErrorWithCorrelation adds the correlation id of the action being handled to the payload, when the context passed to
+// a ContextHandler (or one derived from it) is passed in.
+func ErrorWithCorrelation(ctx context.Context) ErrorOption {
+	return func(p *ErrorPayload) {
+		if c := correlation(ctx); c != nil {
+			p.CorrelationID = c.ID
+		}
+	}
+}
+
+// NewErrorEvent creates an event (see ErrorEventDef) for a failure that flows are expected to handle, with the code,
+// message and details passed in as its payload.
+func NewErrorEvent(code, message string, details interface{}, opts ...ErrorOption) Event {
+	return Event{
+		EventDef: ErrorEventDef,
+		Payload:  newErrorPayload(ErrorPayload{Code: code, Message: message, Details: details}, opts),
+	}
+}
+
+// NewFatalErrorEvent creates a FATAL event (see NewFatalEvent) for the error passed in, with its message and type as
+// the payload.
+func NewFatalErrorEvent(err error, opts ...ErrorOption) Event {
+	return Event{
+		EventDef: EventDef{Name: fatalEventName},
+		Payload:  newErrorPayload(errorPayload(err), opts),
+	}
+}
+
+func errorPayload(err error) ErrorPayload {
+	if err == nil {
+		return ErrorPayload{Message: "unknown error"}
+	}
+	return ErrorPayload{Message: err.Error(), Type: fmt.Sprintf("%T", err)}
+}
+
+func newErrorPayload(p ErrorPayload, opts []ErrorOption) ErrorPayload {
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
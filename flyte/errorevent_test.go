@@ -0,0 +1,68 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_NewErrorEvent_ShouldCreateAStandardErrorPayload(t *testing.T) {
+	ctx := actionContext(&client.Action{ID: "42", CorrelationID: "c-1"})
+
+	e := NewErrorEvent("IssueNotFound", "issue ABC-1 does not exist", map[string]string{"issue": "ABC-1"},
+		ErrorWithCorrelation(ctx), ErrorWithStack())
+
+	assert.Equal(t, ErrorEventDef, e.EventDef)
+	payload := e.Payload.(ErrorPayload)
+	assert.Equal(t, "IssueNotFound", payload.Code)
+	assert.Equal(t, "issue ABC-1 does not exist", payload.Message)
+	assert.Equal(t, "c-1", payload.CorrelationID)
+	assert.Contains(t, payload.Stack, "Test_NewErrorEvent_ShouldCreateAStandardErrorPayload")
+	assert.Equal(t, "IssueNotFound: issue ABC-1 does not exist", payload.String())
+
+	b, err := json.Marshal(e.Payload)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"details":{"issue":"ABC-1"}`)
+}
+
+type issueError struct{ issue string }
+
+func (e issueError) Error() string { return "cannot update issue " + e.issue }
+
+func Test_NewFatalErrorEvent_ShouldIncludeTheErrorMessageAndType(t *testing.T) {
+	e := NewFatalErrorEvent(issueError{"ABC-1"})
+
+	assert.Equal(t, fatalEventName, e.EventDef.Name)
+	assert.Equal(t, ErrorPayload{Message: "cannot update issue ABC-1", Type: "flyte.issueError"}, e.Payload)
+}
+
+func Test_NewFatalEvent_ShouldUseTheStandardPayloadForErrors(t *testing.T) {
+	assert.Equal(t, ErrorPayload{Message: "boom", Type: "*errors.errorString"}, NewFatalEvent(errors.New("boom")).Payload)
+	assert.Equal(t, "boom", NewFatalEvent("boom").Payload)
+}
+
+func Test_ErrorWithCorrelation_ShouldIgnoreContextsWithoutACorrelation(t *testing.T) {
+	e := NewErrorEvent("Failed", "failed", nil, ErrorWithCorrelation(context.Background()))
+
+	assert.Empty(t, e.Payload.(ErrorPayload).CorrelationID)
+}
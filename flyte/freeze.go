@@ -0,0 +1,121 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"sync"
+)
+
+// ExecutionFrozenEventDef is the definition of the event actions are completed with while execution is frozen (see
+// FreezeSwitch). It is added to the output events of every command that is not read only when the pack has a
+// FreezeSwitch.
+var ExecutionFrozenEventDef = EventDef{
+	Name:        "ExecutionFrozen",
+	Description: "the command was not run because execution of the pack is frozen",
+}
+
+// ExecutionFrozenPayload is the payload of the "ExecutionFrozen" event.
+type ExecutionFrozenPayload struct {
+	Command string `json:"command"`          // the command that was not run
+	Reason  string `json:"reason,omitempty"` // why execution is frozen, as passed to FreezeSwitch.Freeze
+}
+
+// NewExecutionFrozenEvent creates the event an action for the command passed in is completed with while execution
+// is frozen.
+func NewExecutionFrozenEvent(command, reason string) Event {
+	return Event{
+		EventDef: ExecutionFrozenEventDef,
+		Payload:  ExecutionFrozenPayload{Command: command, Reason: reason},
+	}
+}
+
+// FreezeSwitch puts packs into read only mode at runtime, e.g. for a change freeze. While frozen, packs keep polling
+// for actions and log the actions they would have run, but only run commands marked as ReadOnly: other actions are
+// completed with an "ExecutionFrozen" event instead (see NewExecutionFrozenEvent). One switch can be shared by any
+// number of packs, see WithFreezeSwitch. A FreezeSwitch is safe for concurrent use.
+type FreezeSwitch struct {
+	mu     sync.RWMutex
+	frozen bool
+	reason string
+}
+
+// NewFreezeSwitch creates a FreezeSwitch, which is not frozen.
+func NewFreezeSwitch() *FreezeSwitch {
+	return &FreezeSwitch{}
+}
+
+// Freeze stops packs using the switch running commands that are not read only, until Unfreeze is called. The reason
+// is included in the "ExecutionFrozen" events.
+func (s *FreezeSwitch) Freeze(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.frozen, s.reason = true, reason
+}
+
+// Unfreeze lets packs using the switch run every command again.
+func (s *FreezeSwitch) Unfreeze() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.frozen, s.reason = false, ""
+}
+
+// Frozen returns true and the reason passed to Freeze while execution is frozen. A nil switch is never frozen.
+func (s *FreezeSwitch) Frozen() (bool, string) {
+	if s == nil {
+		return false, ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.frozen, s.reason
+}
+
+// WithFreezeSwitch lets the switch passed in freeze the execution of the pack commands that are not read only.
+func WithFreezeSwitch(s *FreezeSwitch) Option {
+	return func(p *pack) {
+		p.freeze = s
+	}
+}
+
+// frozen returns true and the reason if the command is not to be run because execution is frozen
+func (p pack) frozen(command string) (bool, string) {
+	frozen, reason := p.freeze.Frozen()
+	if !frozen {
+		return false, ""
+	}
+	for _, c := range p.Commands {
+		if c.Name == command && c.ReadOnly {
+			return false, ""
+		}
+	}
+	return true, reason
+}
+
+// freezableCommands returns the commands with the "ExecutionFrozen" event added to the output events of those that
+// can be frozen, when the pack has a FreezeSwitch
+func (p pack) freezableCommands() []Command {
+	if p.freeze == nil {
+		return p.Commands
+	}
+	commands := make([]Command, len(p.Commands))
+	for i, c := range p.Commands {
+		if !c.ReadOnly {
+			c.OutputEvents = append(c.OutputEvents[:len(c.OutputEvents):len(c.OutputEvents)], ExecutionFrozenEventDef)
+		}
+		commands[i] = c
+	}
+	return commands
+}
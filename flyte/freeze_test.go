@@ -0,0 +1,95 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_FreezeSwitch_ShouldOnlyRunReadOnlyCommandsWhileFrozen(t *testing.T) {
+	// given a pack with a read only command and a command with side effects
+	var events []client.Event
+	freeze := NewFreezeSwitch()
+	p := NewPackWithOptions(PackDef{
+		Name:     "JiraPack",
+		Commands: []Command{{Name: "GetIssue", ReadOnly: true}, {Name: "CreateIssue"}},
+	}, MockClient{
+		completeAction: func(_ client.Action, e client.Event) error {
+			events = append(events, e)
+			return nil
+		},
+	}, WithFreezeSwitch(freeze)).(pack)
+	ran := map[string]int{}
+	handler := func(command string) ContextHandler {
+		return CommandHandler(func(json.RawMessage) Event {
+			ran[command]++
+			return Event{EventDef: EventDef{Name: "Done"}}
+		}).withContext()
+	}
+	handlers := map[string]ContextHandler{"GetIssue": handler("GetIssue"), "CreateIssue": handler("CreateIssue")}
+
+	// when execution is frozen
+	freeze.Freeze("change freeze")
+	p.handleAction(&client.Action{ID: "1", CommandName: "GetIssue"}, handlers)
+	p.handleAction(&client.Action{ID: "2", CommandName: "CreateIssue"}, handlers)
+	// and unfrozen
+	freeze.Unfreeze()
+	p.handleAction(&client.Action{ID: "3", CommandName: "CreateIssue"}, handlers)
+
+	// then
+	assert.Equal(t, map[string]int{"GetIssue": 1, "CreateIssue": 1}, ran)
+	require.Len(t, events, 3)
+	assert.Equal(t, "Done", events[0].Name)
+	assert.Equal(t, "ExecutionFrozen", events[1].Name)
+	assert.Equal(t, ExecutionFrozenPayload{Command: "CreateIssue", Reason: "change freeze"}, events[1].Payload)
+	assert.Equal(t, "Done", events[2].Name)
+	assert.Equal(t, uint64(1), p.Handle().Stats().ActionsFrozen)
+}
+
+func Test_FreezeSwitch_ShouldAddTheFrozenEventToCommandsThatCanBeFrozen(t *testing.T) {
+	// given
+	issueCreated := EventDef{Name: "IssueCreated"}
+	outputEvents := []EventDef{issueCreated}
+	p := NewPackWithOptions(PackDef{
+		Name: "JiraPack",
+		Commands: []Command{
+			{Name: "GetIssue", ReadOnly: true, OutputEvents: []EventDef{{Name: "Issue"}}},
+			{Name: "CreateIssue", OutputEvents: outputEvents},
+		},
+	}, MockClient{}, WithFreezeSwitch(NewFreezeSwitch())).(pack)
+
+	// when
+	registered := p.clientPack()
+
+	// then
+	require.Len(t, registered.Commands, 2)
+	assert.Equal(t, []string{"Issue"}, registered.Commands[0].EventNames)
+	assert.Equal(t, []string{"IssueCreated", "ExecutionFrozen"}, registered.Commands[1].EventNames)
+	assert.Equal(t, []EventDef{issueCreated}, outputEvents, "the command definition should not be changed")
+}
+
+func Test_FreezeSwitch_ShouldNotBeFrozenWhenNil(t *testing.T) {
+	var s *FreezeSwitch
+
+	frozen, _ := s.Frozen()
+
+	assert.False(t, frozen)
+}
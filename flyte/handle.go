@@ -0,0 +1,378 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrPackNotStarted is returned by a PackHandle when the pack has not yet registered with the flyte server.
+	ErrPackNotStarted = errors.New("pack has not been started")
+	// ErrPackStopped is returned by a PackHandle once the pack has been stopped.
+	ErrPackStopped = errors.New("pack has been stopped")
+	// ErrPackNotFound is returned by PackHandle.Err when the pack stopped itself because the flyte server no longer
+	// knew about it while it was polling for actions.
+	ErrPackNotFound = errors.New("pack was not found by the flyte server")
+)
+
+// PackHandle gives goroutines that run alongside a pack (watchers, schedulers and so on) safe access to the pack.
+// A handle can be shared between any number of goroutines.
+//
+// Events can only be sent through a handle while the pack is running: before the pack has registered with the flyte
+// server SendEvent returns ErrPackNotStarted, and once the pack has been stopped it returns ErrPackStopped.
+// Goroutines can wait on Started() before producing events, and should return once Done() is closed.
+type PackHandle interface {
+	// SendEvent spontaneously sends an event that the pack has observed to the flyte server.
+	SendEvent(Event) error
+	// SendEventWithContext sends an event in the same way as SendEvent, attaching the correlation identifiers of the
+	// action being handled, if the context passed to a ContextHandler (or one derived from it) is passed in.
+	SendEventWithContext(context.Context, Event) error
+	// Logger returns a logger that annotates every entry with the pack name.
+	Logger() zerolog.Logger
+	// Datastore gives access to the flyte api datastore.
+	Datastore() client.Datastore
+	// State gives access to state the pack keeps in the flyte api datastore, such as cursors and offsets.
+	State() *PackState
+	// Stats returns a snapshot of the pack activity counters for the current run of the pack.
+	Stats() Stats
+	// LifetimeStats returns a snapshot of the pack activity counters across restarts, when the pack persists its
+	// counters (see WithPersistentStats). Otherwise it is the same as Stats().
+	LifetimeStats() Stats
+	// Started is closed once the pack has registered with the flyte server.
+	Started() <-chan struct{}
+	// Done is closed once the pack has been stopped.
+	Done() <-chan struct{}
+	// Err returns why the pack stopped itself, such as ErrPackNotFound, or nil if it is running or was stopped with
+	// Stop.
+	Err() error
+}
+
+// Stats holds counters describing the activity of a pack.
+type Stats struct {
+	ActionsTaken     uint64 `json:"actionsTaken"`     // actions taken from the flyte server
+	ActionsCompleted uint64 `json:"actionsCompleted"` // actions whose result event was posted to the flyte server
+	ActionsFailed    uint64 `json:"actionsFailed"`    // actions whose result event could not be posted to the flyte server
+	EventsSent       uint64 `json:"eventsSent"`       // spontaneous events posted to the flyte server
+	EventsFailed     uint64 `json:"eventsFailed"`     // spontaneous events that could not be posted to the flyte server
+	CleanupsFailed   uint64 `json:"cleanupsFailed"`   // action cleanup functions that failed, see AddCleanup
+	// actions that were not run because execution was frozen, see FreezeSwitch
+	ActionsFrozen uint64 `json:"actionsFrozen,omitempty"`
+	// action results that could not be posted and were queued to be posted again, see WithResultQueue
+	ResultsQueued uint64 `json:"resultsQueued,omitempty"`
+	// action results that were never posted to the flyte server, see LifecycleHooks.OnResultAbandoned
+	ResultsAbandoned uint64 `json:"resultsAbandoned,omitempty"`
+	// spontaneous events that were not sent as an identical event was sent recently, see WithEventDedup
+	EventsDeduplicated uint64 `json:"eventsDeduplicated,omitempty"`
+	// actions taken by a previous run of the pack that were handled again, see WithActionLog
+	ActionsRedriven uint64 `json:"actionsRedriven,omitempty"`
+	// what happened while the pack was stopping, once it has stopped
+	Drain *DrainSummary `json:"drain,omitempty"`
+}
+
+func (s Stats) plus(o Stats) Stats {
+	return Stats{
+		ActionsTaken:       s.ActionsTaken + o.ActionsTaken,
+		ActionsCompleted:   s.ActionsCompleted + o.ActionsCompleted,
+		ActionsFailed:      s.ActionsFailed + o.ActionsFailed,
+		EventsSent:         s.EventsSent + o.EventsSent,
+		EventsFailed:       s.EventsFailed + o.EventsFailed,
+		CleanupsFailed:     s.CleanupsFailed + o.CleanupsFailed,
+		ActionsFrozen:      s.ActionsFrozen + o.ActionsFrozen,
+		ResultsQueued:      s.ResultsQueued + o.ResultsQueued,
+		ResultsAbandoned:   s.ResultsAbandoned + o.ResultsAbandoned,
+		EventsDeduplicated: s.EventsDeduplicated + o.EventsDeduplicated,
+		ActionsRedriven:    s.ActionsRedriven + o.ActionsRedriven,
+	}
+}
+
+// Handle returns a handle onto the pack that can be used from any goroutine.
+func (p pack) Handle() PackHandle {
+	return packHandle{p}
+}
+
+type packHandle struct {
+	p pack
+}
+
+func (h packHandle) SendEvent(event Event) error {
+	return h.SendEventWithContext(context.Background(), event)
+}
+
+func (h packHandle) SendEventWithContext(ctx context.Context, event Event) error {
+	select {
+	case <-h.p.lifecycle.sendingStopped():
+		return ErrPackStopped
+	default:
+	}
+	select {
+	case <-h.p.lifecycle.Started():
+	default:
+		return ErrPackNotStarted
+	}
+	return h.p.sendEvent(event, correlation(ctx))
+}
+
+func (h packHandle) Logger() zerolog.Logger {
+	return log.With().Str("pack", h.p.Name).Logger()
+}
+
+func (h packHandle) Datastore() client.Datastore {
+	return datastoreOf(h.p.client)
+}
+
+func (h packHandle) State() *PackState {
+	return NewPackState(datastoreOf(h.p.client), h.p.Name)
+}
+
+func (h packHandle) Stats() Stats {
+	s := h.p.counters.snapshot()
+	s.Drain = h.p.lifecycle.drainSummary()
+	return s
+}
+
+func (h packHandle) LifetimeStats() Stats {
+	return h.p.statsStore.lifetime(h.p.counters)
+}
+
+func (h packHandle) Started() <-chan struct{} {
+	return h.p.lifecycle.Started()
+}
+
+func (h packHandle) Done() <-chan struct{} {
+	return h.p.lifecycle.Done()
+}
+
+func (h packHandle) Err() error {
+	return h.p.lifecycle.err()
+}
+
+// lifecycle tracks whether a pack has been started and/or stopped. It is shared by all copies of a pack.
+type lifecycle struct {
+	polled  int64 // when the pack last polled the flyte server for actions, in unix nanoseconds. First for 64 bit alignment
+	once    sync.Once
+	started chan struct{}
+	stop    sync.Once
+	done    chan struct{}
+	mu      sync.Mutex
+	drain   *DrainSummary
+	failure error // why the pack stopped itself, if it did
+	// closed once the pack has stopped sending events, at the end of ShutdownFlushEvents
+	closeSending sync.Once
+	sending      chan struct{}
+	// 1 while the pack is subscribed to an action stream, rather than polling
+	streaming int32
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{started: make(chan struct{}), done: make(chan struct{}), sending: make(chan struct{})}
+}
+
+// markStarted records that the pack has registered with the flyte server
+func (l *lifecycle) markStarted() {
+	if l != nil {
+		l.once.Do(func() { close(l.started) })
+	}
+}
+
+// markStopped records that the pack has been stopped, returning false if it had already been stopped
+func (l *lifecycle) markStopped() bool {
+	if l == nil {
+		return true
+	}
+	stopped := false
+	l.stop.Do(func() {
+		close(l.done)
+		stopped = true
+	})
+	return stopped
+}
+
+// stopSending records that the pack can no longer send events
+func (l *lifecycle) stopSending() {
+	if l != nil {
+		l.closeSending.Do(func() { close(l.sending) })
+	}
+}
+
+// sendingStopped is closed once the pack can no longer send events, which is after it has stopped taking actions
+func (l *lifecycle) sendingStopped() <-chan struct{} {
+	if l == nil {
+		return nil
+	}
+	return l.sending
+}
+
+func (l *lifecycle) setDrainSummary(s DrainSummary) {
+	if l != nil {
+		l.mu.Lock()
+		l.drain = &s
+		l.mu.Unlock()
+	}
+}
+
+// fail records why the pack is stopping itself. Only the first failure is kept
+func (l *lifecycle) fail(err error) {
+	if l != nil {
+		l.mu.Lock()
+		if l.failure == nil {
+			l.failure = err
+		}
+		l.mu.Unlock()
+	}
+}
+
+// err returns why the pack stopped itself, or nil if it has not
+func (l *lifecycle) err() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.failure
+}
+
+// drainSummary returns what happened while the pack was stopping, or nil if it has not stopped
+func (l *lifecycle) drainSummary() *DrainSummary {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.drain == nil {
+		return nil
+	}
+	s := *l.drain
+	return &s
+}
+
+// markPolled records that the pack has just polled the flyte server for actions
+func (l *lifecycle) markPolled() {
+	if l != nil {
+		atomic.StoreInt64(&l.polled, time.Now().UnixNano())
+	}
+}
+
+// setStreaming records whether the pack is subscribed to an action stream
+func (l *lifecycle) setStreaming(streaming bool) {
+	if l != nil {
+		var v int32
+		if streaming {
+			v = 1
+		}
+		atomic.StoreInt32(&l.streaming, v)
+	}
+}
+
+// isStreaming returns whether the pack is subscribed to an action stream
+func (l *lifecycle) isStreaming() bool {
+	return l != nil && atomic.LoadInt32(&l.streaming) == 1
+}
+
+// lastPolled returns when the pack last polled the flyte server for actions, or the zero time if it has not yet polled
+func (l *lifecycle) lastPolled() time.Time {
+	if l == nil {
+		return time.Time{}
+	}
+	polled := atomic.LoadInt64(&l.polled)
+	if polled == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, polled)
+}
+
+// Started returns a channel closed once the pack has started. A nil lifecycle never starts.
+func (l *lifecycle) Started() <-chan struct{} {
+	if l == nil {
+		return nil
+	}
+	return l.started
+}
+
+// Done returns a channel closed once the pack has stopped. A nil lifecycle never stops.
+func (l *lifecycle) Done() <-chan struct{} {
+	if l == nil {
+		return nil
+	}
+	return l.done
+}
+
+// counter identifies one of the pack activity counters
+type counter int
+
+const (
+	actionsTaken counter = iota
+	actionsCompleted
+	actionsFailed
+	eventsSent
+	eventsFailed
+	cleanupsFailed
+	actionsFrozen
+	resultsQueued
+	resultsAbandoned
+	eventsDeduplicated
+	actionsRedriven
+	counterCount
+)
+
+// counters are updated atomically as the pack handles actions and sends events. They are shared by all copies of a pack.
+type counters [counterCount]uint64
+
+// add atomically increments the counter passed in, ignoring nil counters
+func (c *counters) add(n counter) {
+	if c != nil {
+		atomic.AddUint64(&c[n], 1)
+	}
+}
+
+// inFlight returns the number of actions that have been taken but not yet completed. Actions whose result has been
+// queued are not in flight.
+func (c *counters) inFlight() int {
+	s := c.snapshot()
+	// the counters are read one at a time, so an action may be counted as finished but not yet as taken
+	n := int64(s.ActionsTaken) - int64(s.ActionsCompleted) - int64(s.ActionsFailed) - int64(s.ResultsQueued)
+	if n < 0 {
+		return 0
+	}
+	return int(n)
+}
+
+func (c *counters) snapshot() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	return Stats{
+		ActionsTaken:       atomic.LoadUint64(&c[actionsTaken]),
+		ActionsCompleted:   atomic.LoadUint64(&c[actionsCompleted]),
+		ActionsFailed:      atomic.LoadUint64(&c[actionsFailed]),
+		EventsSent:         atomic.LoadUint64(&c[eventsSent]),
+		EventsFailed:       atomic.LoadUint64(&c[eventsFailed]),
+		CleanupsFailed:     atomic.LoadUint64(&c[cleanupsFailed]),
+		ActionsFrozen:      atomic.LoadUint64(&c[actionsFrozen]),
+		ResultsQueued:      atomic.LoadUint64(&c[resultsQueued]),
+		ResultsAbandoned:   atomic.LoadUint64(&c[resultsAbandoned]),
+		EventsDeduplicated: atomic.LoadUint64(&c[eventsDeduplicated]),
+		ActionsRedriven:    atomic.LoadUint64(&c[actionsRedriven]),
+	}
+}
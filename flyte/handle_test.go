@@ -0,0 +1,134 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_PackHandle_ShouldNotSendEventsBeforeThePackIsStarted(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	c := MockClient{
+		postEvent: func(client.Event) error {
+			assert.Fail(t, "postEvent called unexpectedly")
+			return nil
+		},
+	}
+	h := NewPack(PackDef{Name: "HandlePack"}, c).(pack).Handle()
+
+	err := h.SendEvent(Event{EventDef: EventDef{Name: "Observed"}})
+
+	assert.Equal(t, ErrPackNotStarted, err)
+}
+
+func Test_PackHandle_ShouldSendEventsFromOtherGoroutinesOnceStarted(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	var posted int32
+	c := MockClient{
+		createPack: func(client.Pack) error {
+			return nil
+		},
+		postEvent: func(e client.Event) error {
+			atomic.AddInt32(&posted, 1)
+			if e.Payload == "bad" {
+				return errors.New("rejected")
+			}
+			return nil
+		},
+	}
+	p := NewPack(PackDef{Name: "HandlePack2"}, c)
+	h := p.(pack).Handle()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-h.Started()
+			assert.NoError(t, h.SendEvent(Event{EventDef: EventDef{Name: "Observed"}}))
+		}()
+	}
+	p.Start()
+	wg.Wait()
+
+	assert.Error(t, h.SendEvent(Event{EventDef: EventDef{Name: "Observed"}, Payload: "bad"}))
+	assert.Equal(t, int32(11), atomic.LoadInt32(&posted))
+	assert.Equal(t, Stats{EventsSent: 10, EventsFailed: 1}, h.Stats())
+}
+
+func Test_PackHandle_ShouldNotSendEventsOnceThePackIsStopped(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	c := MockClient{
+		createPack: func(client.Pack) error {
+			return nil
+		},
+		postEvent: func(client.Event) error {
+			assert.Fail(t, "postEvent called unexpectedly")
+			return nil
+		},
+	}
+	p := NewPack(PackDef{Name: "HandlePack3"}, c)
+	h := p.(pack).Handle()
+	p.Start()
+
+	p.(pack).Stop()
+
+	select {
+	case <-h.Done():
+	default:
+		assert.Fail(t, "handle should be done once the pack is stopped")
+	}
+	assert.Equal(t, ErrPackStopped, h.SendEvent(Event{EventDef: EventDef{Name: "Observed"}}))
+}
+
+func Test_Stop_ShouldStopPollingForActions(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	var polls int32
+	c := MockClient{
+		createPack: func(client.Pack) error {
+			return nil
+		},
+		takeAction: func() (*client.Action, error) {
+			atomic.AddInt32(&polls, 1)
+			return nil, nil
+		},
+	}
+	p := NewPack(PackDef{Name: "HandlePack4", Commands: []Command{{Name: "doSomething"}}}, c)
+	realPack := p.(pack)
+	realPack.pollingFrequency = time.Millisecond
+	realPack.Start()
+	time.Sleep(20 * time.Millisecond)
+
+	realPack.Stop()
+	time.Sleep(5 * time.Millisecond)
+	stoppedAt := atomic.LoadInt32(&polls)
+	time.Sleep(20 * time.Millisecond)
+
+	require.True(t, stoppedAt > 0)
+	assert.Equal(t, stoppedAt, atomic.LoadInt32(&polls))
+}
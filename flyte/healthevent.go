@@ -0,0 +1,67 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/ExpediaGroup/flyte-client/healthcheck"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+const packHealthEventName = "PackHealth"
+
+// PackHealthPayload is the payload of the "PackHealth" event.
+type PackHealthPayload struct {
+	Healthy bool                          `json:"healthy"` // whether all the health checks passed
+	Checks  map[string]healthcheck.Health `json:"checks"`  // the result of each health check
+}
+
+// WithHealthEvent makes the pack run its health checks (see WithHealthChecks) every interval once it has started, and
+// send the results to the flyte server in a "PackHealth" event. Flows can use these events to alert on unhealthy packs.
+func WithHealthEvent(interval time.Duration) Option {
+	return func(p *pack) {
+		p.EventDefs = append(append([]EventDef(nil), p.EventDefs...), EventDef{Name: packHealthEventName})
+		p.healthEventInterval = interval
+	}
+}
+
+// sendHealthEvents sends a "PackHealth" event every health event interval until the pack is stopped
+func (p pack) sendHealthEvents() {
+	if p.healthEventInterval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(p.healthEventInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.lifecycle.Done():
+				return
+			case <-ticker.C:
+				p.sendHealthEvent()
+			}
+		}
+	}()
+}
+
+func (p pack) sendHealthEvent() {
+	healthy, results := runHealthChecks(p.healthChecks)
+	event := Event{EventDef: EventDef{Name: packHealthEventName}, Payload: PackHealthPayload{Healthy: healthy, Checks: results}}
+	if err := p.SendEvent(event); err != nil {
+		log.Err(err).Msg("could not send pack health event")
+	}
+}
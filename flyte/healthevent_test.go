@@ -0,0 +1,56 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/ExpediaGroup/flyte-client/healthcheck"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_WithHealthEvent_ShouldPeriodicallySendHealthCheckResults(t *testing.T) {
+	// given
+	events := make(chan client.Event, 10)
+	dbCheck := func() (string, healthcheck.Health) {
+		return "db", healthcheck.Health{Healthy: false, Status: "db unreachable"}
+	}
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, MockClient{
+		postEvent: func(event client.Event) error {
+			events <- event
+			return nil
+		},
+	}, WithHealthChecks(dbCheck), WithHealthEvent(10*time.Millisecond)).(pack)
+	defer p.Stop()
+
+	// when
+	p.sendHealthEvents()
+
+	// then
+	select {
+	case event := <-events:
+		assert.Equal(t, "PackHealth", event.Name)
+		assert.Equal(t, PackHealthPayload{
+			Healthy: false,
+			Checks:  map[string]healthcheck.Health{"db": {Healthy: false, Status: "db unreachable"}},
+		}, event.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("health event was not sent")
+	}
+	assert.Equal(t, []EventDef{{Name: "PackHealth"}}, p.EventDefs)
+}
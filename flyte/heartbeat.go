@@ -0,0 +1,146 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"time"
+)
+
+// ErrActionLeaseLost is returned by HeartbeatErr once the flyte server no longer knows the action being handled, for
+// example because its lease expired, so its result will not be accepted.
+var ErrActionLeaseLost = errors.New("the flyte server no longer knows the action")
+
+// WithActionHeartbeat makes the pack tell the flyte server every interval that each action whose handler is still
+// running is being handled, extending the action lease, so the flyte server can tell a slow handler from a dead pack.
+// The first heartbeat is sent an interval after the handler starts, so quick handlers send none. Heartbeats are posted
+// to the "actionHeartbeat" action link; actions without one get no heartbeats. The client must implement
+// client.ActionHeartbeater, as the client returned by client.NewClient does, otherwise no heartbeats are sent.
+//
+// Handlers can check HeartbeatErr with the context they are passed to find out if heartbeats are failing. If the
+// flyte server no longer knows the action, the context is cancelled too, as the action result will not be accepted.
+func WithActionHeartbeat(interval time.Duration) Option {
+	return func(p *pack) {
+		heartbeater, ok := p.client.(client.ActionHeartbeater)
+		if !ok {
+			log.Warn().Msgf("the client does not support action heartbeats, none will be sent")
+			return
+		}
+		if interval <= 0 {
+			p.heartbeats = nil
+			return
+		}
+		p.heartbeats = &heartbeats{client: heartbeater, interval: interval}
+	}
+}
+
+// HeartbeatErr returns why the last heartbeat for the action being handled failed, or ErrActionLeaseLost (wrapping the
+// heartbeat error) once the flyte server no longer knows the action. It returns nil if the last heartbeat succeeded,
+// none has been sent yet, or the context is not the context of an action with heartbeats.
+func HeartbeatErr(ctx context.Context) error {
+	b, ok := ctx.Value(heartbeatKey{}).(*actionHeartbeat)
+	if !ok {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+type heartbeatKey struct{}
+
+// heartbeats sends heartbeats for the actions being handled by the pack
+type heartbeats struct {
+	client   client.ActionHeartbeater
+	interval time.Duration
+}
+
+// actionHeartbeat sends heartbeats for one action until it is stopped
+type actionHeartbeat struct {
+	mu      sync.Mutex
+	err     error
+	done    chan struct{}
+	stopped chan struct{}
+	cancel  context.CancelFunc
+}
+
+// begin starts sending heartbeats for the action, returning the context for its handler and the heartbeat to stop
+// once the handler returns. A nil heartbeats sends none.
+func (h *heartbeats) begin(ctx context.Context, a *client.Action) (context.Context, *actionHeartbeat) {
+	if h == nil {
+		return ctx, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	b := &actionHeartbeat{done: make(chan struct{}), stopped: make(chan struct{}), cancel: cancel}
+	go func() {
+		defer close(b.stopped)
+		b.run(h, a, cancel)
+	}()
+	return context.WithValue(ctx, heartbeatKey{}, b), b
+}
+
+// run sends a heartbeat every interval until the heartbeat is stopped, the flyte server does not support heartbeats
+// for the action, or it no longer knows the action, when the handler context is cancelled
+func (b *actionHeartbeat) run(h *heartbeats, a *client.Action, cancel context.CancelFunc) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+		}
+		err := h.client.HeartbeatAction(*a)
+		switch {
+		case err == nil:
+			b.setErr(nil)
+		case errors.Is(err, client.ErrHeartbeatNotSupported):
+			log.Debug().Msgf("the flyte server does not support heartbeats for action %q", a.ID)
+			return
+		case errors.Is(err, client.ErrNotFound):
+			log.Warn().Err(err).Str("command", a.CommandName).Msgf("the flyte server no longer knows action %q, cancelling it", a.ID)
+			b.setErr(fmt.Errorf("%w: %v", ErrActionLeaseLost, err))
+			cancel()
+			return
+		default:
+			log.Warn().Err(err).Str("command", a.CommandName).Msgf("could not send heartbeat for action %q", a.ID)
+			b.setErr(err)
+		}
+	}
+}
+
+func (b *actionHeartbeat) setErr(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.err = err
+}
+
+// stop stops sending heartbeats, waiting for a heartbeat being sent, and releases the handler context, which the
+// handler has finished with. A nil heartbeat does nothing.
+func (b *actionHeartbeat) stop() {
+	if b == nil {
+		return
+	}
+	close(b.done)
+	<-b.stopped
+	b.cancel()
+}
@@ -0,0 +1,133 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// heartbeatClient is a MockClient that supports action heartbeats
+type heartbeatClient struct {
+	MockClient
+	heartbeat func(client.Action) error
+}
+
+func (c heartbeatClient) HeartbeatAction(a client.Action) error {
+	return c.heartbeat(a)
+}
+
+func newHeartbeatPack(heartbeat func(client.Action) error) (pack, chan client.Event) {
+	completed := make(chan client.Event, 1)
+	c := heartbeatClient{
+		MockClient: MockClient{completeAction: func(_ client.Action, e client.Event) error {
+			completed <- e
+			return nil
+		}},
+		heartbeat: heartbeat,
+	}
+	return NewPackWithOptions(PackDef{Name: "BuildPack"}, c, WithActionHeartbeat(10*time.Millisecond)).(pack), completed
+}
+
+func Test_ActionHeartbeat_ShouldSendHeartbeatsWhileTheHandlerRuns(t *testing.T) {
+	var beats int32
+	p, _ := newHeartbeatPack(func(a client.Action) error {
+		assert.Equal(t, "a1", a.ID)
+		atomic.AddInt32(&beats, 1)
+		return nil
+	})
+	handler := func(ctx context.Context, _ json.RawMessage) Event {
+		time.Sleep(55 * time.Millisecond)
+		assert.NoError(t, HeartbeatErr(ctx))
+		return Event{EventDef: EventDef{Name: "BuildFinished"}}
+	}
+
+	p.handleAction(&client.Action{ID: "a1", CommandName: "Build"}, map[string]ContextHandler{"Build": handler})
+
+	sent := atomic.LoadInt32(&beats)
+	assert.True(t, sent >= 2, "%d heartbeats were sent", sent)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, sent, atomic.LoadInt32(&beats), "no heartbeats are sent once the handler returns")
+}
+
+func Test_ActionHeartbeat_ShouldExposeHeartbeatFailuresToTheHandler(t *testing.T) {
+	unavailable := errors.New("flyte server unavailable")
+	p, _ := newHeartbeatPack(func(client.Action) error { return unavailable })
+	var heartbeatErr error
+	handler := func(ctx context.Context, _ json.RawMessage) Event {
+		time.Sleep(25 * time.Millisecond)
+		heartbeatErr = HeartbeatErr(ctx)
+		return Event{EventDef: EventDef{Name: "BuildFinished"}}
+	}
+
+	p.handleAction(&client.Action{ID: "a1", CommandName: "Build"}, map[string]ContextHandler{"Build": handler})
+
+	assert.Equal(t, unavailable, heartbeatErr)
+}
+
+func Test_ActionHeartbeat_ShouldCancelTheHandlerWhenTheFlyteServerNoLongerKnowsTheAction(t *testing.T) {
+	p, completed := newHeartbeatPack(func(client.Action) error {
+		return client.NotFoundError{Message: "action a1 not found"}
+	})
+	handler := func(ctx context.Context, _ json.RawMessage) Event {
+		select {
+		case <-ctx.Done():
+			return NewFatalErrorEvent(HeartbeatErr(ctx))
+		case <-time.After(time.Second):
+			return Event{EventDef: EventDef{Name: "BuildFinished"}}
+		}
+	}
+
+	p.handleAction(&client.Action{ID: "a1", CommandName: "Build"}, map[string]ContextHandler{"Build": handler})
+
+	e := <-completed
+	require.Equal(t, fatalEventName, e.Name)
+	assert.Contains(t, fmt.Sprint(e.Payload), ErrActionLeaseLost.Error())
+}
+
+func Test_ActionHeartbeat_ShouldStopWhenTheFlyteServerDoesNotSupportHeartbeats(t *testing.T) {
+	var beats int32
+	p, _ := newHeartbeatPack(func(client.Action) error {
+		atomic.AddInt32(&beats, 1)
+		return client.ErrHeartbeatNotSupported
+	})
+	handler := func(ctx context.Context, _ json.RawMessage) Event {
+		time.Sleep(45 * time.Millisecond)
+		assert.NoError(t, HeartbeatErr(ctx))
+		assert.NoError(t, ctx.Err())
+		return Event{EventDef: EventDef{Name: "BuildFinished"}}
+	}
+
+	p.handleAction(&client.Action{ID: "a1", CommandName: "Build"}, map[string]ContextHandler{"Build": handler})
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&beats))
+}
+
+func Test_WithActionHeartbeat_ShouldNotSendHeartbeatsWithAClientThatDoesNotSupportThem(t *testing.T) {
+	p := NewPackWithOptions(PackDef{Name: "BuildPack"}, MockClient{}, WithActionHeartbeat(time.Second)).(pack)
+
+	assert.Nil(t, p.heartbeats)
+	assert.NoError(t, HeartbeatErr(context.Background()))
+}
@@ -0,0 +1,132 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"fmt"
+	"github.com/rs/zerolog/log"
+)
+
+// LifecycleHooks are functions the pack calls at key points of its lifecycle, so pack authors can run initialisation,
+// warmup, cleanup and custom alerting without wrapping Start and Stop themselves. All the hooks are optional, and are
+// called synchronously.
+type LifecycleHooks struct {
+	// OnRegister is called each time the pack has registered with the flyte server, before it takes any actions. If it
+	// returns an error the pack does not start, and registration is retried as if it had failed.
+	OnRegister func(PackHandle) error
+	// OnStart is called once the pack has started handling actions.
+	OnStart func(PackHandle)
+	// OnStop is called once the pack has stopped, with what happened while it was stopping, before Stop returns.
+	OnStop func(DrainSummary)
+	// OnActionError is called when handling an action fails: the handler panicked or returned a FATAL event, there was
+	// no handler for the command, or the result could not be posted to the flyte server. It is called from the
+	// goroutine handling the action.
+	OnActionError func(ActionError)
+	// OnResultAbandoned is called when an action result will never be posted to the flyte server: it could not be
+	// posted and there is no result queue, or it was rejected or ran out of time on the result queue (see
+	// WithResultQueue). OnActionError is called too when the result is abandoned without being queued.
+	OnResultAbandoned func(AbandonedResult)
+}
+
+// ActionError describes an action whose handling failed, for LifecycleHooks.OnActionError.
+type ActionError struct {
+	Command  string // the command of the action
+	ActionID string // the id of the action, if the flyte server sent one
+	Err      error  // why handling the action failed
+}
+
+func (e ActionError) Error() string {
+	return fmt.Sprintf("action for command %q failed: %v", e.Command, e.Err)
+}
+
+func (e ActionError) Unwrap() error {
+	return e.Err
+}
+
+// WithLifecycleHooks adds hooks the pack calls at key points of its lifecycle. Hooks are called in the order they were
+// added.
+func WithLifecycleHooks(hooks LifecycleHooks) Option {
+	return func(p *pack) {
+		p.hooks = append(append(lifecycleHooks(nil), p.hooks...), hooks)
+	}
+}
+
+type lifecycleHooks []LifecycleHooks
+
+// register calls the OnRegister hooks, returning the first error
+func (h lifecycleHooks) register(handle PackHandle) error {
+	for _, hook := range h {
+		if hook.OnRegister == nil {
+			continue
+		}
+		if err := hook.OnRegister(handle); err != nil {
+			return fmt.Errorf("OnRegister hook failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func (h lifecycleHooks) start(handle PackHandle) {
+	for _, hook := range h {
+		if hook.OnStart != nil {
+			hook.OnStart(handle)
+		}
+	}
+}
+
+func (h lifecycleHooks) stop(summary DrainSummary) {
+	for _, hook := range h {
+		if hook.OnStop != nil {
+			hook.OnStop(summary)
+		}
+	}
+}
+
+// actionError calls the OnActionError hooks, recovering from any panic so a failing hook cannot take the pack down
+func (h lifecycleHooks) actionError(e ActionError) {
+	for _, hook := range h {
+		if hook.OnActionError == nil {
+			continue
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().Msgf("OnActionError hook raised a panic: %v", r)
+				}
+			}()
+			hook.OnActionError(e)
+		}()
+	}
+}
+
+// resultAbandoned calls the OnResultAbandoned hooks, recovering from any panic so a failing hook cannot take the pack
+// down
+func (h lifecycleHooks) resultAbandoned(r AbandonedResult) {
+	for _, hook := range h {
+		if hook.OnResultAbandoned == nil {
+			continue
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().Msgf("OnResultAbandoned hook raised a panic: %v", r)
+				}
+			}()
+			hook.OnResultAbandoned(r)
+		}()
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_LifecycleHooks_ShouldBeCalledWhenThePackRegistersStartsAndStops(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	// given a pack whose OnRegister hook fails the first time
+	var mu sync.Mutex
+	var calls []string
+	record := func(call string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, call)
+	}
+	registrations := 0
+	c := MockClient{
+		createPack: func(client.Pack) error {
+			registrations++
+			return nil
+		},
+		takeAction: func() (*client.Action, error) { return nil, nil },
+	}
+	hooks := LifecycleHooks{
+		OnRegister: func(h PackHandle) error {
+			record("register")
+			if registrations == 1 {
+				return errors.New("cache not warm")
+			}
+			return nil
+		},
+		OnStart: func(h PackHandle) {
+			select {
+			case <-h.Started():
+				record("start")
+			default:
+				t.Error("OnStart was called before the pack started")
+			}
+		},
+		OnStop: func(s DrainSummary) { record("stop") },
+	}
+	p := NewPackWithOptions(PackDef{Name: "HookPack"}, c, WithLifecycleHooks(hooks)).(pack)
+	p.retryWait = time.Millisecond
+
+	// when
+	p.Start()
+	p.Stop()
+
+	// then registration is retried until the OnRegister hook succeeds
+	assert.Equal(t, 2, registrations)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"register", "register", "start", "stop"}, calls)
+}
+
+func Test_LifecycleHooks_ShouldBeToldAboutActionErrors(t *testing.T) {
+	// given
+	var errs []ActionError
+	p := NewPackWithOptions(PackDef{Name: "HookPack"}, MockClient{
+		completeAction: func(a client.Action, e client.Event) error {
+			if a.ID == "4" {
+				return errors.New("flyte api unavailable")
+			}
+			return nil
+		},
+	}, WithLifecycleHooks(LifecycleHooks{
+		OnActionError: func(e ActionError) {
+			errs = append(errs, e)
+			panic("hooks cannot take the pack down")
+		},
+	})).(pack)
+	handlers := map[string]ContextHandler{
+		"Fatal": CommandHandler(func(json.RawMessage) Event { return NewFatalEvent("no such issue") }).withContext(),
+		"Panic": CommandHandler(func(json.RawMessage) Event { panic("boom") }).withContext(),
+		"Ok":    CommandHandler(func(json.RawMessage) Event { return Event{EventDef: EventDef{Name: "Done"}} }).withContext(),
+	}
+
+	// when
+	p.handleAction(&client.Action{ID: "1", CommandName: "Fatal"}, handlers)
+	p.handleAction(&client.Action{ID: "2", CommandName: "Panic"}, handlers)
+	p.handleAction(&client.Action{ID: "3", CommandName: "Missing"}, handlers)
+	p.handleAction(&client.Action{ID: "4", CommandName: "Ok"}, handlers)
+	p.handleAction(&client.Action{ID: "5", CommandName: "Ok"}, handlers)
+
+	// then
+	require.Len(t, errs, 4)
+	assert.Equal(t, "Fatal", errs[0].Command)
+	assert.Equal(t, "1", errs[0].ActionID)
+	assert.EqualError(t, errs[0].Err, "handler returned a FATAL event: no such issue")
+	assert.EqualError(t, errs[1].Err, "handler panicked: boom")
+	assert.Equal(t, "Missing", errs[2].Command)
+	assert.Equal(t, "4", errs[3].ActionID)
+	assert.EqualError(t, errs[3], `action for command "Ok" failed: could not complete action: flyte api unavailable`)
+}
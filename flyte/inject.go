@@ -0,0 +1,231 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/rs/zerolog/log"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// WithProviders registers constructors for the dependencies of command handlers created with Command.NewHandler.
+// A constructor is a function that returns the dependency, and optionally an error, for example:
+//
+//	func NewJiraClient(s Secrets) (*jira.Client, error)
+//
+// Each constructor is called at most once, when a handler (or another constructor) first needs the type it returns,
+// and its own parameters are resolved in the same way. The pack itself provides PackHandle and client.Datastore.
+// Dependencies that implement io.Closer are closed, in the reverse order they were created in, once the pack has
+// stopped.
+//
+// WithProviders panics if a constructor is not a function, or returns a type another constructor already provides,
+// as the pack could never run as intended.
+func WithProviders(constructors ...interface{}) Option {
+	return func(p *pack) {
+		if p.container == nil {
+			p.container = newContainer()
+		}
+		for _, c := range constructors {
+			if err := p.container.provide(c); err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// container creates and holds the dependencies of command handlers. It is shared by all copies of a pack.
+type container struct {
+	mu           sync.Mutex
+	constructors map[reflect.Type]reflect.Value
+	instances    map[reflect.Type]reflect.Value
+	// the dependencies created by constructors, in the order they were created in
+	created []reflect.Value
+	// the types being created, so dependency cycles can be reported rather than recursing forever
+	resolving map[reflect.Type]bool
+	closed    bool
+}
+
+func newContainer() *container {
+	return &container{
+		constructors: make(map[reflect.Type]reflect.Value),
+		instances:    make(map[reflect.Type]reflect.Value),
+		resolving:    make(map[reflect.Type]bool),
+	}
+}
+
+func (c *container) provide(constructor interface{}) error {
+	fn := reflect.ValueOf(constructor)
+	if fn.Kind() != reflect.Func {
+		return fmt.Errorf("dependency constructor must be a function, not %T", constructor)
+	}
+	t := fn.Type()
+	if t.NumOut() < 1 || t.NumOut() > 2 || (t.NumOut() == 2 && t.Out(1) != errorType) {
+		return fmt.Errorf("dependency constructor %s must return a dependency and optionally an error", t)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.constructors[t.Out(0)]; ok {
+		return fmt.Errorf("more than one constructor provides %s", t.Out(0))
+	}
+	c.constructors[t.Out(0)] = fn
+	return nil
+}
+
+// supply adds a dependency that has already been created, such as the pack handle
+func (c *container) supply(t reflect.Type, v interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.instances[t]; !ok {
+		c.instances[t] = reflect.ValueOf(v)
+	}
+}
+
+// handler creates a command handler by calling newHandler with its dependencies. newHandler can return a
+// CommandHandler, a ContextHandler or a FallibleHandler, which is retried as the retry policy allows.
+func (c *container) handler(newHandler interface{}, retry *RetryPolicy) (ContextHandler, error) {
+	fn := reflect.ValueOf(newHandler)
+	handlerType := reflect.TypeOf(CommandHandler(nil))
+	contextHandlerType := reflect.TypeOf(ContextHandler(nil))
+	fallibleHandlerType := reflect.TypeOf(FallibleHandler(nil))
+	if fn.Kind() != reflect.Func || fn.Type().NumOut() < 1 ||
+		!(fn.Type().Out(0).ConvertibleTo(handlerType) || fn.Type().Out(0).ConvertibleTo(contextHandlerType) ||
+			fn.Type().Out(0).ConvertibleTo(fallibleHandlerType)) {
+		return nil, fmt.Errorf("handler constructor must be a function returning a CommandHandler, ContextHandler or FallibleHandler, not %T", newHandler)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, err := c.call(fn)
+	if err != nil {
+		return nil, err
+	}
+	if h.Type().ConvertibleTo(contextHandlerType) {
+		return h.Convert(contextHandlerType).Interface().(ContextHandler), nil
+	}
+	if h.Type().ConvertibleTo(fallibleHandlerType) {
+		return h.Convert(fallibleHandlerType).Interface().(FallibleHandler).withRetry(retry), nil
+	}
+	return h.Convert(handlerType).Interface().(CommandHandler).withContext(), nil
+}
+
+// call calls fn with its dependencies, returning its first result. c.mu must be held.
+func (c *container) call(fn reflect.Value) (reflect.Value, error) {
+	t := fn.Type()
+	if t.NumOut() < 1 || t.NumOut() > 2 || (t.NumOut() == 2 && t.Out(1) != errorType) {
+		return reflect.Value{}, fmt.Errorf("%s must return a value and optionally an error", t)
+	}
+	if c.closed {
+		return reflect.Value{}, fmt.Errorf("cannot create %s, the pack has stopped", t.Out(0))
+	}
+
+	args := make([]reflect.Value, t.NumIn())
+	for i := range args {
+		arg, err := c.resolve(t.In(i))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		args[i] = arg
+	}
+	out := fn.Call(args)
+	if len(out) == 2 && !out[1].IsNil() {
+		return reflect.Value{}, out[1].Interface().(error)
+	}
+	return out[0], nil
+}
+
+// resolve returns the dependency of type t, creating it if it has not been created yet. c.mu must be held.
+func (c *container) resolve(t reflect.Type) (reflect.Value, error) {
+	if v, ok := c.instances[t]; ok {
+		return v, nil
+	}
+	constructor, ok := c.constructors[t]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("no constructor provides %s", t)
+	}
+	if c.resolving[t] {
+		return reflect.Value{}, fmt.Errorf("dependency cycle creating %s", t)
+	}
+
+	c.resolving[t] = true
+	defer delete(c.resolving, t)
+	v, err := c.call(constructor)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("cannot create %s: %w", t, err)
+	}
+	c.instances[t] = v
+	c.created = append(c.created, v)
+	return v, nil
+}
+
+// close closes the dependencies that implement io.Closer, most recently created first
+func (c *container) close() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	for i := len(c.created) - 1; i >= 0; i-- {
+		closer, ok := c.created[i].Interface().(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			log.Err(err).Msgf("cannot close %s", c.created[i].Type())
+		}
+	}
+}
+
+// commandHandler returns the handler for the command, creating it with its dependencies when the command has a
+// handler constructor. A handler that cannot be created is logged, and handles every action with a fatal event.
+func (p pack) commandHandler(cmd Command) ContextHandler {
+	if cmd.NewHandler == nil {
+		if cmd.ContextHandler != nil {
+			return cmd.ContextHandler
+		}
+		if cmd.FallibleHandler != nil {
+			return cmd.FallibleHandler.withRetry(cmd.Retry)
+		}
+		return cmd.Handler.withContext()
+	}
+	if p.container == nil {
+		p.container = newContainer()
+	}
+	p.container.supply(reflect.TypeOf((*PackHandle)(nil)).Elem(), p.Handle())
+	p.container.supply(reflect.TypeOf((*client.Datastore)(nil)).Elem(), p.Handle().Datastore())
+
+	h, err := p.container.handler(cmd.NewHandler, cmd.Retry)
+	if err != nil {
+		log.Err(err).Msgf("cannot create handler for command %q", cmd.Name)
+		msg := fmt.Sprintf("cannot create handler: %s", err)
+		return func(context.Context, json.RawMessage) Event {
+			return NewFatalEvent(msg)
+		}
+	}
+	return h
+}
@@ -0,0 +1,157 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type greeter struct {
+	greeting string
+	closed   *[]string
+}
+
+func (g *greeter) Close() error {
+	*g.closed = append(*g.closed, "greeter")
+	return nil
+}
+
+type settings struct {
+	greeting string
+	closed   *[]string
+}
+
+func (s *settings) Close() error {
+	*s.closed = append(*s.closed, "settings")
+	return nil
+}
+
+func Test_WithProviders_ShouldCreateHandlersFromTheirDependencies(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	// given a command whose handler needs a greeter, which in turn needs settings
+	var closed []string
+	settingsCreated := 0
+	completed := make(chan client.Event, 1)
+	taken := false
+	c := MockClient{
+		createPack: func(client.Pack) error { return nil },
+		takeAction: func() (*client.Action, error) {
+			if taken {
+				return nil, nil
+			}
+			taken = true
+			return &client.Action{CommandName: "Greet", Input: json.RawMessage(`"world"`)}, nil
+		},
+		completeAction: func(_ client.Action, e client.Event) error {
+			completed <- e
+			return nil
+		},
+	}
+	newHandler := func(g *greeter, h PackHandle, d client.Datastore) CommandHandler {
+		return func(input json.RawMessage) Event {
+			var name string
+			json.Unmarshal(input, &name)
+			return Event{EventDef: EventDef{Name: "Greeted"}, Payload: g.greeting + " " + name}
+		}
+	}
+	packDef := PackDef{Name: "GreeterPack", Commands: []Command{{Name: "Greet", NewHandler: newHandler}}}
+	p := NewPackWithOptions(packDef, c, WithProviders(
+		func(s *settings) *greeter { return &greeter{greeting: s.greeting, closed: &closed} },
+		func() (*settings, error) {
+			settingsCreated++
+			return &settings{greeting: "hello", closed: &closed}, nil
+		},
+	))
+
+	// when
+	p.Start()
+
+	// then
+	select {
+	case e := <-completed:
+		assert.Equal(t, "Greeted", e.Name)
+		assert.Equal(t, "hello world", e.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("action was not completed")
+	}
+	assert.Equal(t, 1, settingsCreated)
+
+	// and the dependencies are closed, most recently created first, once the pack stops
+	p.(pack).Stop()
+	assert.Equal(t, []string{"greeter", "settings"}, closed)
+}
+
+func Test_commandHandler_ShouldSendFatalEvent_WhenTheHandlerCannotBeCreated(t *testing.T) {
+	// given a handler constructor whose dependency fails to be created
+	p := NewPackWithOptions(PackDef{}, MockClient{}, WithProviders(func() (*settings, error) {
+		return nil, errors.New("no settings")
+	})).(pack)
+	cmd := Command{Name: "Greet", NewHandler: func(*settings) CommandHandler { return nil }}
+
+	// when
+	h := p.commandHandler(cmd)
+
+	// then
+	e := h(context.Background(), nil)
+	assert.Equal(t, fatalEventName, e.EventDef.Name)
+	assert.Contains(t, e.Payload, "no settings")
+}
+
+func Test_container_ShouldReportMissingDependenciesAndCycles(t *testing.T) {
+	c := newContainer()
+	require.NoError(t, c.provide(func(*greeter) *settings { return nil }))
+	require.NoError(t, c.provide(func(*settings) *greeter { return nil }))
+
+	_, err := c.handler(func(*settings) CommandHandler { return nil }, nil)
+	assert.Contains(t, err.Error(), "dependency cycle")
+
+	_, err = c.handler(func(string) CommandHandler { return nil }, nil)
+	assert.EqualError(t, err, "no constructor provides string")
+}
+
+func Test_container_ShouldRejectInvalidConstructors(t *testing.T) {
+	c := newContainer()
+
+	assert.Error(t, c.provide("not a function"))
+	assert.Error(t, c.provide(func() {}))
+	assert.Error(t, c.provide(func() (*settings, string) { return nil, "" }))
+	require.NoError(t, c.provide(func() *settings { return nil }))
+	assert.EqualError(t, c.provide(func() (*settings, error) { return nil, nil }), "more than one constructor provides *flyte.settings")
+	assert.Panics(t, func() { WithProviders(42)(&pack{}) })
+}
+
+func Test_container_ShouldCreateFallibleHandlers(t *testing.T) {
+	c := newContainer()
+	require.NoError(t, c.provide(func() *settings { return &settings{} }))
+
+	h, err := c.handler(func(*settings) FallibleHandler {
+		return func(json.RawMessage) (Event, error) { return Event{}, errors.New("no greeting") }
+	}, nil)
+
+	require.NoError(t, err)
+	e := h(context.Background(), nil)
+	assert.Equal(t, fatalEventName, e.EventDef.Name)
+	assert.Equal(t, "no greeting", e.Payload.(ErrorPayload).Message)
+}
@@ -0,0 +1,184 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/ExpediaGroup/flyte-client/healthcheck"
+	"github.com/rs/zerolog/log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// InventoryKeyPrefix is the prefix of the flyte api datastore items written by packs created with
+	// WithInventoryReporting. The full key is "<prefix><pack name>-<instance id>".
+	InventoryKeyPrefix       = "flyte-inventory-"
+	defaultInventoryInterval = time.Minute
+)
+
+// InventoryRecord describes a running pack instance. It is the value of the datastore items written by packs created
+// with WithInventoryReporting.
+type InventoryRecord struct {
+	Pack          string                        `json:"pack"`          // the pack name
+	Labels        map[string]string             `json:"labels"`        // the pack labels
+	InstanceID    string                        `json:"instanceId"`    // identifies the pack instance, e.g. the pod name
+	Version       string                        `json:"version"`       // the pack version
+	ClientVersion string                        `json:"clientVersion"` // the flyte-client version
+	Commands      []string                      `json:"commands"`      // the names of the pack commands
+	ConfigHash    string                        `json:"configHash"`    // a hash of the remote configuration (see WithConfiguration), if any
+	Healthy       bool                          `json:"healthy"`       // whether all the health checks passed
+	Checks        map[string]healthcheck.Health `json:"checks"`        // the result of each health check
+	Started       time.Time                     `json:"started"`       // when the pack started
+	Updated       time.Time                     `json:"updated"`       // when the record was written
+}
+
+// WithInventoryReporting makes the pack write an InventoryRecord describing itself - its name, version, commands,
+// configuration hash and health - to the flyte api datastore every interval once it has started, so an inventory of
+// every pack instance connected to a flyte environment is maintained automatically. Each instance writes its own
+// datastore item (see InventoryKeyPrefix), using the host name (the pod name on kubernetes) as the instance id, and
+// deletes it when the pack is stopped. Items whose Updated time is well past the interval belong to instances that
+// did not stop cleanly. An interval of zero or less reports every minute.
+func WithInventoryReporting(version string, interval time.Duration) Option {
+	return func(p *pack) {
+		if interval <= 0 {
+			interval = defaultInventoryInterval
+		}
+		instanceID, err := os.Hostname()
+		if err != nil {
+			log.Warn().Err(err).Msg("cannot get the host name to use as the pack instance id")
+		}
+		p.inventory = &inventoryReporter{
+			datastore:  datastoreOf(p.client),
+			key:        InventoryKeyPrefix + p.Name + "-" + instanceID,
+			interval:   interval,
+			version:    version,
+			instanceID: instanceID,
+		}
+	}
+}
+
+// inventoryReporter periodically writes the inventory record of the pack to the datastore
+type inventoryReporter struct {
+	datastore  client.Datastore
+	key        string
+	interval   time.Duration
+	version    string
+	instanceID string
+	mu         sync.Mutex
+	removed    bool // set once the record is deleted, so a report in progress when the pack stops does not recreate it
+}
+
+// run writes the record every interval until the pack is stopped
+func (r *inventoryReporter) run(done <-chan struct{}, record func() InventoryRecord) {
+	if r == nil {
+		return
+	}
+	started := time.Now().UTC()
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			rec := record()
+			rec.InstanceID = r.instanceID
+			rec.Version = r.version
+			rec.Started = started
+			r.report(rec)
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// report writes the record to the datastore
+func (r *inventoryReporter) report(rec InventoryRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.removed {
+		return
+	}
+	rec.Updated = time.Now().UTC()
+	b, err := json.Marshal(rec)
+	if err != nil {
+		log.Err(err).Msg("cannot marshal pack inventory record")
+		return
+	}
+	err = r.datastore.PutDatastoreItem(client.DatastoreItem{
+		Key:         r.key,
+		Description: "pack instance inventory record",
+		ContentType: "application/json",
+		Value:       b,
+	})
+	if err != nil {
+		log.Err(err).Msgf("could not write inventory record to datastore item %q", r.key)
+	}
+}
+
+// remove deletes the record of the stopped pack instance from the datastore
+func (r *inventoryReporter) remove() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removed = true
+	if err := r.datastore.DeleteDatastoreItem(r.key); err != nil {
+		if _, ok := err.(client.NotFoundError); !ok {
+			log.Err(err).Msgf("could not delete inventory datastore item %q", r.key)
+		}
+	}
+}
+
+// inventoryRecord describes the pack as it is now
+func (p pack) inventoryRecord() InventoryRecord {
+	healthy, checks := runHealthChecks(p.healthChecks)
+	var commands []string
+	for _, c := range p.Commands {
+		commands = append(commands, c.Name)
+	}
+	return InventoryRecord{
+		Pack:          p.Name,
+		Labels:        p.Labels,
+		ClientVersion: client.Version,
+		Commands:      commands,
+		ConfigHash:    p.configurator.hash(),
+		Healthy:       healthy,
+		Checks:        checks,
+	}
+}
+
+// hash returns a hash of the current settings, or an empty string if the pack is not configurable
+func (c *configurator) hash() string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	b, err := json.Marshal(c.current)
+	c.mu.Unlock()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,131 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/ExpediaGroup/flyte-client/healthcheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+type tickerSettings struct {
+	Channel string `json:"channel"`
+}
+
+func Test_InventoryReporting_ShouldWriteTheRecordWhenStartedAndDeleteItWhenStopped(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	var mu sync.Mutex
+	written := make(chan client.DatastoreItem, 10)
+	var deleted []string
+	c := MockClient{
+		createPack: func(client.Pack) error { return nil },
+		takeAction: func() (*client.Action, error) { return nil, nil },
+		getDatastoreItem: func(key string) (*client.DatastoreItem, error) {
+			return nil, client.NotFoundError{}
+		},
+		putDatastoreItem: func(item client.DatastoreItem) error {
+			written <- item
+			return nil
+		},
+		deleteDatastoreItem: func(key string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			deleted = append(deleted, key)
+			return nil
+		},
+	}
+	packDef := PackDef{
+		Name:     "TickerPack",
+		Labels:   map[string]string{"env": "test"},
+		Commands: []Command{{Name: "Tick", Handler: func(json.RawMessage) Event { return Event{} }}},
+	}
+	checks := WithHealthChecks(func() (string, healthcheck.Health) {
+		return "Ticker", healthcheck.Health{Healthy: true, Status: "ticking"}
+	})
+	configuration := WithConfiguration(Configuration{Settings: &tickerSettings{Channel: "alerts"}})
+	p := NewPackWithOptions(packDef, c, checks, configuration, WithInventoryReporting("1.2.3", time.Hour))
+
+	// when
+	p.Start()
+
+	// then
+	var item client.DatastoreItem
+	select {
+	case item = <-written:
+	case <-time.After(time.Second):
+		t.Fatal("inventory record was not written")
+	}
+	host, _ := os.Hostname()
+	assert.Equal(t, InventoryKeyPrefix+"TickerPack-"+host, item.Key)
+	var rec InventoryRecord
+	require.NoError(t, json.Unmarshal(item.Value, &rec))
+	assert.Equal(t, "TickerPack", rec.Pack)
+	assert.Equal(t, map[string]string{"env": "test"}, rec.Labels)
+	assert.Equal(t, host, rec.InstanceID)
+	assert.Equal(t, "1.2.3", rec.Version)
+	assert.Equal(t, client.Version, rec.ClientVersion)
+	assert.Equal(t, []string{"Tick", configureCommandName}, rec.Commands)
+	assert.Len(t, rec.ConfigHash, 64)
+	assert.True(t, rec.Healthy)
+	assert.Equal(t, "ticking", rec.Checks["Ticker"].Status)
+	assert.False(t, rec.Started.IsZero())
+	assert.False(t, rec.Updated.Before(rec.Started))
+
+	// and the record is deleted when the pack stops
+	p.(pack).Stop()
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{item.Key}, deleted)
+}
+
+func Test_InventoryReporting_ShouldNotWriteTheRecordOnceRemoved(t *testing.T) {
+	writes := 0
+	r := &inventoryReporter{
+		key: "flyte-inventory-TickerPack-host",
+		datastore: MockClient{
+			putDatastoreItem: func(client.DatastoreItem) error {
+				writes++
+				return nil
+			},
+			deleteDatastoreItem: func(string) error { return nil },
+		},
+	}
+
+	r.report(InventoryRecord{Pack: "TickerPack"})
+	r.remove()
+	r.report(InventoryRecord{Pack: "TickerPack"})
+
+	assert.Equal(t, 1, writes)
+}
+
+func Test_ConfiguratorHash_ShouldChangeWithTheSettings(t *testing.T) {
+	var none *configurator
+	assert.Empty(t, none.hash())
+
+	c := &configurator{current: &tickerSettings{Channel: "alerts"}}
+	before := c.hash()
+	c.current = &tickerSettings{Channel: "incidents"}
+
+	assert.NotEqual(t, before, c.hash())
+}
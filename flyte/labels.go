@@ -0,0 +1,43 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+// WithLabels adds the labels passed in to the pack labels, replacing any pack labels with the same keys. This is
+// useful for deployment-scoped labels, such as the environment or region a pack runs in, that are not known when the
+// pack definition is written (see config.LabelsFromEnv). The labels are sent when the pack registers, and flyte-api
+// only sends the pack actions from flows whose labels match.
+func WithLabels(labels map[string]string) Option {
+	return func(p *pack) {
+		p.Labels = mergeLabels(p.Labels, labels)
+	}
+}
+
+// mergeLabels returns the labels with the extra labels added. A copy is returned, so the labels of the pack definition
+// passed in are not modified
+func mergeLabels(labels, extra map[string]string) map[string]string {
+	if len(extra) == 0 {
+		return labels
+	}
+	merged := make(map[string]string, len(labels)+len(extra))
+	for k, v := range labels {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
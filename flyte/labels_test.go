@@ -0,0 +1,66 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/ExpediaGroup/flyte-client/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_WithLabels_ShouldAddToThePackLabelsWithoutModifyingThePackDef(t *testing.T) {
+	var registered client.Pack
+	c := MockClient{createPack: func(p client.Pack) error {
+		registered = p
+		return nil
+	}}
+	packDef := PackDef{Name: "JiraPack", Labels: map[string]string{"team": "ops", "env": "dev"}}
+
+	p := NewPackWithOptions(packDef, c, WithLabels(map[string]string{"env": "staging", "region": "eu-west-1"})).(pack)
+
+	require.NoError(t, p.register())
+	assert.Equal(t, map[string]string{"team": "ops", "env": "staging", "region": "eu-west-1"}, registered.Labels)
+	assert.Equal(t, map[string]string{"team": "ops", "env": "dev"}, packDef.Labels)
+}
+
+func Test_NewPackFromEnvironment_ShouldAddTheLabelsSetInTheEnvironment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"links": []}`))
+	}))
+	defer server.Close()
+
+	prevGetEnv := config.GetEnv
+	defer func() { config.GetEnv = prevGetEnv }()
+	config.GetEnv = func(name string) string {
+		switch name {
+		case "FLYTE_API":
+			return server.URL
+		case "FLYTE_LABELS":
+			return "env=staging,region=eu-west-1"
+		}
+		return ""
+	}
+
+	p, err := NewPackFromEnvironment(PackDef{Name: "JiraPack", Labels: map[string]string{"team": "ops"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "ops", "env": "staging", "region": "eu-west-1"}, p.(pack).Labels)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"time"
+)
+
+// LeaderElection elects one of the replicas of a horizontally scaled pack as the leader, so every replica can handle
+// actions for availability while only the leader runs the producers of spontaneous events, such as watchers and
+// schedulers. The leader holds a Lock named "leader", renewing its lease every third of the ttl. If the leader is
+// stopped it gives up the lease straight away, and if it dies the lease expires after the ttl, and another replica
+// takes over as leader. As with Lock, election is best effort: two replicas may briefly both believe they lead.
+type LeaderElection struct {
+	lock   *Lock
+	mu     sync.Mutex
+	leader bool
+	// closed, and replaced, whenever this replica becomes or stops being the leader
+	changed chan struct{}
+}
+
+// NewLeaderElection creates a leader election between the replicas of the pack, whose leader's lease expires ttl
+// after it was last renewed. As with NewLock, the ttl is at least a second. Call Run to take part in the election.
+func NewLeaderElection(datastore client.Datastore, packName string, ttl time.Duration) *LeaderElection {
+	return &LeaderElection{
+		lock:    NewLock(datastore, packName, "leader", ttl),
+		changed: make(chan struct{}),
+	}
+}
+
+// Run takes part in the election once the pack has started, renewing the lease while this replica leads and trying
+// to become the leader while it does not, until the pack is stopped, when leadership is given up. Run blocks, so will
+// normally be called in its own goroutine.
+func (e *LeaderElection) Run(h PackHandle) {
+	select {
+	case <-h.Started():
+	case <-h.Done():
+		return
+	}
+	for {
+		e.campaign()
+		select {
+		case <-h.Done():
+			if e.IsLeader() {
+				e.setLeader(false)
+				if err := e.lock.Release(); err != nil {
+					log.Err(err).Msg("cannot give up leadership")
+				}
+			}
+			return
+		case <-time.After(e.interval()):
+		}
+	}
+}
+
+// interval is how often the lease is renewed or campaigned for, a third of the ttl the lock enforces
+func (e *LeaderElection) interval() time.Duration {
+	return e.lock.ttl / 3
+}
+
+// campaign renews the lease if this replica leads, or tries to acquire it if it does not
+func (e *LeaderElection) campaign() {
+	if !e.IsLeader() {
+		_, err := e.lock.TryAcquire()
+		switch {
+		case err == nil:
+			e.setLeader(true)
+		case !errors.Is(err, ErrLockHeld):
+			log.Err(err).Msg("cannot take part in the leader election")
+		}
+		return
+	}
+
+	err := e.lock.Renew()
+	if err == nil {
+		return
+	}
+	// the datastore may be unavailable for a moment, so leadership is only given up once the lease expires
+	if _, held := e.lock.Token(); errors.Is(err, ErrLockLost) || !held {
+		log.Err(err).Msg("lost leadership")
+		e.setLeader(false)
+		return
+	}
+	log.Warn().Err(err).Msg("cannot renew leadership, retrying")
+}
+
+func (e *LeaderElection) setLeader(leader bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.leader == leader {
+		return
+	}
+	e.leader = leader
+	close(e.changed)
+	e.changed = make(chan struct{})
+	if leader {
+		log.Info().Msgf("elected leader, holding lock %q", e.lock.key)
+	} else {
+		log.Info().Msgf("no longer the leader holding lock %q", e.lock.key)
+	}
+}
+
+// IsLeader returns true if this replica is the leader. A nil election always leads, so producers that take an
+// optional election run on every replica when there is none.
+func (e *LeaderElection) IsLeader() bool {
+	if e == nil {
+		return true
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// state returns whether this replica is the leader, and a channel that is closed when that changes
+func (e *LeaderElection) state() (bool, <-chan struct{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader, e.changed
+}
+
+// WhileLeader runs the producer each time this replica becomes the leader, cancelling the context passed to it when
+// the replica stops being the leader, until the pack is stopped. The producer should return once its context is
+// done. WhileLeader blocks, so will normally be called in its own goroutine.
+func (e *LeaderElection) WhileLeader(h PackHandle, producer func(ctx context.Context)) {
+	for {
+		leader, changed := e.state()
+		if !leader {
+			select {
+			case <-changed:
+				continue
+			case <-h.Done():
+				return
+			}
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			producer(ctx)
+		}()
+		select {
+		case <-changed:
+		case <-h.Done():
+		}
+		cancel()
+		<-done
+		select {
+		case <-h.Done():
+			return
+		default:
+		}
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestElection(d *lockDatastore) *LeaderElection {
+	e := NewLeaderElection(d.client(), "ReportPack", 60*time.Millisecond)
+	e.lock.ttl = 60 * time.Millisecond
+	e.lock.settle = 0
+	return e
+}
+
+func Test_LeaderElection_ShouldElectOneLeaderAndFailOverWhenItStops(t *testing.T) {
+	d := newLockDatastore()
+	h1, h2 := newMockHandle(), newMockHandle()
+	e1, e2 := newTestElection(d), newTestElection(d)
+	h1.start()
+	go e1.Run(h1)
+	assert.Eventually(t, e1.IsLeader, time.Second, 5*time.Millisecond)
+
+	// when a second replica starts, it does not lead
+	h2.start()
+	go e2.Run(h2)
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, e1.IsLeader())
+	assert.False(t, e2.IsLeader())
+
+	// then when the leader stops, the second replica takes over
+	h1.stop()
+	assert.Eventually(t, func() bool { return !e1.IsLeader() && e2.IsLeader() }, time.Second, 5*time.Millisecond)
+	h2.stop()
+}
+
+func Test_LeaderElection_ShouldFailOverWhenTheLeaderDies(t *testing.T) {
+	d := newLockDatastore()
+	dead := newTestElection(d)
+	_, err := dead.lock.TryAcquire()
+	assert.NoError(t, err)
+
+	// when the replica holding the lease stops renewing it
+	h := newMockHandle()
+	h.start()
+	defer h.stop()
+	e := newTestElection(d)
+	go e.Run(h)
+
+	// then another replica leads once it expires
+	assert.Eventually(t, e.IsLeader, time.Second, 5*time.Millisecond)
+}
+
+func Test_LeaderElection_WhileLeaderShouldOnlyRunTheProducerWhileLeading(t *testing.T) {
+	d := newLockDatastore()
+	h := newMockHandle()
+	e := newTestElection(d)
+
+	var running int32
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		e.WhileLeader(h, func(ctx context.Context) {
+			atomic.StoreInt32(&running, 1)
+			<-ctx.Done()
+			atomic.StoreInt32(&running, 0)
+		})
+	}()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&running), "the producer must not run before the replica leads")
+
+	h.start()
+	go e.Run(h)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&running) == 1 }, time.Second, 5*time.Millisecond)
+
+	// when the lease is lost to another replica
+	d.steal("ReportPack-lock-leader")
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&running) == 0 }, time.Second, 5*time.Millisecond)
+
+	h.stop()
+	<-stopped
+}
+
+func Test_Watcher_ShouldOnlyObserveWhileLeading(t *testing.T) {
+	d := newLockDatastore()
+	d.steal("ReportPack-lock-leader")
+	h := newMockHandle()
+	h.start()
+	defer h.stop()
+
+	var observed int32
+	w := Watcher{
+		Name:     "follower",
+		Interval: time.Millisecond,
+		Leader:   newTestElection(d),
+		Observe: func() ([]Event, error) {
+			atomic.AddInt32(&observed, 1)
+			return nil, nil
+		},
+	}
+	go w.Run(h)
+	time.Sleep(30 * time.Millisecond)
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&observed))
+}
+
+func Test_NewLeaderElection_ShouldRenewEveryThirdOfTheEnforcedTTL(t *testing.T) {
+	e := NewLeaderElection(newLockDatastore().client(), "ReportPack", 0)
+
+	assert.Equal(t, minLockTTL/3, e.interval())
+}
@@ -0,0 +1,149 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/ExpediaGroup/flyte-client/config"
+	"github.com/ExpediaGroup/flyte-client/flytetest"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"net"
+	"net/url"
+	"time"
+)
+
+// how long to wait before retrying the flyte api in local development mode
+const localDevRetryWait = 500 * time.Millisecond
+
+// newDefaultClient creates a client using the settings read from the environment, and sets the log level if it is
+// configured. In local development mode (see config.LocalDev) debug logging is enabled unless another log level is
+// configured, a fake flyte api is started if nothing is listening at the flyte api url
+// and the client does not verify TLS certificates. In dry-run mode (see config.Values.DryRun) a client that makes no
+// flyte api calls is returned instead (see client.NewDryRunClient). It returns the registration retry wait for the
+// pack, which is zero for the default. The extra options passed in are applied to the client as well.
+func newDefaultClient(cfg config.Values, extra ...client.Option) (client.Client, time.Duration) {
+	opts := append([]client.Option(nil), client.WithJWT(cfg.JWT))
+	opts = append(opts, extra...)
+	if cfg.Compression != "" {
+		opts = append(opts, client.WithCompression(cfg.Compression), client.WithCompressionThreshold(cfg.CompressionThreshold))
+	}
+	if cfg.PackVersion != "" {
+		opts = append(opts, client.WithPackVersion(cfg.PackVersion))
+	}
+	timeouts := client.Timeouts{
+		Dial:           cfg.DialTimeout,
+		TLSHandshake:   cfg.TLSHandshakeTimeout,
+		ResponseHeader: cfg.ResponseHeaderTimeout,
+		IdleConn:       cfg.IdleConnTimeout,
+	}
+	if timeouts != (client.Timeouts{}) {
+		opts = append(opts, client.WithTimeouts(timeouts))
+	}
+	if len(cfg.FallbackApiUrls) > 0 {
+		opts = append(opts, client.WithEndpoints(0, cfg.FallbackApiUrls...))
+	}
+	if cfg.SRVService != "" {
+		opts = append(opts, client.WithSRV(client.SRVConfig{Service: cfg.SRVService}))
+	}
+	if cfg.RateLimit > 0 {
+		opts = append(opts, client.WithRateLimit(cfg.RateLimit, cfg.RateLimitBurst))
+	}
+	if cfg.TLSConfig != nil {
+		opts = append(opts, client.WithTLSConfig(cfg.TLSConfig))
+	}
+	if cfg.StaticLinks() {
+		opts = append(opts, client.WithStaticLinks(client.StaticLinks{
+			TakeAction: cfg.TakeActionURL,
+			Events:     cfg.EventsURL,
+			Health:     cfg.HealthURL,
+			Packs:      cfg.PacksURL,
+		}))
+	}
+	if cfg.LogLevel != "" {
+		level, _ := zerolog.ParseLevel(cfg.LogLevel)
+		zerolog.SetGlobalLevel(level)
+	}
+	if cfg.DryRun {
+		return newDryRunClient(cfg.DryRunActionsFile), 0
+	}
+	if !config.LocalDev() {
+		return client.NewClient(cfg.FlyteApiUrl, cfg.Timeout, opts...), 0
+	}
+
+	if cfg.LogLevel == "" {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	}
+	log.Info().Msgf("local development mode is enabled, using the flyte api at %s", cfg.FlyteApiUrl)
+	startFakeApiIfNothingListening(cfg.FlyteApiUrl)
+	return client.NewInsecureClient(cfg.FlyteApiUrl, cfg.Timeout, append(opts, client.WithRetryWait(localDevRetryWait))...), localDevRetryWait
+}
+
+// newDryRunClient creates the client of dry-run mode. If the scripted actions cannot be read the error is logged and
+// the client takes no actions, so nothing is ever sent to the flyte api.
+func newDryRunClient(actionsFile string) client.Client {
+	c, err := client.NewDryRunClient(actionsFile)
+	if err != nil {
+		log.Error().Err(err).Msg("dry-run mode is enabled but the scripted actions cannot be read, no actions will be taken")
+		c, _ = client.NewDryRunClient("")
+	}
+	return c
+}
+
+// packOptions returns the pack options of the settings read from the environment, which the options passed in to
+// create the pack are applied after
+func packOptions(cfg config.Values) []Option {
+	opts := []Option{WithLabels(cfg.Labels)}
+	if cfg.PollingFrequency > 0 {
+		opts = append(opts, WithPollingFrequency(cfg.PollingFrequency))
+	}
+	if cfg.Concurrency > 0 {
+		opts = append(opts, WithWorkerPool(cfg.Concurrency, 0))
+	}
+	return opts
+}
+
+// startFakeApiIfNothingListening starts a fake flyte api at the url if it is a local url that nothing is listening on
+func startFakeApiIfNothingListening(u *url.URL) {
+	if u.Scheme != "http" || !isLocalHost(u.Hostname()) {
+		return
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+	}
+	addr := net.JoinHostPort(u.Hostname(), port)
+	if conn, err := net.DialTimeout("tcp", addr, 500*time.Millisecond); err == nil {
+		conn.Close()
+		return
+	}
+
+	s, err := flytetest.Listen(addr)
+	if err != nil {
+		log.Warn().Err(err).Msgf("nothing is listening at %s and a fake flyte api could not be started", addr)
+		return
+	}
+	log.Info().Msgf("nothing is listening at %s, started a fake flyte api at %s. It has no flows, so the pack will not receive actions", addr, s.URL)
+}
+
+func isLocalHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
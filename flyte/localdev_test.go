@@ -0,0 +1,70 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/ExpediaGroup/flyte-client/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_NewDefaultPack_ShouldStartAFakeFlyteApiInLocalDevMode(t *testing.T) {
+	// given nothing listening on a local port
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	l.Close()
+
+	prevLevel := zerolog.GlobalLevel()
+	defer zerolog.SetGlobalLevel(prevLevel)
+	prevGetEnv := config.GetEnv
+	defer func() { config.GetEnv = prevGetEnv }()
+	config.GetEnv = func(name string) string {
+		switch name {
+		case "FLYTE_LOCAL_DEV":
+			return "true"
+		case "FLYTE_API":
+			return "http://" + addr
+		}
+		return ""
+	}
+
+	// when
+	helpURL, _ := url.Parse("http://example.com/help")
+	p := NewDefaultPack(PackDef{Name: "LocalPack", HelpURL: helpURL}).(pack)
+
+	// then the pack can reach the fake
+	assert.Equal(t, localDevRetryWait, p.retryWait)
+	assert.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel())
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	require.NoError(t, err)
+	conn.Close()
+	assert.NoError(t, p.register())
+}
+
+func Test_isLocalHost(t *testing.T) {
+	assert.True(t, isLocalHost("localhost"))
+	assert.True(t, isLocalHost("127.0.0.1"))
+	assert.True(t, isLocalHost("::1"))
+	assert.False(t, isLocalHost("flyte.example.com"))
+	assert.False(t, isLocalHost("10.0.0.1"))
+}
@@ -0,0 +1,294 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/rs/zerolog/log"
+	"os"
+	"sync"
+	"time"
+)
+
+// the time a lock waits after writing its lease before reading it back, to see whether another replica wrote over it
+const lockSettleTime = 250 * time.Millisecond
+
+// the shortest lease ttl, which must outlast the settle time
+const minLockTTL = time.Second
+
+var (
+	// ErrLockHeld is returned when a lock cannot be acquired as another replica holds an unexpired lease on it.
+	ErrLockHeld = errors.New("lock is held by another replica")
+	// ErrLockLost is returned when a lease cannot be renewed as it has been taken over by another replica.
+	ErrLockLost = errors.New("lock lease was lost to another replica")
+)
+
+// Lock is a lease on a named task, such as a scheduled job, that the replicas of a horizontally scaled pack share, so
+// only one of them performs the task at a time. The lease is kept in a flyte api datastore item namespaced by the pack
+// name, e.g. "SlackPack-lock-nightly-report", and expires after its ttl unless it is renewed, so a replica that dies
+// while holding it does not block the others for ever.
+//
+// The datastore has no conditional writes, so a lock is best effort: after writing its lease it waits a moment and
+// reads it back to see if another replica wrote over it, which makes it unlikely - but not impossible - for two
+// replicas to acquire it at once. Each lease has a fencing token, incremented every time the lock changes hands, which
+// tasks can pass to the systems they write to so writes from a replica whose lease has been taken over are rejected.
+// Released leases are kept, expired, so tokens keep increasing for as long as the datastore item exists.
+// A Lock is safe for concurrent use.
+type Lock struct {
+	datastore client.Datastore
+	key       string
+	owner     string
+	ttl       time.Duration
+	settle    time.Duration
+	now       func() time.Time
+	mu        sync.Mutex
+	held      *lease // the lease this replica holds, if it holds one
+}
+
+// lease is what is stored in the lock datastore item
+type lease struct {
+	Owner   string    `json:"owner"`
+	Token   uint64    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+// NewLock creates a lock with the name passed in for the replicas of the pack, whose leases expire ttl after they are
+// acquired or renewed. The ttl is at least a second. Each Lock is a separate owner, even in the same process.
+func NewLock(datastore client.Datastore, packName, name string, ttl time.Duration) *Lock {
+	key := packName + "-lock-" + name
+	if ttl < minLockTTL {
+		log.Warn().Msgf("Enforcing lower limit of %v for the ttl of lock %q", minLockTTL, key)
+		ttl = minLockTTL
+	}
+	return &Lock{
+		datastore: datastore,
+		key:       key,
+		owner:     lockOwner(),
+		ttl:       ttl,
+		settle:    lockSettleTime,
+		now:       time.Now,
+	}
+}
+
+// lockOwner returns an id for a lock owner made up of the host name (the pod name on kubernetes), the process id and
+// a random suffix
+func lockOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		log.Warn().Err(err).Msg("cannot get the host name to use in the lock owner id")
+	}
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+// TryAcquire acquires the lock if no other replica holds an unexpired lease on it, returning the fencing token of the
+// lease. ErrLockHeld is returned if another replica holds the lock. Acquiring a lock that is already held renews it.
+func (l *Lock) TryAcquire() (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	current, err := l.get()
+	if err != nil {
+		return 0, err
+	}
+	if current != nil && current.Owner != l.owner && l.now().Before(current.Expires) {
+		return 0, ErrLockHeld
+	}
+	next := lease{Owner: l.owner, Token: 1, Expires: l.now().Add(l.ttl)}
+	if current != nil {
+		next.Token = current.Token
+		if current.Owner != l.owner {
+			next.Token++
+		}
+	}
+	if err := l.put(next); err != nil {
+		return 0, err
+	}
+
+	// another replica may have written its own lease at the same time
+	time.Sleep(l.settle)
+	current, err = l.get()
+	if err != nil {
+		return 0, err
+	}
+	if current == nil || current.Owner != l.owner {
+		return 0, ErrLockHeld
+	}
+	l.held = current
+	return current.Token, nil
+}
+
+// Renew extends the lease by the ttl. ErrLockLost is returned if another replica has taken the lock over, or this
+// replica does not hold it.
+func (l *Lock) Renew() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held == nil {
+		return ErrLockLost
+	}
+	current, err := l.get()
+	if err != nil {
+		return err
+	}
+	if current == nil || current.Owner != l.owner || current.Token != l.held.Token {
+		l.held = nil
+		return ErrLockLost
+	}
+	renewed := lease{Owner: l.owner, Token: current.Token, Expires: l.now().Add(l.ttl)}
+	if err := l.put(renewed); err != nil {
+		return err
+	}
+	l.held = &renewed
+	return nil
+}
+
+// Release gives up the lease, so another replica can acquire the lock straight away. The lease is expired rather than
+// deleted, so the next holder gets the next fencing token. Releasing a lock this replica does not hold does nothing.
+func (l *Lock) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held == nil {
+		return nil
+	}
+	l.held = nil
+	current, err := l.get()
+	if err != nil {
+		return err
+	}
+	if current == nil || current.Owner != l.owner {
+		return nil
+	}
+	if err := l.put(lease{Owner: l.owner, Token: current.Token, Expires: l.now()}); err != nil {
+		return fmt.Errorf("cannot release lock %q: %w", l.key, err)
+	}
+	return nil
+}
+
+// Token returns the fencing token of the lease, and true if this replica holds the lock and its lease has not expired.
+func (l *Lock) Token() (uint64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held == nil || !l.now().Before(l.held.Expires) {
+		return 0, false
+	}
+	return l.held.Token, true
+}
+
+// Run acquires the lock and runs the task with the fencing token of the lease, renewing the lease every third of the
+// ttl while the task runs and releasing it once the task returns. The context passed to the task is cancelled if the
+// lease is lost to another replica, or expires because it could not be renewed, so the task should stop. ErrLockHeld
+// is returned, without running the task, if another replica holds the lock. Otherwise the error returned by the task
+// is returned.
+func (l *Lock) Run(ctx context.Context, task func(ctx context.Context, token uint64) error) error {
+	token, err := l.TryAcquire()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	renewing := make(chan struct{})
+	go func() {
+		defer close(renewing)
+		l.keepRenewed(ctx, cancel)
+	}()
+
+	err = task(ctx, token)
+	cancel()
+	<-renewing
+	if rerr := l.Release(); rerr != nil {
+		log.Err(rerr).Msgf("cannot release lock %q", l.key)
+	}
+	return err
+}
+
+// keepRenewed renews the lease every third of the ttl until the context is done, cancelling it if the lease is lost
+// or expires
+func (l *Lock) keepRenewed(ctx context.Context, cancel context.CancelFunc) {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := l.Renew()
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, ErrLockLost) {
+			log.Warn().Msgf("lock %q was lost to another replica", l.key)
+			cancel()
+			return
+		}
+		// the datastore may be unavailable for a moment, so keep trying until the lease expires
+		if _, held := l.Token(); !held {
+			log.Err(err).Msgf("lease on lock %q expired as it could not be renewed", l.key)
+			cancel()
+			return
+		}
+		log.Warn().Err(err).Msgf("cannot renew lock %q, retrying", l.key)
+	}
+}
+
+// get returns the lease stored in the datastore, or nil if there is none
+func (l *Lock) get() (*lease, error) {
+	item, err := l.datastore.GetDatastoreItem(l.key)
+	if errors.Is(err, client.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get lock %q: %w", l.key, err)
+	}
+	if item == nil {
+		return nil, nil
+	}
+	var current lease
+	if err := json.Unmarshal(item.Value, &current); err != nil {
+		// the token of an unreadable lease is unknown, so the lock cannot be taken over without reusing a token
+		return nil, fmt.Errorf("invalid lease on lock %q, delete the %q datastore item to reset it: %w", l.key, l.key, err)
+	}
+	return &current, nil
+}
+
+// put stores the lease in the datastore
+func (l *Lock) put(ls lease) error {
+	b, err := json.Marshal(ls)
+	if err != nil {
+		return fmt.Errorf("cannot marshal lease on lock %q: %w", l.key, err)
+	}
+	err = l.datastore.PutDatastoreItem(client.DatastoreItem{
+		Key:         l.key,
+		Description: "pack lock lease",
+		ContentType: "application/json",
+		Value:       b,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot put lease on lock %q: %w", l.key, err)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockDatastore is a datastore shared by the locks of several replicas, safe for concurrent use
+type lockDatastore struct {
+	mu    sync.Mutex
+	items map[string]client.DatastoreItem
+	// optional. Called after an item is put, with the lock held
+	afterPut func(item client.DatastoreItem)
+}
+
+func newLockDatastore() *lockDatastore {
+	return &lockDatastore{items: map[string]client.DatastoreItem{}}
+}
+
+func (d *lockDatastore) client() MockClient {
+	return MockClient{
+		getDatastoreItem: func(key string) (*client.DatastoreItem, error) {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			item, ok := d.items[key]
+			if !ok {
+				return nil, client.NotFoundError{Message: "no item " + key}
+			}
+			return &item, nil
+		},
+		putDatastoreItem: func(item client.DatastoreItem) error {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			d.items[item.Key] = item
+			if d.afterPut != nil {
+				d.afterPut(item)
+			}
+			return nil
+		},
+		deleteDatastoreItem: func(key string) error {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			delete(d.items, key)
+			return nil
+		},
+	}
+}
+
+// steal replaces the lease on the lock with one held by another replica
+func (d *lockDatastore) steal(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.items[key] = client.DatastoreItem{Key: key, Value: []byte(`{"owner": "thief", "token": 99, "expires": "2100-01-01T00:00:00Z"}`)}
+}
+
+func newTestLock(d *lockDatastore, clock *fakeClock, ttl time.Duration) *Lock {
+	l := NewLock(d.client(), "ReportPack", "nightly-report", ttl)
+	l.ttl = ttl
+	l.settle = 0
+	if clock != nil {
+		l.now = clock.Now
+	}
+	return l
+}
+
+func Test_Lock_ShouldOnlyBeHeldByOneReplica(t *testing.T) {
+	d := newLockDatastore()
+	clock := &fakeClock{now: time.Now()}
+	a, b := newTestLock(d, clock, time.Minute), newTestLock(d, clock, time.Minute)
+
+	token, err := a.TryAcquire()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), token)
+	assert.Contains(t, d.items, "ReportPack-lock-nightly-report")
+
+	_, err = b.TryAcquire()
+	assert.Equal(t, ErrLockHeld, err)
+	_, held := b.Token()
+	assert.False(t, held)
+
+	// re-acquiring renews the lease with the same token
+	token, err = a.TryAcquire()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), token)
+}
+
+func Test_Lock_ShouldBeTakenOverOnceTheLeaseExpires(t *testing.T) {
+	d := newLockDatastore()
+	clock := &fakeClock{now: time.Now()}
+	a, b := newTestLock(d, clock, time.Minute), newTestLock(d, clock, time.Minute)
+	_, err := a.TryAcquire()
+	require.NoError(t, err)
+
+	// when the lease expires without being renewed
+	clock.advance(time.Minute)
+	_, held := a.Token()
+	assert.False(t, held)
+
+	// then another replica can acquire the lock, with the next fencing token
+	token, err := b.TryAcquire()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), token)
+
+	// and the first replica has lost it
+	assert.Equal(t, ErrLockLost, a.Renew())
+}
+
+func Test_Lock_RenewShouldExtendTheLease(t *testing.T) {
+	d := newLockDatastore()
+	clock := &fakeClock{now: time.Now()}
+	a, b := newTestLock(d, clock, time.Minute), newTestLock(d, clock, time.Minute)
+	_, err := a.TryAcquire()
+	require.NoError(t, err)
+
+	clock.advance(50 * time.Second)
+	require.NoError(t, a.Renew())
+	clock.advance(50 * time.Second)
+
+	token, held := a.Token()
+	assert.True(t, held)
+	assert.Equal(t, uint64(1), token)
+	_, err = b.TryAcquire()
+	assert.Equal(t, ErrLockHeld, err)
+}
+
+func Test_Lock_ReleaseShouldLetAnotherReplicaAcquireTheLock(t *testing.T) {
+	d := newLockDatastore()
+	a, b := newTestLock(d, nil, time.Hour), newTestLock(d, nil, time.Hour)
+	_, err := a.TryAcquire()
+	require.NoError(t, err)
+
+	require.NoError(t, a.Release())
+	_, held := a.Token()
+	assert.False(t, held)
+
+	// the released lease is kept, so the fencing token still increases
+	token, err := b.TryAcquire()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), token)
+	assert.NoError(t, a.Release(), "releasing a lock that is not held does nothing")
+	assert.Len(t, d.items, 1)
+}
+
+func Test_Lock_ShouldNotBeAcquiredWhenAnotherReplicaWritesItsLeaseAtTheSameTime(t *testing.T) {
+	d := newLockDatastore()
+	d.afterPut = func(item client.DatastoreItem) {
+		d.items[item.Key] = client.DatastoreItem{Key: item.Key, Value: []byte(`{"owner": "other", "token": 1}`)}
+	}
+	a := newTestLock(d, nil, time.Hour)
+
+	_, err := a.TryAcquire()
+
+	assert.Equal(t, ErrLockHeld, err)
+}
+
+func Test_Lock_RunShouldRunTheTaskWithTheTokenAndReleaseTheLock(t *testing.T) {
+	d := newLockDatastore()
+	a, b := newTestLock(d, nil, time.Hour), newTestLock(d, nil, time.Hour)
+
+	var ran uint64
+	err := a.Run(context.Background(), func(ctx context.Context, token uint64) error {
+		ran = token
+		// another replica cannot run the task at the same time
+		assert.Equal(t, ErrLockHeld, b.Run(ctx, func(context.Context, uint64) error {
+			t.Error("the task should not run")
+			return nil
+		}))
+		return errors.New("task failed")
+	})
+
+	assert.EqualError(t, err, "task failed")
+	assert.Equal(t, uint64(1), ran)
+	_, err = b.TryAcquire()
+	assert.NoError(t, err, "the lock is released")
+}
+
+func Test_Lock_RunShouldCancelTheTaskWhenTheLeaseIsLost(t *testing.T) {
+	d := newLockDatastore()
+	a := newTestLock(d, nil, 30*time.Millisecond)
+
+	err := a.Run(context.Background(), func(ctx context.Context, token uint64) error {
+		d.steal("ReportPack-lock-nightly-report")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	})
+
+	assert.Equal(t, context.Canceled, err)
+	assert.Contains(t, d.items, "ReportPack-lock-nightly-report", "the lease of the other replica is not released")
+}
+
+func Test_Lock_ShouldNotBeTakenOverWhenTheLeaseIsInvalid(t *testing.T) {
+	d := newLockDatastore()
+	d.items["ReportPack-lock-nightly-report"] = client.DatastoreItem{Key: "ReportPack-lock-nightly-report", Value: []byte("not json")}
+	a := newTestLock(d, nil, time.Hour)
+
+	_, err := a.TryAcquire()
+
+	assert.Error(t, err)
+	assert.NotEqual(t, ErrLockHeld, err)
+	assert.Equal(t, []byte("not json"), d.items["ReportPack-lock-nightly-report"].Value)
+}
+
+func Test_NewLock_ShouldEnforceAMinimumTTL(t *testing.T) {
+	l := NewLock(newLockDatastore().client(), "ReportPack", "nightly-report", time.Nanosecond)
+
+	assert.Equal(t, minLockTTL, l.ttl)
+}
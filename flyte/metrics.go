@@ -0,0 +1,105 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/ExpediaGroup/flyte-client/client"
+	"time"
+)
+
+// Metrics is a metrics integration, such as the Prometheus one in the github.com/ExpediaGroup/flyte-client/metrics
+// module, which is kept out of this module so packs that do not record metrics do not depend on a metrics library.
+type Metrics interface {
+	// Recorder returns the recorder of the metrics of the pack, whose current state the gauges read
+	Recorder(packName string, gauges PackGauges) MetricsRecorder
+}
+
+// MetricsRecorder records the metrics of a pack as actions are handled.
+type MetricsRecorder interface {
+	// CommandHandled records an action handled by the command, and whether its handler panicked or returned a FATAL
+	// event
+	CommandHandled(command string, took time.Duration, failed bool)
+	// TakeActionFailed records an error taking an action from the flyte server, and whether it was a transient
+	// transport error, such as a connection reset
+	TakeActionFailed(transient bool)
+	// CleanupFailed records an action cleanup function of the command that returned an error or panicked, see
+	// AddCleanup
+	CleanupFailed(command string)
+}
+
+// PackGauges read the current state of a pack, for metrics integrations to report as gauges.
+type PackGauges struct {
+	ActionsInFlight     func() int    // actions taken from the flyte server that are still being handled
+	ActionsQueued       func() int    // actions taken from the flyte server that are waiting for a worker
+	ActionQueueCapacity func() int    // actions that can wait for a worker before the pack stops taking actions
+	WorkersBusy         func() int    // workers handling actions, when the pack has a worker pool
+	ResultsAbandoned    func() uint64 // action results that were never posted to the flyte server
+}
+
+// WithMetrics records metrics for the pack with the metrics integration passed in, e.g. with Prometheus:
+//
+//	flyte.WithMetrics(metrics.Prometheus(registerer))
+//
+// Per command invocation counts, error counts and handler latencies are recorded, along with the number of actions in
+// flight and the worker pool usage. Commands are counted as erroring if their handler panics or returns a FATAL event.
+func WithMetrics(m Metrics) Option {
+	return func(p *pack) {
+		c, w := p.counters, p.workers
+		gauges := PackGauges{
+			ActionsInFlight: c.inFlight,
+			ActionsQueued:   w.queued,
+			ActionQueueCapacity: func() int {
+				if !w.enabled() {
+					return 0
+				}
+				return w.queueSize
+			},
+			WorkersBusy:      w.busyWorkers,
+			ResultsAbandoned: func() uint64 { return c.snapshot().ResultsAbandoned },
+		}
+		p.metrics = &packMetrics{recorder: m.Recorder(p.Name, gauges)}
+	}
+}
+
+// packMetrics records the metrics of a pack with its recorder, if it has one
+type packMetrics struct {
+	recorder MetricsRecorder
+}
+
+// takeActionFailed records an error taking an action from the flyte server
+func (m *packMetrics) takeActionFailed(err error) {
+	if m == nil {
+		return
+	}
+	m.recorder.TakeActionFailed(client.IsTransient(err))
+}
+
+// observe records an action handled by the command
+func (m *packMetrics) observe(command string, took time.Duration, failed bool) {
+	if m == nil {
+		return
+	}
+	m.recorder.CommandHandled(command, took, failed)
+}
+
+// cleanupFailed records an action cleanup function that failed
+func (m *packMetrics) cleanupFailed(command string) {
+	if m == nil {
+		return
+	}
+	m.recorder.CleanupFailed(command)
+}
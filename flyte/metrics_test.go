@@ -0,0 +1,118 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_WithMetrics_ShouldRecordPerCommandInvocationsAndErrors(t *testing.T) {
+	// given a pack with a command that succeeds, a command that fails and a command that panics
+	m := &mockMetrics{}
+	packDef := PackDef{
+		Name: "SlackPack",
+		Commands: []Command{
+			{Name: "sendMessage", Handler: func(json.RawMessage) Event { return Event{EventDef: EventDef{Name: "MessageSent"}} }},
+			{Name: "deleteMessage", Handler: func(json.RawMessage) Event { return NewFatalEvent("cannot delete") }},
+			{Name: "editMessage", Handler: func(json.RawMessage) Event { panic("oops") }},
+		},
+	}
+	p := NewPackWithOptions(packDef, MockClient{
+		completeAction: func(client.Action, client.Event) error { return nil },
+	}, WithMetrics(m)).(pack)
+	handlers := p.createHandlersMap()
+
+	// when
+	for _, command := range []string{"sendMessage", "sendMessage", "deleteMessage", "editMessage"} {
+		p.handleAction(&client.Action{CommandName: command}, handlers)
+	}
+
+	// then
+	assert.Equal(t, "SlackPack", m.packName)
+	assert.Equal(t, []string{"sendMessage", "sendMessage", "deleteMessage failed", "editMessage failed"}, m.handled)
+}
+
+func Test_WithMetrics_ShouldGiveTheRecorderGaugesOfThePackState(t *testing.T) {
+	m := &mockMetrics{}
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, MockClient{}, WithMetrics(m), WithWorkerPool(4, 10)).(pack)
+	p.counters.add(actionsTaken)
+	p.counters.add(actionsTaken)
+
+	assert.Equal(t, 2, m.gauges.ActionsInFlight())
+	assert.Equal(t, 0, m.gauges.ActionsQueued())
+	assert.Equal(t, 10, m.gauges.ActionQueueCapacity())
+	assert.Equal(t, 0, m.gauges.WorkersBusy())
+	assert.Equal(t, uint64(0), m.gauges.ResultsAbandoned())
+}
+
+func Test_WithMetrics_ShouldCountTransientTakeActionErrorsSeparately(t *testing.T) {
+	m := &mockMetrics{}
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, MockClient{}, WithMetrics(m)).(pack)
+
+	p.metrics.takeActionFailed(fmt.Errorf("error taking action: %w", syscall.ECONNRESET))
+	p.metrics.takeActionFailed(errors.New("http status '500 Internal Server Error'"))
+	p.metrics.takeActionFailed(io.ErrUnexpectedEOF)
+
+	assert.Equal(t, []bool{true, false, true}, m.takeErrors)
+}
+
+func Test_packMetrics_ShouldDoNothingWithoutMetrics(t *testing.T) {
+	p := NewPack(PackDef{Name: "SlackPack"}, MockClient{}).(pack)
+
+	require.Nil(t, p.metrics)
+	p.metrics.observe("sendMessage", time.Second, false)
+	p.metrics.takeActionFailed(io.ErrUnexpectedEOF)
+	p.metrics.cleanupFailed("sendMessage")
+}
+
+// mockMetrics records what the pack recorded
+type mockMetrics struct {
+	packName   string
+	gauges     PackGauges
+	handled    []string
+	takeErrors []bool
+	cleanups   []string
+}
+
+func (m *mockMetrics) Recorder(packName string, gauges PackGauges) MetricsRecorder {
+	m.packName, m.gauges = packName, gauges
+	return m
+}
+
+func (m *mockMetrics) CommandHandled(command string, _ time.Duration, failed bool) {
+	if failed {
+		command += " failed"
+	}
+	m.handled = append(m.handled, command)
+}
+
+func (m *mockMetrics) TakeActionFailed(transient bool) {
+	m.takeErrors = append(m.takeErrors, transient)
+}
+
+func (m *mockMetrics) CleanupFailed(command string) {
+	m.cleanups = append(m.cleanups, command)
+}
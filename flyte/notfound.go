@@ -0,0 +1,97 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+// the longest the pack waits between polls while the flyte server does not find it, see NotFoundBackoff
+const maxNotFoundBackoff = 5 * time.Minute
+
+// NotFoundPolicy says what a pack does when the flyte server no longer finds it while it polls for actions, e.g.
+// because the pack was deleted through the flyte api, or a flyte api without persistent storage was restarted.
+type NotFoundPolicy int
+
+const (
+	// NotFoundStop stops the pack, and PackHandle.Err returns ErrPackNotFound. This is the default.
+	NotFoundStop NotFoundPolicy = iota
+	// NotFoundReregister registers the pack again and carries on polling. If registration fails the pack backs off
+	// before polling, and so trying to register, again.
+	NotFoundReregister
+	// NotFoundBackoff carries on polling, doubling the wait between polls up to 5 minutes, for deployments where the
+	// pack is registered by someone else, e.g. another replica or a deployment job.
+	NotFoundBackoff
+)
+
+func (p NotFoundPolicy) String() string {
+	switch p {
+	case NotFoundStop:
+		return "stop"
+	case NotFoundReregister:
+		return "reregister"
+	case NotFoundBackoff:
+		return "backoff"
+	}
+	return "unknown"
+}
+
+// WithNotFoundPolicy sets what the pack does when the flyte server responds to a poll for actions with a
+// client.NotFoundError. By default the pack stops.
+func WithNotFoundPolicy(policy NotFoundPolicy) Option {
+	return func(p *pack) {
+		p.notFound = func(error, int) NotFoundPolicy { return policy }
+	}
+}
+
+// WithNotFoundHandler calls the function passed in when the flyte server responds to a poll for actions with a
+// client.NotFoundError, with the error and the number of consecutive polls it has been returned for, and does what
+// the policy it returns says. It can, for example, alert and re-register the pack a few times before stopping it.
+func WithNotFoundHandler(handle func(err error, notFounds int) NotFoundPolicy) Option {
+	return func(p *pack) {
+		p.notFound = handle
+	}
+}
+
+// recoverNotFound does what the not found policy says, returning how long to wait before polling again, or false if
+// the pack has been stopped
+func (p pack) recoverNotFound(err error, notFounds int) (time.Duration, bool) {
+	policy := NotFoundStop
+	if p.notFound != nil {
+		policy = p.notFound(err, notFounds)
+	}
+	wait := backoff(p.pollInterval(0), maxNotFoundBackoff, notFounds-1)
+	switch policy {
+	case NotFoundReregister:
+		log.Warn().Err(err).Msgf("pack %q not found while polling for actions, registering it again", p.Name)
+		if err := p.register(); err != nil {
+			log.Err(err).Msgf("cannot register pack %q again, polling again in %v", p.Name, wait)
+			return wait, true
+		}
+		return 0, true
+	case NotFoundBackoff:
+		log.Warn().Err(err).Msgf("pack %q not found while polling for actions, polling again in %v", p.Name, wait)
+		return wait, true
+	default:
+		// the pack can no longer take actions, so it stops rather than polling forever
+		log.Err(err).Msgf("pack %q not found while polling for actions, stopping the pack", p.Name)
+		p.lifecycle.fail(ErrPackNotFound)
+		p.Stop()
+		return 0, false
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func Test_nextAction_ShouldRegisterThePackAgainWhenItIsNotFound(t *testing.T) {
+	registered := 0
+	polls := 0
+	c := MockClient{
+		createPack: func(client.Pack) error {
+			registered++
+			return nil
+		},
+		takeAction: func() (*client.Action, error) {
+			polls++
+			if polls == 1 {
+				return nil, client.NotFoundError{Message: "pack not found"}
+			}
+			return &client.Action{CommandName: "SendMessage"}, nil
+		},
+	}
+	p := NewPackWithOptions(PackDef{Name: "JiraPack"}, c, WithNotFoundPolicy(NotFoundReregister)).(pack)
+
+	a, ok := p.nextAction(time.Now().Add(time.Second))
+
+	require.True(t, ok)
+	require.NotNil(t, a)
+	assert.Equal(t, "SendMessage", a.CommandName)
+	assert.Equal(t, 1, registered)
+	assert.NoError(t, p.Handle().Err())
+}
+
+func Test_nextAction_ShouldRegisterThePackAgainWhenAWrappedNotFoundErrorIsReturned(t *testing.T) {
+	registered := 0
+	polls := 0
+	c := MockClient{
+		createPack: func(client.Pack) error {
+			registered++
+			return nil
+		},
+		takeAction: func() (*client.Action, error) {
+			polls++
+			if polls == 1 {
+				return nil, fmt.Errorf("taking action: %w", client.NotFoundError{Message: "pack not found"})
+			}
+			return &client.Action{CommandName: "SendMessage"}, nil
+		},
+	}
+	p := NewPackWithOptions(PackDef{Name: "JiraPack"}, c, WithNotFoundPolicy(NotFoundReregister)).(pack)
+
+	a, ok := p.nextAction(time.Now().Add(time.Second))
+
+	require.True(t, ok)
+	require.NotNil(t, a)
+	assert.Equal(t, 1, registered)
+}
+
+func Test_recoverNotFound_ShouldBackOffWhileThePackIsNotFound(t *testing.T) {
+	p := NewPackWithOptions(PackDef{Name: "JiraPack"}, MockClient{}, WithPollingFrequency(time.Second),
+		WithNotFoundPolicy(NotFoundBackoff)).(pack)
+	err := client.NotFoundError{Message: "pack not found"}
+
+	first, polling := p.recoverNotFound(err, 1)
+	assert.True(t, polling)
+	third, _ := p.recoverNotFound(err, 3)
+	last, _ := p.recoverNotFound(err, 20)
+
+	assert.Equal(t, time.Second, first)
+	assert.Equal(t, 4*time.Second, third)
+	assert.Equal(t, maxNotFoundBackoff, last)
+	assert.NoError(t, p.Handle().Err())
+}
+
+func Test_recoverNotFound_ShouldDoWhatTheHandlerSays(t *testing.T) {
+	var calls []int
+	p := NewPackWithOptions(PackDef{Name: "JiraPack"}, MockClient{}, WithNotFoundHandler(func(err error, notFounds int) NotFoundPolicy {
+		calls = append(calls, notFounds)
+		if notFounds < 3 {
+			return NotFoundBackoff
+		}
+		return NotFoundStop
+	})).(pack)
+	err := client.NotFoundError{Message: "pack not found"}
+
+	_, polling := p.recoverNotFound(err, 1)
+	assert.True(t, polling)
+	_, polling = p.recoverNotFound(err, 3)
+	assert.False(t, polling)
+
+	assert.Equal(t, []int{1, 3}, calls)
+	assert.Equal(t, ErrPackNotFound, p.Handle().Err())
+}
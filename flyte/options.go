@@ -0,0 +1,59 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/ExpediaGroup/flyte-client/healthcheck"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+const minPollingFrequency = 500 * time.Millisecond
+
+// Option configures optional pack behaviour. Options are passed to NewPackWithOptions.
+type Option func(*pack)
+
+// Creates a Pack in the same way as NewPack, with its optional behaviour configured by the options passed in.
+func NewPackWithOptions(packDef PackDef, client client.Client, opts ...Option) Pack {
+	p := NewPack(packDef, client).(pack)
+	// the pack commands are copied so options adding built in commands don't modify the pack definition passed in
+	p.Commands = append([]Command(nil), packDef.Commands...)
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
+
+// WithHealthChecks sets the health checks run by the pack health check server.
+func WithHealthChecks(healthChecks ...healthcheck.HealthCheck) Option {
+	return func(p *pack) {
+		p.healthChecks = addDefaultHealthCheckIfNoneExist(healthChecks)
+	}
+}
+
+// WithPollingFrequency sets how long the pack waits before polling the flyte server again when no actions are
+// available. The minimum is 500 milliseconds.
+func WithPollingFrequency(polling time.Duration) Option {
+	return func(p *pack) {
+		if polling < minPollingFrequency {
+			polling = minPollingFrequency
+			log.Warn().Msgf("Enforcing lower limit of 500 Milliseconds for commands polling frequency")
+		}
+		p.pollingFrequency = polling
+	}
+}
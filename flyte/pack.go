@@ -42,11 +42,77 @@ type Pack interface {
 	SendEvent(Event) error
 }
 
+// Stopper is implemented by packs that can be stopped. The packs created by this package implement it.
+type Stopper interface {
+	// Stop stops the pack from taking any further actions from the flyte server. Actions that are already being
+	// handled are left to complete, and Stop waits for them up to the drain timeout (see WithDrainTimeout).
+	// A stopped pack cannot be restarted.
+	Stop()
+}
+
+// HandleProvider is implemented by packs that give out handles onto themselves. The packs created by this package
+// implement it.
+type HandleProvider interface {
+	// Handle returns a handle onto the pack that background goroutines can use to send events.
+	Handle() PackHandle
+}
+
+// pack is the Pack created by this package
+var (
+	_ Pack           = pack{}
+	_ Stopper        = pack{}
+	_ HandleProvider = pack{}
+)
+
 type pack struct {
 	PackDef
 	client           client.Client
 	pollingFrequency time.Duration
 	healthChecks     []healthcheck.HealthCheck
+	lifecycle        *lifecycle
+	counters         *counters
+	configurator     *configurator
+	statsStore       *statsStore
+	statusReporter   *statusReporter
+	probes           *probes
+	// how often the health checks are run and sent in an event, if at all
+	healthEventInterval time.Duration
+	metrics             *packMetrics
+	drainTimeout        time.Duration
+	flushers            []Flusher
+	// the longest interval between polls when polling adaptively, see WithAdaptivePolling
+	maxPollingFrequency time.Duration
+	// how long to wait before retrying registration, when it differs from the default
+	retryWait time.Duration
+	// whether actions are received from an action stream, where the flyte server supports it
+	actionStream bool
+	// the fraction the wait between polls is randomly adjusted by, and the max random delay before the first poll
+	pollingJitter       float64
+	maxInitialPollDelay time.Duration
+	panicEvents         *panicEvents
+	workers             *workerPool
+	container           *container
+	usage               *usageReporter
+	inventory           *inventoryReporter
+	resync              *resyncer
+	freeze              *FreezeSwitch
+	shutdownHooks       shutdownHooks
+	// whether the pack is run by a PackSet, which serves the health checks of all its packs
+	inSet  bool
+	scopes *actionScopes
+	hooks  lifecycleHooks
+	// re-reads the settings of a pack created from the environment, see Reload
+	reloader *reloader
+	// decides what the pack does when the flyte server does not find it, see WithNotFoundHandler
+	notFound func(err error, notFounds int) NotFoundPolicy
+	// keeps action results that could not be posted, to post them again later, see WithResultQueue
+	results *resultQueue
+	// suppresses spontaneous events identical to one sent recently, see WithEventDedup
+	dedup *eventDedup
+	// logs the actions taken, so those not completed are handled again after a crash, see WithActionLog
+	actions *actionLog
+	// sends heartbeats for the actions being handled, see WithActionHeartbeat
+	heartbeats *heartbeats
 }
 
 // Creates a Pack struct with the details from the pack definition and a connection to the flyte api through the client.
@@ -61,25 +127,72 @@ func NewPack(packDef PackDef, client client.Client, healthChecks ...healthcheck.
 		// - if actions are available then the pack/client will consume them as quickly as it can)
 		pollingFrequency: 5 * time.Second,
 		healthChecks:     addDefaultHealthCheckIfNoneExist(healthChecks),
+		lifecycle:        newLifecycle(),
+		counters:         &counters{},
+		workers:          &workerPool{},
+		container:        newContainer(),
+		scopes:           newActionScopes(),
 	}
 }
 
+// Creates a Pack with a client configured from the environment (see config.ReadEnvironment). It panics if the
+// environment is invalid; use NewPackFromEnvironment to handle the error instead.
 func NewDefaultPack(packDef PackDef) Pack {
-	cfg := config.FromEnvironment()
-	return NewPack(packDef, client.NewClient(cfg.FlyteApiUrl, cfg.Timeout))
+	p, err := NewPackFromEnvironment(packDef)
+	if err != nil {
+		panic(err)
+	}
+	return p
 }
 
+// Creates a Pack in the same way as NewPackWithOptions, with a client configured from the environment (see
+// config.ReadEnvironment). The labels set in the environment are added to the pack labels, and the polling frequency
+// and concurrency set in the environment are used unless the options passed in set them. An error is returned if
+// the environment is invalid.
+func NewPackFromEnvironment(packDef PackDef, opts ...Option) (Pack, error) {
+	return NewPackFromEnv(config.Env{}, packDef, opts...)
+}
+
+// Creates a Pack in the same way as NewPackFromEnvironment, from the environment variables with the prefix of the Env
+// passed in, e.g. STAGING_API for config.Env{Prefix: "STAGING_"}, so packs in one process can be configured
+// independently.
+func NewPackFromEnv(env config.Env, packDef PackDef, opts ...Option) (Pack, error) {
+	cfg, err := env.ReadEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	c, retryWait := newDefaultClient(cfg)
+	p := NewPackWithOptions(packDef, c, append(packOptions(cfg), opts...)...).(pack)
+	p.retryWait = retryWait
+	p.reloader = newReloader(env, c, cfg)
+	return p, nil
+}
+
+// Creates a Pack with a client configured from the environment, polling for actions at the frequency passed in. It
+// panics if the environment is invalid; use NewPackFromEnvironment with WithPollingFrequency to handle the error
+// instead.
 func NewPackWithPolling(packDef PackDef, polling time.Duration) Pack {
-	cfg := config.FromEnvironment()
+	cfg, err := config.ReadEnvironment()
+	if err != nil {
+		panic(err)
+	}
+	c, retryWait := newDefaultClient(cfg)
+	packDef.Labels = mergeLabels(packDef.Labels, cfg.Labels)
 	if polling < 500 * time.Millisecond {
 		polling = 500 * time.Millisecond
 		log.Warn().Msgf("Enforcing lower limit of 500 Milliseconds for commands polling frequency")
 	}
 	return pack{
 		PackDef: packDef,
-		client:  client.NewClient(cfg.FlyteApiUrl, cfg.Timeout),
+		client:  c,
 		pollingFrequency: polling,
 		healthChecks:     addDefaultHealthCheckIfNoneExist([]healthcheck.HealthCheck{}),
+		lifecycle:        newLifecycle(),
+		counters:         &counters{},
+		workers:          &workerPool{},
+		container:        newContainer(),
+		scopes:           newActionScopes(),
+		retryWait:        retryWait,
 	}
 }
 
@@ -96,58 +209,142 @@ func addDefaultHealthCheckIfNoneExist(healthChecks []healthcheck.HealthCheck) []
 // Once started the Pack is also available to send observed events.
 // This will also start up a pack health check server.
 func (p pack) Start() {
-	if err := p.register(); err != nil {
+	select {
+	case <-p.lifecycle.Done():
+		log.Warn().Msgf("pack %q has been stopped and cannot be started", p.Name)
+		return
+	default:
+	}
+	// probes are served while the pack registers, so it is live (but not ready) if registration is retried
+	p.startProbeServer()
+
+	err := p.register()
+	if err == nil {
+		err = p.hooks.register(p.Handle())
+	}
+	if err != nil {
 		log.Err(err).Msg("cannot register pack")
-		time.Sleep(registerRetryWait)
+		wait := registerRetryWait
+		if p.retryWait > 0 {
+			wait = p.retryWait
+		}
+		time.Sleep(retryWait(wait, err))
 		p.Start()
 		return
 	}
+	p.configurator.restore()
+	p.statsStore.restore()
+	p.statsStore.run(p.lifecycle.Done())
+	p.results.run(p.lifecycle.Done(), p.retryQueuedResults)
+	p.lifecycle.markStarted()
+	p.statusReporter.run(p.lifecycle.Done())
+	p.usage.run(p.lifecycle.Done(), p.features())
+	p.inventory.run(p.lifecycle.Done(), p.inventoryRecord)
+	p.resync.run(p.lifecycle.Done(), p.client, p.clientPack)
+	p.sendHealthEvents()
 	p.handleCommands()
 	p.startHealthCheckServer()
+	p.hooks.start(p.Handle())
 }
 
 // Spontaneously sends an event that the pack has observed to the flyte server.
 func (p pack) SendEvent(event Event) error {
-	return p.client.PostEvent(client.Event{
-		Name:    event.EventDef.Name,
-		Payload: event.Payload,
+	return p.sendEvent(event, nil)
+}
+
+func (p pack) sendEvent(event Event, correlation *client.Correlation) error {
+	digest, send := p.dedup.send(event)
+	if !send {
+		p.counters.add(eventsDeduplicated)
+		return nil
+	}
+	err := p.client.PostEvent(client.Event{
+		Name:        event.EventDef.Name,
+		Payload:     event.Payload,
+		Correlation: correlation,
 	})
+	if err != nil {
+		p.usage.countError(err)
+		p.counters.add(eventsFailed)
+		p.dedup.forget(digest)
+		return err
+	}
+	p.counters.add(eventsSent)
+	return nil
+}
+
+// Stops the pack taking any further actions from the flyte server, waiting for the actions being handled to complete.
+// A summary of what happened while stopping is logged, and is available from PackHandle.Stats().
+func (p pack) Stop() {
+	summary, stopped := p.stopAndDrain()
+	if !stopped {
+		return
+	}
+	p.container.close()
+	p.actions.close()
+	p.statsStore.persist()
+	p.inventory.remove()
+	p.shutdownHooks.run(ShutdownCloseState)
+	logDrainSummary(p.Name, summary)
+	p.hooks.stop(summary)
+	p.shutdownHooks.run(ShutdownFinal)
 }
 
 var StartHealthCheckServer = true // this is only overridden for testing purposes
 
 func (p pack) startHealthCheckServer() {
-	if StartHealthCheckServer == true {
+	if StartHealthCheckServer == true && !p.inSet {
 		healthcheck.Start(p.healthChecks)
 	}
 }
 
 // The main configuration struct for defining a pack.
 type PackDef struct {
-	Name      string            // the pack name
-	Labels    map[string]string // the pack labels. These act as a filter that determines when the pack will execute against a flow
-	EventDefs []EventDef        // the event definitions of a pack. These can be events a pack observes and sends spontaneously
-	Commands  []Command         // the commands a pack exposes
-	HelpURL   *url.URL          // a help url to a page that describes what the pack does and how it is used
+	Name        string            // the pack name
+	Labels      map[string]string // the pack labels. These act as a filter that determines when the pack will execute against a flow
+	EventDefs   []EventDef        // the event definitions of a pack. These can be events a pack observes and sends spontaneously
+	Commands    []Command         // the commands a pack exposes
+	HelpURL     *url.URL          // a help url to a page that describes what the pack does and how it is used
+	Description string            // optional, a short description of what the pack does, shown in the flyte UI
 }
 
-// Defines an event. The help URL is optional.
+// Defines an event. The help URL and description are optional.
 type EventDef struct {
-	Name    string
-	HelpURL *url.URL
+	Name        string
+	HelpURL     *url.URL
+	Description string // a short description of what the event means, shown in the flyte UI
 }
 
-// Defines a command - its name, the events it can output and a handler for incoming actions. The help URL is optional.
+// Defines a command - its name, the events it can output and a handler for incoming actions. The help URL and
+// description are optional.
 type Command struct {
 	Name         string         // the name of the command
 	OutputEvents []EventDef     // the events a pack can output
 	Handler      CommandHandler // the handler is where the functionality of a pack is implemented when a command is called
 	HelpURL      *url.URL       // optional
+	Description  string         // optional, a short description of what the command does, shown in the flyte UI
+	// optional, a function that creates the handler from its dependencies, used instead of Handler. It is called
+	// once the pack has started, with the dependencies it takes as parameters (see WithProviders)
+	NewHandler interface{}
+	// optional, a handler that is also passed a context carrying the correlation identifiers of the action, used
+	// instead of Handler
+	ContextHandler ContextHandler
+	// optional, a handler that can return an error instead of an event, used instead of Handler
+	FallibleHandler FallibleHandler
+	// optional, retries the FallibleHandler (or the FallibleHandler created by NewHandler) when it returns an error,
+	// before the action is completed with a FATAL event
+	Retry *RetryPolicy
+	// true if the command has no side effects, so it is still run while execution is frozen (see FreezeSwitch)
+	ReadOnly bool
 }
 
 // Command handlers will be invoked with the input JSON when they are invoked from a flow step in the flyte server.
 type CommandHandler func(input json.RawMessage) Event
 
+// FallibleHandler is a command handler that returns an error when it fails. The action is then completed with a
+// FATAL event for the error (see NewFatalErrorEvent), so the handler does not have to convert errors into events.
+type FallibleHandler func(input json.RawMessage) (Event, error)
+
 // The event data the pack can send for events it observes (using SendEvent()) or from commands that have been called.
 // The payload will be marshalled into JSON, so should be annotated appropriately.
 type Event struct {
@@ -155,8 +352,12 @@ type Event struct {
 	Payload  interface{}
 }
 
-// This is the preferred way for packs to handle serious errors within the handler.
+// This is the preferred way for packs to handle serious errors within the handler. An error passed in as the payload
+// is sent in the standard error payload, as for NewFatalErrorEvent.
 func NewFatalEvent(payload interface{}) Event {
+	if err, ok := payload.(error); ok {
+		return NewFatalErrorEvent(err)
+	}
 	return Event{
 		EventDef: EventDef{Name: fatalEventName},
 		Payload:  payload,
@@ -513,16 +513,32 @@ func Test_NewDefaultPackWithPolling_ShouldCreatePackWithDefaultClientAndCustomPo
 	assert.Equal(t, 1 * time.Second, realPack.pollingFrequency)
 }
 
+func Test_NewPackFromEnvironment_ShouldReturnAnErrorWhenTheEnvironmentIsInvalid(t *testing.T) {
+	prevGetEnv := config.GetEnv
+	defer func() { config.GetEnv = prevGetEnv }()
+	config.GetEnv = func(name string) string { return "" }
+
+	p, err := NewPackFromEnvironment(PackDef{Name: "JiraPack"})
+
+	assert.Nil(t, p)
+	assert.EqualError(t, err, "FLYTE_API environment variable is not set")
+	assert.Panics(t, func() { NewDefaultPack(PackDef{Name: "JiraPack"}) })
+}
+
 type createPack func(client.Pack) error
 type postEvent func(client.Event) error
 type takeAction func() (*client.Action, error)
 type completeAction func(action client.Action, event client.Event) error
 
 type MockClient struct {
-	createPack     createPack
-	postEvent      postEvent
-	takeAction     takeAction
-	completeAction completeAction
+	createPack          createPack
+	postEvent           postEvent
+	takeAction          takeAction
+	completeAction      completeAction
+	getDatastoreItem    func(key string) (*client.DatastoreItem, error)
+	putDatastoreItem    func(item client.DatastoreItem) error
+	deleteDatastoreItem func(key string) error
+	updatePackStatus    func(status client.PackStatus) error
 }
 
 func (c MockClient) CreatePack(pack client.Pack) error {
@@ -545,6 +561,34 @@ func (c MockClient) GetFlyteHealthCheckURL() (*url.URL, error) {
 	return nil, nil
 }
 
+func (c MockClient) GetDatastoreItem(key string) (*client.DatastoreItem, error) {
+	if c.getDatastoreItem == nil {
+		return nil, nil
+	}
+	return c.getDatastoreItem(key)
+}
+
+func (c MockClient) PutDatastoreItem(item client.DatastoreItem) error {
+	if c.putDatastoreItem == nil {
+		return nil
+	}
+	return c.putDatastoreItem(item)
+}
+
+func (c MockClient) DeleteDatastoreItem(key string) error {
+	if c.deleteDatastoreItem == nil {
+		return nil
+	}
+	return c.deleteDatastoreItem(key)
+}
+
+func (c MockClient) UpdatePackStatus(status client.PackStatus) error {
+	if c.updatePackStatus == nil {
+		return nil
+	}
+	return c.updatePackStatus(status)
+}
+
 func waitForChannelOrTimeout(c chan bool, duration time.Duration) error {
 	select {
 	case <-c:
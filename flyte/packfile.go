@@ -0,0 +1,198 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"bytes"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"sort"
+)
+
+// packFile is the declarative definition of a pack read by PackDefFromFile
+type packFile struct {
+	Name        string            `yaml:"name"`
+	Labels      map[string]string `yaml:"labels"`
+	HelpURL     string            `yaml:"helpURL"`
+	Description string            `yaml:"description"`
+	Events      []eventFile       `yaml:"events"`
+	Commands    []commandFile     `yaml:"commands"`
+}
+
+type eventFile struct {
+	Name        string `yaml:"name"`
+	HelpURL     string `yaml:"helpURL"`
+	Description string `yaml:"description"`
+}
+
+type commandFile struct {
+	Name         string      `yaml:"name"`
+	HelpURL      string      `yaml:"helpURL"`
+	Description  string      `yaml:"description"`
+	OutputEvents []eventFile `yaml:"outputEvents"`
+	ReadOnly     bool        `yaml:"readOnly"`
+}
+
+// UnmarshalYAML allows an event without a help URL to be given as just its name
+func (e *eventFile) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		return n.Decode(&e.Name)
+	}
+	type plain eventFile
+	return n.Decode((*plain)(e))
+}
+
+// PackDefFromFile loads the pack definition - its name, labels, help URL, description, events and commands - from a
+// YAML or JSON file, binding each command in the file to the handler with the same name:
+//
+//	name: Jira
+//	helpURL: https://github.com/your-org/jira-pack
+//	description: Creates and tracks Jira issues
+//	labels:
+//	  team: tools
+//	events:
+//	  - IssueUpdated
+//	commands:
+//	  - name: CreateIssue
+//	    description: Creates an issue in the project passed in
+//	    outputEvents:
+//	      - name: IssueCreated
+//	        helpURL: https://github.com/your-org/jira-pack#issuecreated
+//	      - IssueCreationError
+//	  - name: GetIssue
+//	    readOnly: true
+//	    outputEvents:
+//	      - IssueFound
+//
+// An error is returned if the file cannot be read or is invalid, or if a command has no handler or a handler has no
+// command.
+func PackDefFromFile(path string, handlers map[string]CommandHandler) (PackDef, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return PackDef{}, fmt.Errorf("cannot read pack definition: %w", err)
+	}
+	packDef, err := parsePackDef(b, handlers)
+	if err != nil {
+		return PackDef{}, fmt.Errorf("invalid pack definition in %s: %w", path, err)
+	}
+	return packDef, nil
+}
+
+// parsePackDef parses the pack definition and binds the handlers to its commands
+func parsePackDef(b []byte, handlers map[string]CommandHandler) (PackDef, error) {
+	var f packFile
+	d := yaml.NewDecoder(bytes.NewReader(b))
+	d.KnownFields(true)
+	if err := d.Decode(&f); err != nil {
+		if err == io.EOF {
+			return PackDef{}, fmt.Errorf("the file is empty")
+		}
+		return PackDef{}, err
+	}
+	if f.Name == "" {
+		return PackDef{}, fmt.Errorf("the pack has no name")
+	}
+
+	helpURL, err := parseHelpURL(f.HelpURL)
+	if err != nil {
+		return PackDef{}, fmt.Errorf("pack %q: %w", f.Name, err)
+	}
+	events, err := toEventDefs(f.Events)
+	if err != nil {
+		return PackDef{}, err
+	}
+	packDef := PackDef{
+		Name:        f.Name,
+		Labels:      f.Labels,
+		EventDefs:   events,
+		HelpURL:     helpURL,
+		Description: f.Description,
+	}
+
+	bound := make(map[string]bool)
+	for _, c := range f.Commands {
+		if c.Name == "" {
+			return PackDef{}, fmt.Errorf("a command has no name")
+		}
+		if bound[c.Name] {
+			return PackDef{}, fmt.Errorf("command %q is defined more than once", c.Name)
+		}
+		handler := handlers[c.Name]
+		if handler == nil {
+			return PackDef{}, fmt.Errorf("command %q has no handler", c.Name)
+		}
+		helpURL, err := parseHelpURL(c.HelpURL)
+		if err != nil {
+			return PackDef{}, fmt.Errorf("command %q: %w", c.Name, err)
+		}
+		outputEvents, err := toEventDefs(c.OutputEvents)
+		if err != nil {
+			return PackDef{}, fmt.Errorf("command %q: %w", c.Name, err)
+		}
+		packDef.Commands = append(packDef.Commands, Command{
+			Name:         c.Name,
+			OutputEvents: outputEvents,
+			Handler:      handler,
+			HelpURL:      helpURL,
+			Description:  c.Description,
+			ReadOnly:     c.ReadOnly,
+		})
+		bound[c.Name] = true
+	}
+
+	var unbound []string
+	for name := range handlers {
+		if !bound[name] {
+			unbound = append(unbound, name)
+		}
+	}
+	if len(unbound) > 0 {
+		sort.Strings(unbound)
+		return PackDef{}, fmt.Errorf("no command is defined for the handlers %q", unbound)
+	}
+	return packDef, nil
+}
+
+func toEventDefs(events []eventFile) ([]EventDef, error) {
+	var defs []EventDef
+	for _, e := range events {
+		if e.Name == "" {
+			return nil, fmt.Errorf("an event has no name")
+		}
+		helpURL, err := parseHelpURL(e.HelpURL)
+		if err != nil {
+			return nil, fmt.Errorf("event %q: %w", e.Name, err)
+		}
+		defs = append(defs, EventDef{Name: e.Name, HelpURL: helpURL, Description: e.Description})
+	}
+	return defs, nil
+}
+
+// parseHelpURL parses the help url, which is optional, returning nil if there is none
+func parseHelpURL(s string) (*url.URL, error) {
+	if s == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid help url: %w", err)
+	}
+	return u, nil
+}
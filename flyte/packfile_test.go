@@ -0,0 +1,153 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const jiraPackFile = `
+name: Jira
+helpURL: https://github.com/your-org/jira-pack
+description: Creates and tracks Jira issues
+labels:
+  team: tools
+events:
+  - IssueUpdated
+commands:
+  - name: CreateIssue
+    helpURL: https://github.com/your-org/jira-pack#createissue
+    description: Creates an issue
+    outputEvents:
+      - name: IssueCreated
+        helpURL: https://github.com/your-org/jira-pack#issuecreated
+        description: The issue was created
+      - IssueCreationError
+`
+
+func writePackFile(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "packfile")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func createIssue(json.RawMessage) Event {
+	return Event{EventDef: EventDef{Name: "IssueCreated"}}
+}
+
+func Test_PackDefFromFile_ShouldLoadThePackDefinitionFromYAML(t *testing.T) {
+	path := writePackFile(t, "pack.yaml", jiraPackFile)
+
+	packDef, err := PackDefFromFile(path, map[string]CommandHandler{"CreateIssue": createIssue})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Jira", packDef.Name)
+	assert.Equal(t, "https://github.com/your-org/jira-pack", packDef.HelpURL.String())
+	assert.Equal(t, "Creates and tracks Jira issues", packDef.Description)
+	assert.Equal(t, map[string]string{"team": "tools"}, packDef.Labels)
+	assert.Equal(t, []EventDef{{Name: "IssueUpdated"}}, packDef.EventDefs)
+	require.Len(t, packDef.Commands, 1)
+	cmd := packDef.Commands[0]
+	assert.Equal(t, "CreateIssue", cmd.Name)
+	assert.Equal(t, "https://github.com/your-org/jira-pack#createissue", cmd.HelpURL.String())
+	assert.Equal(t, "Creates an issue", cmd.Description)
+	require.Len(t, cmd.OutputEvents, 2)
+	assert.Equal(t, "IssueCreated", cmd.OutputEvents[0].Name)
+	assert.Equal(t, "https://github.com/your-org/jira-pack#issuecreated", cmd.OutputEvents[0].HelpURL.String())
+	assert.Equal(t, "The issue was created", cmd.OutputEvents[0].Description)
+	assert.Equal(t, EventDef{Name: "IssueCreationError"}, cmd.OutputEvents[1])
+	require.NotNil(t, cmd.Handler)
+	assert.Equal(t, "IssueCreated", cmd.Handler(nil).EventDef.Name)
+}
+
+func Test_PackDefFromFile_ShouldLoadReadOnlyCommands(t *testing.T) {
+	path := writePackFile(t, "pack.yaml", `
+name: Jira
+commands:
+  - name: GetIssue
+    readOnly: true
+  - name: CreateIssue
+`)
+
+	packDef, err := PackDefFromFile(path, map[string]CommandHandler{"GetIssue": createIssue, "CreateIssue": createIssue})
+
+	require.NoError(t, err)
+	require.Len(t, packDef.Commands, 2)
+	assert.True(t, packDef.Commands[0].ReadOnly)
+	assert.False(t, packDef.Commands[1].ReadOnly)
+}
+
+func Test_PackDefFromFile_ShouldLoadThePackDefinitionFromJSON(t *testing.T) {
+	path := writePackFile(t, "pack.json", `{
+		"name": "Jira",
+		"commands": [{"name": "CreateIssue", "outputEvents": ["IssueCreated", {"name": "IssueCreationError"}]}]
+	}`)
+
+	packDef, err := PackDefFromFile(path, map[string]CommandHandler{"CreateIssue": createIssue})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Jira", packDef.Name)
+	assert.Nil(t, packDef.HelpURL)
+	require.Len(t, packDef.Commands, 1)
+	assert.Equal(t, []EventDef{{Name: "IssueCreated"}, {Name: "IssueCreationError"}}, packDef.Commands[0].OutputEvents)
+}
+
+func Test_PackDefFromFile_ShouldReturnAnErrorForAnInvalidDefinition(t *testing.T) {
+	handlers := map[string]CommandHandler{"CreateIssue": createIssue}
+	tests := []struct {
+		name     string
+		content  string
+		handlers map[string]CommandHandler
+		err      string
+	}{
+		{"empty file", "", handlers, "the file is empty"},
+		{"no name", "commands: [{name: CreateIssue}]", handlers, "the pack has no name"},
+		{"unknown field", "name: Jira\ncommand: []", nil, "field command not found"},
+		{"command without handler", "name: Jira\ncommands: [{name: CreateIssue}]", nil, `command "CreateIssue" has no handler`},
+		{"handler without command", "name: Jira", handlers, `no command is defined for the handlers ["CreateIssue"]`},
+		{"duplicate command", "name: Jira\ncommands: [{name: CreateIssue}, {name: CreateIssue}]", handlers, `command "CreateIssue" is defined more than once`},
+		{"unnamed event", "name: Jira\nevents: [{helpURL: http://example.com}]", nil, "an event has no name"},
+		{"invalid help url", "name: Jira\nhelpURL: \"http://[::1\"", nil, "invalid help url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writePackFile(t, "pack.yaml", tt.content)
+
+			_, err := PackDefFromFile(path, tt.handlers)
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func Test_PackDefFromFile_ShouldReturnAnErrorIfTheFileCannotBeRead(t *testing.T) {
+	_, err := PackDefFromFile(filepath.Join(os.TempDir(), "no-such-pack.yaml"), nil)
+
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
@@ -0,0 +1,152 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"crypto/tls"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/ExpediaGroup/flyte-client/config"
+	"github.com/ExpediaGroup/flyte-client/healthcheck"
+	"net/http"
+	"sync"
+)
+
+// PackSet runs several packs from one process, so many small integrations can be consolidated into one deployment.
+// The packs are started and stopped together, and one health check server serves the health checks of all of them.
+type PackSet struct {
+	packs   []pack
+	workers *workerPool
+	done    chan struct{}
+	stop    sync.Once
+}
+
+// NewPackSet groups the packs passed in into a set. When workers is more than zero the packs handle their actions with
+// one shared worker pool of that many workers and queueSize queued actions (see WithWorkerPool), instead of a pool
+// each, so the set as a whole only takes the actions it can handle promptly. Otherwise each pack handles actions as
+// it is configured to. To share connections to the flyte api as well, create the pack clients with the same transport
+// (see client.WithTransport), or use NewDefaultPackSet. The packs must have been created by this package, e.g. with
+// NewPack, else an error is returned.
+func NewPackSet(workers, queueSize int, packs ...Pack) (*PackSet, error) {
+	s := &PackSet{done: make(chan struct{})}
+	if workers > 0 {
+		s.workers = &workerPool{}
+		s.workers.configure(workers, queueSize)
+	}
+	for i, p := range packs {
+		member, ok := p.(pack)
+		if !ok {
+			return nil, fmt.Errorf("pack %d of the set is a %T, not a pack created by the flyte package", i, p)
+		}
+		member.inSet = true
+		if s.workers != nil {
+			member.workers = s.workers
+		}
+		s.packs = append(s.packs, member)
+	}
+	return s, nil
+}
+
+// NewDefaultPackSet creates a set of packs from the pack definitions passed in, with the options passed in, which all
+// connect to the flyte api configured in the environment (see NewDefaultPack) through one shared connection pool.
+// The workers and queueSize configure a worker pool shared by the packs, as for NewPackSet. It panics if the
+// environment is invalid; use NewPackSetFromEnvironment to handle the error instead.
+func NewDefaultPackSet(workers, queueSize int, packDefs []PackDef, opts ...Option) *PackSet {
+	s, err := NewPackSetFromEnvironment(workers, queueSize, packDefs, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// NewPackSetFromEnvironment creates a set of packs in the same way as NewDefaultPackSet, returning an error if the
+// environment is invalid.
+func NewPackSetFromEnvironment(workers, queueSize int, packDefs []PackDef, opts ...Option) (*PackSet, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.LocalDev() {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	cfg, err := config.ReadEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(packOptions(cfg), opts...)
+	var packs []Pack
+	for _, packDef := range packDefs {
+		c, retryWait := newDefaultClient(cfg, client.WithTransport(transport))
+		p := NewPackWithOptions(packDef, c, opts...).(pack)
+		p.retryWait = retryWait
+		p.reloader = newReloader(config.Env{}, c, cfg)
+		packs = append(packs, p)
+	}
+	return NewPackSet(workers, queueSize, packs...)
+}
+
+// Packs returns the packs in the set.
+func (s *PackSet) Packs() []Pack {
+	packs := make([]Pack, 0, len(s.packs))
+	for _, p := range s.packs {
+		packs = append(packs, p)
+	}
+	return packs
+}
+
+// Start starts all the packs in the set, returning once they have all registered with the flyte server.
+func (s *PackSet) Start() {
+	s.workers.start(s.done)
+	s.each(pack.Start)
+	if StartHealthCheckServer {
+		healthcheck.Start(s.healthChecks())
+	}
+}
+
+// Stop stops all the packs in the set, waiting for the actions they are handling to complete (see Stopper).
+func (s *PackSet) Stop() {
+	s.each(pack.Stop)
+	s.stop.Do(func() { close(s.done) })
+}
+
+// each calls the function with every pack in the set concurrently, and waits for the calls to return
+func (s *PackSet) each(f func(pack)) {
+	var wg sync.WaitGroup
+	for _, p := range s.packs {
+		wg.Add(1)
+		go func(p pack) {
+			defer wg.Done()
+			f(p)
+		}(p)
+	}
+	wg.Wait()
+}
+
+// healthChecks returns the health checks of all the packs, with each check named after its pack
+func (s *PackSet) healthChecks() []healthcheck.HealthCheck {
+	var checks []healthcheck.HealthCheck
+	for _, p := range s.packs {
+		for _, check := range p.healthChecks {
+			checks = append(checks, packHealthCheck(p.Name, check))
+		}
+	}
+	return checks
+}
+
+func packHealthCheck(packName string, check healthcheck.HealthCheck) healthcheck.HealthCheck {
+	return func() (string, healthcheck.Health) {
+		name, health := check()
+		return packName + "/" + name, health
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/ExpediaGroup/flyte-client/config"
+	"github.com/ExpediaGroup/flyte-client/healthcheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// setMember returns a pack with one command, that takes a single action and records its completion
+func setMember(name string, completed chan<- string) Pack {
+	var once sync.Once
+	c := MockClient{
+		createPack: func(client.Pack) error { return nil },
+		takeAction: func() (*client.Action, error) {
+			var a *client.Action
+			once.Do(func() { a = &client.Action{CommandName: "Ping"} })
+			return a, nil
+		},
+		completeAction: func(client.Action, client.Event) error {
+			completed <- name
+			return nil
+		},
+	}
+	packDef := PackDef{Name: name, Commands: []Command{{Name: "Ping", Handler: func(json.RawMessage) Event {
+		return Event{EventDef: EventDef{Name: "Pong"}}
+	}}}}
+	return NewPackWithOptions(packDef, c, WithPollingFrequency(minPollingFrequency))
+}
+
+func Test_PackSet_ShouldRunThePacksTogetherWithASharedWorkerPool(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	completed := make(chan string, 2)
+	s, err := NewPackSet(2, 0, setMember("Slack", completed), setMember("Jira", completed))
+	require.NoError(t, err)
+
+	// when
+	s.Start()
+
+	// then both packs are started, and handle their actions with the shared pool
+	var names []string
+	for i := 0; i < 2; i++ {
+		select {
+		case name := <-completed:
+			names = append(names, name)
+		case <-time.After(2 * time.Second):
+			t.Fatal("actions were not completed")
+		}
+	}
+	assert.ElementsMatch(t, []string{"Slack", "Jira"}, names)
+	packs := s.Packs()
+	require.Len(t, packs, 2)
+	for _, p := range packs {
+		assert.Same(t, s.workers, p.(pack).workers)
+		assert.True(t, p.(pack).inSet)
+		select {
+		case <-p.(pack).Handle().Started():
+		default:
+			t.Errorf("pack %s was not started", p.(pack).Name)
+		}
+	}
+	assert.Equal(t, 2, s.workers.workers)
+
+	// and they are stopped together
+	s.Stop()
+	for _, p := range packs {
+		select {
+		case <-p.(pack).Handle().Done():
+		default:
+			t.Errorf("pack %s was not stopped", p.(pack).Name)
+		}
+	}
+}
+
+func Test_PackSet_ShouldLeaveThePackWorkersAloneWithoutASharedPool(t *testing.T) {
+	p := NewPackWithOptions(PackDef{Name: "Slack"}, MockClient{}, WithWorkerPool(3, 1))
+
+	s, err := NewPackSet(0, 0, p)
+	require.NoError(t, err)
+
+	assert.Nil(t, s.workers)
+	assert.Same(t, p.(pack).workers, s.packs[0].workers)
+}
+
+func Test_NewPackSet_ShouldReturnAnErrorForPacksNotCreatedByThePackage(t *testing.T) {
+	type wrappedPack struct{ Pack }
+	p := NewPackWithOptions(PackDef{Name: "Slack"}, MockClient{})
+
+	s, err := NewPackSet(2, 0, p, wrappedPack{p})
+
+	assert.Nil(t, s)
+	assert.EqualError(t, err, "pack 1 of the set is a flyte.wrappedPack, not a pack created by the flyte package")
+}
+
+func Test_PackSet_ShouldNameHealthChecksAfterTheirPack(t *testing.T) {
+	check := func() (string, healthcheck.Health) {
+		return "Api", healthcheck.Health{Healthy: true, Status: "ok"}
+	}
+	slack := NewPackWithOptions(PackDef{Name: "Slack"}, MockClient{}, WithHealthChecks(check))
+	jira := NewPackWithOptions(PackDef{Name: "Jira"}, MockClient{})
+	s, err := NewPackSet(0, 0, slack, jira)
+	require.NoError(t, err)
+
+	var names []string
+	for _, c := range s.healthChecks() {
+		name, _ := c()
+		names = append(names, name)
+	}
+
+	assert.Equal(t, []string{"Slack/Api", "Jira/DefaultCheck"}, names)
+}
+
+func Test_NewPackSetFromEnvironment_ShouldReturnAnErrorWhenTheEnvironmentIsInvalid(t *testing.T) {
+	prevGetEnv := config.GetEnv
+	defer func() { config.GetEnv = prevGetEnv }()
+	config.GetEnv = func(name string) string { return "" }
+
+	s, err := NewPackSetFromEnvironment(2, 0, []PackDef{{Name: "Slack"}})
+
+	assert.Nil(t, s)
+	assert.EqualError(t, err, "FLYTE_API environment variable is not set")
+}
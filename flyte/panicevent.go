@@ -0,0 +1,84 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/rs/zerolog/log"
+	"os"
+	"time"
+)
+
+const (
+	packPanicEventName = "PackPanic"
+	// the most of the stack included in a "PackPanic" event, so the event stays a reasonable size
+	maxPanicStackBytes = 4096
+)
+
+// PackPanicPayload is the payload of the "PackPanic" event.
+type PackPanicPayload struct {
+	Command    string    `json:"command"`    // the command whose handler panicked
+	ActionID   string    `json:"actionId"`   // the id of the action being handled, if the flyte server sent one
+	Panic      string    `json:"panic"`      // the value the handler panicked with
+	Stack      string    `json:"stack"`      // the stack of the panicking goroutine, truncated to 4KB
+	Version    string    `json:"version"`    // the pack version
+	InstanceID string    `json:"instanceId"` // identifies the pack instance, e.g. the pod name
+	Time       time.Time `json:"time"`       // when the panic was recovered
+}
+
+// panicEvents configures the "PackPanic" events sent by a pack
+type panicEvents struct {
+	version    string
+	instanceID string
+}
+
+// WithPanicEvents makes the pack send a "PackPanic" event, as well as logging, whenever a command handler panics.
+// The event includes the command, a truncated stack, the pack version passed in and the pack instance id (the host
+// name, which is the pod name on kubernetes), so crash rates across a fleet of packs can be tracked through flyte.
+func WithPanicEvents(version string) Option {
+	return func(p *pack) {
+		instanceID, err := os.Hostname()
+		if err != nil {
+			log.Warn().Err(err).Msg("cannot get the host name to use as the pack instance id")
+		}
+		p.EventDefs = append(append([]EventDef(nil), p.EventDefs...), EventDef{Name: packPanicEventName})
+		p.panicEvents = &panicEvents{version: version, instanceID: instanceID}
+	}
+}
+
+// sendPanicEvent sends a "PackPanic" event for the handler panic, if the pack sends panic events
+func (p pack) sendPanicEvent(a *client.Action, r interface{}, stack []byte) {
+	if p.panicEvents == nil {
+		return
+	}
+	if len(stack) > maxPanicStackBytes {
+		stack = append(stack[:maxPanicStackBytes:maxPanicStackBytes], "\n... (truncated)"...)
+	}
+	payload := PackPanicPayload{
+		Command:    a.CommandName,
+		ActionID:   a.ID,
+		Panic:      fmt.Sprintf("%v", r),
+		Stack:      string(stack),
+		Version:    p.panicEvents.version,
+		InstanceID: p.panicEvents.instanceID,
+		Time:       time.Now().UTC(),
+	}
+	if err := p.SendEvent(Event{EventDef: EventDef{Name: packPanicEventName}, Payload: payload}); err != nil {
+		log.Err(err).Msgf("could not send %q event", packPanicEventName)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"strings"
+	"testing"
+)
+
+func panickingHandler(json.RawMessage) Event {
+	panic("out of cheese")
+}
+
+func Test_WithPanicEvents_ShouldSendAPackPanicEventWhenAHandlerPanics(t *testing.T) {
+	// given
+	var events []client.Event
+	c := MockClient{
+		postEvent: func(e client.Event) error {
+			events = append(events, e)
+			return nil
+		},
+		completeAction: func(client.Action, client.Event) error { return nil },
+	}
+	p := NewPackWithOptions(PackDef{Name: "PanicPack"}, c, WithPanicEvents("1.2.3")).(pack)
+	handlers := map[string]ContextHandler{"Cheese": CommandHandler(panickingHandler).withContext()}
+
+	// when
+	p.handleAction(&client.Action{ID: "42", CommandName: "Cheese"}, handlers)
+
+	// then
+	require.Len(t, events, 1)
+	assert.Equal(t, packPanicEventName, events[0].Name)
+	payload := events[0].Payload.(PackPanicPayload)
+	hostname, _ := os.Hostname()
+	assert.Equal(t, "Cheese", payload.Command)
+	assert.Equal(t, "42", payload.ActionID)
+	assert.Equal(t, "out of cheese", payload.Panic)
+	assert.Equal(t, "1.2.3", payload.Version)
+	assert.Equal(t, hostname, payload.InstanceID)
+	assert.Contains(t, payload.Stack, "panickingHandler")
+	assert.Contains(t, p.EventDefs, EventDef{Name: packPanicEventName})
+}
+
+func Test_sendPanicEvent_ShouldTruncateLongStacks(t *testing.T) {
+	var payload PackPanicPayload
+	c := MockClient{
+		postEvent: func(e client.Event) error {
+			payload = e.Payload.(PackPanicPayload)
+			return nil
+		},
+	}
+	p := NewPackWithOptions(PackDef{Name: "PanicPack2"}, c, WithPanicEvents("1.2.3")).(pack)
+
+	p.sendPanicEvent(&client.Action{CommandName: "Cheese"}, "out of cheese", []byte(strings.Repeat("x", 10000)))
+
+	assert.True(t, strings.HasPrefix(payload.Stack, strings.Repeat("x", maxPanicStackBytes)))
+	assert.True(t, strings.HasSuffix(payload.Stack, "(truncated)"))
+	assert.True(t, len(payload.Stack) < 5000)
+}
+
+func Test_handleAction_ShouldNotSendPanicEventsUnlessEnabled(t *testing.T) {
+	c := MockClient{
+		postEvent: func(e client.Event) error {
+			assert.Fail(t, "postEvent called unexpectedly")
+			return nil
+		},
+		completeAction: func(client.Action, client.Event) error { return nil },
+	}
+	p := NewPackWithOptions(PackDef{Name: "PanicPack3"}, c).(pack)
+
+	p.handleAction(&client.Action{CommandName: "Cheese"}, map[string]ContextHandler{"Cheese": CommandHandler(panickingHandler).withContext()})
+}
@@ -0,0 +1,147 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"time"
+)
+
+const defaultStatsPersistInterval = time.Minute
+
+// WithPersistentStats persists the pack activity counters to the flyte api datastore every interval (and when the pack
+// is stopped), and restores them when the pack starts. This gives lifetime counters that are not reset by every
+// restart or deploy, available from PackHandle.LifetimeStats(). PackHandle.Stats() still only counts the current run.
+// Counters are stored in the datastore item "<pack name>-stats". An interval of zero or less persists every minute.
+// The counters are only persisted once they have been restored, so a datastore failure when the pack starts cannot
+// overwrite them. Replicas of a pack share the datastore item and overwrite each other's counters, so persist the
+// stats of one replica only.
+func WithPersistentStats(interval time.Duration) Option {
+	return func(p *pack) {
+		if interval <= 0 {
+			interval = defaultStatsPersistInterval
+		}
+		p.statsStore = &statsStore{
+			datastore: datastoreOf(p.client),
+			key:       p.Name + "-stats",
+			interval:  interval,
+			counters:  p.counters,
+		}
+	}
+}
+
+// statsStore persists the pack counters so they survive restarts
+type statsStore struct {
+	datastore client.Datastore
+	key       string
+	interval  time.Duration
+	counters  *counters
+	mu        sync.Mutex
+	restored  Stats // lifetime counters persisted by previous runs of the pack
+	loaded    bool  // whether the persisted counters have been restored, or found not to exist
+}
+
+// lifetime returns the counters of previous runs plus those of the current run. Without a store these are the same as
+// the counters of the current run.
+func (s *statsStore) lifetime(c *counters) Stats {
+	if s == nil {
+		return c.snapshot()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.restored.plus(s.counters.snapshot())
+}
+
+// restore loads the counters persisted by previous runs of the pack, if there are any, returning false if they could
+// not be read from the datastore
+func (s *statsStore) restore() bool {
+	if s == nil {
+		return false
+	}
+	item, err := s.datastore.GetDatastoreItem(s.key)
+	if errors.Is(err, client.ErrNotFound) {
+		item, err = nil, nil
+	}
+	if err != nil {
+		log.Err(err).Msgf("could not get persisted stats from datastore item %q", s.key)
+		return false
+	}
+
+	var restored Stats
+	if item != nil {
+		if err := json.Unmarshal(item.Value, &restored); err != nil {
+			log.Err(err).Msgf("could not restore persisted stats from datastore item %q, they are reset", s.key)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.restored = restored
+	s.loaded = true
+	return true
+}
+
+// run persists the counters every interval until the pack is stopped
+func (s *statsStore) run(done <-chan struct{}) {
+	if s == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				s.persist()
+			}
+		}
+	}()
+}
+
+// persist stores the lifetime counters in the datastore. If the persisted counters have not been restored yet they
+// are restored first, and nothing is stored if they still cannot be.
+func (s *statsStore) persist() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	loaded := s.loaded
+	s.mu.Unlock()
+	if !loaded && !s.restore() {
+		log.Warn().Msgf("not persisting stats to datastore item %q, as those persisted before could not be restored", s.key)
+		return
+	}
+	b, err := json.Marshal(s.lifetime(s.counters))
+	if err != nil {
+		log.Err(err).Msg("cannot marshal pack stats")
+		return
+	}
+	err = s.datastore.PutDatastoreItem(client.DatastoreItem{
+		Key:         s.key,
+		Description: "pack lifetime activity counters",
+		ContentType: "application/json",
+		Value:       b,
+	})
+	if err != nil {
+		log.Err(err).Msgf("could not persist stats to datastore item %q", s.key)
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_PersistentStats_ShouldRestoreLifetimeCountersAndPersistThemWhenStopped(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	// given counters persisted by a previous run of the pack
+	var mu sync.Mutex
+	var persisted []client.DatastoreItem
+	c := MockClient{
+		createPack: func(client.Pack) error {
+			return nil
+		},
+		takeAction: func() (*client.Action, error) {
+			return nil, nil
+		},
+		postEvent: func(client.Event) error {
+			return nil
+		},
+		getDatastoreItem: func(key string) (*client.DatastoreItem, error) {
+			assert.Equal(t, "SlackPack-stats", key)
+			return &client.DatastoreItem{Key: key, Value: []byte(`{"actionsTaken": 10, "eventsSent": 5}`)}, nil
+		},
+		putDatastoreItem: func(item client.DatastoreItem) error {
+			mu.Lock()
+			defer mu.Unlock()
+			persisted = append(persisted, item)
+			return nil
+		},
+	}
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, c, WithPersistentStats(time.Hour))
+	h := p.(pack).Handle()
+
+	// when
+	p.Start()
+	require.NoError(t, h.SendEvent(Event{EventDef: EventDef{Name: "MessageSent"}}))
+
+	// then the session counters only cover this run, while the lifetime counters include previous runs
+	assert.Equal(t, Stats{EventsSent: 1}, h.Stats())
+	assert.Equal(t, Stats{ActionsTaken: 10, EventsSent: 6}, h.LifetimeStats())
+
+	// and the lifetime counters are persisted when the pack stops
+	p.(pack).Stop()
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, persisted, 1)
+	assert.Equal(t, "SlackPack-stats", persisted[0].Key)
+	assert.JSONEq(t, `{"actionsTaken": 10, "actionsCompleted": 0, "actionsFailed": 0, "eventsSent": 6, "eventsFailed": 0, "cleanupsFailed": 0}`, string(persisted[0].Value))
+}
+
+func Test_PersistentStats_ShouldPersistCountersEveryInterval(t *testing.T) {
+	persisted := make(chan client.DatastoreItem, 10)
+	s := &statsStore{
+		datastore: MockClient{
+			putDatastoreItem: func(item client.DatastoreItem) error {
+				persisted <- item
+				return nil
+			},
+		},
+		key:      "SlackPack-stats",
+		interval: 10 * time.Millisecond,
+		counters: &counters{},
+	}
+	s.counters.add(actionsTaken)
+	done := make(chan struct{})
+	defer close(done)
+
+	s.run(done)
+
+	select {
+	case item := <-persisted:
+		assert.JSONEq(t, `{"actionsTaken": 1, "actionsCompleted": 0, "actionsFailed": 0, "eventsSent": 0, "eventsFailed": 0, "cleanupsFailed": 0}`, string(item.Value))
+	case <-time.After(time.Second):
+		t.Fatal("stats were not persisted")
+	}
+}
+
+func Test_PersistentStats_ShouldNotOverwriteCountersThatCouldNotBeRestored(t *testing.T) {
+	unavailable := true
+	var persisted []client.DatastoreItem
+	s := &statsStore{
+		datastore: MockClient{
+			getDatastoreItem: func(key string) (*client.DatastoreItem, error) {
+				if unavailable {
+					return nil, errors.New("datastore unavailable")
+				}
+				return &client.DatastoreItem{Key: key, Value: []byte(`{"actionsTaken": 10}`)}, nil
+			},
+			putDatastoreItem: func(item client.DatastoreItem) error {
+				persisted = append(persisted, item)
+				return nil
+			},
+		},
+		key:      "SlackPack-stats",
+		counters: &counters{},
+	}
+	s.counters.add(actionsTaken)
+
+	// when the persisted counters cannot be restored
+	assert.False(t, s.restore())
+	s.persist()
+
+	// then they are not overwritten
+	assert.Empty(t, persisted)
+
+	// until they have been restored
+	unavailable = false
+	s.persist()
+	require.Len(t, persisted, 1)
+	assert.JSONEq(t, `{"actionsTaken": 11, "actionsCompleted": 0, "actionsFailed": 0, "eventsSent": 0, "eventsFailed": 0, "cleanupsFailed": 0}`, string(persisted[0].Value))
+}
+
+func Test_LifetimeStats_ShouldBeTheSameAsStatsWhenStatsAreNotPersisted(t *testing.T) {
+	p := NewPack(PackDef{Name: "SlackPack"}, MockClient{postEvent: func(client.Event) error { return nil }})
+	p.(pack).lifecycle.markStarted()
+	h := p.(pack).Handle()
+
+	require.NoError(t, h.SendEvent(Event{EventDef: EventDef{Name: "MessageSent"}}))
+
+	assert.Equal(t, h.Stats(), h.LifetimeStats())
+}
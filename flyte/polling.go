@@ -0,0 +1,82 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+// WithAdaptivePolling makes the pack poll the flyte server for actions adaptively: while actions are being returned
+// it polls again straight away, and each time there are no actions it waits twice as long before polling again,
+// starting from min and capped at max. This keeps latency low when the pack is busy and reduces the load on the
+// flyte server when it is idle. The minimum for min is 500 milliseconds.
+func WithAdaptivePolling(min, max time.Duration) Option {
+	return func(p *pack) {
+		WithPollingFrequency(min)(p)
+		if max < p.pollingFrequency {
+			log.Warn().Msgf("maximum polling interval %v is less than the minimum %v, polling every %v", max, p.pollingFrequency, p.pollingFrequency)
+			max = p.pollingFrequency
+		}
+		p.maxPollingFrequency = max
+	}
+}
+
+// pollInterval returns how long to wait before polling again after the number of consecutive polls passed in
+// returned no action
+func (p pack) pollInterval(emptyPolls int) time.Duration {
+	frequency := p.reloader.polling(p.pollingFrequency)
+	if p.maxPollingFrequency <= frequency || emptyPolls < 1 {
+		return frequency
+	}
+	return backoff(frequency, p.maxPollingFrequency, emptyPolls-1)
+}
+
+// maxPollInterval returns the longest the pack waits between polls, barring Retry-After delays from the flyte api
+func (p pack) maxPollInterval() time.Duration {
+	frequency := p.reloader.polling(p.pollingFrequency)
+	if p.maxPollingFrequency > frequency {
+		return p.maxPollingFrequency
+	}
+	return frequency
+}
+
+// WithPollingJitter randomises the polling schedule so that many replicas of a pack started at the same time do not
+// poll the flyte server in lockstep. Each wait between polls is randomly adjusted by up to +/- the fraction passed in
+// (e.g. 0.2 for 20%), and the first poll is delayed by a random duration of up to maxInitialDelay.
+func WithPollingJitter(fraction float64, maxInitialDelay time.Duration) Option {
+	return func(p *pack) {
+		p.pollingJitter = fraction
+		p.maxInitialPollDelay = maxInitialDelay
+	}
+}
+
+// waitBeforeFirstPoll waits for a random duration of up to the max initial poll delay, returning false if the pack
+// is stopped while waiting
+func (p pack) waitBeforeFirstPoll() bool {
+	if p.maxInitialPollDelay <= 0 {
+		return true
+	}
+	delay := time.Duration(randomInt63n(int64(p.maxInitialPollDelay)))
+	log.Debug().Msgf("delaying the first poll for actions by %v", delay)
+	select {
+	case <-p.lifecycle.Done():
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_WithAdaptivePolling_ShouldDoubleTheIntervalForEachEmptyPollUpToTheMax(t *testing.T) {
+	p := NewPackWithOptions(PackDef{Name: "PollingPack"}, MockClient{}, WithAdaptivePolling(time.Second, 5*time.Second)).(pack)
+
+	assert.Equal(t, time.Second, p.pollInterval(1))
+	assert.Equal(t, 2*time.Second, p.pollInterval(2))
+	assert.Equal(t, 4*time.Second, p.pollInterval(3))
+	assert.Equal(t, 5*time.Second, p.pollInterval(4))
+	assert.Equal(t, 5*time.Second, p.pollInterval(100))
+	assert.Equal(t, 5*time.Second, p.maxPollInterval())
+}
+
+func Test_WithAdaptivePolling_ShouldEnforceTheMinimumAndNotAllowAMaxBelowTheMin(t *testing.T) {
+	p := NewPackWithOptions(PackDef{Name: "PollingPack2"}, MockClient{}, WithAdaptivePolling(time.Millisecond, 100*time.Millisecond)).(pack)
+
+	assert.Equal(t, minPollingFrequency, p.pollInterval(1))
+	assert.Equal(t, minPollingFrequency, p.pollInterval(10))
+	assert.Equal(t, minPollingFrequency, p.maxPollInterval())
+}
+
+func Test_pollInterval_ShouldBeFixedWithoutAdaptivePolling(t *testing.T) {
+	p := NewPackWithOptions(PackDef{Name: "PollingPack3"}, MockClient{}, WithPollingFrequency(time.Second)).(pack)
+
+	assert.Equal(t, time.Second, p.pollInterval(1))
+	assert.Equal(t, time.Second, p.pollInterval(10))
+}
+
+func Test_getNextAction_ShouldBackOffWhileNoActionsAreReturned(t *testing.T) {
+	// given a pack that gets an action on its fourth poll
+	var polls []time.Time
+	mock := mockClient{takeAction: func() (*client.Action, error) {
+		polls = append(polls, time.Now())
+		if len(polls) == 4 {
+			return &client.Action{}, nil
+		}
+		return nil, nil
+	}}
+	p := pack{client: mock, pollingFrequency: 10 * time.Millisecond, maxPollingFrequency: 40 * time.Millisecond}
+
+	// when
+	a := p.getNextAction()
+
+	// then the waits were 10, 20 and 40 milliseconds
+	if assert.NotNil(t, a) {
+		assert.True(t, polls[1].Sub(polls[0]) >= 10*time.Millisecond)
+		assert.True(t, polls[2].Sub(polls[1]) >= 20*time.Millisecond)
+		assert.True(t, polls[3].Sub(polls[2]) >= 40*time.Millisecond)
+	}
+}
+
+func Test_waitBeforeFirstPoll_ShouldWaitUpToTheMaxInitialDelay(t *testing.T) {
+	p := NewPackWithOptions(PackDef{Name: "PollingPack4"}, MockClient{}, WithPollingJitter(0.2, 50*time.Millisecond)).(pack)
+
+	start := time.Now()
+	assert.True(t, p.waitBeforeFirstPoll())
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Equal(t, 0.2, p.pollingJitter)
+}
+
+func Test_waitBeforeFirstPoll_ShouldStopWaitingWhenThePackIsStopped(t *testing.T) {
+	p := NewPackWithOptions(PackDef{Name: "PollingPack5"}, MockClient{}, WithPollingJitter(0.2, time.Hour)).(pack)
+	p.lifecycle.markStopped()
+
+	assert.False(t, p.waitBeforeFirstPoll())
+}
+
+func Test_waitBeforeFirstPoll_ShouldNotWaitWithoutJitter(t *testing.T) {
+	p := NewPackWithOptions(PackDef{Name: "PollingPack6"}, MockClient{}).(pack)
+	p.lifecycle.markStopped()
+
+	assert.True(t, p.waitBeforeFirstPoll())
+}
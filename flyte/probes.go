@@ -0,0 +1,201 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/config"
+	"github.com/ExpediaGroup/flyte-client/healthcheck"
+	"github.com/rs/zerolog/log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// the polling loop is considered stalled if it has not polled for this many polling intervals (or a minute, if longer)
+const pollStalledIntervals = 10
+
+// WithProbes starts an HTTP server serving liveness and readiness endpoints for the pack when it is started, for use
+// as Kubernetes probes. The port, paths and saturation limit are read from the environment (see
+// config.ReadProbes); by default the endpoints are served on port 8091 at /live and /ready. If the environment is
+// invalid the error is logged and the endpoints are not served; use WithProbeSettings to handle the error instead.
+//
+// The pack is live unless it has been stopped or its polling loop has stalled. It is ready once it has registered
+// with flyte-api, while it is live, while the number of actions being handled is below the saturation limit and while
+// the pack health checks (see WithHealthChecks) pass.
+// Both endpoints return a 200 response when passing and a 503 response otherwise, along with the individual check
+// results in JSON format.
+func WithProbes() Option {
+	return func(p *pack) {
+		settings, err := config.ReadProbes()
+		if err != nil {
+			log.Err(err).Msg("invalid probe settings, the liveness and readiness endpoints will not be served")
+			return
+		}
+		WithProbeSettings(settings)(p)
+	}
+}
+
+// WithProbeSettings serves liveness and readiness endpoints in the same way as WithProbes, configured by the settings
+// passed in rather than the environment.
+func WithProbeSettings(settings config.Probes) Option {
+	return func(p *pack) {
+		p.probes = &probes{Probes: settings}
+	}
+}
+
+// probes holds the probe server settings. The server is only started once, however many times the pack tries to start
+type probes struct {
+	config.Probes
+	once sync.Once
+}
+
+// startProbeServer starts the liveness and readiness endpoints, if configured, and stops them once the pack is stopped
+func (p pack) startProbeServer() {
+	if p.probes == nil {
+		return
+	}
+	p.probes.once.Do(p.serveProbes)
+}
+
+func (p pack) serveProbes() {
+	mux := http.NewServeMux()
+	mux.HandleFunc(p.probes.LivenessPath, probeHandler(p.livenessChecks()))
+	mux.HandleFunc(p.probes.ReadinessPath, probeHandler(p.readinessChecks()))
+	srv := &http.Server{Handler: mux}
+
+	l, err := net.Listen("tcp", ":"+p.probes.Port)
+	if err != nil {
+		log.Err(err).Msgf("cannot start probe server on port %s", p.probes.Port)
+		return
+	}
+	log.Info().Msgf("serving liveness probe on port %s at %s and readiness probe at %s", p.probes.Port, p.probes.LivenessPath, p.probes.ReadinessPath)
+	go func() {
+		if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
+			log.Err(err).Msg("probe server failed")
+		}
+	}()
+	go func() {
+		<-p.lifecycle.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+}
+
+func (p pack) livenessChecks() []healthcheck.HealthCheck {
+	return []healthcheck.HealthCheck{p.runningCheck, p.pollingCheck}
+}
+
+func (p pack) readinessChecks() []healthcheck.HealthCheck {
+	checks := []healthcheck.HealthCheck{p.registeredCheck, p.runningCheck, p.pollingCheck, p.saturationCheck}
+	return append(checks, p.healthChecks...)
+}
+
+func (p pack) registeredCheck() (string, healthcheck.Health) {
+	select {
+	case <-p.lifecycle.Started():
+		return "registered", healthcheck.Health{Healthy: true, Status: "pack is registered with flyte-api"}
+	default:
+		return "registered", healthcheck.Health{Healthy: false, Status: "pack has not yet registered with flyte-api"}
+	}
+}
+
+func (p pack) runningCheck() (string, healthcheck.Health) {
+	select {
+	case <-p.lifecycle.Done():
+		return "running", healthcheck.Health{Healthy: false, Status: "pack has been stopped"}
+	default:
+		return "running", healthcheck.Health{Healthy: true, Status: "pack is running"}
+	}
+}
+
+func (p pack) pollingCheck() (string, healthcheck.Health) {
+	if len(p.Commands) == 0 {
+		return "polling", healthcheck.Health{Healthy: true, Status: "pack has no commands so does not poll for actions"}
+	}
+	if p.workers.saturated() {
+		return "polling", healthcheck.Health{Healthy: true, Status: "pack is waiting for its workers to free up before polling for actions"}
+	}
+	if p.lifecycle.isStreaming() {
+		return "polling", healthcheck.Health{Healthy: true, Status: "pack is receiving actions from an action stream"}
+	}
+	polled := p.lifecycle.lastPolled()
+	if polled.IsZero() {
+		return "polling", healthcheck.Health{Healthy: true, Status: "pack has not yet polled for actions"}
+	}
+
+	stalledAfter := pollStalledIntervals * p.maxPollInterval()
+	if stalledAfter < time.Minute {
+		stalledAfter = time.Minute
+	}
+	since := time.Since(polled)
+	if since > stalledAfter {
+		return "polling", healthcheck.Health{Healthy: false, Status: fmt.Sprintf("pack has not polled for actions for %v", since.Round(time.Second))}
+	}
+	return "polling", healthcheck.Health{Healthy: true, Status: fmt.Sprintf("pack last polled for actions %v ago", since.Round(time.Millisecond))}
+}
+
+func (p pack) saturationCheck() (string, healthcheck.Health) {
+	inFlight := p.counters.inFlight()
+	if p.probes != nil && p.probes.MaxInFlight > 0 && inFlight >= p.probes.MaxInFlight {
+		return "saturation", healthcheck.Health{Healthy: false, Status: fmt.Sprintf("%d actions in flight, limit is %d", inFlight, p.probes.MaxInFlight)}
+	}
+	if p.workers.saturated() {
+		return "saturation", healthcheck.Health{Healthy: false, Status: fmt.Sprintf("all %d workers are busy and %d actions are queued", p.workers.workers, p.workers.queued())}
+	}
+	return "saturation", healthcheck.Health{Healthy: true, Status: fmt.Sprintf("%d actions in flight", inFlight)}
+}
+
+// runHealthChecks runs the checks, returning their results and whether they all passed
+func runHealthChecks(checks []healthcheck.HealthCheck) (bool, map[string]healthcheck.Health) {
+	results := make(map[string]healthcheck.Health)
+	healthy := true
+	for _, check := range checks {
+		name, health := check()
+		results[name] = health
+		if !health.Healthy {
+			healthy = false
+		}
+	}
+	return healthy, results
+}
+
+// probeHandler runs the checks, returning their results in JSON format with a 200 response code if they all pass or
+// a 503 response code if any fail
+func probeHandler(checks []healthcheck.HealthCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		healthy, results := runHealthChecks(checks)
+		status := http.StatusOK
+		if !healthy {
+			status = http.StatusServiceUnavailable
+		}
+
+		body, err := json.Marshal(results)
+		if err != nil {
+			log.Err(err).Msgf("json marshalling error. probe results: %+v", results)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(status)
+		w.Write(body)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-client/config"
+	"github.com/ExpediaGroup/flyte-client/healthcheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_Probes_ShouldBeLiveButNotReadyBeforeThePackHasRegistered(t *testing.T) {
+	p := newProbeTestPack(0)
+
+	assert.Equal(t, http.StatusOK, probe(t, p.livenessChecks()).Code)
+
+	rec := probe(t, p.readinessChecks())
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.False(t, probeResults(t, rec)["registered"].Healthy)
+}
+
+func Test_Probes_ShouldBeReadyOnceThePackHasRegistered(t *testing.T) {
+	p := newProbeTestPack(0)
+	p.lifecycle.markStarted()
+	p.lifecycle.markPolled()
+
+	rec := probe(t, p.readinessChecks())
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, probeResults(t, rec), 5) // including the default pack health check
+}
+
+func Test_Probes_ShouldNotBeLiveOrReadyOnceThePackHasStopped(t *testing.T) {
+	p := newProbeTestPack(0)
+	p.lifecycle.markStarted()
+	p.lifecycle.markStopped()
+
+	assert.Equal(t, http.StatusServiceUnavailable, probe(t, p.livenessChecks()).Code)
+	assert.Equal(t, http.StatusServiceUnavailable, probe(t, p.readinessChecks()).Code)
+}
+
+func Test_Probes_ShouldNotBeLiveWhenThePollingLoopHasStalled(t *testing.T) {
+	p := newProbeTestPack(0)
+	p.lifecycle.markStarted()
+	p.lifecycle.polled = time.Now().Add(-2 * time.Minute).UnixNano()
+
+	rec := probe(t, p.livenessChecks())
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.False(t, probeResults(t, rec)["polling"].Healthy)
+}
+
+func Test_Probes_ShouldNotBeReadyWhenSaturated(t *testing.T) {
+	p := newProbeTestPack(2)
+	p.lifecycle.markStarted()
+	p.counters.add(actionsTaken)
+	p.counters.add(actionsTaken)
+
+	rec := probe(t, p.readinessChecks())
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, healthcheck.Health{Healthy: false, Status: "2 actions in flight, limit is 2"}, probeResults(t, rec)["saturation"])
+
+	// and is ready again once an action completes
+	p.counters.add(actionsCompleted)
+	assert.Equal(t, http.StatusOK, probe(t, p.readinessChecks()).Code)
+}
+
+func newProbeTestPack(maxInFlight int) pack {
+	p := NewPack(PackDef{Name: "SlackPack", Commands: []Command{{Name: "sendMessage"}}}, MockClient{}).(pack)
+	p.probes = &probes{Probes: config.Probes{Port: "0", LivenessPath: "/live", ReadinessPath: "/ready", MaxInFlight: maxInFlight}}
+	return p
+}
+
+func probe(t *testing.T, checks []healthcheck.HealthCheck) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	probeHandler(checks)(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec
+}
+
+func probeResults(t *testing.T, rec *httptest.ResponseRecorder) map[string]healthcheck.Health {
+	var results map[string]healthcheck.Health
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+	return results
+}
+
+func Test_Probes_ShouldNotBeReadyWhenAPackHealthCheckFails(t *testing.T) {
+	p := newProbeTestPack(0)
+	p.lifecycle.markStarted()
+	p.healthChecks = []healthcheck.HealthCheck{func() (string, healthcheck.Health) {
+		return "db", healthcheck.Health{Healthy: false, Status: "db unreachable"}
+	}}
+
+	rec := probe(t, p.readinessChecks())
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, healthcheck.Health{Healthy: false, Status: "db unreachable"}, probeResults(t, rec)["db"])
+	assert.Equal(t, http.StatusOK, probe(t, p.livenessChecks()).Code, "pack health checks should not affect liveness")
+}
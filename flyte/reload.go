@@ -0,0 +1,97 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/ExpediaGroup/flyte-client/config"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"time"
+)
+
+// ErrReloadNotSupported is returned by Reload for a pack that was not created from the environment.
+var ErrReloadNotSupported = errors.New("the pack was not created from the environment, its settings cannot be reloaded")
+
+// Reload reads the environment and the config file again (see config.ReadEnvironment and NewPackFromEnv) and applies the log level,
+// polling frequency and rate limit read to the running pack and its client, without restarting the pack. Settings
+// that are no longer set go back to those the pack was created with. The other settings need a restart to change.
+// If any settings are invalid an error is returned and nothing is changed. Run calls Reload when the process receives
+// SIGHUP. Only packs created from the environment, e.g. with NewPackFromEnvironment, can be reloaded, otherwise
+// ErrReloadNotSupported is returned.
+func Reload(p Pack) error {
+	pk, ok := p.(pack)
+	if !ok || pk.reloader == nil {
+		return ErrReloadNotSupported
+	}
+	cfg, err := pk.reloader.env.ReadEnvironment()
+	if err != nil {
+		return err
+	}
+	pk.reloader.apply(cfg)
+	log.Info().Msgf("reloaded the settings of pack %q", pk.Name)
+	return nil
+}
+
+// reloader holds the settings of a pack created from the environment that can be reloaded
+type reloader struct {
+	env    config.Env
+	client client.Client
+	mu     sync.RWMutex
+	// the polling frequency read when the settings were last reloaded, zero for the frequency the pack was created with
+	pollingFrequency time.Duration
+	// whether the client rate limit was set from the environment, so it is removed when it is no longer set
+	rateLimited bool
+	// the log level the pack was created with, restored when the log level is no longer set
+	logLevel zerolog.Level
+}
+
+func newReloader(env config.Env, c client.Client, cfg config.Values) *reloader {
+	return &reloader{env: env, client: c, rateLimited: cfg.RateLimit > 0, logLevel: zerolog.GlobalLevel()}
+}
+
+func (r *reloader) apply(cfg config.Values) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	level := r.logLevel
+	if cfg.LogLevel != "" {
+		level, _ = zerolog.ParseLevel(cfg.LogLevel)
+	}
+	zerolog.SetGlobalLevel(level)
+	r.pollingFrequency = cfg.PollingFrequency
+	if cfg.RateLimit > 0 || r.rateLimited {
+		if client.SetRateLimit(r.client, cfg.RateLimit, cfg.RateLimitBurst) {
+			r.rateLimited = cfg.RateLimit > 0
+		}
+	}
+}
+
+// polling returns the reloaded polling frequency, or the frequency passed in if there is none
+func (r *reloader) polling(frequency time.Duration) time.Duration {
+	if r == nil {
+		return frequency
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.pollingFrequency > 0 {
+		return r.pollingFrequency
+	}
+	return frequency
+}
@@ -0,0 +1,87 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/ExpediaGroup/flyte-client/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_Reload_ShouldApplyTheSettingsReadAgainToTheRunningPack(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"links": []}`))
+	}))
+	defer server.Close()
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+
+	env := map[string]string{"FLYTE_API": server.URL, "FLYTE_POLLING_FREQUENCY": "2s"}
+	prevGetEnv := config.GetEnv
+	defer func() { config.GetEnv = prevGetEnv }()
+	config.GetEnv = func(name string) string { return env[name] }
+
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	p, err := NewPackFromEnvironment(PackDef{Name: "JiraPack"})
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, p.(pack).pollInterval(0))
+
+	// when
+	env["FLYTE_POLLING_FREQUENCY"] = "10s"
+	env["FLYTE_LOG_LEVEL"] = "error"
+	require.NoError(t, Reload(p))
+
+	// then
+	assert.Equal(t, 10*time.Second, p.(pack).pollInterval(0))
+	assert.Equal(t, zerolog.ErrorLevel, zerolog.GlobalLevel())
+
+	// and an unset polling frequency and log level go back to those the pack was created with
+	delete(env, "FLYTE_POLLING_FREQUENCY")
+	delete(env, "FLYTE_LOG_LEVEL")
+	require.NoError(t, Reload(p))
+	assert.Equal(t, 2*time.Second, p.(pack).pollInterval(0))
+	assert.Equal(t, zerolog.InfoLevel, zerolog.GlobalLevel())
+}
+
+func Test_Reload_ShouldLeaveTheSettingsUnchangedWhenTheyAreInvalid(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"links": []}`))
+	}))
+	defer server.Close()
+
+	env := map[string]string{"FLYTE_API": server.URL, "FLYTE_POLLING_FREQUENCY": "2s"}
+	prevGetEnv := config.GetEnv
+	defer func() { config.GetEnv = prevGetEnv }()
+	config.GetEnv = func(name string) string { return env[name] }
+
+	p, err := NewPackFromEnvironment(PackDef{Name: "JiraPack"})
+	require.NoError(t, err)
+
+	env["FLYTE_POLLING_FREQUENCY"] = "soon"
+	assert.Error(t, Reload(p))
+	assert.Equal(t, 2*time.Second, p.(pack).pollInterval(0))
+}
+
+func Test_Reload_ShouldNotBeSupportedForAPackNotCreatedFromTheEnvironment(t *testing.T) {
+	p := NewPack(PackDef{Name: "JiraPack"}, MockClient{})
+
+	assert.Equal(t, ErrReloadNotSupported, Reload(p))
+}
@@ -0,0 +1,214 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/rs/zerolog/log"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultResultQueueMaxAge = 24 * time.Hour
+	resultRetryInterval      = 30 * time.Second
+	resultFileExt            = ".json"
+)
+
+// WithResultQueue keeps the results of actions that could not be posted to the flyte server, once the client has
+// given up retrying them, in files in dir, and posts them again every 30 seconds until they are accepted, so a flyte
+// server outage does not leave flows waiting on results that were lost. The queue survives restarts: results left by
+// a previous run of the pack are posted once it has started. A result that has not been posted maxAge after it was
+// queued, or that the flyte server rejects with a 4xx response, is abandoned (see
+// LifecycleHooks.OnResultAbandoned). A maxAge of zero or less keeps results for a day. Each pack must have its own
+// dir.
+func WithResultQueue(dir string, maxAge time.Duration) Option {
+	return func(p *pack) {
+		if maxAge <= 0 {
+			maxAge = defaultResultQueueMaxAge
+		}
+		p.results = &resultQueue{dir: dir, maxAge: maxAge, interval: resultRetryInterval}
+	}
+}
+
+// AbandonedResult describes an action result that was never posted to the flyte server, for
+// LifecycleHooks.OnResultAbandoned.
+type AbandonedResult struct {
+	Command  string // the command of the action
+	ActionID string // the id of the action, if the flyte server sent one
+	Event    string // the name of the result event
+	// when the result was put on the result queue, see WithResultQueue. Zero if it was abandoned without being queued
+	QueuedAt time.Time
+	Err      error // the last error posting the result
+}
+
+// resultQueue keeps action results that could not be posted in files, one per result, to be posted again later
+type resultQueue struct {
+	dir      string
+	maxAge   time.Duration
+	interval time.Duration
+}
+
+// queuedResult is what is stored in a result file
+type queuedResult struct {
+	Action   client.Action `json:"action"`
+	Event    client.Event  `json:"event"`
+	QueuedAt time.Time     `json:"queuedAt"`
+	// the file the result is stored in
+	path string
+}
+
+// queueable reports whether a result that could not be posted for the error passed in is worth posting again: the
+// flyte server could not be reached, timed out, or responded with a 5xx or 429. A nil queue does not queue anything.
+func (q *resultQueue) queueable(err error) bool {
+	if q == nil {
+		return false
+	}
+	if client.IsTransient(err) || errors.Is(err, client.ErrTimeout) {
+		return true
+	}
+	var re client.ResponseError
+	if errors.As(err, &re) {
+		return re.StatusCode >= http.StatusInternalServerError || re.StatusCode == http.StatusTooManyRequests
+	}
+	// no response was received, e.g. the flyte server host could not be resolved
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// add stores the result in a new file in the queue dir. The file is written under a temporary name and then renamed,
+// so a result is never read half written.
+func (q *resultQueue) add(a client.Action, e client.Event) error {
+	b, err := json.Marshal(queuedResult{Action: a, Event: e, QueuedAt: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("cannot marshal action result: %w", err)
+	}
+	if err := os.MkdirAll(q.dir, 0700); err != nil {
+		return fmt.Errorf("cannot create result queue dir: %w", err)
+	}
+	f, err := ioutil.TempFile(q.dir, "result-*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot create result file: %w", err)
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), strings.TrimSuffix(f.Name(), ".tmp")+resultFileExt)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("cannot write result file: %w", err)
+	}
+	return nil
+}
+
+// load returns the queued results, oldest first. Files that cannot be read as a result are removed.
+func (q *resultQueue) load() []queuedResult {
+	paths, err := filepath.Glob(filepath.Join(q.dir, "*"+resultFileExt))
+	if err != nil {
+		log.Err(err).Msgf("cannot list result queue dir %q", q.dir)
+		return nil
+	}
+	var results []queuedResult
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Err(err).Msgf("cannot read queued result %q", path)
+			continue
+		}
+		r := queuedResult{path: path}
+		if err := json.Unmarshal(b, &r); err != nil {
+			log.Err(err).Msgf("removing queued result %q, which is not a valid action result", path)
+			q.remove(r)
+			continue
+		}
+		results = append(results, r)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].QueuedAt.Before(results[j].QueuedAt)
+	})
+	return results
+}
+
+func (q *resultQueue) remove(r queuedResult) {
+	if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+		log.Err(err).Msgf("cannot remove queued result %q", r.path)
+	}
+}
+
+// run calls retry straight away, to post the results left by previous runs of the pack, and then every interval until
+// the pack is stopped
+func (q *resultQueue) run(done <-chan struct{}, retry func()) {
+	if q == nil {
+		return
+	}
+	go func() {
+		retry()
+		ticker := time.NewTicker(q.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				retry()
+			}
+		}
+	}()
+}
+
+// retryQueuedResults posts the queued results again, removing those that are posted or abandoned
+func (p pack) retryQueuedResults() {
+	for _, r := range p.results.load() {
+		err := p.client.CompleteAction(r.Action, r.Event)
+		if err == nil {
+			log.Info().Msgf("posted queued result %q of action %q", r.Event.Name, r.Action.ID)
+			p.results.remove(r)
+			continue
+		}
+		if p.results.queueable(err) && time.Since(r.QueuedAt) < p.results.maxAge {
+			log.Warn().Err(err).Msgf("could not post queued result of action %q, it will be retried", r.Action.ID)
+			continue
+		}
+		log.Err(err).Msgf("abandoning queued result %q of action %q, queued at %s", r.Event.Name, r.Action.ID, r.QueuedAt)
+		p.results.remove(r)
+		p.resultAbandoned(r.Action, r.Event, r.QueuedAt, err)
+	}
+}
+
+// resultAbandoned counts the result that will never be posted and calls the OnResultAbandoned hooks
+func (p pack) resultAbandoned(a client.Action, e client.Event, queuedAt time.Time, err error) {
+	p.counters.add(resultsAbandoned)
+	p.hooks.resultAbandoned(AbandonedResult{
+		Command:  a.CommandName,
+		ActionID: a.ID,
+		Event:    e.Name,
+		QueuedAt: queuedAt,
+		Err:      err,
+	})
+}
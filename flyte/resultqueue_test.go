@@ -0,0 +1,168 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newResultQueuePack(t *testing.T, dir string, completeAction completeAction, abandoned *[]AbandonedResult) pack {
+	return NewPackWithOptions(PackDef{Name: "SlackPack"}, MockClient{completeAction: completeAction},
+		WithResultQueue(dir, time.Hour),
+		WithLifecycleHooks(LifecycleHooks{OnResultAbandoned: func(r AbandonedResult) {
+			*abandoned = append(*abandoned, r)
+		}}),
+	).(pack)
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "flyte")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func Test_CompleteAction_ShouldQueueTheResultAndPostItLater(t *testing.T) {
+	dir := filepath.Join(tempDir(t), "results")
+	var abandoned []AbandonedResult
+	unavailable := client.ResponseError{StatusCode: http.StatusServiceUnavailable}
+
+	// given the flyte server is unavailable
+	p := newResultQueuePack(t, dir, func(client.Action, client.Event) error { return unavailable }, &abandoned)
+
+	// when
+	p.completeAction(&client.Action{ID: "123", CommandName: "SendMessage"}, Event{EventDef: EventDef{Name: "MessageSent"}})
+
+	// then the result is queued, rather than failing the action
+	assert.Equal(t, Stats{ResultsQueued: 1}, p.counters.snapshot())
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	assert.Len(t, files, 1)
+
+	// and it is posted once the flyte server is back, by the next run of the pack
+	var posted []string
+	next := newResultQueuePack(t, dir, func(a client.Action, e client.Event) error {
+		posted = append(posted, fmt.Sprintf("%s %s %s", a.ID, a.CommandName, e.Name))
+		return nil
+	}, &abandoned)
+	next.retryQueuedResults()
+	assert.Equal(t, []string{"123 SendMessage MessageSent"}, posted)
+	files, _ = filepath.Glob(filepath.Join(dir, "*.json"))
+	assert.Empty(t, files)
+	assert.Empty(t, abandoned)
+}
+
+func Test_CompleteAction_ShouldAbandonAResultRejectedByTheFlyteServer(t *testing.T) {
+	dir := tempDir(t)
+	var abandoned []AbandonedResult
+	rejected := client.BadRequestError{Message: "invalid event"}
+	p := newResultQueuePack(t, dir, func(client.Action, client.Event) error { return rejected }, &abandoned)
+
+	p.completeAction(&client.Action{ID: "123", CommandName: "SendMessage"}, Event{EventDef: EventDef{Name: "MessageSent"}})
+
+	assert.Equal(t, Stats{ActionsFailed: 1, ResultsAbandoned: 1}, p.counters.snapshot())
+	require.Len(t, abandoned, 1)
+	assert.Equal(t, "SendMessage", abandoned[0].Command)
+	assert.Equal(t, "123", abandoned[0].ActionID)
+	assert.Equal(t, "MessageSent", abandoned[0].Event)
+	assert.True(t, abandoned[0].QueuedAt.IsZero())
+	assert.Equal(t, rejected, abandoned[0].Err)
+	files, _ := ioutil.ReadDir(dir)
+	assert.Empty(t, files)
+}
+
+func Test_CompleteAction_ShouldAbandonAResultWithoutAResultQueue(t *testing.T) {
+	var abandoned []AbandonedResult
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"},
+		MockClient{completeAction: func(client.Action, client.Event) error { return syscall.ECONNREFUSED }},
+		WithLifecycleHooks(LifecycleHooks{OnResultAbandoned: func(r AbandonedResult) {
+			abandoned = append(abandoned, r)
+		}}),
+	).(pack)
+
+	p.completeAction(&client.Action{ID: "123", CommandName: "SendMessage"}, Event{EventDef: EventDef{Name: "MessageSent"}})
+
+	assert.Equal(t, Stats{ActionsFailed: 1, ResultsAbandoned: 1}, p.counters.snapshot())
+	assert.Len(t, abandoned, 1)
+}
+
+func Test_RetryQueuedResults_ShouldAbandonResultsOlderThanTheMaxAge(t *testing.T) {
+	dir := tempDir(t)
+	var abandoned []AbandonedResult
+	unavailable := client.ResponseError{StatusCode: http.StatusBadGateway}
+	p := newResultQueuePack(t, dir, func(client.Action, client.Event) error { return unavailable }, &abandoned)
+	p.completeAction(&client.Action{ID: "123", CommandName: "SendMessage"}, Event{EventDef: EventDef{Name: "MessageSent"}})
+
+	// while the result is younger than the max age it is kept
+	p.retryQueuedResults()
+	assert.Empty(t, abandoned)
+
+	// but once it is older it is abandoned
+	p.results.maxAge = time.Nanosecond
+	p.retryQueuedResults()
+	require.Len(t, abandoned, 1)
+	assert.Equal(t, "123", abandoned[0].ActionID)
+	assert.False(t, abandoned[0].QueuedAt.IsZero())
+	assert.Equal(t, uint64(1), p.counters.snapshot().ResultsAbandoned)
+	files, _ := ioutil.ReadDir(dir)
+	assert.Empty(t, files)
+}
+
+func Test_RetryQueuedResults_ShouldRemoveInvalidResultFiles(t *testing.T) {
+	dir := tempDir(t)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "result-1.json"), []byte("not json"), 0600))
+	var abandoned []AbandonedResult
+	p := newResultQueuePack(t, dir, func(client.Action, client.Event) error {
+		t.Fatal("no result should be posted")
+		return nil
+	}, &abandoned)
+
+	p.retryQueuedResults()
+
+	_, err := os.Stat(filepath.Join(dir, "result-1.json"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_ResultQueue_Queueable(t *testing.T) {
+	q := &resultQueue{}
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("posting: %w", syscall.ECONNRESET), true},
+		{client.TimeoutError{Err: errors.New("deadline exceeded")}, true},
+		{client.ResponseError{StatusCode: http.StatusServiceUnavailable}, true},
+		{client.ResponseError{StatusCode: http.StatusTooManyRequests}, true},
+		{client.ResponseError{StatusCode: http.StatusConflict}, false},
+		{client.NotFoundError{Message: "gone"}, false},
+		{errors.New("no actionResult link"), false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, q.queueable(c.err), c.err.Error())
+	}
+	assert.False(t, (*resultQueue)(nil).queueable(syscall.ECONNRESET))
+}
@@ -0,0 +1,138 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+const defaultResyncInterval = 5 * time.Minute
+
+// ResyncPolicy says what a pack does when its registration on the flyte server no longer matches its definition.
+type ResyncPolicy int
+
+const (
+	// ResyncReregister re-registers the pack, replacing the definition on the flyte server with the pack's own.
+	ResyncReregister ResyncPolicy = iota
+	// ResyncAlert only reports the drift, leaving the registration as it is.
+	ResyncAlert
+)
+
+// Drift describes how the registration of a pack on the flyte server differs from the pack definition.
+type Drift struct {
+	Pack string
+	// Missing is true if the pack is no longer registered, for example because it was deleted through the flyte api
+	Missing bool
+	// Diff describes the changes from the pack definition to the registered definition, when the pack is registered
+	Diff client.PackDiff
+}
+
+func (d Drift) String() string {
+	if d.Missing {
+		return "pack is not registered"
+	}
+	return d.Diff.String()
+}
+
+// WithDefinitionResync makes the pack fetch its registration from the flyte server every interval once it has started
+// and compare it with the pack definition, so changes made out of band - the pack being deleted through the flyte
+// api, or its labels or commands being edited - are noticed. Drift is logged and passed to onDrift, which may be nil,
+// and the policy decides whether the pack re-registers itself. The client must implement client.PackFetcher, as the
+// client returned by client.NewClient does, otherwise the definition is not re-synced. An interval of zero or less
+// re-syncs every 5 minutes.
+func WithDefinitionResync(interval time.Duration, policy ResyncPolicy, onDrift func(Drift)) Option {
+	return func(p *pack) {
+		if interval <= 0 {
+			interval = defaultResyncInterval
+		}
+		p.resync = &resyncer{interval: interval, policy: policy, onDrift: onDrift}
+	}
+}
+
+// resyncer periodically compares the registered pack definition with the pack's own
+type resyncer struct {
+	interval time.Duration
+	policy   ResyncPolicy
+	onDrift  func(Drift)
+}
+
+// run re-syncs the pack definition every interval until the pack is stopped. The first check is made after an interval,
+// as the pack has just registered.
+func (r *resyncer) run(done <-chan struct{}, c client.Client, definition func() client.Pack) {
+	if r == nil {
+		return
+	}
+	fetcher, ok := c.(client.PackFetcher)
+	if !ok {
+		log.Warn().Msg("the flyte client cannot fetch pack registrations, the pack definition will not be re-synced")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				r.resync(fetcher, c, definition())
+			}
+		}
+	}()
+}
+
+// resync compares the registered definition with the pack's own, handling any drift according to the policy
+func (r *resyncer) resync(fetcher client.PackFetcher, c client.Client, pack client.Pack) {
+	drift, ok := r.check(fetcher, pack)
+	if !ok {
+		return
+	}
+	log.Warn().Msgf("registration of pack %q has drifted from its definition: %s", pack.Name, drift)
+	if r.onDrift != nil {
+		r.onDrift(drift)
+	}
+	if r.policy != ResyncReregister {
+		return
+	}
+	updater, ok := c.(client.PackUpdater)
+	if !ok {
+		log.Warn().Msgf("the flyte client cannot replace pack registrations, pack %q will not be re-registered", pack.Name)
+		return
+	}
+	if err := updater.UpdatePack(pack); err != nil {
+		log.Err(err).Msgf("could not re-register pack %q", pack.Name)
+		return
+	}
+	log.Info().Msgf("re-registered pack %q", pack.Name)
+}
+
+// check fetches the registered definition of the pack, returning the drift and true if it differs from the pack's own
+func (r *resyncer) check(fetcher client.PackFetcher, pack client.Pack) (Drift, bool) {
+	registered, err := fetcher.GetPack(pack)
+	if errors.Is(err, client.ErrNotFound) {
+		return Drift{Pack: pack.Name, Missing: true}, true
+	}
+	if err != nil {
+		log.Err(err).Msgf("could not fetch the registration of pack %q", pack.Name)
+		return Drift{}, false
+	}
+	diff := client.DiffPacks(pack, *registered)
+	return Drift{Pack: pack.Name, Diff: diff}, !diff.Empty()
+}
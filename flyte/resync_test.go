@@ -0,0 +1,218 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fetcherClient is a MockClient that can fetch pack registrations
+type fetcherClient struct {
+	MockClient
+	getPack    func(client.Pack) (*client.Pack, error)
+	updatePack func(client.Pack) error
+}
+
+func (c fetcherClient) GetPack(pack client.Pack) (*client.Pack, error) {
+	return c.getPack(pack)
+}
+
+func (c fetcherClient) UpdatePack(pack client.Pack) error {
+	return c.updatePack(pack)
+}
+
+func Test_DefinitionResync_ShouldReregisterAPackThatIsNoLongerRegistered(t *testing.T) {
+	// given the pack has been deleted from the flyte server
+	updated := make(chan client.Pack, 1)
+	drifts := make(chan Drift, 1)
+	c := fetcherClient{
+		getPack: func(client.Pack) (*client.Pack, error) {
+			return nil, client.NotFoundError{Message: "pack not found"}
+		},
+		updatePack: func(pack client.Pack) error {
+			updated <- pack
+			return nil
+		},
+	}
+	p := NewPackWithOptions(PackDef{Name: "SlackPack", Labels: map[string]string{"env": "prod"}}, c,
+		WithDefinitionResync(5*time.Millisecond, ResyncReregister, func(d Drift) { drifts <- d })).(pack)
+
+	// when
+	done := make(chan struct{})
+	defer close(done)
+	p.resync.run(done, p.client, p.clientPack)
+
+	// then
+	select {
+	case d := <-drifts:
+		assert.Equal(t, Drift{Pack: "SlackPack", Missing: true}, d)
+		assert.Equal(t, "pack is not registered", d.String())
+	case <-time.After(time.Second):
+		t.Fatal("drift was not reported")
+	}
+	select {
+	case pack := <-updated:
+		assert.Equal(t, "SlackPack", pack.Name)
+		assert.Equal(t, map[string]string{"env": "prod"}, pack.Labels)
+	case <-time.After(time.Second):
+		t.Fatal("pack was not re-registered")
+	}
+}
+
+func Test_DefinitionResync_ShouldOnlyReportDriftWhenAlerting(t *testing.T) {
+	// given the pack labels have been edited on the flyte server
+	var drifts []Drift
+	c := fetcherClient{
+		getPack: func(pack client.Pack) (*client.Pack, error) {
+			pack.Labels = map[string]string{"env": "test"}
+			return &pack, nil
+		},
+		updatePack: func(client.Pack) error {
+			t.Error("pack should not be re-registered")
+			return nil
+		},
+	}
+	p := NewPackWithOptions(PackDef{Name: "SlackPack", Labels: map[string]string{"env": "prod"}}, c,
+		WithDefinitionResync(time.Hour, ResyncAlert, func(d Drift) { drifts = append(drifts, d) })).(pack)
+
+	// when
+	p.resync.resync(c, c, p.clientPack())
+
+	// then
+	require.Len(t, drifts, 1)
+	assert.False(t, drifts[0].Missing)
+	assert.True(t, drifts[0].Diff.LabelsChanged)
+	assert.Equal(t, "labels changed", drifts[0].String())
+}
+
+func Test_DefinitionResync_ShouldDoNothingWhenTheRegistrationMatches(t *testing.T) {
+	// given
+	c := fetcherClient{
+		getPack: func(pack client.Pack) (*client.Pack, error) {
+			return &pack, nil
+		},
+		updatePack: func(client.Pack) error {
+			t.Error("pack should not be re-registered")
+			return nil
+		},
+	}
+	p := NewPackWithOptions(PackDef{Name: "SlackPack", Commands: []Command{{Name: "SendMessage"}}}, c,
+		WithDefinitionResync(time.Hour, ResyncReregister, func(Drift) { t.Error("drift should not be reported") })).(pack)
+
+	// when
+	p.resync.resync(c, c, p.clientPack())
+}
+
+func Test_DefinitionResync_ShouldNotReregisterWhenTheRegistrationCannotBeFetched(t *testing.T) {
+	// given
+	c := fetcherClient{
+		getPack: func(client.Pack) (*client.Pack, error) {
+			return nil, errors.New("connection refused")
+		},
+		updatePack: func(client.Pack) error {
+			t.Error("pack should not be re-registered")
+			return nil
+		},
+	}
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, c,
+		WithDefinitionResync(time.Hour, ResyncReregister, nil)).(pack)
+
+	// when
+	p.resync.resync(c, c, p.clientPack())
+}
+
+func Test_DefinitionResync_ShouldBeDisabledWhenTheClientCannotFetchRegistrations(t *testing.T) {
+	// given a client that does not implement client.PackFetcher
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, MockClient{},
+		WithDefinitionResync(0, ResyncReregister, nil)).(pack)
+	assert.Equal(t, defaultResyncInterval, p.resync.interval)
+
+	// when
+	done := make(chan struct{})
+	defer close(done)
+	p.resync.run(done, p.client, p.clientPack)
+
+	// then the pack definition is never requested, so nothing is called on the mock client
+	time.Sleep(10 * time.Millisecond)
+}
+
+// run with -race: the resync replaces the pack links of the client the pack is polling and sending events with
+func Test_DefinitionResync_ShouldReregisterWhileThePackIsPollingAndSendingEvents(t *testing.T) {
+	StartHealthCheckServer = false
+
+	// given a flyte server whose registration of the pack always differs from the pack definition
+	var registrations, polls int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registered := fmt.Sprintf(`{"name": "SlackPack", "labels": {"env": "test"}, "links": [
+			{"href": "%[1]s/v1/packs/SlackPack", "rel": "self"},
+			{"href": "%[1]s/v1/packs/SlackPack/actions/take", "rel": "takeAction"},
+			{"href": "%[1]s/v1/packs/SlackPack/events", "rel": "event"}]}`, server.URL)
+		switch {
+		case r.URL.Path == "/v1":
+			fmt.Fprintf(w, `{"links": [{"href": "%s/v1/packs", "rel": "pack/listPacks"}]}`, server.URL)
+		case r.URL.Path == "/v1/packs" && r.Method == http.MethodPost:
+			atomic.AddInt32(&registrations, 1)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(registered))
+		case r.URL.Path == "/v1/packs":
+			fmt.Fprintf(w, `{"packs": [%s]}`, registered)
+		case r.URL.Path == "/v1/packs/SlackPack":
+			w.Write([]byte(registered))
+		case r.URL.Path == "/v1/packs/SlackPack/actions/take":
+			atomic.AddInt32(&polls, 1)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	packDef := PackDef{
+		Name:      "SlackPack",
+		Commands:  []Command{{Name: "SendMessage", Handler: func(json.RawMessage) Event { return Event{} }}},
+		EventDefs: []EventDef{{Name: "MessageSent"}},
+	}
+	p := NewPackWithOptions(packDef, client.NewClient(u, time.Second),
+		WithPollingFrequency(minPollingFrequency),
+		WithDefinitionResync(10*time.Millisecond, ResyncReregister, nil)).(pack)
+	defer p.Stop()
+
+	// when
+	p.Start()
+	deadline := time.Now().Add(5 * time.Second)
+	for (atomic.LoadInt32(&registrations) < 5 || atomic.LoadInt32(&polls) < 2) && time.Now().Before(deadline) {
+		assert.NoError(t, p.SendEvent(Event{EventDef: EventDef{Name: "MessageSent"}}))
+	}
+
+	// then
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&registrations), int32(5))
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&polls), int32(2))
+}
@@ -0,0 +1,96 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+const (
+	defaultRetryBackoff    = time.Second
+	defaultRetryMaxBackoff = 30 * time.Second
+)
+
+// RetryPolicy says how a FallibleHandler is retried when it returns an error, so handlers wrapping flaky downstream
+// APIs do not need their own retry loops. The action is completed with a FATAL event for the last error once the
+// attempts run out, or the error is not retryable.
+type RetryPolicy struct {
+	// how many times the handler is called in all, including the first call. One or less disables retries
+	MaxAttempts int
+	// the wait before the first retry, doubling for each further retry. Defaults to a second
+	Backoff time.Duration
+	// the longest wait between retries. Defaults to 30 seconds
+	MaxBackoff time.Duration
+	// optional, decides whether an error is retried. Every error is retried if it is not set
+	Retryable func(error) bool
+}
+
+// attempts returns how many times the handler may be called
+func (r *RetryPolicy) attempts() int {
+	if r == nil || r.MaxAttempts < 1 {
+		return 1
+	}
+	return r.MaxAttempts
+}
+
+// retryable returns true if the error is to be retried
+func (r *RetryPolicy) retryable(err error) bool {
+	return r.Retryable == nil || r.Retryable(err)
+}
+
+// wait returns how long to wait after the failed attempt passed in (counting from 1) before retrying
+func (r *RetryPolicy) wait(attempt int) time.Duration {
+	base, max := r.Backoff, r.MaxBackoff
+	if base <= 0 {
+		base = defaultRetryBackoff
+	}
+	if max <= 0 {
+		max = defaultRetryMaxBackoff
+	}
+	return backoff(base, max, attempt-1)
+}
+
+// withRetry adapts the handler to a ContextHandler that calls it again for retryable errors, as the policy allows,
+// and returns a FATAL event for the last error. The retries stop early if the action context is done. A nil policy
+// does not retry, and a nil handler is left nil.
+func (h FallibleHandler) withRetry(policy *RetryPolicy) ContextHandler {
+	if h == nil {
+		return nil
+	}
+	return func(ctx context.Context, input json.RawMessage) Event {
+		attempts := policy.attempts()
+		for attempt := 1; ; attempt++ {
+			event, err := h(input)
+			if err == nil {
+				return event
+			}
+			if attempt >= attempts || !policy.retryable(err) {
+				return NewFatalErrorEvent(err, ErrorWithCorrelation(ctx))
+			}
+			wait := policy.wait(attempt)
+			log.Warn().Err(err).Msgf("handler failed on attempt %d of %d, retrying in %s", attempt, attempts, wait)
+			select {
+			case <-ctx.Done():
+				return NewFatalErrorEvent(err, ErrorWithCorrelation(ctx))
+			case <-time.After(wait):
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var errFlaky = errors.New("downstream unavailable")
+
+// flakyHandler fails with the error passed in until it has been called the number of times passed in
+func flakyHandler(failures int, err error, calls *int) FallibleHandler {
+	return func(json.RawMessage) (Event, error) {
+		*calls++
+		if *calls <= failures {
+			return Event{}, err
+		}
+		return Event{EventDef: EventDef{Name: "Done"}}, nil
+	}
+}
+
+func Test_withRetry_ShouldRetryUntilTheHandlerSucceeds(t *testing.T) {
+	var calls int
+	h := flakyHandler(2, errFlaky, &calls).withRetry(&RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+
+	e := h(context.Background(), nil)
+
+	assert.Equal(t, "Done", e.EventDef.Name)
+	assert.Equal(t, 3, calls)
+}
+
+func Test_withRetry_ShouldReturnAFatalEventWhenTheAttemptsRunOut(t *testing.T) {
+	var calls int
+	h := flakyHandler(5, errFlaky, &calls).withRetry(&RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+
+	e := h(context.Background(), nil)
+
+	assert.Equal(t, fatalEventName, e.EventDef.Name)
+	assert.Equal(t, errFlaky.Error(), e.Payload.(ErrorPayload).Message)
+	assert.Equal(t, 3, calls)
+}
+
+func Test_withRetry_ShouldNotRetryErrorsThatAreNotRetryable(t *testing.T) {
+	var calls int
+	policy := &RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		Retryable:   func(err error) bool { return err == errFlaky },
+	}
+	h := flakyHandler(5, errors.New("invalid input"), &calls).withRetry(policy)
+
+	e := h(context.Background(), nil)
+
+	assert.Equal(t, fatalEventName, e.EventDef.Name)
+	assert.Equal(t, 1, calls)
+}
+
+func Test_withRetry_ShouldNotRetryWithoutAPolicy(t *testing.T) {
+	var calls int
+	h := flakyHandler(1, errFlaky, &calls).withRetry(nil)
+
+	e := h(context.Background(), nil)
+
+	assert.Equal(t, fatalEventName, e.EventDef.Name)
+	assert.Equal(t, 1, calls)
+}
+
+func Test_withRetry_ShouldStopRetryingWhenTheActionContextIsDone(t *testing.T) {
+	var calls int
+	h := flakyHandler(5, errFlaky, &calls).withRetry(&RetryPolicy{MaxAttempts: 5, Backoff: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	e := h(ctx, nil)
+
+	assert.Equal(t, fatalEventName, e.EventDef.Name)
+	assert.Equal(t, 1, calls)
+}
+
+func Test_RetryPolicy_ShouldDoubleTheWaitUpToTheMaxBackoff(t *testing.T) {
+	policy := &RetryPolicy{Backoff: time.Second, MaxBackoff: 3 * time.Second}
+
+	assert.Equal(t, time.Second, policy.wait(1))
+	assert.Equal(t, 2*time.Second, policy.wait(2))
+	assert.Equal(t, 3*time.Second, policy.wait(3))
+	assert.Equal(t, defaultRetryBackoff, (&RetryPolicy{}).wait(1))
+}
+
+func Test_commandHandler_ShouldRetryFallibleHandlers(t *testing.T) {
+	var calls int
+	p := NewPack(PackDef{Name: "JiraPack"}, MockClient{}).(pack)
+	cmd := Command{
+		Name:            "CreateIssue",
+		FallibleHandler: flakyHandler(1, errFlaky, &calls),
+		Retry:           &RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+	}
+
+	e := p.commandHandler(cmd)(context.Background(), nil)
+
+	assert.Equal(t, "Done", e.EventDef.Name)
+	assert.Equal(t, 2, calls)
+}
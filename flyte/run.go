@@ -0,0 +1,106 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Run starts the pack and keeps it running until the context is cancelled or the process receives SIGINT or SIGTERM,
+// then stops it gracefully: the pack stops taking actions and waits for the actions it is handling to complete, for
+// up to the drain timeout (see WithDrainTimeout). It replaces the start and signal handling boilerplate in a pack's
+// main function:
+//
+//	func main() {
+//		p := flyte.NewPackWithOptions(packDef, c, flyte.WithDrainTimeout(time.Minute))
+//		if err := flyte.Run(context.Background(), p); err != nil {
+//			log.Err(err).Msg("pack did not stop cleanly")
+//			os.Exit(1)
+//		}
+//	}
+//
+// When the pack was created from the environment (see Reload) SIGHUP reloads its settings while it runs.
+//
+// Run also returns if the pack is stopped some other way. It returns nil once the pack has stopped cleanly, or an
+// error if the pack had already been stopped, the pack stopped itself (see PackHandle.Err) or actions were still being
+// handled when the drain timeout expired. The pack must implement Stopper and HandleProvider, as the packs created by
+// this package do, else an error is returned without starting it.
+func Run(ctx context.Context, p Pack) error {
+	stopper, ok := p.(Stopper)
+	if !ok {
+		return fmt.Errorf("a %T cannot be stopped, run a pack created by the flyte package", p)
+	}
+	provider, ok := p.(HandleProvider)
+	if !ok {
+		return fmt.Errorf("a %T has no handle, run a pack created by the flyte package", p)
+	}
+	h := provider.Handle()
+	select {
+	case <-h.Done():
+		return ErrPackStopped
+	default:
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	reloads := make(chan os.Signal, 1)
+	if pk, ok := p.(pack); ok && pk.reloader != nil {
+		signal.Notify(reloads, syscall.SIGHUP)
+		defer signal.Stop(reloads)
+	}
+
+	// registration is retried until it succeeds, so the pack is started in the background to be able to stop it
+	// while it is still registering
+	started := make(chan struct{})
+	go func() {
+		defer close(started)
+		p.Start()
+	}()
+
+	for stop := false; !stop; {
+		select {
+		case s := <-signals:
+			log.Info().Msgf("received %s, stopping the pack", s)
+			stop = true
+		case <-ctx.Done():
+			log.Info().Msg("context is done, stopping the pack")
+			stop = true
+		case <-h.Done():
+			stop = true
+		case <-reloads:
+			if err := Reload(p); err != nil {
+				log.Err(err).Msg("cannot reload the pack settings, they are unchanged")
+			}
+		}
+	}
+	stopper.Stop()
+	<-started
+
+	if err := h.Err(); err != nil {
+		return err
+	}
+	if drain := h.Stats().Drain; drain != nil && drain.ActionsAborted > 0 {
+		return fmt.Errorf("%d actions were still being handled when the drain timeout expired", drain.ActionsAborted)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func runPack(name string) Pack {
+	c := MockClient{
+		createPack: func(client.Pack) error { return nil },
+		takeAction: func() (*client.Action, error) { return nil, nil },
+	}
+	return NewPackWithOptions(PackDef{Name: name}, c, WithDrainTimeout(20*time.Millisecond))
+}
+
+func Test_Run_ShouldStopThePackWhenTheContextIsDone(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	p := runPack("RunPack")
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-p.(pack).Handle().Started()
+		cancel()
+	}()
+
+	err := Run(ctx, p)
+
+	require.NoError(t, err)
+	assert.NotNil(t, p.(pack).Handle().Stats().Drain)
+}
+
+func Test_Run_ShouldStopThePackOnSIGTERM(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	p := runPack("RunPack2")
+	go func() {
+		<-p.(pack).Handle().Started()
+		proc, err := os.FindProcess(os.Getpid())
+		require.NoError(t, err)
+		require.NoError(t, proc.Signal(syscall.SIGTERM))
+	}()
+
+	err := Run(context.Background(), p)
+
+	require.NoError(t, err)
+	select {
+	case <-p.(pack).Handle().Done():
+	default:
+		t.Fatal("pack was not stopped")
+	}
+}
+
+func Test_Run_ShouldReturnAnErrorWhenActionsAreAbortedOrThePackIsAlreadyStopped(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	// given a pack with an action that never completes
+	p := runPack("RunPack3")
+	p.(pack).counters.add(actionsTaken)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// when
+	err := Run(ctx, p)
+
+	// then
+	assert.EqualError(t, err, "1 actions were still being handled when the drain timeout expired")
+	assert.Equal(t, ErrPackStopped, Run(context.Background(), p))
+}
+
+func Test_Run_ShouldReturnAnErrorWhenThePackIsNoLongerFoundByTheFlyteServer(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	c := MockClient{
+		createPack: func(client.Pack) error { return nil },
+		takeAction: func() (*client.Action, error) { return nil, client.NotFoundError{Message: "not found"} },
+	}
+	handler := func(json.RawMessage) Event { return Event{} }
+	p := NewPackWithOptions(PackDef{Name: "RunPack4", Commands: []Command{{Name: "Cmd", Handler: handler}}}, c)
+
+	err := Run(context.Background(), p)
+
+	assert.Equal(t, ErrPackNotFound, err)
+	assert.Equal(t, ErrPackNotFound, p.(pack).Handle().Err())
+}
+
+func Test_Run_ShouldReturnAnErrorForPacksThatCannotBeStopped(t *testing.T) {
+	type wrappedPack struct{ Pack }
+
+	err := Run(context.Background(), wrappedPack{runPack("RunPack5")})
+
+	assert.EqualError(t, err, "a flyte.wrappedPack cannot be stopped, run a pack created by the flyte package")
+}
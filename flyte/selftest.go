@@ -0,0 +1,112 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"strconv"
+	"time"
+)
+
+const (
+	selfTestCommandName     = "SelfTest"
+	selfTestEventName       = "SelfTestEvent"
+	selfTestPassedEventName = "SelfTestPassed"
+	selfTestFailedEventName = "SelfTestFailed"
+)
+
+// SelfTestPayload is the payload of the "SelfTestPassed" and "SelfTestFailed" events.
+type SelfTestPayload struct {
+	Steps []SelfTestStep `json:"steps"`
+}
+
+// SelfTestStep is the result of one step of the self test.
+type SelfTestStep struct {
+	Name  string `json:"name"`
+	Error string `json:"error,omitempty"`
+}
+
+// WithSelfTest adds a built in "SelfTest" command to the pack. When invoked it posts a "SelfTestEvent" to the flyte
+// server, then writes, reads back and deletes a scratch datastore item ("<pack name>-selftest"). The results of each
+// step are returned in a "SelfTestPassed" event, or a "SelfTestFailed" event if any step failed. This gives operators a
+// quick way to check that a deployed pack's credentials and links all work.
+func WithSelfTest() Option {
+	return func(p *pack) {
+		st := selfTest{client: p.client, datastore: datastoreOf(p.client), key: p.Name + "-selftest"}
+		p.EventDefs = append(append([]EventDef(nil), p.EventDefs...), EventDef{Name: selfTestEventName})
+		p.Commands = append(p.Commands, Command{
+			Name:         selfTestCommandName,
+			OutputEvents: []EventDef{{Name: selfTestPassedEventName}, {Name: selfTestFailedEventName}},
+			Handler:      st.handle,
+		})
+	}
+}
+
+type selfTest struct {
+	client    client.Client
+	datastore client.Datastore
+	key       string
+}
+
+// handle is the "SelfTest" command handler
+func (s selfTest) handle(json.RawMessage) Event {
+	value := []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+
+	payload := SelfTestPayload{}
+	passed := true
+	run := func(name string, step func() error) {
+		result := SelfTestStep{Name: name}
+		if err := step(); err != nil {
+			result.Error = err.Error()
+			passed = false
+		}
+		payload.Steps = append(payload.Steps, result)
+	}
+
+	run("postEvent", func() error {
+		return s.client.PostEvent(client.Event{Name: selfTestEventName, Payload: map[string]string{"value": string(value)}})
+	})
+	run("writeDatastoreItem", func() error {
+		return s.datastore.PutDatastoreItem(client.DatastoreItem{
+			Key:         s.key,
+			Description: "scratch item written by the SelfTest command",
+			ContentType: "text/plain",
+			Value:       value,
+		})
+	})
+	run("readDatastoreItem", func() error {
+		item, err := s.datastore.GetDatastoreItem(s.key)
+		if err != nil {
+			return err
+		}
+		if item == nil || !bytes.Equal(item.Value, value) {
+			return fmt.Errorf("datastore item %q does not contain the value written", s.key)
+		}
+		return nil
+	})
+	run("deleteDatastoreItem", func() error {
+		return s.datastore.DeleteDatastoreItem(s.key)
+	})
+
+	if !passed {
+		return Event{EventDef: EventDef{Name: selfTestFailedEventName}, Payload: payload}
+	}
+	return Event{EventDef: EventDef{Name: selfTestPassedEventName}, Payload: payload}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_SelfTestCommand_ShouldPostEventAndRoundTripDatastoreItem(t *testing.T) {
+	// given a client backed by an in memory datastore
+	items := map[string]client.DatastoreItem{}
+	var posted []client.Event
+	c := MockClient{
+		postEvent: func(event client.Event) error {
+			posted = append(posted, event)
+			return nil
+		},
+		putDatastoreItem: func(item client.DatastoreItem) error {
+			items[item.Key] = item
+			return nil
+		},
+		getDatastoreItem: func(key string) (*client.DatastoreItem, error) {
+			item := items[key]
+			return &item, nil
+		},
+		deleteDatastoreItem: func(key string) error {
+			delete(items, key)
+			return nil
+		},
+	}
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, c, WithSelfTest()).(pack)
+
+	// when
+	require.Len(t, p.Commands, 1)
+	event := p.Commands[0].Handler(nil)
+
+	// then
+	assert.Equal(t, "SelfTestPassed", event.EventDef.Name)
+	assert.Equal(t, SelfTestPayload{Steps: []SelfTestStep{
+		{Name: "postEvent"}, {Name: "writeDatastoreItem"}, {Name: "readDatastoreItem"}, {Name: "deleteDatastoreItem"},
+	}}, event.Payload)
+	require.Len(t, posted, 1)
+	assert.Equal(t, "SelfTestEvent", posted[0].Name)
+	assert.Empty(t, items, "the scratch datastore item should be deleted")
+	assert.Equal(t, []EventDef{{Name: "SelfTestEvent"}}, p.EventDefs)
+}
+
+func Test_SelfTestCommand_ShouldReportFailedSteps(t *testing.T) {
+	// given a client that cannot post events or read datastore items
+	c := MockClient{
+		postEvent: func(client.Event) error {
+			return errors.New("401 unauthorized")
+		},
+		getDatastoreItem: func(key string) (*client.DatastoreItem, error) {
+			return nil, client.NotFoundError{Message: "not found"}
+		},
+	}
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, c, WithSelfTest()).(pack)
+
+	// when
+	event := p.Commands[0].Handler(nil)
+
+	// then
+	assert.Equal(t, "SelfTestFailed", event.EventDef.Name)
+	assert.Equal(t, SelfTestPayload{Steps: []SelfTestStep{
+		{Name: "postEvent", Error: "401 unauthorized"},
+		{Name: "writeDatastoreItem"},
+		{Name: "readDatastoreItem", Error: "not found"},
+		{Name: "deleteDatastoreItem"},
+	}}, event.Payload)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"fmt"
+	"github.com/rs/zerolog/log"
+)
+
+// ShutdownPhase is one of the ordered steps a pack goes through when it is stopped. The phases run in the order they
+// are declared in, and the pack's own work for a phase is done before the shutdown hooks registered for it are called
+// (see WithShutdownHook).
+type ShutdownPhase int
+
+const (
+	// ShutdownStopIntake is when the pack stops taking actions from the flyte server. PackHandle.Done() is closed,
+	// so watchers and other background goroutines stop producing work.
+	ShutdownStopIntake ShutdownPhase = iota
+	// ShutdownDrainHandlers is when the actions being handled have completed, or the drain timeout has expired.
+	ShutdownDrainHandlers
+	// ShutdownFlushEvents is when components holding back events are flushed (see WithFlushOnStop). It is the last
+	// phase events can be sent in.
+	ShutdownFlushEvents
+	// ShutdownCloseState is when the pack closes its dependencies and persists its state, such as its counters.
+	ShutdownCloseState
+	// ShutdownFinal is when the pack has stopped, after the drain summary has been logged and the OnStop lifecycle
+	// hooks have been called.
+	ShutdownFinal
+)
+
+func (p ShutdownPhase) String() string {
+	switch p {
+	case ShutdownStopIntake:
+		return "stopIntake"
+	case ShutdownDrainHandlers:
+		return "drainHandlers"
+	case ShutdownFlushEvents:
+		return "flushEvents"
+	case ShutdownCloseState:
+		return "closeState"
+	case ShutdownFinal:
+		return "final"
+	default:
+		return fmt.Sprintf("ShutdownPhase(%d)", int(p))
+	}
+}
+
+// WithShutdownHook calls fn in the phase passed in when the pack is stopped, so the cleanup code packs would
+// otherwise defer in main runs in the right order relative to the pack's own shutdown: closing a database in
+// ShutdownCloseState, say, once the handlers using it have drained. Hooks in the same phase are called in the order
+// they were added. An error or panic from a hook is logged, and does not stop the rest of the shutdown.
+func WithShutdownHook(phase ShutdownPhase, fn func() error) Option {
+	return func(p *pack) {
+		p.shutdownHooks = append(append(shutdownHooks(nil), p.shutdownHooks...), shutdownHook{phase: phase, fn: fn})
+	}
+}
+
+type shutdownHook struct {
+	phase ShutdownPhase
+	fn    func() error
+}
+
+type shutdownHooks []shutdownHook
+
+// run calls the hooks registered for the phase, recovering from any panic so a failing hook cannot stop the shutdown
+func (h shutdownHooks) run(phase ShutdownPhase) {
+	for _, hook := range h {
+		if hook.phase != phase {
+			continue
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().Msgf("%s shutdown hook raised a panic: %v", phase, r)
+				}
+			}()
+			if err := hook.fn(); err != nil {
+				log.Err(err).Msgf("%s shutdown hook failed", phase)
+			}
+		}()
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Stop_ShouldRunTheShutdownPhasesInOrder(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	// given hooks added out of order, some of which fail
+	var calls []string
+	hook := func(name string, err error) func() error {
+		return func() error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+	var h PackHandle
+	p := NewPackWithOptions(PackDef{Name: "ShutdownPack"}, MockClient{postEvent: func(client.Event) error { return nil }},
+		WithShutdownHook(ShutdownFinal, hook("final", nil)),
+		WithShutdownHook(ShutdownCloseState, hook("closeState", errors.New("database already closed"))),
+		WithShutdownHook(ShutdownFlushEvents, func() error {
+			calls = append(calls, "flushEvents")
+			return h.SendEvent(Event{EventDef: EventDef{Name: "Flushed"}})
+		}),
+		WithShutdownHook(ShutdownDrainHandlers, hook("drainHandlers", nil)),
+		WithShutdownHook(ShutdownStopIntake, func() error {
+			select {
+			case <-h.Done():
+				calls = append(calls, "stopIntake")
+			default:
+				t.Error("intake should have stopped")
+			}
+			return nil
+		}),
+		WithShutdownHook(ShutdownStopIntake, func() error { panic("boom") }),
+		WithFlushOnStop(FlusherFunc(func() { calls = append(calls, "flusher") })),
+		WithLifecycleHooks(LifecycleHooks{OnStop: func(DrainSummary) { calls = append(calls, "onStop") }}),
+	).(pack)
+	h = p.Handle()
+	p.lifecycle.markStarted()
+
+	// when
+	p.Stop()
+
+	// then
+	assert.Equal(t, []string{"stopIntake", "drainHandlers", "flusher", "flushEvents", "closeState", "onStop", "final"}, calls)
+	assert.Equal(t, uint64(1), h.Stats().Drain.EventsFlushed)
+	assert.Equal(t, ErrPackStopped, h.SendEvent(Event{EventDef: EventDef{Name: "TooLate"}}))
+}
+
+func Test_ShutdownPhase_String(t *testing.T) {
+	assert.Equal(t, "stopIntake", ShutdownStopIntake.String())
+	assert.Equal(t, "final", ShutdownFinal.String())
+	assert.Equal(t, "ShutdownPhase(9)", ShutdownPhase(9).String())
+}
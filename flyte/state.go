@@ -0,0 +1,85 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+)
+
+// PackState is a small key-value store on top of the flyte api datastore, so packs can persist state such as cursors,
+// offsets and the events they have already seen across restarts without bringing in their own database. Values are
+// stored as JSON, in datastore items whose keys are namespaced by the pack name, e.g. "SlackPack-state-cursor", so
+// packs sharing a flyte server do not overwrite each other's state. A PackState is safe for concurrent use, although
+// concurrent puts of the same key from several instances of a pack replace each other.
+type PackState struct {
+	datastore client.Datastore
+	namespace string
+}
+
+// NewPackState creates a PackState storing the state of the pack with the name passed in in the datastore. Packs can
+// get one from PackHandle.State() instead.
+func NewPackState(datastore client.Datastore, packName string) *PackState {
+	return &PackState{datastore: datastore, namespace: packName + "-state-"}
+}
+
+// Get unmarshals the JSON value stored against the key into v, returning false if there is no value for the key.
+func (s *PackState) Get(key string, v interface{}) (bool, error) {
+	item, err := s.datastore.GetDatastoreItem(s.namespace + key)
+	if errors.Is(err, client.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("cannot get pack state %q: %w", key, err)
+	}
+	if item == nil {
+		return false, nil
+	}
+	if err := json.Unmarshal(item.Value, v); err != nil {
+		return false, fmt.Errorf("cannot unmarshal pack state %q: %w", key, err)
+	}
+	return true, nil
+}
+
+// Put stores v, marshalled to JSON, against the key, replacing any value already stored.
+func (s *PackState) Put(key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("cannot marshal pack state %q: %w", key, err)
+	}
+	err = s.datastore.PutDatastoreItem(client.DatastoreItem{
+		Key:         s.namespace + key,
+		Description: "pack state",
+		ContentType: "application/json",
+		Value:       b,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot put pack state %q: %w", key, err)
+	}
+	return nil
+}
+
+// Delete removes the value stored against the key. Deleting a key without a value is not an error.
+func (s *PackState) Delete(key string) error {
+	err := s.datastore.DeleteDatastoreItem(s.namespace + key)
+	if err != nil && !errors.Is(err, client.ErrNotFound) {
+		return fmt.Errorf("cannot delete pack state %q: %w", key, err)
+	}
+	return nil
+}
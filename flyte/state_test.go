@@ -0,0 +1,127 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// memoryDatastore returns a MockClient that keeps datastore items in the map passed in
+func memoryDatastore(items map[string]client.DatastoreItem) MockClient {
+	return MockClient{
+		getDatastoreItem: func(key string) (*client.DatastoreItem, error) {
+			item, ok := items[key]
+			if !ok {
+				return nil, client.NotFoundError{Message: "no item " + key}
+			}
+			return &item, nil
+		},
+		putDatastoreItem: func(item client.DatastoreItem) error {
+			items[item.Key] = item
+			return nil
+		},
+		deleteDatastoreItem: func(key string) error {
+			if _, ok := items[key]; !ok {
+				return client.NotFoundError{Message: "no item " + key}
+			}
+			delete(items, key)
+			return nil
+		},
+	}
+}
+
+type cursor struct {
+	Offset int    `json:"offset"`
+	Topic  string `json:"topic"`
+}
+
+func Test_PackState_ShouldPutGetAndDeleteValuesNamespacedByPackName(t *testing.T) {
+	items := map[string]client.DatastoreItem{}
+	s := NewPackState(memoryDatastore(items), "KafkaPack")
+
+	// when
+	require.NoError(t, s.Put("cursor", cursor{Offset: 42, Topic: "alerts"}))
+
+	// then the value is stored as JSON in an item namespaced by the pack name
+	require.Contains(t, items, "KafkaPack-state-cursor")
+	assert.Equal(t, "application/json", items["KafkaPack-state-cursor"].ContentType)
+	assert.JSONEq(t, `{"offset": 42, "topic": "alerts"}`, string(items["KafkaPack-state-cursor"].Value))
+
+	// and it can be read back
+	var c cursor
+	found, err := s.Get("cursor", &c)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, cursor{Offset: 42, Topic: "alerts"}, c)
+
+	// and deleted
+	require.NoError(t, s.Delete("cursor"))
+	assert.Empty(t, items)
+}
+
+func Test_PackState_GetShouldReturnFalseWhenThereIsNoValue(t *testing.T) {
+	s := NewPackState(memoryDatastore(map[string]client.DatastoreItem{}), "KafkaPack")
+
+	var c cursor
+	found, err := s.Get("cursor", &c)
+
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func Test_PackState_DeleteShouldIgnoreMissingValues(t *testing.T) {
+	s := NewPackState(memoryDatastore(map[string]client.DatastoreItem{}), "KafkaPack")
+
+	assert.NoError(t, s.Delete("cursor"))
+}
+
+func Test_PackState_ShouldReturnDatastoreErrors(t *testing.T) {
+	unavailable := errors.New("datastore unavailable")
+	s := NewPackState(MockClient{
+		getDatastoreItem:    func(string) (*client.DatastoreItem, error) { return nil, unavailable },
+		putDatastoreItem:    func(client.DatastoreItem) error { return unavailable },
+		deleteDatastoreItem: func(string) error { return unavailable },
+	}, "KafkaPack")
+
+	_, err := s.Get("cursor", &cursor{})
+	assert.True(t, errors.Is(err, unavailable))
+	assert.True(t, errors.Is(s.Put("cursor", cursor{}), unavailable))
+	assert.True(t, errors.Is(s.Delete("cursor"), unavailable))
+}
+
+func Test_PackState_GetShouldReturnAnErrorForAValueThatIsNotJSON(t *testing.T) {
+	items := map[string]client.DatastoreItem{"KafkaPack-state-cursor": {Value: []byte("not json")}}
+	s := NewPackState(memoryDatastore(items), "KafkaPack")
+
+	found, err := s.Get("cursor", &cursor{})
+
+	assert.Error(t, err)
+	assert.False(t, found)
+}
+
+func Test_PackHandle_StateShouldBeNamespacedByThePackName(t *testing.T) {
+	items := map[string]client.DatastoreItem{}
+	p := NewPackWithOptions(PackDef{Name: "KafkaPack"}, memoryDatastore(items))
+
+	require.NoError(t, p.(pack).Handle().State().Put("offset", 7))
+
+	assert.Contains(t, items, "KafkaPack-state-offset")
+}
@@ -0,0 +1,90 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+const defaultStatusReportInterval = 10 * time.Second
+
+// WithStatusReporting reports the pack saturation (actions in flight and queued) to the flyte server every interval,
+// so the server can prefer less loaded replicas of the pack. Flyte servers that do not support pack status updates
+// are detected and reporting stops quietly. An interval of zero or less reports every 10 seconds. The client must
+// implement client.PackStatusUpdater, as the client returned by client.NewClient does, otherwise no status is reported.
+func WithStatusReporting(interval time.Duration) Option {
+	return func(p *pack) {
+		updater, ok := p.client.(client.PackStatusUpdater)
+		if !ok {
+			log.Warn().Msg("the client does not support pack status updates, pack status will not be reported")
+			return
+		}
+		if interval <= 0 {
+			interval = defaultStatusReportInterval
+		}
+		p.statusReporter = &statusReporter{client: updater, interval: interval, counters: p.counters, workers: p.workers}
+	}
+}
+
+// statusReporter periodically reports the pack status to the flyte server
+type statusReporter struct {
+	client   client.PackStatusUpdater
+	interval time.Duration
+	counters *counters
+	workers  *workerPool
+}
+
+// run reports the pack status every interval until the pack is stopped, or the flyte server turns out not to
+// support status updates
+func (r *statusReporter) run(done <-chan struct{}) {
+	if r == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			if !r.report() {
+				return
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// report sends the current status to the flyte server, returning false if the server does not support status updates
+func (r *statusReporter) report() bool {
+	err := r.client.UpdatePackStatus(r.status())
+	if err == client.ErrPackStatusNotSupported {
+		log.Info().Msg("flyte server does not support pack status updates, pack status will not be reported")
+		return false
+	}
+	if err != nil {
+		log.Err(err).Msg("could not report pack status")
+	}
+	return true
+}
+
+func (r *statusReporter) status() client.PackStatus {
+	return client.PackStatus{InFlight: r.counters.inFlight(), QueueDepth: r.workers.queued()}
+}
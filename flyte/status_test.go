@@ -0,0 +1,72 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_StatusReporter_ShouldReportActionsInFlight(t *testing.T) {
+	// given two actions taken, one of which has completed
+	statuses := make(chan client.PackStatus, 10)
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, MockClient{
+		updatePackStatus: func(status client.PackStatus) error {
+			statuses <- status
+			return nil
+		},
+	}, WithStatusReporting(time.Hour)).(pack)
+	p.counters.add(actionsTaken)
+	p.counters.add(actionsTaken)
+	p.counters.add(actionsCompleted)
+
+	// when
+	done := make(chan struct{})
+	defer close(done)
+	p.statusReporter.run(done)
+
+	// then
+	select {
+	case status := <-statuses:
+		assert.Equal(t, client.PackStatus{InFlight: 1}, status)
+	case <-time.After(time.Second):
+		t.Fatal("status was not reported")
+	}
+}
+
+func Test_StatusReporter_ShouldStopReportingWhenServerDoesNotSupportStatusUpdates(t *testing.T) {
+	// given
+	var reports int32
+	p := NewPackWithOptions(PackDef{Name: "SlackPack"}, MockClient{
+		updatePackStatus: func(client.PackStatus) error {
+			atomic.AddInt32(&reports, 1)
+			return client.ErrPackStatusNotSupported
+		},
+	}, WithStatusReporting(5*time.Millisecond)).(pack)
+
+	// when
+	done := make(chan struct{})
+	defer close(done)
+	p.statusReporter.run(done)
+	time.Sleep(50 * time.Millisecond)
+
+	// then
+	assert.Equal(t, int32(1), atomic.LoadInt32(&reports))
+}
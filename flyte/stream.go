@@ -0,0 +1,77 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+const (
+	streamRetryWait    = time.Second
+	maxStreamRetryWait = time.Minute
+)
+
+// WithActionStream makes the pack receive actions pushed by the flyte server over a server-sent events stream, where
+// the flyte server supports it, instead of polling for them. If the flyte server does not support action streams the
+// pack polls as usual. If the stream fails, the pack polls for actions while it waits to reconnect, backing off
+// after repeated failures.
+func WithActionStream() Option {
+	return func(p *pack) {
+		p.actionStream = true
+	}
+}
+
+// receives actions from the action stream and handles them until the pack is stopped, falling back to polling
+func (p pack) streamActions(streamer client.ActionStreamer, handlers map[string]ContextHandler) {
+	failures := 0
+	for {
+		start := time.Now()
+		p.lifecycle.setStreaming(true)
+		err := streamer.StreamActions(p.lifecycle.Done(), func(a *client.Action) {
+			p.lifecycle.markPolled()
+			// when the workers are saturated this holds up the stream, so the flyte server stops pushing actions
+			if p.workers.acquire(p.lifecycle.Done()) {
+				p.takeAction(a, handlers)
+			}
+		})
+		p.lifecycle.setStreaming(false)
+
+		select {
+		case <-p.lifecycle.Done():
+			return
+		default:
+		}
+		if err == client.ErrActionStreamNotSupported {
+			log.Info().Msg("the flyte server does not support action streams, polling for actions instead")
+			p.pollActions(handlers, time.Time{})
+			return
+		}
+
+		// a stream that was up for a while is not a repeated failure
+		if time.Since(start) > maxStreamRetryWait {
+			failures = 0
+		}
+		wait := withJitter(backoff(streamRetryWait, maxStreamRetryWait, failures), 0.1)
+		failures++
+		log.Err(err).Msgf("action stream failed, polling for actions for %v before reconnecting", wait)
+		if !p.pollActions(handlers, time.Now().Add(wait)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// streamingClient is a MockClient that also implements client.ActionStreamer
+type streamingClient struct {
+	MockClient
+	streamActions func(done <-chan struct{}, handle func(*client.Action)) error
+}
+
+func (c streamingClient) StreamActions(done <-chan struct{}, handle func(*client.Action)) error {
+	return c.streamActions(done, handle)
+}
+
+func streamPackDef(handler CommandHandler) PackDef {
+	return PackDef{
+		Name:     "StreamPack",
+		Commands: []Command{{Name: "SendMessage", Handler: handler}},
+	}
+}
+
+func Test_WithActionStream_ShouldHandleStreamedActions(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	// given a flyte server streaming an action
+	var wg sync.WaitGroup
+	wg.Add(1)
+	c := streamingClient{
+		MockClient: MockClient{
+			createPack: func(client.Pack) error { return nil },
+			takeAction: func() (*client.Action, error) {
+				assert.Fail(t, "takeAction called while streaming")
+				return nil, nil
+			},
+			completeAction: func(a client.Action, e client.Event) error {
+				defer wg.Done()
+				assert.Equal(t, "1", a.ID)
+				assert.Equal(t, "MessageSent", e.Name)
+				return nil
+			},
+		},
+		streamActions: func(done <-chan struct{}, handle func(*client.Action)) error {
+			handle(&client.Action{ID: "1", CommandName: "SendMessage"})
+			<-done
+			return nil
+		},
+	}
+	p := NewPackWithOptions(streamPackDef(func(json.RawMessage) Event {
+		return Event{EventDef: EventDef{Name: "MessageSent"}}
+	}), c, WithActionStream())
+
+	// when
+	p.Start()
+	defer p.(pack).Stop()
+
+	// then
+	wg.Wait()
+	assert.True(t, p.(pack).lifecycle.isStreaming())
+}
+
+func Test_WithActionStream_ShouldPollWhenTheFlyteServerDoesNotSupportStreams(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	var polls int32
+	c := streamingClient{
+		MockClient: MockClient{
+			createPack: func(client.Pack) error { return nil },
+			takeAction: func() (*client.Action, error) {
+				atomic.AddInt32(&polls, 1)
+				return nil, nil
+			},
+		},
+		streamActions: func(done <-chan struct{}, handle func(*client.Action)) error {
+			return client.ErrActionStreamNotSupported
+		},
+	}
+	p := NewPackWithOptions(streamPackDef(nil), c, WithActionStream())
+
+	p.Start()
+	defer p.(pack).Stop()
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&polls) > 0 }, time.Second, 10*time.Millisecond)
+}
+
+func Test_streamActions_ShouldPollWhileWaitingToReconnectAfterAStreamFails(t *testing.T) {
+	// given a stream that fails once
+	var streams, polls int32
+	done := make(chan struct{})
+	c := streamingClient{
+		MockClient: MockClient{
+			takeAction: func() (*client.Action, error) {
+				atomic.AddInt32(&polls, 1)
+				return nil, nil
+			},
+		},
+		streamActions: func(stop <-chan struct{}, handle func(*client.Action)) error {
+			if atomic.AddInt32(&streams, 1) == 1 {
+				return errors.New("connection reset")
+			}
+			close(done)
+			<-stop
+			return nil
+		},
+	}
+	p := pack{client: c, pollingFrequency: 10 * time.Millisecond, lifecycle: newLifecycle(), counters: &counters{}}
+	go p.streamActions(c, map[string]ContextHandler{})
+	defer p.lifecycle.markStopped()
+
+	// then it polls, and reconnects after the retry wait
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "the stream was not reconnected")
+	}
+	assert.True(t, atomic.LoadInt32(&polls) > 1)
+}
@@ -0,0 +1,103 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"time"
+)
+
+// Subscription lets a pack react to the events sibling packs send to the flyte server, without the round trip through
+// a flow. It polls the flyte api audit for new events matching its query, and calls OnEvent with each one, oldest
+// first. Subscriptions are run by a Watcher, so they back off in the same way after failures.
+type Subscription struct {
+	Name       string                        // the name used to identify the subscription in log entries
+	Events     client.EventFinder            // where events are found, normally the pack client
+	Query      client.EventQuery             // the events subscribed to. Only events sent after Query.Since, or after the subscription starts if it is not set, are received
+	Filter     func(client.AuditEvent) bool  // optional. Further selects the events OnEvent is called with
+	OnEvent    func(client.AuditEvent) error // called with each event. Returning an error causes the subscription to back off and call it with the event again
+	Interval   time.Duration                 // how often to poll for new events
+	MaxBackoff time.Duration                 // the maximum wait after consecutive failures. Defaults to 1 minute
+	Jitter     float64                       // optional. The fraction (0 to 1) by which waits are randomly adjusted
+}
+
+// Run waits for the pack to start, then polls for events until the pack is stopped. Run blocks, so will normally be
+// called in its own goroutine.
+func (s Subscription) Run(h PackHandle) {
+	since := s.Query.Since
+	if since.IsZero() {
+		since = time.Now()
+	}
+	cursor := &eventCursor{since: since, seen: make(map[string]bool)}
+	Watcher{
+		Name:       s.Name,
+		Observe:    func() ([]Event, error) { return nil, s.poll(cursor) },
+		Interval:   s.Interval,
+		MaxBackoff: s.MaxBackoff,
+		Jitter:     s.Jitter,
+	}.Run(h)
+}
+
+// poll finds the events after the cursor and calls OnEvent with each one, moving the cursor past the events handled
+func (s Subscription) poll(cursor *eventCursor) error {
+	query := s.Query
+	query.Since = cursor.since
+	events, err := s.Events.FindEvents(query)
+	if err != nil {
+		return fmt.Errorf("cannot find events: %w", err)
+	}
+	for _, e := range events {
+		if cursor.handled(e) {
+			continue
+		}
+		if s.Filter == nil || s.Filter(e) {
+			if err := s.OnEvent(e); err != nil {
+				return fmt.Errorf("could not handle %q event from pack %q: %w", e.Name, e.PackName, err)
+			}
+		}
+		cursor.advance(e)
+	}
+	return nil
+}
+
+// eventCursor tracks the events a subscription has handled. The audit is searched from the time of the latest event
+// handled, so the events already handled at that time are remembered to avoid handling them twice.
+type eventCursor struct {
+	since time.Time
+	seen  map[string]bool
+}
+
+func (c *eventCursor) handled(e client.AuditEvent) bool {
+	return e.Time.Before(c.since) || c.seen[eventKey(e)]
+}
+
+func (c *eventCursor) advance(e client.AuditEvent) {
+	if e.Time.After(c.since) {
+		c.since = e.Time
+		c.seen = make(map[string]bool)
+	}
+	c.seen[eventKey(e)] = true
+}
+
+// eventKey identifies the event, by its id if the flyte api gave it one
+func eventKey(e client.AuditEvent) string {
+	if e.ID != "" {
+		return e.ID
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", e.PackName, e.Name, e.Time.Format(time.RFC3339Nano), e.Payload)
+}
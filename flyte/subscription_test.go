@@ -0,0 +1,110 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// eventFinderFunc adapts a function to a client.EventFinder
+type eventFinderFunc func(client.EventQuery) ([]client.AuditEvent, error)
+
+func (f eventFinderFunc) FindEvents(q client.EventQuery) ([]client.AuditEvent, error) {
+	return f(q)
+}
+
+func Test_Subscription_ShouldHandleEachNewEventOnceAndRetryEventsThatFail(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+
+	// given the audit returns events from the time queried, including the events at that time
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	audit := []client.AuditEvent{
+		{ID: "1", PackName: "Jira", Name: "IssueCreated", Time: start.Add(time.Second)},
+		{ID: "2", PackName: "Jira", Name: "IssueUpdated", Time: start.Add(time.Second)},
+		{ID: "3", PackName: "Jira", Name: "IssueCreated", Time: start.Add(2 * time.Second)},
+		{ID: "4", PackName: "Jira", Name: "IssueCreated", Time: start.Add(3 * time.Second)},
+	}
+	var queries []client.EventQuery
+	finder := eventFinderFunc(func(q client.EventQuery) ([]client.AuditEvent, error) {
+		queries = append(queries, q)
+		var found []client.AuditEvent
+		for _, e := range audit {
+			if !e.Time.Before(q.Since) {
+				found = append(found, e)
+			}
+		}
+		return found, nil
+	})
+
+	// and a subscription to issues created, whose callback fails once
+	var handled []string
+	failed := false
+	s := Subscription{
+		Name:   "issues",
+		Events: finder,
+		Query:  client.EventQuery{PackName: "Jira", Since: start},
+		Filter: func(e client.AuditEvent) bool { return e.Name == "IssueCreated" },
+		OnEvent: func(e client.AuditEvent) error {
+			if e.ID == "3" && !failed {
+				failed = true
+				return errors.New("downstream unavailable")
+			}
+			handled = append(handled, e.ID)
+			if e.ID == "4" {
+				h.stop()
+			}
+			return nil
+		},
+		Interval: time.Millisecond,
+	}
+
+	// when
+	s.Run(h)
+
+	// then
+	assert.Equal(t, []string{"1", "3", "4"}, handled)
+	assert.Equal(t, start, queries[0].Since)
+	assert.Equal(t, "Jira", queries[0].PackName)
+	assert.Equal(t, start.Add(time.Second), queries[1].Since)
+}
+
+func Test_Subscription_ShouldOnlyReceiveEventsSentAfterItStarts(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+
+	var since time.Time
+	s := Subscription{
+		Events: eventFinderFunc(func(q client.EventQuery) ([]client.AuditEvent, error) {
+			since = q.Since
+			h.stop()
+			return nil, nil
+		}),
+		Query:    client.EventQuery{PackName: "Jira"},
+		OnEvent:  func(client.AuditEvent) error { return nil },
+		Interval: time.Millisecond,
+	}
+	before := time.Now()
+
+	s.Run(h)
+
+	assert.False(t, since.Before(before))
+}
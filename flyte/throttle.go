@@ -0,0 +1,261 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/rs/zerolog/log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventSender is implemented by anything that spontaneous events can be sent through, such as a Pack or PackHandle.
+type EventSender interface {
+	SendEvent(Event) error
+}
+
+// ThrottleRule limits events with a given name to at most Limit per window. If a Key function is provided, events are
+// throttled separately for each key, e.g. at most one "DiskSpaceLow" event per host every 10 minutes.
+type ThrottleRule struct {
+	EventName string             // the name of the events the rule applies to
+	Window    time.Duration      // the time the limit applies to, starting with the first event sent
+	Key       func(Event) string // optional. Returns the key events are throttled by
+	// optional. Applied to the first event sent after others were suppressed, if it is sent within a window of the end
+	// of the window they were suppressed in
+	Conflate func(e Event, suppressed int) Event
+	// optional. The number of events with the same key sent per window. Defaults to one
+	Limit int
+	// optional. Merges the events suppressed in a window into a summary event, which is sent when the window ends, so
+	// the flyte server hears about every event during an incident storm without being flooded. Conflate is not applied
+	// to events that were coalesced.
+	Coalesce func(suppressed []Event) Event
+}
+
+// limit returns the number of events sent per window
+func (r ThrottleRule) limit() int {
+	if r.Limit < 1 {
+		return 1
+	}
+	return r.Limit
+}
+
+// ThrottledPayload is the payload of events produced by the SuppressedCount conflate function.
+type ThrottledPayload struct {
+	Payload    interface{} `json:"payload"`    // the original event payload
+	Suppressed int         `json:"suppressed"` // the number of events suppressed since the last one was sent
+}
+
+// SuppressedCount can be used as a ThrottleRule Conflate function. It wraps the event payload in a ThrottledPayload
+// recording the number of events that were suppressed.
+func SuppressedCount(e Event, suppressed int) Event {
+	e.Payload = ThrottledPayload{Payload: e.Payload, Suppressed: suppressed}
+	return e
+}
+
+// CoalescedPayload is the payload of the summary events produced by the CoalesceInto coalesce function.
+type CoalescedPayload struct {
+	Count    int           `json:"count"`              // the number of events suppressed in the window
+	Payloads []interface{} `json:"payloads,omitempty"` // the payloads of the first events suppressed
+}
+
+// CoalesceInto returns a function that can be used as a ThrottleRule Coalesce function. It merges the suppressed
+// events into a summary event with the definition passed in, whose payload is a CoalescedPayload holding the payloads
+// of up to maxPayloads of the events.
+func CoalesceInto(summary EventDef, maxPayloads int) func([]Event) Event {
+	return func(suppressed []Event) Event {
+		p := CoalescedPayload{Count: len(suppressed)}
+		for i := 0; i < len(suppressed) && i < maxPayloads; i++ {
+			p.Payloads = append(p.Payloads, suppressed[i].Payload)
+		}
+		return Event{EventDef: summary, Payload: p}
+	}
+}
+
+// Throttler is an EventSender that suppresses events sent more often than its rules allow, so noisy watchers don't
+// flood the flyte server. Events without a rule are sent straight through. A Throttler is safe for concurrent use, and
+// is a Flusher, so summaries of suppressed events can be sent when the pack stops (see WithFlushOnStop).
+type Throttler struct {
+	sender     EventSender
+	rules      map[string]ThrottleRule
+	now        func() time.Time
+	mu         sync.Mutex
+	windows    map[throttleKey]*throttleWindow
+	suppressed uint64
+}
+
+type throttleKey struct {
+	name, key string
+}
+
+type throttleWindow struct {
+	start      time.Time
+	sent       int
+	suppressed int
+	// the events suppressed in the window that are still to be coalesced, and the timer that coalesces them when the
+	// window ends
+	coalesce []Event
+	timer    *time.Timer
+}
+
+// NewThrottler creates a Throttler that sends events allowed by the rules through the sender passed in.
+func NewThrottler(sender EventSender, rules ...ThrottleRule) *Throttler {
+	t := &Throttler{
+		sender:  sender,
+		rules:   make(map[string]ThrottleRule, len(rules)),
+		now:     time.Now,
+		windows: make(map[throttleKey]*throttleWindow),
+	}
+	for _, r := range rules {
+		t.rules[r.EventName] = r
+	}
+	return t
+}
+
+// SendEvent sends the event unless the rule limit of events with the same name and key have already been sent within
+// the rule window, in which case the event is suppressed and nil is returned.
+func (t *Throttler) SendEvent(e Event) error {
+	rule, ok := t.rules[e.EventDef.Name]
+	if !ok {
+		return t.sender.SendEvent(e)
+	}
+
+	k := throttleKey{name: e.EventDef.Name}
+	if rule.Key != nil {
+		k.key = rule.Key(e)
+	}
+
+	t.mu.Lock()
+	now := t.now()
+	w, ok := t.windows[k]
+	if ok && now.Sub(w.start) < rule.Window {
+		if w.sent < rule.limit() {
+			w.sent++
+			t.mu.Unlock()
+			return t.sender.SendEvent(e)
+		}
+		w.suppressed++
+		if rule.Coalesce != nil {
+			w.coalesce = append(w.coalesce, e)
+			if w.timer == nil {
+				w.timer = time.AfterFunc(rule.Window-now.Sub(w.start), func() { t.flush(k) })
+			}
+		}
+		t.mu.Unlock()
+		atomic.AddUint64(&t.suppressed, 1)
+		log.Debug().Msgf("suppressed %q event with key %q", k.name, k.key)
+		return nil
+	}
+	suppressed := 0
+	conflate := false
+	var coalesce []Event
+	if ok {
+		coalesce = w.takeCoalesced()
+		suppressed = w.suppressed
+		// the count is only conflated into an event sent within a window of the end of the window it was suppressed in
+		conflate = now.Sub(w.start) < 2*rule.Window
+	}
+	t.windows[k] = &throttleWindow{start: now, sent: 1}
+	t.prune(now)
+	t.mu.Unlock()
+
+	// the summary of the last window is sent first, if its timer has not sent it yet
+	t.sendSummary(rule, k, coalesce)
+	if suppressed > 0 {
+		log.Info().Msgf("%d %q event(s) with key %q were suppressed", suppressed, k.name, k.key)
+		if rule.Conflate != nil && conflate {
+			e = rule.Conflate(e, suppressed)
+		}
+	}
+	return t.sender.SendEvent(e)
+}
+
+// Flush sends the summary events of the windows that have suppressed events to coalesce straight away, rather than
+// when the windows end.
+func (t *Throttler) Flush() {
+	t.mu.Lock()
+	keys := make([]throttleKey, 0, len(t.windows))
+	for k, w := range t.windows {
+		if len(w.coalesce) > 0 {
+			keys = append(keys, k)
+		}
+	}
+	t.mu.Unlock()
+
+	for _, k := range keys {
+		t.flush(k)
+	}
+}
+
+// flush sends the summary of the events suppressed in the current window for the key, if there are any
+func (t *Throttler) flush(k throttleKey) {
+	t.mu.Lock()
+	var coalesce []Event
+	if w, ok := t.windows[k]; ok {
+		coalesce = w.takeCoalesced()
+	}
+	t.mu.Unlock()
+	t.sendSummary(t.rules[k.name], k, coalesce)
+}
+
+// sendSummary sends the event the rule coalesces the suppressed events into, if there are any
+func (t *Throttler) sendSummary(rule ThrottleRule, k throttleKey, suppressed []Event) {
+	if len(suppressed) == 0 {
+		return
+	}
+	if err := t.sender.SendEvent(rule.Coalesce(suppressed)); err != nil {
+		log.Err(err).Msgf("could not send the summary of %d suppressed %q event(s) with key %q", len(suppressed), k.name, k.key)
+	}
+}
+
+// takeCoalesced returns the suppressed events still to be coalesced, stopping the timer that would coalesce them. As
+// they are reported by the summary event, they are no longer counted as suppressed.
+// must be called with the lock held
+func (w *throttleWindow) takeCoalesced() []Event {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	coalesce := w.coalesce
+	if len(coalesce) > 0 {
+		w.coalesce = nil
+		w.suppressed = 0
+	}
+	return coalesce
+}
+
+// Suppressed returns the total number of events the throttler has suppressed.
+func (t *Throttler) Suppressed() uint64 {
+	return atomic.LoadUint64(&t.suppressed)
+}
+
+// prune removes expired windows, so keys that are no longer seen don't build up. Windows with suppressed events are
+// kept for one more window, for their count to be conflated into the next event with the key, then dropped with the
+// count logged. Windows with events still to coalesce are left to their timer.
+// must be called with the lock held
+func (t *Throttler) prune(now time.Time) {
+	for k, w := range t.windows {
+		window := t.rules[k.name].Window
+		switch age := now.Sub(w.start); {
+		case len(w.coalesce) > 0:
+		case w.suppressed == 0 && age >= window:
+			delete(t.windows, k)
+		case age >= 2*window:
+			log.Info().Msgf("%d %q event(s) with key %q were suppressed", w.suppressed, k.name, k.key)
+			delete(t.windows, k)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_Throttler_ShouldSuppressEventsWithinTheWindowPerKey(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+	clock := &fakeClock{now: time.Now()}
+
+	throttler := NewThrottler(h, ThrottleRule{
+		EventName: "DiskSpaceLow",
+		Window:    10 * time.Minute,
+		Key:       func(e Event) string { return e.Payload.(string) },
+		Conflate:  SuppressedCount,
+	})
+	throttler.now = clock.Now
+
+	diskSpaceLow := func(host string) Event {
+		return Event{EventDef: EventDef{Name: "DiskSpaceLow"}, Payload: host}
+	}
+
+	assert.NoError(t, throttler.SendEvent(diskSpaceLow("host1")))
+	assert.NoError(t, throttler.SendEvent(diskSpaceLow("host2")))
+	clock.advance(time.Minute)
+	assert.NoError(t, throttler.SendEvent(diskSpaceLow("host1")))
+	assert.NoError(t, throttler.SendEvent(diskSpaceLow("host1")))
+	clock.advance(10 * time.Minute)
+	assert.NoError(t, throttler.SendEvent(diskSpaceLow("host1")))
+
+	assert.Equal(t, []interface{}{"host1", "host2", ThrottledPayload{Payload: "host1", Suppressed: 2}}, h.payloads())
+	assert.Equal(t, uint64(2), throttler.Suppressed())
+}
+
+func Test_Throttler_ShouldSendEventsWithoutRulesStraightThrough(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+
+	throttler := NewThrottler(h, ThrottleRule{EventName: "DiskSpaceLow", Window: time.Hour})
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, throttler.SendEvent(Event{EventDef: EventDef{Name: "BuildSuccess"}, Payload: i}))
+	}
+
+	assert.Equal(t, []interface{}{0, 1, 2}, h.payloads())
+	assert.Equal(t, uint64(0), throttler.Suppressed())
+}
+
+func Test_Throttler_ShouldSendEventUnchangedWhenThereIsNoConflateFunction(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+	clock := &fakeClock{now: time.Now()}
+
+	throttler := NewThrottler(h, ThrottleRule{EventName: "DiskSpaceLow", Window: time.Minute})
+	throttler.now = clock.Now
+
+	throttler.SendEvent(Event{EventDef: EventDef{Name: "DiskSpaceLow"}, Payload: 1})
+	throttler.SendEvent(Event{EventDef: EventDef{Name: "DiskSpaceLow"}, Payload: 2})
+	clock.advance(time.Minute)
+	throttler.SendEvent(Event{EventDef: EventDef{Name: "DiskSpaceLow"}, Payload: 3})
+
+	assert.Equal(t, []interface{}{1, 3}, h.payloads())
+}
+
+func Test_Throttler_ShouldSendUpToTheLimitOfEventsPerWindow(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+	clock := &fakeClock{now: time.Now()}
+
+	throttler := NewThrottler(h, ThrottleRule{EventName: "AlertRaised", Window: time.Minute, Limit: 2})
+	throttler.now = clock.Now
+
+	for i := 1; i <= 4; i++ {
+		assert.NoError(t, throttler.SendEvent(Event{EventDef: EventDef{Name: "AlertRaised"}, Payload: i}))
+	}
+	clock.advance(time.Minute)
+	assert.NoError(t, throttler.SendEvent(Event{EventDef: EventDef{Name: "AlertRaised"}, Payload: 5}))
+
+	assert.Equal(t, []interface{}{1, 2, 5}, h.payloads())
+	assert.Equal(t, uint64(2), throttler.Suppressed())
+}
+
+func Test_Throttler_ShouldCoalesceSuppressedEventsIntoASummaryWhenTheWindowEnds(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+
+	summary := EventDef{Name: "AlertStorm"}
+	throttler := NewThrottler(h, ThrottleRule{
+		EventName: "AlertRaised",
+		Window:    50 * time.Millisecond,
+		Coalesce:  CoalesceInto(summary, 2),
+	})
+
+	for i := 1; i <= 4; i++ {
+		assert.NoError(t, throttler.SendEvent(Event{EventDef: EventDef{Name: "AlertRaised"}, Payload: i}))
+	}
+
+	assert.Eventually(t, func() bool { return len(h.payloads()) == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []interface{}{1, CoalescedPayload{Count: 3, Payloads: []interface{}{2, 3}}}, h.payloads())
+	assert.Equal(t, "AlertStorm", (*h.events)[1].EventDef.Name)
+}
+
+func Test_Throttler_ShouldSendTheSummaryBeforeTheFirstEventOfTheNextWindow(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+	clock := &fakeClock{now: time.Now()}
+
+	throttler := NewThrottler(h, ThrottleRule{
+		EventName: "AlertRaised",
+		Window:    time.Hour,
+		Conflate:  SuppressedCount,
+		Coalesce:  CoalesceInto(EventDef{Name: "AlertStorm"}, 0),
+	})
+	throttler.now = clock.Now
+
+	throttler.SendEvent(Event{EventDef: EventDef{Name: "AlertRaised"}, Payload: 1})
+	throttler.SendEvent(Event{EventDef: EventDef{Name: "AlertRaised"}, Payload: 2})
+	clock.advance(time.Hour)
+	throttler.SendEvent(Event{EventDef: EventDef{Name: "AlertRaised"}, Payload: 3})
+
+	// the suppressed event is reported by the summary, so the next event is not conflated
+	assert.Equal(t, []interface{}{1, CoalescedPayload{Count: 1}, 3}, h.payloads())
+}
+
+func Test_Throttler_FlushShouldSendTheSummariesStraightAway(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+
+	throttler := NewThrottler(h, ThrottleRule{
+		EventName: "AlertRaised",
+		Window:    time.Hour,
+		Key:       func(e Event) string { return e.Payload.(string) },
+		Coalesce:  CoalesceInto(EventDef{Name: "AlertStorm"}, 1),
+	})
+
+	for _, host := range []string{"host1", "host1", "host2", "host2", "host2"} {
+		throttler.SendEvent(Event{EventDef: EventDef{Name: "AlertRaised"}, Payload: host})
+	}
+	throttler.Flush()
+	throttler.Flush()
+
+	assert.ElementsMatch(t, []interface{}{
+		"host1",
+		"host2",
+		CoalescedPayload{Count: 1, Payloads: []interface{}{"host1"}},
+		CoalescedPayload{Count: 2, Payloads: []interface{}{"host2"}},
+	}, h.payloads())
+}
+
+func Test_Throttler_ShouldDropWindowsWithSuppressedEventsAWindowAfterTheyEnd(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+	clock := &fakeClock{now: time.Now()}
+
+	throttler := NewThrottler(h, ThrottleRule{
+		EventName: "DiskSpaceLow",
+		Window:    time.Minute,
+		Key:       func(e Event) string { return e.Payload.(string) },
+		Conflate:  SuppressedCount,
+	})
+	throttler.now = clock.Now
+
+	throttler.SendEvent(Event{EventDef: EventDef{Name: "DiskSpaceLow"}, Payload: "host1"})
+	throttler.SendEvent(Event{EventDef: EventDef{Name: "DiskSpaceLow"}, Payload: "host1"})
+	clock.advance(time.Minute)
+	throttler.SendEvent(Event{EventDef: EventDef{Name: "DiskSpaceLow"}, Payload: "host2"})
+	assert.Len(t, throttler.windows, 2, "the suppressed count is kept for the next host1 event")
+	clock.advance(time.Minute)
+	throttler.SendEvent(Event{EventDef: EventDef{Name: "DiskSpaceLow"}, Payload: "host3"})
+
+	assert.Len(t, throttler.windows, 1)
+	assert.Contains(t, throttler.windows, throttleKey{name: "DiskSpaceLow", key: "host3"})
+}
+
+func Test_Throttler_ShouldNotConflateCountsFromWindowsThatEndedMoreThanAWindowAgo(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+	clock := &fakeClock{now: time.Now()}
+
+	throttler := NewThrottler(h, ThrottleRule{
+		EventName: "DiskSpaceLow",
+		Window:    time.Minute,
+		Conflate:  SuppressedCount,
+	})
+	throttler.now = clock.Now
+	diskSpaceLow := Event{EventDef: EventDef{Name: "DiskSpaceLow"}, Payload: "host1"}
+
+	// an event suppressed in the first window, then nothing until well after the next window
+	assert.NoError(t, throttler.SendEvent(diskSpaceLow))
+	assert.NoError(t, throttler.SendEvent(diskSpaceLow))
+	clock.advance(5 * time.Minute)
+	assert.NoError(t, throttler.SendEvent(diskSpaceLow))
+
+	assert.Equal(t, []interface{}{"host1", "host1"}, h.payloads())
+}
+
+// fakeClock is a clock that only moves when advanced
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,213 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+const minUsageReportInterval = time.Minute
+
+// WithUsageTelemetry opts the pack in to reporting anonymous usage statistics to the endpoint passed in every
+// interval, to help the platform team running flyte see which client features packs rely on. Each report holds the
+// client version, the optional features the pack uses and how many errors of each class (e.g. "transient",
+// "unauthorized") the pack has had since the last report. Nothing identifying the pack, such as its name, labels, host
+// or payloads, is reported. Reports that cannot be sent are not retried, but their error counts are added to the next
+// report. Intervals of less than a minute are raised to a minute.
+func WithUsageTelemetry(endpoint *url.URL, interval time.Duration) Option {
+	return func(p *pack) {
+		if interval < minUsageReportInterval {
+			interval = minUsageReportInterval
+		}
+		p.usage = &usageReporter{
+			endpoint:   endpoint,
+			interval:   interval,
+			httpClient: &http.Client{Timeout: 10 * time.Second},
+			errors:     make(map[string]uint64),
+		}
+	}
+}
+
+// UsageReport is the anonymous usage report sent by packs created with WithUsageTelemetry.
+type UsageReport struct {
+	ClientVersion string            `json:"clientVersion"`
+	Features      []string          `json:"features"`
+	Errors        map[string]uint64 `json:"errors"`
+	Time          time.Time         `json:"time"`
+}
+
+// usageReporter counts errors by class and periodically reports them, along with the features the pack uses
+type usageReporter struct {
+	endpoint   *url.URL
+	interval   time.Duration
+	httpClient *http.Client
+	mu         sync.Mutex
+	features   []string
+	errors     map[string]uint64
+}
+
+// run reports usage every interval until the pack is stopped
+func (u *usageReporter) run(done <-chan struct{}, features []string) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	u.features = features
+	u.mu.Unlock()
+	go func() {
+		ticker := time.NewTicker(u.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				u.report()
+			}
+		}
+	}()
+}
+
+// countError counts the error by its class
+func (u *usageReporter) countError(err error) {
+	if u == nil || err == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.errors[errorClass(err)]++
+}
+
+// report sends the usage since the last report, keeping the error counts for the next report if it cannot be sent
+func (u *usageReporter) report() {
+	u.mu.Lock()
+	r := UsageReport{ClientVersion: client.Version, Features: u.features, Errors: u.errors, Time: time.Now().UTC()}
+	u.errors = make(map[string]uint64)
+	u.mu.Unlock()
+
+	if err := u.send(r); err != nil {
+		log.Debug().Err(err).Msg("could not send usage report")
+		u.mu.Lock()
+		for class, n := range r.Errors {
+			u.errors[class] += n
+		}
+		u.mu.Unlock()
+	}
+}
+
+func (u *usageReporter) send(r UsageReport) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	resp, err := u.httpClient.Post(u.endpoint.String(), "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("usage report not accepted by %s, status was %s", u.endpoint, resp.Status)
+	}
+	return nil
+}
+
+// errorClass classifies the error without including anything from the error message
+func errorClass(err error) string {
+	switch {
+	case client.IsTransient(err):
+		return "transient"
+	case errors.Is(err, client.ErrTimeout):
+		return "timeout"
+	case errors.Is(err, client.ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, client.ErrBadRequest):
+		return "badRequest"
+	case errors.Is(err, client.ErrNotFound):
+		return "notFound"
+	case errors.Is(err, client.ErrConflict):
+		return "conflict"
+	case errors.Is(err, client.ErrLinkNotFound):
+		return "linkNotFound"
+	case errors.Is(err, client.ErrPayloadTooLarge):
+		return "payloadTooLarge"
+	case errors.Is(err, client.ErrResponseTooLarge):
+		return "responseTooLarge"
+	}
+	var respErr client.ResponseError
+	if errors.As(err, &respErr) {
+		return fmt.Sprintf("status%d", respErr.StatusCode)
+	}
+	return "other"
+}
+
+// features lists the optional features the pack uses, for usage reports
+func (p pack) features() []string {
+	var f []string
+	add := func(name string, used bool) {
+		if used {
+			f = append(f, name)
+		}
+	}
+	add("actionStream", p.actionStream)
+	add("adaptivePolling", p.maxPollingFrequency > 0)
+	add("configuration", p.configurator != nil)
+	add("definitionResync", p.resync != nil)
+	add("dependencies", p.container != nil && len(p.container.constructors) > 0)
+	add("flushOnStop", len(p.flushers) > 0)
+	add("freezeSwitch", p.freeze != nil)
+	add("healthEvents", p.healthEventInterval > 0)
+	add("inventoryReporting", p.inventory != nil)
+	add("lifecycleHooks", len(p.hooks) > 0)
+	add("metrics", p.metrics != nil)
+	add("panicEvents", p.panicEvents != nil)
+	add("packSet", p.inSet)
+	add("persistentStats", p.statsStore != nil)
+	add("pollingJitter", p.pollingJitter > 0)
+	add("probes", p.probes != nil)
+	add("shutdownHooks", len(p.shutdownHooks) > 0)
+	add("statusReporting", p.statusReporter != nil)
+	add("workerPool", p.workers.enabled())
+	for _, c := range p.Commands {
+		add("contextHandlers", c.ContextHandler != nil)
+		add("handlerConstructors", c.NewHandler != nil)
+		add("handlerRetries", c.Retry != nil)
+		add("selfTest", c.Name == selfTestCommandName)
+	}
+	sort.Strings(f)
+	return dedupe(f)
+}
+
+// dedupe removes repeated strings from the sorted slice
+func dedupe(s []string) []string {
+	out := s[:0]
+	for i, v := range s {
+		if i == 0 || v != s[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,92 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_usageReporter_ShouldReportFeaturesAndErrorClassCounts(t *testing.T) {
+	// given a usage endpoint
+	reports := make(chan UsageReport, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report UsageReport
+		json.NewDecoder(r.Body).Decode(&report)
+		reports <- report
+	}))
+	defer server.Close()
+	endpoint, _ := url.Parse(server.URL)
+	p := NewPackWithOptions(PackDef{Name: "SecretPack"}, MockClient{},
+		WithUsageTelemetry(endpoint, time.Hour), WithWorkerPool(2, 2), WithActionStream()).(pack)
+	p.usage.countError(fmt.Errorf("error taking action: %w", syscall.ECONNRESET))
+	p.usage.countError(client.UnauthorizedError{ResponseError: client.ResponseError{StatusCode: 401}})
+	p.usage.countError(client.ResponseError{StatusCode: 502})
+	p.usage.countError(errors.New("something secret"))
+	p.usage.features = p.features()
+
+	// when
+	p.usage.report()
+
+	// then
+	report := <-reports
+	assert.Equal(t, client.Version, report.ClientVersion)
+	assert.Equal(t, []string{"actionStream", "workerPool"}, report.Features)
+	assert.Equal(t, map[string]uint64{"transient": 1, "unauthorized": 1, "status502": 1, "other": 1}, report.Errors)
+	assert.Empty(t, p.usage.errors, "errors should be counted from the last report")
+}
+
+func Test_usageReporter_ShouldKeepErrorCounts_WhenTheReportCannotBeSent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	endpoint, _ := url.Parse(server.URL)
+	p := NewPackWithOptions(PackDef{}, MockClient{}, WithUsageTelemetry(endpoint, time.Hour)).(pack)
+	p.usage.countError(errors.New("boom"))
+
+	p.usage.report()
+
+	assert.Equal(t, map[string]uint64{"other": 1}, p.usage.errors)
+}
+
+func Test_WithUsageTelemetry_ShouldEnforceAMinimumInterval(t *testing.T) {
+	endpoint, _ := url.Parse("http://localhost:1")
+
+	p := NewPackWithOptions(PackDef{}, MockClient{}, WithUsageTelemetry(endpoint, time.Second)).(pack)
+
+	require.NotNil(t, p.usage)
+	assert.Equal(t, time.Minute, p.usage.interval)
+}
+
+func Test_features_ShouldListEachFeatureOnce(t *testing.T) {
+	noop := func(json.RawMessage) Event { return Event{} }
+	p := NewPackWithOptions(PackDef{Commands: []Command{{Name: "A", NewHandler: noop}, {Name: "B", NewHandler: noop}}},
+		MockClient{}, WithSelfTest()).(pack)
+
+	assert.Equal(t, []string{"handlerConstructors", "selfTest"}, p.features())
+}
@@ -0,0 +1,129 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"time"
+)
+
+const (
+	defaultWatcherInterval   = time.Second
+	defaultWatcherMaxBackoff = time.Minute
+)
+
+// ObserveFunc is called each time a Watcher observes the external system it is watching. Any events returned are
+// sent to the flyte server. Returning an error causes the watcher to back off before observing again.
+type ObserveFunc func() ([]Event, error)
+
+// Watcher is the common skeleton of a pack that observes an external system: it repeatedly calls an ObserveFunc,
+// either on an interval or whenever the Trigger channel receives a value, and sends the resulting events through
+// the pack handle.
+type Watcher struct {
+	Name       string          // the name used to identify the watcher in log entries
+	Observe    ObserveFunc     // the function that observes the external system
+	Interval   time.Duration   // how often to observe. Defaults to 1 second. Ignored if Trigger is set
+	Trigger    <-chan struct{} // optional. If set, the watcher observes each time a value is received. Closing it stops the watcher
+	MaxBackoff time.Duration   // the maximum wait after consecutive observation errors. Defaults to 1 minute
+	Jitter     float64         // optional. The fraction (0 to 1) by which waits are randomly adjusted, to stop watchers running in lockstep
+	// optional. If set, the watcher only observes while this replica of the pack is the leader
+	Leader *LeaderElection
+}
+
+// Run waits for the pack to start, then observes until the pack is stopped (or the Trigger channel is closed).
+// Run blocks, so will normally be called in its own goroutine. An observation that is in progress when the pack is
+// stopped is allowed to finish, but its events will not be sent.
+func (w Watcher) Run(h PackHandle) {
+	logger := h.Logger().With().Str("watcher", w.Name).Logger()
+	if w.Trigger == nil && w.Interval <= 0 {
+		logger.Warn().Msgf("watcher interval %v is not positive, defaulting to %v", w.Interval, defaultWatcherInterval)
+		w.Interval = defaultWatcherInterval
+	}
+
+	select {
+	case <-h.Started():
+	case <-h.Done():
+		return
+	}
+
+	failures := 0
+	for first := true; ; first = false {
+		if !w.wait(h, failures, first) {
+			logger.Info().Msg("watcher stopped")
+			return
+		}
+		if !w.Leader.IsLeader() {
+			continue
+		}
+
+		events, err := w.Observe()
+		if err != nil {
+			failures++
+			logger.Err(err).Msgf("observation failed, %d consecutive failure(s)", failures)
+			continue
+		}
+		failures = 0
+
+		for _, e := range events {
+			if err := h.SendEvent(e); err != nil {
+				if err == ErrPackStopped {
+					logger.Info().Msg("watcher stopped")
+					return
+				}
+				logger.Err(err).Msgf("could not send %q event", e.EventDef.Name)
+			}
+		}
+	}
+}
+
+// wait blocks until it is time for the next observation, returning false if the watcher should stop instead.
+// watchers on an interval observe as soon as they start. after failures the watcher backs off, regardless of whether
+// it is triggered or on an interval
+func (w Watcher) wait(h PackHandle, failures int, first bool) bool {
+	if failures > 0 {
+		base := w.Interval
+		if base <= 0 {
+			base = defaultWatcherInterval
+		}
+		max := w.MaxBackoff
+		if max <= 0 {
+			max = defaultWatcherMaxBackoff
+		}
+		select {
+		case <-h.Done():
+			return false
+		case <-time.After(withJitter(backoff(base, max, failures), w.Jitter)):
+		}
+	}
+
+	if w.Trigger != nil {
+		select {
+		case <-h.Done():
+			return false
+		case _, ok := <-w.Trigger:
+			return ok
+		}
+	}
+
+	if failures == 0 && !first {
+		select {
+		case <-h.Done():
+			return false
+		case <-time.After(withJitter(w.Interval, w.Jitter)):
+		}
+	}
+	return true
+}
@@ -0,0 +1,195 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"context"
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_Watcher_ShouldSendObservedEventsOnInterval(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+
+	observations := 0
+	w := Watcher{
+		Name:     "test",
+		Interval: time.Millisecond,
+		Observe: func() ([]Event, error) {
+			observations++
+			if observations == 3 {
+				h.stop()
+			}
+			return []Event{{EventDef: EventDef{Name: "Observed"}, Payload: observations}}, nil
+		},
+	}
+
+	w.Run(h)
+
+	assert.Equal(t, 3, observations)
+	assert.Equal(t, []interface{}{1, 2}, h.payloads())
+}
+
+func Test_Watcher_ShouldObserveWhenTriggeredAndStopWhenTriggerIsClosed(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+
+	trigger := make(chan struct{})
+	w := Watcher{
+		Name:    "test",
+		Trigger: trigger,
+		Observe: func() ([]Event, error) {
+			return []Event{{EventDef: EventDef{Name: "Observed"}}}, nil
+		},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		w.Run(h)
+		close(done)
+	}()
+	trigger <- struct{}{}
+	trigger <- struct{}{}
+	close(trigger)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		assert.Fail(t, "watcher should stop when the trigger is closed")
+	}
+	assert.Len(t, h.payloads(), 2)
+}
+
+func Test_Watcher_ShouldBackOffAfterObservationErrors(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+
+	var times []time.Time
+	w := Watcher{
+		Name:       "test",
+		Interval:   10 * time.Millisecond,
+		MaxBackoff: 40 * time.Millisecond,
+		Observe: func() ([]Event, error) {
+			times = append(times, time.Now())
+			if len(times) == 4 {
+				h.stop()
+			}
+			return nil, errors.New("external system unavailable")
+		},
+	}
+
+	w.Run(h)
+
+	// waits should be 20ms, 40ms (doubling from the interval)
+	assert.True(t, times[2].Sub(times[1]) >= 20*time.Millisecond)
+	assert.True(t, times[3].Sub(times[2]) >= 40*time.Millisecond)
+	assert.Empty(t, h.payloads())
+}
+
+func Test_Watcher_ShouldNotObserveIfPackStopsBeforeStarting(t *testing.T) {
+	h := newMockHandle()
+	h.stop()
+
+	w := Watcher{
+		Name: "test",
+		Observe: func() ([]Event, error) {
+			assert.Fail(t, "observe called unexpectedly")
+			return nil, nil
+		},
+	}
+
+	w.Run(h)
+}
+
+// mockHandle is a PackHandle that records the events sent through it
+type mockHandle struct {
+	lifecycle *lifecycle
+	mu        *sync.Mutex
+	events    *[]Event
+}
+
+func newMockHandle() mockHandle {
+	return mockHandle{lifecycle: newLifecycle(), mu: &sync.Mutex{}, events: &[]Event{}}
+}
+
+func (h mockHandle) start() { h.lifecycle.markStarted() }
+func (h mockHandle) stop()  { h.lifecycle.markStopped() }
+
+func (h mockHandle) payloads() []interface{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var p []interface{}
+	for _, e := range *h.events {
+		p = append(p, e.Payload)
+	}
+	return p
+}
+
+func (h mockHandle) SendEvent(e Event) error {
+	select {
+	case <-h.lifecycle.Done():
+		return ErrPackStopped
+	default:
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.events = append(*h.events, e)
+	return nil
+}
+
+func (h mockHandle) SendEventWithContext(_ context.Context, e Event) error {
+	return h.SendEvent(e)
+}
+
+func (h mockHandle) Logger() zerolog.Logger      { return log.Logger }
+func (h mockHandle) Datastore() client.Datastore { return nil }
+func (h mockHandle) State() *PackState           { return nil }
+func (h mockHandle) Stats() Stats                { return Stats{} }
+func (h mockHandle) LifetimeStats() Stats        { return Stats{} }
+func (h mockHandle) Started() <-chan struct{}    { return h.lifecycle.Started() }
+func (h mockHandle) Done() <-chan struct{}       { return h.lifecycle.Done() }
+func (h mockHandle) Err() error                  { return h.lifecycle.err() }
+
+func Test_Watcher_ShouldDefaultANonPositiveInterval(t *testing.T) {
+	h := newMockHandle()
+	h.start()
+
+	observations := 0
+	w := Watcher{
+		Name: "test",
+		Observe: func() ([]Event, error) {
+			observations++
+			if observations == 2 {
+				h.stop()
+			}
+			return nil, nil
+		},
+	}
+
+	start := time.Now()
+	w.Run(h)
+
+	assert.Equal(t, 2, observations)
+	assert.True(t, time.Since(start) >= defaultWatcherInterval)
+}
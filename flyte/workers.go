@@ -0,0 +1,163 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"github.com/rs/zerolog/log"
+	"sync"
+	"sync/atomic"
+)
+
+// WithWorkerPool handles actions with a fixed number of workers, instead of a goroutine per action, queueing up to
+// queueSize actions while all the workers are busy. When the workers are busy and the queue is full the pack stops
+// taking actions from the flyte server until capacity frees up, so it does not take actions it cannot handle
+// promptly and other replicas of the pack can take them instead.
+func WithWorkerPool(workers, queueSize int) Option {
+	return func(p *pack) {
+		if p.workers == nil {
+			p.workers = &workerPool{}
+		}
+		p.workers.configure(workers, queueSize)
+	}
+}
+
+// workerPool handles actions with a fixed number of workers. A pool with no workers is disabled, and handles each
+// action in its own goroutine. It is shared by all copies of a pack.
+type workerPool struct {
+	workers   int
+	queueSize int
+	// a slot is held for each action from before it is taken until it has been handled, so the pack only takes
+	// actions when a worker or queue slot is free
+	slots chan struct{}
+	queue chan func()
+	busy  int32
+	once  sync.Once
+}
+
+func (w *workerPool) configure(workers, queueSize int) {
+	if workers < 1 {
+		log.Warn().Msgf("a worker pool needs at least 1 worker, not %d, using 1", workers)
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	w.workers = workers
+	w.queueSize = queueSize
+	w.slots = make(chan struct{}, workers+queueSize)
+	w.queue = make(chan func(), workers+queueSize)
+}
+
+func (w *workerPool) enabled() bool {
+	return w != nil && w.workers > 0
+}
+
+// start starts the workers, which run until the pack is stopped and any queued actions have been handled
+func (w *workerPool) start(done <-chan struct{}) {
+	if !w.enabled() {
+		return
+	}
+	w.once.Do(func() {
+		for i := 0; i < w.workers; i++ {
+			go w.work(done)
+		}
+	})
+}
+
+func (w *workerPool) work(done <-chan struct{}) {
+	for {
+		select {
+		case f := <-w.queue:
+			w.run(f)
+		case <-done:
+			for {
+				select {
+				case f := <-w.queue:
+					w.run(f)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *workerPool) run(f func()) {
+	atomic.AddInt32(&w.busy, 1)
+	defer func() {
+		atomic.AddInt32(&w.busy, -1)
+		w.release()
+	}()
+	f()
+}
+
+// acquire waits for a free slot before an action is taken, returning false if the pack is stopped while waiting
+func (w *workerPool) acquire(done <-chan struct{}) bool {
+	if !w.enabled() {
+		return true
+	}
+	select {
+	case w.slots <- struct{}{}:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// release frees a slot, once an action has been handled or if no action was taken
+func (w *workerPool) release() {
+	if w.enabled() {
+		<-w.slots
+	}
+}
+
+// submit handles the action with the pool. The slot acquired before the action was taken is released once it has
+// been handled.
+func (w *workerPool) submit(done <-chan struct{}, f func()) {
+	if !w.enabled() {
+		go f()
+		return
+	}
+	select {
+	case <-done:
+		// the workers may have stopped
+		go w.run(f)
+	default:
+		w.queue <- f
+	}
+}
+
+// saturated returns whether every worker is busy and the queue is full
+func (w *workerPool) saturated() bool {
+	return w.enabled() && len(w.slots) == cap(w.slots)
+}
+
+// queued returns the number of actions waiting for a worker
+func (w *workerPool) queued() int {
+	if !w.enabled() {
+		return 0
+	}
+	return len(w.queue)
+}
+
+// busyWorkers returns the number of workers handling actions
+func (w *workerPool) busyWorkers() int {
+	if w == nil {
+		return 0
+	}
+	return int(atomic.LoadInt32(&w.busy))
+}
@@ -0,0 +1,108 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flyte
+
+import (
+	"encoding/json"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_WithWorkerPool_ShouldStopTakingActionsWhileTheWorkersAreSaturated(t *testing.T) {
+	StartHealthCheckServer = false // we need this to stop multiple registrations of the healthcheck server
+
+	// given a pack with one worker and a queue of one, and a flyte server with plenty of actions
+	var taken int32
+	release := make(chan struct{})
+	c := MockClient{
+		createPack: func(client.Pack) error { return nil },
+		takeAction: func() (*client.Action, error) {
+			atomic.AddInt32(&taken, 1)
+			return &client.Action{CommandName: "Slow"}, nil
+		},
+		completeAction: func(client.Action, client.Event) error { return nil },
+	}
+	packDef := PackDef{Name: "WorkerPack", Commands: []Command{{Name: "Slow", Handler: func(json.RawMessage) Event {
+		<-release
+		return Event{EventDef: EventDef{Name: "Done"}}
+	}}}}
+	p := NewPackWithOptions(packDef, c, WithWorkerPool(1, 1)).(pack)
+
+	// when
+	p.Start()
+	defer p.Stop()
+
+	// then only the actions that can be handled promptly are taken
+	assert.Eventually(t, p.workers.saturated, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&taken))
+	assert.Equal(t, 1, p.workers.queued())
+	assert.Equal(t, 1, p.workers.busyWorkers())
+	_, health := p.saturationCheck()
+	assert.False(t, health.Healthy)
+	_, health = p.pollingCheck()
+	assert.True(t, health.Healthy)
+
+	// and more actions are taken once the workers free up
+	close(release)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&taken) > 2 }, time.Second, time.Millisecond)
+}
+
+func Test_workerPool_ShouldHandleEachActionInItsOwnGoroutineWhenDisabled(t *testing.T) {
+	w := &workerPool{}
+	done := make(chan struct{})
+	handled := make(chan struct{})
+
+	assert.True(t, w.acquire(done))
+	w.submit(done, func() { close(handled) })
+
+	<-handled
+	assert.False(t, w.saturated())
+	assert.Equal(t, 0, w.queued())
+}
+
+func Test_workerPool_ShouldFinishQueuedActionsWhenThePackIsStopped(t *testing.T) {
+	w := &workerPool{}
+	w.configure(1, 2)
+	done := make(chan struct{})
+	var handled int32
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, w.acquire(done))
+		w.submit(done, func() { atomic.AddInt32(&handled, 1) })
+	}
+	close(done)
+	w.start(done)
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&handled) == 3 }, time.Second, time.Millisecond)
+}
+
+func Test_statusReporter_ShouldReportTheQueueDepth(t *testing.T) {
+	w := &workerPool{}
+	w.configure(1, 2)
+	done := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		w.acquire(done)
+		w.submit(done, func() {})
+	}
+	r := statusReporter{counters: &counters{}, workers: w}
+
+	assert.Equal(t, client.PackStatus{QueueDepth: 2}, r.status())
+}
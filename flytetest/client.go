@@ -0,0 +1,167 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flytetest
+
+import (
+	"github.com/ExpediaGroup/flyte-client/client"
+	"net/url"
+)
+
+// Client is a client.Client for pack unit tests, which calls the function set for each operation so a test only has
+// to substitute the operations it is interested in. Operations without a function succeed without doing anything:
+// TakeAction returns no action, and GetDatastoreItem and GetFlow return a client.NotFoundError. To test a pack
+// against the flyte api over http instead, create a real client for a Server.
+type Client struct {
+	CreatePackFunc             func(client.Pack) error
+	UpdatePackFunc             func(client.Pack) error
+	PostEventFunc              func(client.Event) error
+	TakeActionFunc             func() (*client.Action, error)
+	CompleteActionFunc         func(client.Action, client.Event) error
+	UpdatePackStatusFunc       func(client.PackStatus) error
+	GetFlyteHealthCheckURLFunc func() (*url.URL, error)
+	StatsFunc                  func() client.Stats
+	GetDatastoreItemFunc       func(key string) (*client.DatastoreItem, error)
+	PutDatastoreItemFunc       func(client.DatastoreItem) error
+	DeleteDatastoreItemFunc    func(key string) error
+	ListFlowsFunc              func() ([]client.Flow, error)
+	GetFlowFunc                func(name string) (*client.Flow, error)
+	PutFlowFunc                func(client.Flow) error
+	DeleteFlowFunc             func(name string) error
+	HeartbeatActionFunc        func(client.Action) error
+}
+
+var (
+	_ client.Client            = Client{}
+	_ client.PackUpdater       = Client{}
+	_ client.PackStatusUpdater = Client{}
+	_ client.StatsReporter     = Client{}
+	_ client.Datastore         = Client{}
+	_ client.Flows             = Client{}
+	_ client.ActionHeartbeater = Client{}
+)
+
+func (c Client) CreatePack(p client.Pack) error {
+	if c.CreatePackFunc == nil {
+		return nil
+	}
+	return c.CreatePackFunc(p)
+}
+
+func (c Client) UpdatePack(p client.Pack) error {
+	if c.UpdatePackFunc == nil {
+		return nil
+	}
+	return c.UpdatePackFunc(p)
+}
+
+func (c Client) PostEvent(e client.Event) error {
+	if c.PostEventFunc == nil {
+		return nil
+	}
+	return c.PostEventFunc(e)
+}
+
+func (c Client) TakeAction() (*client.Action, error) {
+	if c.TakeActionFunc == nil {
+		return nil, nil
+	}
+	return c.TakeActionFunc()
+}
+
+func (c Client) CompleteAction(a client.Action, e client.Event) error {
+	if c.CompleteActionFunc == nil {
+		return nil
+	}
+	return c.CompleteActionFunc(a, e)
+}
+
+func (c Client) HeartbeatAction(a client.Action) error {
+	if c.HeartbeatActionFunc == nil {
+		return nil
+	}
+	return c.HeartbeatActionFunc(a)
+}
+
+func (c Client) UpdatePackStatus(s client.PackStatus) error {
+	if c.UpdatePackStatusFunc == nil {
+		return nil
+	}
+	return c.UpdatePackStatusFunc(s)
+}
+
+func (c Client) GetFlyteHealthCheckURL() (*url.URL, error) {
+	if c.GetFlyteHealthCheckURLFunc == nil {
+		return &url.URL{}, nil
+	}
+	return c.GetFlyteHealthCheckURLFunc()
+}
+
+func (c Client) Stats() client.Stats {
+	if c.StatsFunc == nil {
+		return client.Stats{}
+	}
+	return c.StatsFunc()
+}
+
+func (c Client) GetDatastoreItem(key string) (*client.DatastoreItem, error) {
+	if c.GetDatastoreItemFunc == nil {
+		return nil, client.NotFoundError{Message: "datastore item " + key + " not found"}
+	}
+	return c.GetDatastoreItemFunc(key)
+}
+
+func (c Client) PutDatastoreItem(item client.DatastoreItem) error {
+	if c.PutDatastoreItemFunc == nil {
+		return nil
+	}
+	return c.PutDatastoreItemFunc(item)
+}
+
+func (c Client) DeleteDatastoreItem(key string) error {
+	if c.DeleteDatastoreItemFunc == nil {
+		return nil
+	}
+	return c.DeleteDatastoreItemFunc(key)
+}
+
+func (c Client) ListFlows() ([]client.Flow, error) {
+	if c.ListFlowsFunc == nil {
+		return nil, nil
+	}
+	return c.ListFlowsFunc()
+}
+
+func (c Client) GetFlow(name string) (*client.Flow, error) {
+	if c.GetFlowFunc == nil {
+		return nil, client.NotFoundError{Message: "flow " + name + " not found"}
+	}
+	return c.GetFlowFunc(name)
+}
+
+func (c Client) PutFlow(f client.Flow) error {
+	if c.PutFlowFunc == nil {
+		return nil
+	}
+	return c.PutFlowFunc(f)
+}
+
+func (c Client) DeleteFlow(name string) error {
+	if c.DeleteFlowFunc == nil {
+		return nil
+	}
+	return c.DeleteFlowFunc(name)
+}
@@ -0,0 +1,54 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flytetest_test
+
+import (
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"github.com/ExpediaGroup/flyte-client/flytetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestClient_ShouldCallTheFunctionsSetForAPack(t *testing.T) {
+	var posted []client.Event
+	c := flytetest.Client{PostEventFunc: func(e client.Event) error {
+		posted = append(posted, e)
+		return nil
+	}}
+	p := flyte.NewPack(flyte.PackDef{Name: "JiraPack"}, c)
+
+	err := p.SendEvent(flyte.Event{EventDef: flyte.EventDef{Name: "IssueCreated"}, Payload: "JIRA-1"})
+
+	require.NoError(t, err)
+	require.Len(t, posted, 1)
+	assert.Equal(t, "IssueCreated", posted[0].Name)
+}
+
+func TestClient_ShouldSucceedWithoutDoingAnythingByDefault(t *testing.T) {
+	var c client.Client = flytetest.Client{}
+
+	action, err := c.TakeAction()
+	assert.NoError(t, err)
+	assert.Nil(t, action)
+	assert.NoError(t, c.PostEvent(client.Event{}))
+	_, err = c.(client.Datastore).GetDatastoreItem("settings")
+	assert.True(t, errors.Is(err, client.ErrNotFound))
+	assert.NoError(t, c.(client.ActionHeartbeater).HeartbeatAction(client.Action{}))
+}
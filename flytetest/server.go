@@ -0,0 +1,310 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package flytetest provides an in-memory fake of the flyte api, for pack tests and local development.
+//
+// The fake registers packs, hands out queued actions, accepts events and action results and stores datastore items.
+// It does not run flows: actions are queued with QueueAction instead.
+package flytetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ExpediaGroup/flyte-client/client"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const swaggerRel = "http://example.com/swagger#!/"
+
+// Server is a fake flyte api. It is safe for concurrent use.
+type Server struct {
+	// the root url of the server, e.g. http://127.0.0.1:41234. Clients are created with this url.
+	URL string
+
+	httpServer *http.Server
+	listener   net.Listener
+	mu         sync.Mutex
+	packs      map[string]client.Pack
+	actions    map[string][]client.Action
+	nextID     int
+	events     []Event
+	results    []Result
+	datastore  map[string]client.DatastoreItem
+}
+
+// Event is an event posted to the fake by a pack.
+type Event struct {
+	Pack    string      // the name of the pack that posted the event
+	Name    string      // the event name
+	Payload interface{} // the event payload, as decoded from JSON
+}
+
+// Result is an action result posted to the fake by a pack.
+type Result struct {
+	Pack     string // the name of the pack that completed the action
+	ActionID string // the id of the action completed
+	Event           // the result event
+}
+
+// NewServer starts a fake flyte api on a random local port. It should be closed once done with.
+func NewServer() *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("flytetest: failed to listen on a port: %v", err))
+	}
+	return serve(l)
+}
+
+// Listen starts a fake flyte api on the address passed in, e.g. "localhost:8080". It should be closed once done with.
+func Listen(addr string) (*Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return serve(l), nil
+}
+
+func serve(l net.Listener) *Server {
+	s := &Server{
+		URL:       "http://" + l.Addr().String(),
+		listener:  l,
+		packs:     make(map[string]client.Pack),
+		actions:   make(map[string][]client.Action),
+		datastore: make(map[string]client.DatastoreItem),
+	}
+	s.httpServer = &http.Server{Handler: http.HandlerFunc(s.handle)}
+	go s.httpServer.Serve(l)
+	return s
+}
+
+// Close stops the server.
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// QueueAction queues an action for the pack, to be returned the next time the pack takes an action. The input is
+// marshalled to JSON. It returns the id of the action.
+func (s *Server) QueueAction(packName, commandName string, input interface{}) string {
+	b, err := json.Marshal(input)
+	if err != nil {
+		panic(fmt.Sprintf("flytetest: cannot marshal action input %+v: %v", input, err))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	id := fmt.Sprintf("%d", s.nextID)
+	s.actions[packName] = append(s.actions[packName], client.Action{
+		ID:          id,
+		CommandName: commandName,
+		Input:       b,
+		Links:       []client.Link{s.link(fmt.Sprintf("/v1/packs/%s/actions/%s/result", packName, id), "action/actionResult")},
+	})
+	return id
+}
+
+// Packs returns the packs registered with the server.
+func (s *Server) Packs() []client.Pack {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	packs := make([]client.Pack, 0, len(s.packs))
+	for _, p := range s.packs {
+		packs = append(packs, p)
+	}
+	return packs
+}
+
+// Events returns the events posted to the server, in the order they were posted.
+func (s *Server) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event(nil), s.events...)
+}
+
+// Results returns the action results posted to the server, in the order they were posted.
+func (s *Server) Results() []Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Result(nil), s.results...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "v1" && r.Method == http.MethodGet:
+		s.writeJSON(w, http.StatusOK, map[string][]client.Link{"links": {
+			s.link("/v1/packs", "pack/listPacks"),
+			s.link("/v1/health", "info/health"),
+			s.link("/v1/datastore", "datastore/listDataItems"),
+		}})
+	case len(parts) == 2 && parts[1] == "health":
+		w.WriteHeader(http.StatusOK)
+	case len(parts) >= 2 && parts[1] == "packs":
+		s.handlePacks(w, r, parts[2:])
+	case len(parts) >= 3 && parts[1] == "datastore":
+		s.handleDatastore(w, r, strings.Join(parts[2:], "/"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *Server) handlePacks(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			packs := make([]client.Pack, 0, len(s.packs))
+			for _, p := range s.packs {
+				packs = append(packs, p)
+			}
+			s.writeJSON(w, http.StatusOK, map[string][]client.Pack{"packs": packs})
+		case http.MethodPost:
+			s.registerPack(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	name := parts[0]
+	p, ok := s.packs[name]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		s.writeJSON(w, http.StatusOK, p)
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		s.registerPack(w, r)
+	case len(parts) == 2 && parts[1] == "events" && r.Method == http.MethodPost:
+		e, ok := readEvent(w, r, name)
+		if ok {
+			s.events = append(s.events, e)
+			w.WriteHeader(http.StatusAccepted)
+		}
+	case len(parts) == 3 && parts[1] == "actions" && parts[2] == "take" && r.Method == http.MethodPost:
+		queued := s.actions[name]
+		if len(queued) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		s.actions[name] = queued[1:]
+		s.writeJSON(w, http.StatusOK, queued[0])
+	case len(parts) == 4 && parts[1] == "actions" && parts[3] == "result" && r.Method == http.MethodPost:
+		e, ok := readEvent(w, r, name)
+		if ok {
+			s.results = append(s.results, Result{Pack: name, ActionID: parts[2], Event: e})
+			w.WriteHeader(http.StatusAccepted)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *Server) registerPack(w http.ResponseWriter, r *http.Request) {
+	var p client.Pack
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil || p.Name == "" {
+		http.Error(w, fmt.Sprintf("invalid pack: %v", err), http.StatusBadRequest)
+		return
+	}
+	p.Links = append(p.Links,
+		s.link("/v1/packs/"+p.Name, "self"),
+		s.link("/v1/packs/"+p.Name+"/actions/take", "action/takeAction"),
+		s.link("/v1/packs/"+p.Name+"/events", "event/event"),
+	)
+	s.packs[p.Name] = p
+	status := http.StatusCreated
+	if r.Method == http.MethodPut {
+		status = http.StatusOK
+	}
+	s.writeJSON(w, status, p)
+}
+
+func (s *Server) handleDatastore(w http.ResponseWriter, r *http.Request, key string) {
+	switch r.Method {
+	case http.MethodGet:
+		item, ok := s.datastore[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", item.ContentType)
+		w.Write(item.Value)
+	case http.MethodPut:
+		file, header, err := r.FormFile("value")
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid datastore item: %v", err), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		value, _ := ioutil.ReadAll(file)
+		_, exists := s.datastore[key]
+		s.datastore[key] = client.DatastoreItem{
+			Key:         key,
+			Description: r.FormValue("description"),
+			ContentType: header.Header.Get("Content-Type"),
+			Value:       value,
+		}
+		if exists {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusCreated)
+		}
+	case http.MethodDelete:
+		if _, ok := s.datastore[key]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(s.datastore, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func readEvent(w http.ResponseWriter, r *http.Request, packName string) (Event, bool) {
+	var e struct {
+		Name    string      `json:"event"`
+		Payload interface{} `json:"payload"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil || e.Name == "" {
+		http.Error(w, fmt.Sprintf("invalid event: %v", err), http.StatusBadRequest)
+		return Event{}, false
+	}
+	return Event{Pack: packName, Name: e.Name, Payload: e.Payload}, true
+}
+
+func (s *Server) link(path, rel string) client.Link {
+	u, _ := url.Parse(s.URL + path)
+	if !strings.Contains(rel, "/") {
+		return client.Link{Href: u, Rel: rel}
+	}
+	return client.Link{Href: u, Rel: swaggerRel + rel}
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flytetest
+
+import (
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newClient(t *testing.T, s *Server) client.Client {
+	u, err := url.Parse(s.URL)
+	require.NoError(t, err)
+	return client.NewClient(u, 5*time.Second)
+}
+
+func Test_Server_ShouldRegisterPacksAndRecordEvents(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := newClient(t, s)
+
+	require.NoError(t, c.CreatePack(client.Pack{Name: "Slack", EventDefs: []client.EventDef{{Name: "MessageSent"}}}))
+	require.NoError(t, c.PostEvent(client.Event{Name: "MessageSent", Payload: map[string]string{"text": "hello"}}))
+
+	require.Len(t, s.Packs(), 1)
+	assert.Equal(t, "Slack", s.Packs()[0].Name)
+	assert.Equal(t, []Event{{Pack: "Slack", Name: "MessageSent", Payload: map[string]interface{}{"text": "hello"}}}, s.Events())
+}
+
+func Test_Server_ShouldHandOutQueuedActionsAndRecordTheirResults(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := newClient(t, s)
+	require.NoError(t, c.CreatePack(client.Pack{Name: "Slack"}))
+
+	// no action is queued
+	a, err := c.TakeAction()
+	require.NoError(t, err)
+	assert.Nil(t, a)
+
+	// an action is queued
+	id := s.QueueAction("Slack", "SendMessage", map[string]string{"channel": "general"})
+	a, err = c.TakeAction()
+	require.NoError(t, err)
+	require.NotNil(t, a)
+	assert.Equal(t, id, a.ID)
+	assert.Equal(t, "SendMessage", a.CommandName)
+	assert.JSONEq(t, `{"channel":"general"}`, string(a.Input))
+
+	require.NoError(t, c.CompleteAction(*a, client.Event{Name: "MessageSent"}))
+	assert.Equal(t, []Result{{Pack: "Slack", ActionID: id, Event: Event{Pack: "Slack", Name: "MessageSent"}}}, s.Results())
+}
+
+func Test_Server_ShouldReturnNotFoundWhenTakingActionsForAnUnknownPack(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := newClient(t, s)
+	require.NoError(t, c.CreatePack(client.Pack{Name: "Slack"}))
+	s.mu.Lock()
+	delete(s.packs, "Slack")
+	s.mu.Unlock()
+
+	_, err := c.TakeAction()
+
+	assert.IsType(t, client.NotFoundError{}, err)
+}
+
+func Test_Server_ShouldStoreDatastoreItems(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := newClient(t, s).(client.Datastore)
+
+	require.NoError(t, c.PutDatastoreItem(client.DatastoreItem{Key: "settings", ContentType: "application/json", Value: []byte(`{"a":1}`)}))
+	item, err := c.GetDatastoreItem("settings")
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", item.ContentType)
+	assert.Equal(t, `{"a":1}`, string(item.Value))
+
+	require.NoError(t, c.DeleteDatastoreItem("settings"))
+	_, err = c.GetDatastoreItem("settings")
+	assert.IsType(t, client.NotFoundError{}, err)
+}
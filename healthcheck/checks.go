@@ -0,0 +1,54 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package healthcheck
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TCPCheck returns a health check that is healthy if a TCP connection can be made to the address (host:port) within
+// the timeout, e.g. to check that a database is reachable.
+func TCPCheck(name, address string, timeout time.Duration) HealthCheck {
+	return func() (string, Health) {
+		conn, err := net.DialTimeout("tcp", address, timeout)
+		if err != nil {
+			return name, Health{Healthy: false, Status: fmt.Sprintf("cannot connect to %s: %v", address, err)}
+		}
+		conn.Close()
+		return name, Health{Healthy: true, Status: fmt.Sprintf("connected to %s", address)}
+	}
+}
+
+// HTTPCheck returns a health check that is healthy if a GET request to the url returns a 2xx response within the
+// timeout, e.g. to check that a downstream service is up.
+func HTTPCheck(name, url string, timeout time.Duration) HealthCheck {
+	httpClient := &http.Client{Timeout: timeout}
+	return func() (string, Health) {
+		r, err := httpClient.Get(url)
+		if err != nil {
+			return name, Health{Healthy: false, Status: fmt.Sprintf("error in http call to %s: %v", url, err)}
+		}
+		defer r.Body.Close()
+		if r.StatusCode < 200 || r.StatusCode > 299 {
+			return name, Health{Healthy: false, Status: fmt.Sprintf("%s responded with http status '%s'", url, r.Status)}
+		}
+		return name, Health{Healthy: true, Status: fmt.Sprintf("%s responded with http status '%s'", url, r.Status)}
+	}
+}
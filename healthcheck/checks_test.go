@@ -0,0 +1,80 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package healthcheck
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_TCPCheck_ShouldBeHealthyWhenAddressAcceptsConnections(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	name, health := TCPCheck("db", l.Addr().String(), time.Second)()
+
+	assert.Equal(t, "db", name)
+	assert.True(t, health.Healthy)
+}
+
+func Test_TCPCheck_ShouldBeUnhealthyWhenAddressDoesNotAcceptConnections(t *testing.T) {
+	// given an address that nothing is listening on
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := l.Addr().String()
+	l.Close()
+
+	_, health := TCPCheck("db", address, time.Second)()
+
+	assert.False(t, health.Healthy)
+	assert.Contains(t, health.Status, "cannot connect to "+address)
+}
+
+func Test_HTTPCheck_ShouldBeHealthyOnlyFor2xxResponses(t *testing.T) {
+	for status, healthy := range map[int]bool{
+		http.StatusOK:                  true,
+		http.StatusNoContent:           true,
+		http.StatusNotFound:            false,
+		http.StatusInternalServerError: false,
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+
+		name, health := HTTPCheck("jira", server.URL, time.Second)()
+
+		assert.Equal(t, "jira", name)
+		assert.Equal(t, healthy, health.Healthy, "status %d", status)
+		server.Close()
+	}
+}
+
+func Test_HTTPCheck_ShouldBeUnhealthyWhenRequestFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	_, health := HTTPCheck("jira", server.URL, time.Second)()
+
+	assert.False(t, health.Healthy)
+	assert.Contains(t, health.Status, "error in http call to "+server.URL)
+}
@@ -10,9 +10,19 @@ import (
 const timeout = time.Duration(5) * time.Second
 
 func FlyteApiHealthCheck(c client.Client) Health {
+	if err := pingFlyteApi(c); err != nil {
+		return Health{Healthy: true, Status: err.Error()}
+	}
+	healthCheckURL, _ := c.GetFlyteHealthCheckURL()
+	return Health{Healthy: true, Status: fmt.Sprintf("flyte-api is up and responding to requests. url: '%s'", healthCheckURL)}
+}
+
+// pingFlyteApi calls the flyte-api healthcheck url, returning an error if flyte-api cannot be reached or is not
+// responding as expected
+func pingFlyteApi(c client.Client) error {
 	healthCheckURL, err := c.GetFlyteHealthCheckURL()
 	if err != nil {
-		return Health{Healthy: true, Status: fmt.Sprintf("cannot perform flyte-api healthcheck. error getting flyte-api healthcheck url. error: '%s'", err.Error())}
+		return fmt.Errorf("cannot perform flyte-api healthcheck. error getting flyte-api healthcheck url. error: '%s'", err.Error())
 	}
 
 	httpClient := &http.Client{
@@ -21,10 +31,11 @@ func FlyteApiHealthCheck(c client.Client) Health {
 
 	r, err := httpClient.Get(healthCheckURL.String())
 	if err != nil {
-		return Health{Healthy: true, Status: fmt.Sprintf("error in http call to flyte-api: '%s'. url: '%s'", err.Error(), healthCheckURL)}
+		return fmt.Errorf("error in http call to flyte-api: '%s'. url: '%s'", err.Error(), healthCheckURL)
 	}
+	defer r.Body.Close()
 	if r.StatusCode != http.StatusOK {
-		return Health{Healthy: true, Status: fmt.Sprintf("flyte-api is not responding as expected. http status: '%s'. url: '%s'", r.Status, healthCheckURL)}
+		return fmt.Errorf("flyte-api is not responding as expected. http status: '%s'. url: '%s'", r.Status, healthCheckURL)
 	}
-	return Health{Healthy: true, Status: fmt.Sprintf("flyte-api is up and responding to requests. url: '%s'", healthCheckURL)}
+	return nil
 }
@@ -0,0 +1,146 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package healthcheck
+
+import (
+	"github.com/ExpediaGroup/flyte-client/client"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"time"
+)
+
+const defaultMonitorInterval = 30 * time.Second
+
+// ApiStatus is the flyte-api connectivity tracked by a FlyteApiMonitor.
+type ApiStatus struct {
+	Healthy             bool      `json:"healthy"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	LastError           string    `json:"lastError,omitempty"`
+	LastChecked         time.Time `json:"lastChecked"`
+}
+
+// FlyteApiMonitor periodically calls the flyte-api healthcheck url and tracks whether flyte-api is reachable, so that
+// packs can surface flyte-api connectivity in their own health endpoints (see HealthCheck()).
+//
+// flyte-api is considered healthy until FailureThreshold consecutive checks have failed, and healthy again as soon as a
+// check succeeds. OnTransition, if set, is called whenever the healthy state changes.
+type FlyteApiMonitor struct {
+	Client           client.Client
+	Interval         time.Duration            // how often flyte-api is checked. Defaults to 30 seconds
+	FailureThreshold int                      // consecutive failures before flyte-api is unhealthy. Defaults to 1
+	OnTransition     func(from, to ApiStatus) // optional. Called from the monitor goroutine on healthy state changes
+	mu               sync.RWMutex
+	status           ApiStatus
+	stop             chan struct{}
+	stopOnce         sync.Once
+}
+
+// NewFlyteApiMonitor creates a monitor that checks flyte-api through the client every interval. Call Start() to begin
+// monitoring.
+func NewFlyteApiMonitor(c client.Client, interval time.Duration) *FlyteApiMonitor {
+	return &FlyteApiMonitor{Client: c, Interval: interval, status: ApiStatus{Healthy: true}}
+}
+
+// Start checks flyte-api straight away and then every interval until Stop() is called. It does not block.
+func (m *FlyteApiMonitor) Start() {
+	m.mu.Lock()
+	m.stop = make(chan struct{})
+	interval := m.Interval
+	if interval <= 0 {
+		interval = defaultMonitorInterval
+	}
+	stop := m.stop
+	m.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			m.Check()
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops monitoring flyte-api.
+func (m *FlyteApiMonitor) Stop() {
+	m.stopOnce.Do(func() {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		if m.stop != nil {
+			close(m.stop)
+		}
+	})
+}
+
+// Status returns the current flyte-api status.
+func (m *FlyteApiMonitor) Status() ApiStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// Check checks flyte-api once, updating and returning the status.
+func (m *FlyteApiMonitor) Check() ApiStatus {
+	err := pingFlyteApi(m.Client)
+
+	m.mu.Lock()
+	from := m.status
+	to := from
+	to.LastChecked = time.Now()
+	if err != nil {
+		to.ConsecutiveFailures++
+		to.LastError = err.Error()
+		threshold := m.FailureThreshold
+		if threshold < 1 {
+			threshold = 1
+		}
+		if to.ConsecutiveFailures >= threshold {
+			to.Healthy = false
+		}
+	} else {
+		to.ConsecutiveFailures = 0
+		to.LastError = ""
+		to.Healthy = true
+	}
+	m.status = to
+	m.mu.Unlock()
+
+	if from.Healthy != to.Healthy {
+		if to.Healthy {
+			log.Info().Msg("flyte-api is healthy again")
+		} else {
+			log.Warn().Msgf("flyte-api is unhealthy after %d consecutive failed checks: %s", to.ConsecutiveFailures, to.LastError)
+		}
+		if m.OnTransition != nil {
+			m.OnTransition(from, to)
+		}
+	}
+	return to
+}
+
+// HealthCheck returns a health check reporting the current flyte-api status, which can be passed to a pack.
+func (m *FlyteApiMonitor) HealthCheck() HealthCheck {
+	return func() (name string, health Health) {
+		status := m.Status()
+		return "FlyteApi", Health{Healthy: status.Healthy, Status: status}
+	}
+}
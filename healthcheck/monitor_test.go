@@ -0,0 +1,108 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package healthcheck
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_FlyteApiMonitor_ShouldBecomeUnhealthyAfterConsecutiveFailuresAndRecover(t *testing.T) {
+	// given a flyte-api whose healthcheck can be made to fail
+	var failing int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// and a monitor that records transitions
+	var transitions []ApiStatus
+	m := NewFlyteApiMonitor(MockClient{healthCheckURL: createURL(server.URL + "/health")}, time.Hour)
+	m.FailureThreshold = 2
+	m.OnTransition = func(from, to ApiStatus) {
+		transitions = append(transitions, to)
+	}
+
+	// when flyte-api is healthy
+	assert.True(t, m.Check().Healthy)
+
+	// and fails once
+	atomic.StoreInt32(&failing, 1)
+	status := m.Check()
+	assert.True(t, status.Healthy, "a single failure should not be enough to be unhealthy")
+	assert.Equal(t, 1, status.ConsecutiveFailures)
+
+	// and fails again
+	status = m.Check()
+	assert.False(t, status.Healthy)
+	assert.Equal(t, 2, status.ConsecutiveFailures)
+	assert.Contains(t, status.LastError, "flyte-api is not responding as expected. http status: '503 Service Unavailable'")
+
+	// and recovers
+	atomic.StoreInt32(&failing, 0)
+	status = m.Check()
+	assert.True(t, status.Healthy)
+	assert.Equal(t, 0, status.ConsecutiveFailures)
+
+	// then
+	require.Len(t, transitions, 2)
+	assert.False(t, transitions[0].Healthy)
+	assert.True(t, transitions[1].Healthy)
+	assert.Equal(t, status, m.Status())
+}
+
+func Test_FlyteApiMonitor_ShouldCheckPeriodicallyOnceStarted(t *testing.T) {
+	// given
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	m := NewFlyteApiMonitor(MockClient{healthCheckURL: createURL(server.URL + "/health")}, 10*time.Millisecond)
+
+	// when
+	m.Start()
+	defer m.Stop()
+
+	// then
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) >= 3 }, time.Second, 5*time.Millisecond)
+	assert.False(t, m.Status().LastChecked.IsZero())
+}
+
+func Test_FlyteApiMonitor_HealthCheck_ShouldReportTheCurrentStatus(t *testing.T) {
+	// given a monitor that cannot get the flyte-api healthcheck url
+	m := NewFlyteApiMonitor(MockClient{err: assert.AnError}, time.Hour)
+	m.Check()
+
+	// when
+	name, health := m.HealthCheck()()
+
+	// then
+	assert.Equal(t, "FlyteApi", name)
+	assert.False(t, health.Healthy)
+	assert.Equal(t, m.Status(), health.Status)
+}
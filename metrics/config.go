@@ -0,0 +1,54 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"errors"
+	"github.com/ExpediaGroup/flyte-client/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var legacyEnvVarsUsed = prometheus.NewDesc(
+	"flyte_config_legacy_env_vars_used_total",
+	"Number of times a setting was read from a legacy environment variable name.",
+	[]string{"name", "replacement"}, nil,
+)
+
+// RegisterConfigMetrics registers the config metrics, such as the count of settings read from legacy environment
+// variable names (see config.LegacyEnvVarUses), with the registerer passed in.
+func RegisterConfigMetrics(reg prometheus.Registerer) error {
+	if err := reg.Register(configCollector{}); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return err
+		}
+	}
+	return nil
+}
+
+// configCollector collects the config metrics from the counts the config package keeps
+type configCollector struct{}
+
+func (configCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- legacyEnvVarsUsed
+}
+
+func (configCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, u := range config.LegacyEnvVarUses() {
+		ch <- prometheus.MustNewConstMetric(legacyEnvVarsUsed, prometheus.CounterValue, float64(u.Count), u.Name, u.Replacement)
+	}
+}
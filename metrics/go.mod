@@ -0,0 +1,13 @@
+module github.com/ExpediaGroup/flyte-client/metrics
+
+go 1.14
+
+require (
+	github.com/ExpediaGroup/flyte-client v0.0.0-20261016144008-17b98e0e851d
+	github.com/prometheus/client_golang v1.12.2
+	github.com/rs/zerolog v1.26.1
+	github.com/stretchr/testify v1.7.1
+)
+
+// the module is developed and tested against the flyte client in the parent directory
+replace github.com/ExpediaGroup/flyte-client => ../
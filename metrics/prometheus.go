@@ -0,0 +1,161 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics records the metrics of flyte packs with Prometheus. It is a module of its own, so packs that do not
+// record metrics do not depend on the Prometheus client.
+package metrics
+
+import (
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+const namespace = "flyte_pack"
+
+// Prometheus records the metrics of the packs it is passed to with flyte.WithMetrics in the registerer passed in, or in
+// the default Prometheus registerer if it is nil. All the metrics are labelled with the pack name.
+func Prometheus(reg prometheus.Registerer) flyte.Metrics {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	return promMetrics{reg: reg}
+}
+
+type promMetrics struct {
+	reg prometheus.Registerer
+}
+
+type promRecorder struct {
+	invocations *prometheus.CounterVec
+	errors      *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+	// errors taking actions from the flyte server, by whether they were transient transport errors
+	takeErrors *prometheus.CounterVec
+	// action cleanup functions that failed, see flyte.AddCleanup
+	cleanupErrors *prometheus.CounterVec
+}
+
+func (m promMetrics) Recorder(packName string, g flyte.PackGauges) flyte.MetricsRecorder {
+	reg := prometheus.WrapRegistererWith(prometheus.Labels{"pack": packName}, m.reg)
+	r := &promRecorder{
+		invocations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "command_invocations_total",
+			Help:      "Number of actions handled, by command.",
+		}, []string{"command"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "command_errors_total",
+			Help:      "Number of actions whose command handler panicked or returned a FATAL event, by command.",
+		}, []string{"command"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "command_duration_seconds",
+			Help:      "Time taken by command handlers to handle actions, by command.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"command"}),
+		takeErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "take_action_errors_total",
+			Help:      "Number of errors taking actions from the flyte server, by kind: transient transport errors such as connection resets, or other errors.",
+		}, []string{"kind"}),
+		cleanupErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cleanup_errors_total",
+			Help:      "Number of action cleanup functions that returned an error or panicked, by command.",
+		}, []string{"command"}),
+	}
+	inFlight := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "actions_in_flight",
+		Help:      "Number of actions taken from the flyte server that are still being handled.",
+	}, func() float64 {
+		return float64(g.ActionsInFlight())
+	})
+	queued := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "actions_queued",
+		Help:      "Number of actions taken from the flyte server that are waiting for a worker.",
+	}, func() float64 {
+		return float64(g.ActionsQueued())
+	})
+	queueCapacity := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "action_queue_capacity",
+		Help:      "Number of actions that can wait for a worker before the pack stops taking actions.",
+	}, func() float64 {
+		return float64(g.ActionQueueCapacity())
+	})
+	busy := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "workers_busy",
+		Help:      "Number of workers handling actions, when the pack has a worker pool.",
+	}, func() float64 {
+		return float64(g.WorkersBusy())
+	})
+	abandoned := prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "action_results_abandoned_total",
+		Help:      "Number of action results that were never posted to the flyte server.",
+	}, func() float64 {
+		return float64(g.ResultsAbandoned())
+	})
+
+	r.invocations = register(reg, r.invocations).(*prometheus.CounterVec)
+	r.errors = register(reg, r.errors).(*prometheus.CounterVec)
+	r.duration = register(reg, r.duration).(*prometheus.HistogramVec)
+	r.takeErrors = register(reg, r.takeErrors).(*prometheus.CounterVec)
+	r.cleanupErrors = register(reg, r.cleanupErrors).(*prometheus.CounterVec)
+	register(reg, inFlight)
+	register(reg, queued)
+	register(reg, queueCapacity)
+	register(reg, busy)
+	register(reg, abandoned)
+	return r
+}
+
+// register registers the collector, returning the collector already registered if there is one
+func register(reg prometheus.Registerer, c prometheus.Collector) prometheus.Collector {
+	if err := reg.Register(c); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return are.ExistingCollector
+		}
+		log.Err(err).Msg("cannot register pack metrics")
+	}
+	return c
+}
+
+func (r *promRecorder) CommandHandled(command string, took time.Duration, failed bool) {
+	r.invocations.WithLabelValues(command).Inc()
+	r.duration.WithLabelValues(command).Observe(took.Seconds())
+	if failed {
+		r.errors.WithLabelValues(command).Inc()
+	}
+}
+
+func (r *promRecorder) TakeActionFailed(transient bool) {
+	kind := "other"
+	if transient {
+		kind = "transient"
+	}
+	r.takeErrors.WithLabelValues(kind).Inc()
+}
+
+func (r *promRecorder) CleanupFailed(command string) {
+	r.cleanupErrors.WithLabelValues(command).Inc()
+}
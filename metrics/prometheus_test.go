@@ -0,0 +1,120 @@
+/*
+Copyright (C) 2018 Expedia Group.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/ExpediaGroup/flyte-client/flyte"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func gauges() flyte.PackGauges {
+	return flyte.PackGauges{
+		ActionsInFlight:     func() int { return 2 },
+		ActionsQueued:       func() int { return 1 },
+		ActionQueueCapacity: func() int { return 10 },
+		WorkersBusy:         func() int { return 4 },
+		ResultsAbandoned:    func() uint64 { return 3 },
+	}
+}
+
+func Test_Prometheus_ShouldRecordPerCommandInvocationsAndErrors(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	r := Prometheus(reg).Recorder("SlackPack", gauges())
+
+	r.CommandHandled("sendMessage", time.Second, false)
+	r.CommandHandled("sendMessage", time.Second, false)
+	r.CommandHandled("deleteMessage", time.Second, true)
+
+	expected := `
+# HELP flyte_pack_command_errors_total Number of actions whose command handler panicked or returned a FATAL event, by command.
+# TYPE flyte_pack_command_errors_total counter
+flyte_pack_command_errors_total{command="deleteMessage",pack="SlackPack"} 1
+# HELP flyte_pack_command_invocations_total Number of actions handled, by command.
+# TYPE flyte_pack_command_invocations_total counter
+flyte_pack_command_invocations_total{command="deleteMessage",pack="SlackPack"} 1
+flyte_pack_command_invocations_total{command="sendMessage",pack="SlackPack"} 2
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "flyte_pack_command_invocations_total", "flyte_pack_command_errors_total")
+	assert.NoError(t, err)
+
+	count, err := testutil.GatherAndCount(reg, "flyte_pack_command_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func Test_Prometheus_ShouldReportThePackGauges(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	Prometheus(reg).Recorder("WorkerPack", gauges())
+
+	expected := `
+# HELP flyte_pack_action_queue_capacity Number of actions that can wait for a worker before the pack stops taking actions.
+# TYPE flyte_pack_action_queue_capacity gauge
+flyte_pack_action_queue_capacity{pack="WorkerPack"} 10
+# HELP flyte_pack_action_results_abandoned_total Number of action results that were never posted to the flyte server.
+# TYPE flyte_pack_action_results_abandoned_total counter
+flyte_pack_action_results_abandoned_total{pack="WorkerPack"} 3
+# HELP flyte_pack_actions_in_flight Number of actions taken from the flyte server that are still being handled.
+# TYPE flyte_pack_actions_in_flight gauge
+flyte_pack_actions_in_flight{pack="WorkerPack"} 2
+# HELP flyte_pack_actions_queued Number of actions taken from the flyte server that are waiting for a worker.
+# TYPE flyte_pack_actions_queued gauge
+flyte_pack_actions_queued{pack="WorkerPack"} 1
+# HELP flyte_pack_workers_busy Number of workers handling actions, when the pack has a worker pool.
+# TYPE flyte_pack_workers_busy gauge
+flyte_pack_workers_busy{pack="WorkerPack"} 4
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "flyte_pack_action_queue_capacity",
+		"flyte_pack_action_results_abandoned_total", "flyte_pack_actions_in_flight", "flyte_pack_actions_queued", "flyte_pack_workers_busy"))
+}
+
+func Test_Prometheus_ShouldCountTransientTakeActionErrorsSeparately(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	r := Prometheus(reg).Recorder("SlackPack", gauges())
+
+	r.TakeActionFailed(true)
+	r.TakeActionFailed(false)
+	r.TakeActionFailed(true)
+
+	expected := `
+# HELP flyte_pack_take_action_errors_total Number of errors taking actions from the flyte server, by kind: transient transport errors such as connection resets, or other errors.
+# TYPE flyte_pack_take_action_errors_total counter
+flyte_pack_take_action_errors_total{kind="other",pack="SlackPack"} 1
+flyte_pack_take_action_errors_total{kind="transient",pack="SlackPack"} 2
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "flyte_pack_take_action_errors_total"))
+}
+
+func Test_Prometheus_ShouldShareTheMetricsOfPacksWithTheSameName(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := Prometheus(reg)
+
+	m.Recorder("SlackPack", gauges()).CleanupFailed("sendMessage")
+	m.Recorder("SlackPack", gauges()).CleanupFailed("sendMessage")
+
+	expected := `
+# HELP flyte_pack_cleanup_errors_total Number of action cleanup functions that returned an error or panicked, by command.
+# TYPE flyte_pack_cleanup_errors_total counter
+flyte_pack_cleanup_errors_total{command="sendMessage",pack="SlackPack"} 2
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "flyte_pack_cleanup_errors_total"))
+}